@@ -10,12 +10,12 @@ require (
 	github.com/pelletier/go-toml/v2 v2.0.6
 	github.com/piot/go-lsp v0.0.0-20210308100331-e96ace6e5b0d
 	github.com/piot/lsp-server v0.0.0-20210308100659-f6871334c685
+	github.com/piot/raff-go v0.0.0-20230117233549-bbb38d362baa
 	github.com/stretchr/testify v1.8.1
 	github.com/swamp/assembler v0.0.0-20220828131015-e4bc9acfd44d
 	github.com/swamp/disassembler v0.0.0-20220828130657-a02b36df9c27
 	github.com/swamp/opcodes v0.0.0-20220302163745-47703b09858c
 	github.com/swamp/pack v0.0.0-20230117234029-5897064a22e0
-
 )
 
 require (
@@ -24,7 +24,6 @@ require (
 	github.com/mattn/go-isatty v0.0.17 // indirect
 	github.com/mewmew/float v0.0.0-20211212214546-4fe539893335 // indirect
 	github.com/piot/jsonrpc2 v0.0.0-20210220142131-b277991378fa // indirect
-	github.com/piot/raff-go v0.0.0-20230117233549-bbb38d362baa // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/mod v0.7.0 // indirect
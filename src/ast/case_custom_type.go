@@ -15,12 +15,13 @@ import (
 type CaseConsequenceForCustomType struct {
 	variantName *TypeIdentifier
 	arguments   []*VariableIdentifier
+	guard       Expression
 	expression  Expression
 	comment     token.Comment
 }
 
-func NewCaseConsequenceForCustomType(variantName *TypeIdentifier, arguments []*VariableIdentifier, expression Expression, comment token.Comment) *CaseConsequenceForCustomType {
-	return &CaseConsequenceForCustomType{variantName: variantName, arguments: arguments, expression: expression, comment: comment}
+func NewCaseConsequenceForCustomType(variantName *TypeIdentifier, arguments []*VariableIdentifier, guard Expression, expression Expression, comment token.Comment) *CaseConsequenceForCustomType {
+	return &CaseConsequenceForCustomType{variantName: variantName, arguments: arguments, guard: guard, expression: expression, comment: comment}
 }
 
 func (c *CaseConsequenceForCustomType) Identifier() *TypeIdentifier {
@@ -31,6 +32,11 @@ func (c *CaseConsequenceForCustomType) Arguments() []*VariableIdentifier {
 	return c.arguments
 }
 
+// Guard is the optional condition that must be true for the consequence to match. It is nil if there is no guard.
+func (c *CaseConsequenceForCustomType) Guard() Expression {
+	return c.guard
+}
+
 func (c *CaseConsequenceForCustomType) Expression() Expression {
 	return c.expression
 }
@@ -40,7 +46,7 @@ func (c *CaseConsequenceForCustomType) Comment() token.Comment {
 }
 
 func (c *CaseConsequenceForCustomType) String() string {
-	return fmt.Sprintf("[CaseConsCustomType %v (%v) => %v]", c.variantName, c.arguments, c.expression)
+	return fmt.Sprintf("[CaseConsCustomType %v (%v)%v => %v]", c.variantName, c.arguments, caseGuardToString(c.guard), c.expression)
 }
 
 func caseConsequenceArrayToStringEx(expressions []*CaseConsequenceForCustomType, ch string) string {
@@ -0,0 +1,232 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package ast
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/swamp/compiler/src/token"
+)
+
+// CasePattern is a (possibly nested) pattern on the left hand side of a case consequence.
+type CasePattern interface {
+	Node
+	DebugString() string
+}
+
+type PatternWildcard struct {
+	symbol token.RuneToken
+}
+
+func NewPatternWildcard(symbol token.RuneToken) *PatternWildcard {
+	return &PatternWildcard{symbol: symbol}
+}
+
+func (p *PatternWildcard) Symbol() token.RuneToken {
+	return p.symbol
+}
+
+func (p *PatternWildcard) FetchPositionLength() token.SourceFileReference {
+	return p.symbol.FetchPositionLength()
+}
+
+func (p *PatternWildcard) String() string {
+	return "_"
+}
+
+func (p *PatternWildcard) DebugString() string {
+	return "[PatternWildcard]"
+}
+
+type PatternVariable struct {
+	identifier *VariableIdentifier
+}
+
+func NewPatternVariable(identifier *VariableIdentifier) *PatternVariable {
+	return &PatternVariable{identifier: identifier}
+}
+
+func (p *PatternVariable) Identifier() *VariableIdentifier {
+	return p.identifier
+}
+
+func (p *PatternVariable) FetchPositionLength() token.SourceFileReference {
+	return p.identifier.FetchPositionLength()
+}
+
+func (p *PatternVariable) String() string {
+	return p.identifier.String()
+}
+
+func (p *PatternVariable) DebugString() string {
+	return "[PatternVariable]"
+}
+
+type PatternLiteral struct {
+	literal Literal
+}
+
+func NewPatternLiteral(literal Literal) *PatternLiteral {
+	return &PatternLiteral{literal: literal}
+}
+
+func (p *PatternLiteral) Literal() Literal {
+	return p.literal
+}
+
+func (p *PatternLiteral) FetchPositionLength() token.SourceFileReference {
+	return p.literal.FetchPositionLength()
+}
+
+func (p *PatternLiteral) String() string {
+	return p.literal.String()
+}
+
+func (p *PatternLiteral) DebugString() string {
+	return "[PatternLiteral]"
+}
+
+type PatternVariant struct {
+	variantName *TypeIdentifier
+	arguments   []CasePattern
+	inclusive   token.SourceFileReference
+}
+
+func NewPatternVariant(variantName *TypeIdentifier, arguments []CasePattern) *PatternVariant {
+	inclusive := variantName.FetchPositionLength()
+	if len(arguments) > 0 {
+		inclusive = token.MakeInclusiveSourceFileReference(inclusive, arguments[len(arguments)-1].FetchPositionLength())
+	}
+	return &PatternVariant{variantName: variantName, arguments: arguments, inclusive: inclusive}
+}
+
+func (p *PatternVariant) Identifier() *TypeIdentifier {
+	return p.variantName
+}
+
+func (p *PatternVariant) Arguments() []CasePattern {
+	return p.arguments
+}
+
+func (p *PatternVariant) FetchPositionLength() token.SourceFileReference {
+	return p.inclusive
+}
+
+func (p *PatternVariant) String() string {
+	if len(p.arguments) == 0 {
+		return fmt.Sprintf("[PVariant %v]", p.variantName)
+	}
+	return fmt.Sprintf("[PVariant %v %v]", p.variantName, casePatternArrayToString(p.arguments))
+}
+
+func (p *PatternVariant) DebugString() string {
+	return "[PatternVariant]"
+}
+
+type PatternTuple struct {
+	patterns   []CasePattern
+	startParen token.ParenToken
+	endParen   token.ParenToken
+	inclusive  token.SourceFileReference
+}
+
+func NewPatternTuple(startParen token.ParenToken, endParen token.ParenToken, patterns []CasePattern) *PatternTuple {
+	inclusive := token.MakeInclusiveSourceFileReference(startParen.SourceFileReference, endParen.SourceFileReference)
+	return &PatternTuple{startParen: startParen, endParen: endParen, patterns: patterns, inclusive: inclusive}
+}
+
+func (p *PatternTuple) Patterns() []CasePattern {
+	return p.patterns
+}
+
+func (p *PatternTuple) FetchPositionLength() token.SourceFileReference {
+	return p.inclusive
+}
+
+func (p *PatternTuple) String() string {
+	return fmt.Sprintf("[PTuple %v]", casePatternArrayToString(p.patterns))
+}
+
+func (p *PatternTuple) DebugString() string {
+	return "[PatternTuple]"
+}
+
+type PatternRecord struct {
+	fields     []*VariableIdentifier
+	startCurly token.ParenToken
+	endCurly   token.ParenToken
+	inclusive  token.SourceFileReference
+}
+
+func NewPatternRecord(startCurly token.ParenToken, endCurly token.ParenToken, fields []*VariableIdentifier) *PatternRecord {
+	inclusive := token.MakeInclusiveSourceFileReference(startCurly.SourceFileReference, endCurly.SourceFileReference)
+	return &PatternRecord{startCurly: startCurly, endCurly: endCurly, fields: fields, inclusive: inclusive}
+}
+
+func (p *PatternRecord) Fields() []*VariableIdentifier {
+	return p.fields
+}
+
+func (p *PatternRecord) FetchPositionLength() token.SourceFileReference {
+	return p.inclusive
+}
+
+func (p *PatternRecord) String() string {
+	return fmt.Sprintf("[PRecord %v]", p.fields)
+}
+
+func (p *PatternRecord) DebugString() string {
+	return "[PatternRecord]"
+}
+
+type PatternAs struct {
+	pattern    CasePattern
+	keywordAs  token.Keyword
+	identifier *VariableIdentifier
+	inclusive  token.SourceFileReference
+}
+
+func NewPatternAs(pattern CasePattern, keywordAs token.Keyword, identifier *VariableIdentifier) *PatternAs {
+	inclusive := token.MakeInclusiveSourceFileReference(pattern.FetchPositionLength(), identifier.FetchPositionLength())
+	return &PatternAs{pattern: pattern, keywordAs: keywordAs, identifier: identifier, inclusive: inclusive}
+}
+
+func (p *PatternAs) Pattern() CasePattern {
+	return p.pattern
+}
+
+func (p *PatternAs) KeywordAs() token.Keyword {
+	return p.keywordAs
+}
+
+func (p *PatternAs) Identifier() *VariableIdentifier {
+	return p.identifier
+}
+
+func (p *PatternAs) FetchPositionLength() token.SourceFileReference {
+	return p.inclusive
+}
+
+func (p *PatternAs) String() string {
+	return fmt.Sprintf("[PAs %v %v]", p.pattern, p.identifier)
+}
+
+func (p *PatternAs) DebugString() string {
+	return "[PatternAs]"
+}
+
+func casePatternArrayToString(patterns []CasePattern) string {
+	var out bytes.Buffer
+
+	for index, pattern := range patterns {
+		if index > 0 {
+			out.WriteString(" ")
+		}
+		out.WriteString(pattern.String())
+	}
+	return out.String()
+}
@@ -14,13 +14,14 @@ import (
 
 type CaseConsequencePatternMatching struct {
 	literal    Literal
+	guard      Expression
 	expression Expression
 	index      int
 	comment    token.Comment
 }
 
-func NewCaseConsequenceForPatternMatching(index int, literal Literal, expression Expression, comment token.Comment) *CaseConsequencePatternMatching {
-	return &CaseConsequencePatternMatching{index: index, literal: literal, expression: expression, comment: comment}
+func NewCaseConsequenceForPatternMatching(index int, literal Literal, guard Expression, expression Expression, comment token.Comment) *CaseConsequencePatternMatching {
+	return &CaseConsequencePatternMatching{index: index, literal: literal, guard: guard, expression: expression, comment: comment}
 }
 
 func (c *CaseConsequencePatternMatching) Literal() Literal {
@@ -31,6 +32,10 @@ func (c *CaseConsequencePatternMatching) Index() int {
 	return c.index
 }
 
+func (c *CaseConsequencePatternMatching) Guard() Expression {
+	return c.guard
+}
+
 func (c *CaseConsequencePatternMatching) Expression() Expression {
 	return c.expression
 }
@@ -46,7 +51,7 @@ func (c *CaseConsequencePatternMatching) String() string {
 	} else {
 		literalString = c.literal.String()
 	}
-	return fmt.Sprintf("[CaseConsPm %v => %v]", literalString, c.expression)
+	return fmt.Sprintf("[CaseConsPm %v%v => %v]", literalString, caseGuardToString(c.guard), c.expression)
 }
 
 func caseConsequencePatternMatchingArrayToStringEx(expressions []*CaseConsequencePatternMatching, ch string) string {
@@ -0,0 +1,112 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package ast
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/swamp/compiler/src/token"
+)
+
+type CaseConsequenceForPatterns struct {
+	pattern    CasePattern
+	guard      Expression
+	expression Expression
+	index      int
+	comment    token.Comment
+}
+
+func NewCaseConsequenceForPatterns(index int, pattern CasePattern, guard Expression, expression Expression, comment token.Comment) *CaseConsequenceForPatterns {
+	return &CaseConsequenceForPatterns{index: index, pattern: pattern, guard: guard, expression: expression, comment: comment}
+}
+
+// caseGuardToString returns the guard in the format used by the case consequences, or an empty string if there is no guard.
+func caseGuardToString(guard Expression) string {
+	if guard == nil {
+		return ""
+	}
+	return fmt.Sprintf(" | %v", guard)
+}
+
+func (c *CaseConsequenceForPatterns) Pattern() CasePattern {
+	return c.pattern
+}
+
+func (c *CaseConsequenceForPatterns) Index() int {
+	return c.index
+}
+
+func (c *CaseConsequenceForPatterns) Guard() Expression {
+	return c.guard
+}
+
+func (c *CaseConsequenceForPatterns) Expression() Expression {
+	return c.expression
+}
+
+func (c *CaseConsequenceForPatterns) Comment() token.Comment {
+	return c.comment
+}
+
+func (c *CaseConsequenceForPatterns) String() string {
+	return fmt.Sprintf("[CaseConsPatterns %v%v => %v]", c.pattern, caseGuardToString(c.guard), c.expression)
+}
+
+func caseConsequencePatternsArrayToStringEx(expressions []*CaseConsequenceForPatterns, ch string) string {
+	var out bytes.Buffer
+
+	for index, expression := range expressions {
+		if index > 0 {
+			out.WriteString(ch)
+		}
+		out.WriteString(expression.String())
+	}
+	return out.String()
+}
+
+// CaseForPatterns is a case expression where at least one consequence uses a nested or structured pattern.
+type CaseForPatterns struct {
+	test        Expression
+	cases       []*CaseConsequenceForPatterns
+	keywordCase token.Keyword
+	keywordOf   token.Keyword
+	inclusive   token.SourceFileReference
+}
+
+func NewCaseForPatterns(keywordCase token.Keyword, keywordOf token.Keyword, test Expression, cases []*CaseConsequenceForPatterns) *CaseForPatterns {
+	inclusive := token.MakeInclusiveSourceFileReference(keywordCase.FetchPositionLength(), cases[len(cases)-1].Expression().FetchPositionLength())
+
+	return &CaseForPatterns{keywordCase: keywordCase, keywordOf: keywordOf, test: test, cases: cases, inclusive: inclusive}
+}
+
+func (i *CaseForPatterns) String() string {
+	return fmt.Sprintf("[CasePatterns %v of %v]", i.test, caseConsequencePatternsArrayToStringEx(i.cases, ";"))
+}
+
+func (i *CaseForPatterns) Test() Expression {
+	return i.test
+}
+
+func (i *CaseForPatterns) KeywordCase() token.Keyword {
+	return i.keywordCase
+}
+
+func (i *CaseForPatterns) KeywordOf() token.Keyword {
+	return i.keywordOf
+}
+
+func (i *CaseForPatterns) FetchPositionLength() token.SourceFileReference {
+	return i.inclusive
+}
+
+func (i *CaseForPatterns) Consequences() []*CaseConsequenceForPatterns {
+	return i.cases
+}
+
+func (i *CaseForPatterns) DebugString() string {
+	return "[CasePatterns]"
+}
@@ -207,6 +207,7 @@ func writeCase(caseExpression *ast.CaseForCustomType, colorer coloring.Colorer,
 			writeFunctionIdentifiers(consequence.Arguments(), colorer)
 			colorer.OneSpace()
 		}
+		writeCaseGuard(consequence.Guard(), colorer)
 		colorer.RightArrow()
 		colorer.NewLine(indentation + 2)
 		WriteExpression(consequence.Expression(), colorer, 0)
@@ -215,6 +216,97 @@ func writeCase(caseExpression *ast.CaseForCustomType, colorer coloring.Colorer,
 	// colorer.NewLine(indentation)
 }
 
+func writeCasePattern(pattern ast.CasePattern, colorer coloring.Colorer, isArgument bool) {
+	switch t := pattern.(type) {
+	case *ast.PatternWildcard:
+		colorer.OperatorString("_")
+	case *ast.PatternVariable:
+		colorer.Parameter(t.Identifier().Symbol())
+	case *ast.PatternLiteral:
+		WriteExpression(t.Literal(), colorer, 0)
+	case *ast.PatternVariant:
+		if isArgument && len(t.Arguments()) > 0 {
+			colorer.OperatorString("(")
+		}
+		colorer.TypeSymbol(t.Identifier().Symbol())
+		for _, argument := range t.Arguments() {
+			colorer.OneSpace()
+			writeCasePattern(argument, colorer, true)
+		}
+		if isArgument && len(t.Arguments()) > 0 {
+			colorer.OperatorString(")")
+		}
+	case *ast.PatternTuple:
+		colorer.OperatorString("(")
+		colorer.OneSpace()
+		for index, subPattern := range t.Patterns() {
+			if index > 0 {
+				colorer.OperatorString(",")
+				colorer.OneSpace()
+			}
+			writeCasePattern(subPattern, colorer, false)
+		}
+		colorer.OneSpace()
+		colorer.OperatorString(")")
+	case *ast.PatternRecord:
+		colorer.OperatorString("{")
+		colorer.OneSpace()
+		for index, field := range t.Fields() {
+			if index > 0 {
+				colorer.OperatorString(",")
+				colorer.OneSpace()
+			}
+			colorer.RecordField(field.Symbol())
+		}
+		colorer.OneSpace()
+		colorer.OperatorString("}")
+	case *ast.PatternAs:
+		if isArgument {
+			colorer.OperatorString("(")
+		}
+		writeCasePattern(t.Pattern(), colorer, false)
+		colorer.OneSpace()
+		colorer.KeywordString("as")
+		colorer.OneSpace()
+		colorer.Parameter(t.Identifier().Symbol())
+		if isArgument {
+			colorer.OperatorString(")")
+		}
+	}
+}
+
+func writeCaseGuard(guard ast.Expression, colorer coloring.Colorer) {
+	if guard == nil {
+		return
+	}
+	colorer.KeywordString("|")
+	colorer.OneSpace()
+	WriteExpression(guard, colorer, 0)
+	colorer.OneSpace()
+}
+
+func writeCasePatterns(caseExpression *ast.CaseForPatterns, colorer coloring.Colorer, indentation int) {
+	colorer.KeywordString("case")
+	colorer.OneSpace()
+	WriteExpression(caseExpression.Test(), colorer, 0)
+	colorer.OneSpace()
+	colorer.KeywordString("of")
+	colorer.NewLine(indentation + 1)
+
+	for index, consequence := range caseExpression.Consequences() {
+		if index > 0 {
+			colorer.NewLine(0)
+			colorer.NewLine(indentation + 1)
+		}
+		writeCasePattern(consequence.Pattern(), colorer, false)
+		colorer.OneSpace()
+		writeCaseGuard(consequence.Guard(), colorer)
+		colorer.RightArrow()
+		colorer.NewLine(indentation + 2)
+		WriteExpression(consequence.Expression(), colorer, 0)
+	}
+}
+
 func writeGuard(guardExpression *ast.GuardExpression, colorer coloring.Colorer, indentation int) {
 	for index, item := range guardExpression.Items() {
 		if index > 0 {
@@ -337,6 +429,20 @@ func writeIf(letExpression *ast.IfExpression, colorer coloring.Colorer, indentat
 	WriteExpression(letExpression.Alternative(), colorer, indentation+1)
 }
 
+func writeLambda(lambda *ast.Lambda, colorer coloring.Colorer, indentation int) {
+	colorer.OperatorString("\\")
+	for index, parameter := range lambda.Parameters() {
+		if index > 0 {
+			colorer.OneSpace()
+		}
+		colorer.Parameter(parameter.Identifier().Symbol())
+	}
+	colorer.OneSpace()
+	colorer.RightArrow()
+	colorer.OneSpace()
+	WriteExpression(lambda.Expression(), colorer, indentation)
+}
+
 func writeBinaryOperator(binaryOperator *ast.BinaryOperator, colorer coloring.Colorer, indentation int) {
 	WriteExpression(binaryOperator.Left(), colorer, 0)
 	colorer.OneSpace()
@@ -558,6 +664,10 @@ func WriteExpression(expression ast.Expression, colorer coloring.Colorer, indent
 		{
 			writeCase(t, colorer, indentation)
 		}
+	case *ast.CaseForPatterns:
+		{
+			writeCasePatterns(t, colorer, indentation)
+		}
 	case *ast.SingleLineComment:
 		{
 			writeSingleLineComment(t, colorer, indentation)
@@ -610,6 +720,10 @@ func WriteExpression(expression ast.Expression, colorer coloring.Colorer, indent
 		{
 			writeGuard(t, colorer, indentation)
 		}
+	case *ast.Lambda:
+		{
+			writeLambda(t, colorer, indentation)
+		}
 	case *ast.RecordLiteral:
 		{
 			writeRecordLiteral(t, colorer, indentation)
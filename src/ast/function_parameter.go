@@ -12,14 +12,18 @@ import (
 
 type FunctionParameter struct {
 	identifier    *VariableIdentifier // allowed to be nil
-	parameterType Type
+	parameterType Type                // nil for lambda parameters, the type is inferred from the call site
 	inclusive     token.SourceFileReference
 }
 
 func NewFunctionParameter(identifier *VariableIdentifier, parameterType Type) *FunctionParameter {
-	inclusive := parameterType.FetchPositionLength()
-	if identifier != nil {
+	var inclusive token.SourceFileReference
+	if parameterType == nil {
+		inclusive = identifier.FetchPositionLength()
+	} else if identifier != nil {
 		inclusive = token.MakeInclusiveSourceFileReference(identifier.FetchPositionLength(), parameterType.FetchPositionLength())
+	} else {
+		inclusive = parameterType.FetchPositionLength()
 	}
 
 	return &FunctionParameter{inclusive: inclusive, identifier: identifier, parameterType: parameterType}
@@ -52,6 +56,9 @@ func (i *FunctionParameter) FetchPositionLength() token.SourceFileReference {
 }
 
 func (i *FunctionParameter) String() string {
+	if i.parameterType == nil {
+		return fmt.Sprintf("[Arg %s]", i.identifier.Symbol())
+	}
 	if i.identifier != nil {
 		return fmt.Sprintf("[Arg %s: %s]", i.identifier.Symbol(), i.parameterType)
 	}
@@ -0,0 +1,58 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package ast
+
+import (
+	"fmt"
+
+	"github.com/swamp/compiler/src/token"
+)
+
+type Lambda struct {
+	lambdaToken token.LambdaToken
+	parameters  []*FunctionParameter
+	expression  Expression
+	inclusive   token.SourceFileReference
+}
+
+func NewLambda(lambdaToken token.LambdaToken, identifiers []*VariableIdentifier, expression Expression) *Lambda {
+	inclusive := token.MakeInclusiveSourceFileReference(lambdaToken.FetchPositionLength(), expression.FetchPositionLength())
+
+	var parameters []*FunctionParameter
+	for _, identifier := range identifiers {
+		parameters = append(parameters, NewFunctionParameter(identifier, nil))
+	}
+
+	return &Lambda{lambdaToken: lambdaToken, parameters: parameters, expression: expression, inclusive: inclusive}
+}
+
+func (i *Lambda) LambdaToken() token.LambdaToken {
+	return i.lambdaToken
+}
+
+func (i *Lambda) Parameters() []*FunctionParameter {
+	return i.parameters
+}
+
+func (i *Lambda) Expression() Expression {
+	return i.expression
+}
+
+func (i *Lambda) FetchPositionLength() token.SourceFileReference {
+	return i.inclusive
+}
+
+func (i *Lambda) String() string {
+	var identifiers []*VariableIdentifier
+	for _, parameter := range i.parameters {
+		identifiers = append(identifiers, parameter.Identifier())
+	}
+	return fmt.Sprintf("[Lambda %v => %v]", identifiers, i.expression)
+}
+
+func (i *Lambda) DebugString() string {
+	return "[lambda]"
+}
@@ -0,0 +1,363 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+// Package bindings describes the memory layout of the types in compiled packages, so that the engine side
+// can use the same layout as dectype.GetMemorySizeAndAlignment.
+package bindings
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+	"github.com/swamp/compiler/src/generate_sp"
+	"github.com/swamp/compiler/src/loader"
+	"github.com/swamp/compiler/src/typeinfo"
+)
+
+type Field struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Offset int    `json:"offset"`
+	Size   int    `json:"size"`
+	Align  int    `json:"align"`
+
+	infoType typeinfo.InfoType
+}
+
+// Struct is a record or a tuple.
+type Struct struct {
+	Name   string  `json:"name"`
+	Kind   string  `json:"kind"`
+	Size   int     `json:"size"`
+	Align  int     `json:"align"`
+	Fields []Field `json:"fields"`
+}
+
+type Variant struct {
+	Name   string  `json:"name"`
+	Tag    int     `json:"tag"`
+	Fields []Field `json:"fields"`
+}
+
+// Custom is a custom type. The first octet is the tag, that tells which variant it is.
+type Custom struct {
+	Name     string    `json:"name"`
+	Size     int       `json:"size"`
+	Align    int       `json:"align"`
+	Variants []Variant `json:"variants"`
+}
+
+type Parameter struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// HasTypeId is set if the type id of the argument is passed before it, as for `Any`
+	HasTypeId bool `json:"hasTypeId,omitempty"`
+
+	infoType typeinfo.InfoType
+}
+
+func (p Parameter) InfoType() typeinfo.InfoType {
+	return p.infoType
+}
+
+type ExternalFunction struct {
+	Name       string      `json:"name"`
+	Parameters []Parameter `json:"parameters"`
+	Return     *Parameter  `json:"return,omitempty"`
+	IsGeneric  bool        `json:"isGeneric,omitempty"`
+}
+
+// Declaration is either a Struct or a Custom.
+type Declaration interface {
+	declarationName() string
+}
+
+func (s *Struct) declarationName() string {
+	return s.Name
+}
+
+func (c *Custom) declarationName() string {
+	return c.Name
+}
+
+// Bindings is the layout of the types that are used by the definitions in the packages, in an order
+// where each declaration comes after the declarations that it contains by value.
+type Bindings struct {
+	Declarations      []Declaration
+	ExternalFunctions []*ExternalFunction
+}
+
+type builder struct {
+	names        map[typeinfo.InfoType]string
+	declared     map[typeinfo.InfoType]bool
+	declarations []Declaration
+	dataLayout   dectype.DataLayout
+}
+
+// Unalias returns the type that the aliases refer to.
+func Unalias(infoType typeinfo.InfoType) typeinfo.InfoType {
+	for {
+		alias, isAlias := infoType.(*typeinfo.AliasType)
+		if !isAlias {
+			return infoType
+		}
+		infoType = alias.RealType()
+	}
+}
+
+// Identifier replaces the characters that are not allowed in C identifiers with underscores.
+func Identifier(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// TypeName returns a language neutral name of the type, e.g. `List<Position>`.
+func (b *builder) TypeName(infoType typeinfo.InfoType) string {
+	infoType = Unalias(infoType)
+	if name, hasName := b.names[infoType]; hasName {
+		return name
+	}
+
+	switch t := infoType.(type) {
+	case *typeinfo.IntType:
+		return "Int"
+	case *typeinfo.FixedType:
+		return "Fixed"
+	case *typeinfo.BoolType:
+		return "Bool"
+	case *typeinfo.CharacterType:
+		return "Char"
+	case *typeinfo.ResourceNameType:
+		return "ResourceName"
+	case *typeinfo.StringType:
+		return "String"
+	case *typeinfo.BlobType:
+		return "Blob"
+	case *typeinfo.TypeRefIdType:
+		return "TypeRef"
+	case *typeinfo.AnyType, *typeinfo.AnyMatchingTypes:
+		return "Any"
+	case *typeinfo.ListType:
+		return fmt.Sprintf("List<%v>", b.TypeName(t.ItemType()))
+	case *typeinfo.ArrayType:
+		return fmt.Sprintf("Array<%v>", b.TypeName(t.ItemType()))
+	case *typeinfo.UnmanagedType:
+		return fmt.Sprintf("Unmanaged<%v>", t.Name())
+	case *typeinfo.FunctionType:
+		return "Function"
+	}
+
+	return fmt.Sprintf("Type%d", infoType.Index())
+}
+
+func (b *builder) nameTypes(infoTypes []typeinfo.InfoType) {
+	// Records and tuples are named by the first alias that refers to them
+	for _, infoType := range infoTypes {
+		alias, isAlias := infoType.(*typeinfo.AliasType)
+		if !isAlias {
+			continue
+		}
+		realType := Unalias(alias)
+		if _, hasName := b.names[realType]; hasName {
+			continue
+		}
+		switch realType.(type) {
+		case *typeinfo.RecordType, *typeinfo.TupleType:
+			b.names[realType] = Identifier(alias.Name())
+		}
+	}
+
+	for _, infoType := range infoTypes {
+		if _, hasName := b.names[infoType]; hasName {
+			continue
+		}
+		switch t := infoType.(type) {
+		case *typeinfo.RecordType:
+			b.names[t] = fmt.Sprintf("Record%d", t.Index())
+		case *typeinfo.TupleType:
+			b.names[t] = fmt.Sprintf("Tuple%d", t.Index())
+		}
+	}
+
+	// Custom types with generics are specialized, so the generics are part of the name, e.g. `Maybe_Int`
+	for _, infoType := range infoTypes {
+		custom, isCustom := infoType.(*typeinfo.CustomType)
+		if !isCustom {
+			continue
+		}
+		name := Identifier(custom.Name())
+		for _, generic := range custom.Generics() {
+			name += "_" + Identifier(b.TypeName(generic))
+		}
+		b.names[custom] = name
+	}
+}
+
+func (b *builder) field(name string, infoType typeinfo.InfoType, info typeinfo.MemoryOffsetInfo) Field {
+	return Field{
+		Name:     name,
+		Type:     b.TypeName(infoType),
+		Offset:   int(info.MemoryOffset),
+		Size:     int(info.MemoryInfo.MemorySize),
+		Align:    int(info.MemoryInfo.MemoryAlign),
+		infoType: Unalias(infoType),
+	}
+}
+
+// declare adds the declaration for the type, after the declarations of the types that it contains by value.
+func (b *builder) declare(infoType typeinfo.InfoType) {
+	infoType = Unalias(infoType)
+	if b.declared[infoType] {
+		return
+	}
+
+	switch t := infoType.(type) {
+	case *typeinfo.RecordType:
+		b.declared[t] = true
+		declaration := &Struct{Name: b.names[t], Kind: "record", Size: int(t.MemoryInfo().MemorySize), Align: int(t.MemoryInfo().MemoryAlign)}
+		for _, field := range t.Fields() {
+			b.declare(field.FieldType())
+			declaration.Fields = append(declaration.Fields, b.field(field.Name(), field.FieldType(), field.MemoryOffsetInfo()))
+		}
+		b.declarations = append(b.declarations, declaration)
+	case *typeinfo.TupleType:
+		b.declared[t] = true
+		declaration := &Struct{Name: b.names[t], Kind: "tuple", Size: int(t.MemoryInfo().MemorySize), Align: int(t.MemoryInfo().MemoryAlign)}
+		for index, field := range t.Fields() {
+			b.declare(field.FieldType())
+			declaration.Fields = append(declaration.Fields, b.field(fmt.Sprintf("field%d", index), field.FieldType(), field.MemoryOffsetInfo()))
+		}
+		b.declarations = append(b.declarations, declaration)
+	case *typeinfo.CustomType:
+		b.declared[t] = true
+		declaration := &Custom{Name: b.names[t], Size: int(t.MemoryInfo().MemorySize), Align: int(t.MemoryInfo().MemoryAlign)}
+		for tag, variant := range t.Variants() {
+			declaredVariant := Variant{Name: Identifier(variant.Name()), Tag: tag}
+			for _, field := range variant.Fields() {
+				b.declare(field.FieldType())
+				declaredVariant.Fields = append(declaredVariant.Fields, b.field(fmt.Sprintf("field%d", field.Index()), field.FieldType(), field.MemoryOffsetInfo()))
+			}
+			declaration.Variants = append(declaration.Variants, declaredVariant)
+		}
+		b.declarations = append(b.declarations, declaration)
+	}
+}
+
+func (b *builder) externalFunction(name string, functionValue *decorated.FunctionValue, chunk *typeinfo.Chunk) (*ExternalFunction, error) {
+	externalFunction := &ExternalFunction{Name: name, Parameters: []Parameter{}}
+	if dectype.TypeIsTemplateHasLocalTypes(functionValue.ForcedFunctionType()) {
+		externalFunction.IsGeneric = true
+		return externalFunction, nil
+	}
+
+	infoType, consumeErr := chunk.Consume(functionValue.Type())
+	if consumeErr != nil {
+		return nil, fmt.Errorf("external function '%v' %w", name, consumeErr)
+	}
+
+	functionType, isFunctionType := Unalias(infoType).(*typeinfo.FunctionType)
+	if !isFunctionType {
+		return nil, fmt.Errorf("external function '%v' has type %v", name, infoType)
+	}
+
+	// The last type is the return type
+	types := functionType.ParameterTypes()
+	for index, parameterType := range types[:len(types)-1] {
+		parameterName := fmt.Sprintf("p%d", index)
+		if index < len(functionValue.Parameters()) {
+			if name := functionValue.Parameters()[index].Parameter().Name(); name != "" && name != "_" {
+				parameterName = Identifier(name)
+			}
+		}
+		b.declare(parameterType)
+		externalFunction.Parameters = append(externalFunction.Parameters, Parameter{Name: parameterName, Type: b.TypeName(parameterType), infoType: Unalias(parameterType)})
+	}
+
+	// The type ids are taken from the same stack layout as the code generator, a type reference only has the type id
+	// slot and is passed as the value itself.
+	layout := generate_sp.CalculateExternalFunctionStackLayout(b.dataLayout, functionValue.ForcedFunctionType(), len(functionValue.Parameters()))
+	for index, slot := range layout.Slots {
+		isOnlySlot := index+1 == len(layout.Slots) || layout.Slots[index+1].ParameterIndex != slot.ParameterIndex
+		if slot.IsTypeId && !isOnlySlot && slot.ParameterIndex < len(externalFunction.Parameters) {
+			externalFunction.Parameters[slot.ParameterIndex].HasTypeId = true
+		}
+	}
+
+	if layout.Return.Size > 0 {
+		returnType := types[len(types)-1]
+		b.declare(returnType)
+		externalFunction.Return = &Parameter{Name: "result", Type: b.TypeName(returnType), infoType: Unalias(returnType)}
+	}
+
+	return externalFunction, nil
+}
+
+// FromPackages collects the layout of all types that are used by the definitions in the packages, and the signatures
+// of the external functions, with the sizes of the dataLayout. The core modules are not included.
+func FromPackages(compiledPackages []*loader.Package, dataLayout dectype.DataLayout) (*Bindings, error) {
+	chunk := typeinfo.NewChunk(dataLayout)
+
+	type external struct {
+		name          string
+		functionValue *decorated.FunctionValue
+	}
+	var externals []external
+
+	for _, compiledPackage := range compiledPackages {
+		for _, module := range compiledPackage.AllModules() {
+			if module.IsInternal() {
+				continue
+			}
+			for _, named := range module.LocalDefinitions().Definitions() {
+				functionValue, isFunction := named.Expression().(*decorated.FunctionValue)
+				if isFunction && functionValue.IsSomeKindOfExternal() {
+					externals = append(externals, external{name: module.FullyQualifiedName(named.Identifier()).String(), functionValue: functionValue})
+					continue
+				}
+				// types that can not be described, e.g. generic functions, are not part of the bindings
+				chunk.ConsumeType(named.Expression().Type())
+			}
+		}
+	}
+
+	// The function types of the externals must be in the chunk before the names are given
+	for _, foundExternal := range externals {
+		if !dectype.TypeIsTemplateHasLocalTypes(foundExternal.functionValue.ForcedFunctionType()) {
+			if _, err := chunk.Consume(foundExternal.functionValue.Type()); err != nil {
+				return nil, fmt.Errorf("external function '%v' %w", foundExternal.name, err)
+			}
+		}
+	}
+
+	b := &builder{names: make(map[typeinfo.InfoType]string), declared: make(map[typeinfo.InfoType]bool), dataLayout: dataLayout}
+	b.nameTypes(chunk.InfoTypes())
+
+	for _, infoType := range chunk.InfoTypes() {
+		b.declare(infoType)
+	}
+
+	bindings := &Bindings{}
+	for _, foundExternal := range externals {
+		externalFunction, err := b.externalFunction(foundExternal.name, foundExternal.functionValue, chunk)
+		if err != nil {
+			return nil, err
+		}
+		bindings.ExternalFunctions = append(bindings.ExternalFunctions, externalFunction)
+	}
+	sort.Slice(bindings.ExternalFunctions, func(i, j int) bool {
+		return bindings.ExternalFunctions[i].Name < bindings.ExternalFunctions[j].Name
+	})
+
+	bindings.Declarations = b.declarations
+
+	return bindings, nil
+}
@@ -0,0 +1,325 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package bindings
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	deccy "github.com/swamp/compiler/src/decorated"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+	"github.com/swamp/compiler/src/loader"
+	"github.com/swamp/compiler/src/parser"
+)
+
+const testCode = `
+type alias Position =
+    { x : Int
+    , y : Int
+    , speed : Fixed
+    }
+
+
+type Shape =
+    Circle Int
+    | Box Position Bool
+
+
+__externalfn log : (value: Any, level: Int) -> Bool
+
+
+__externalfn move : (position: Position, shape: Shape, name: String) -> Position
+
+
+__externalfn reset : (pair: (Int, Bool)) -> Int
+
+
+__externalvarfn map : ((a -> b), List a) -> List b
+`
+
+func testBindings(t *testing.T) *Bindings {
+	t.Helper()
+	const useCores = false
+	const errorsAsWarnings = false
+	module, compileErr := deccy.CompileToModuleOnceForTest(strings.TrimSpace(testCode), useCores, errorsAsWarnings)
+	if parser.IsCompileError(compileErr) {
+		t.Fatal(compileErr)
+	}
+
+	compiledPackage := loader.NewPackage(loader.LocalFileSystemRoot(""), "someName", dectype.DataLayoutLP64)
+	compiledPackage.AddModule(dectype.MakeArtifactFullyQualifiedModuleName(nil), module)
+
+	foundBindings, bindingsErr := FromPackages([]*loader.Package{compiledPackage}, dectype.DataLayoutLP64)
+	if bindingsErr != nil {
+		t.Fatal(bindingsErr)
+	}
+
+	return foundBindings
+}
+
+func TestWriteC(t *testing.T) {
+	var written bytes.Buffer
+	WriteC(&written, testBindings(t), "game")
+
+	const expected = `
+/* Generated by swamp bindings. Do not edit. */
+
+#ifndef GAME_H
+#define GAME_H
+
+#include <stddef.h>
+#include <stdint.h>
+
+struct SwampString;
+struct SwampList;
+struct SwampArray;
+struct SwampBlob;
+struct SwampFunc;
+struct SwampUnmanaged;
+
+typedef struct Position {
+    int32_t speed; /* Fixed */
+    int32_t x;
+    int32_t y;
+} Position;
+_Static_assert(offsetof(Position, speed) == 0, "Position.speed offset");
+_Static_assert(offsetof(Position, x) == 4, "Position.x offset");
+_Static_assert(offsetof(Position, y) == 8, "Position.y offset");
+_Static_assert(sizeof(Position) == 12, "Position size");
+_Static_assert(_Alignof(Position) == 4, "Position align");
+
+typedef enum ShapeTag {
+    ShapeTag_Circle = 0,
+    ShapeTag_Box = 1,
+} ShapeTag;
+
+typedef struct Shape_Circle {
+    uint8_t tag;
+    uint8_t _pad0[3];
+    int32_t field0;
+    uint8_t _pad1[12];
+} Shape_Circle;
+_Static_assert(offsetof(Shape_Circle, field0) == 4, "Shape_Circle.field0 offset");
+_Static_assert(sizeof(Shape_Circle) == 20, "Shape_Circle size");
+
+typedef struct Shape_Box {
+    uint8_t tag;
+    uint8_t _pad0[3];
+    Position field0;
+    uint8_t field1;
+    uint8_t _pad1[3];
+} Shape_Box;
+_Static_assert(offsetof(Shape_Box, field0) == 4, "Shape_Box.field0 offset");
+_Static_assert(offsetof(Shape_Box, field1) == 16, "Shape_Box.field1 offset");
+_Static_assert(sizeof(Shape_Box) == 20, "Shape_Box size");
+
+typedef union Shape {
+    uint8_t tag;
+    Shape_Circle Circle;
+    Shape_Box Box;
+} Shape;
+_Static_assert(sizeof(Shape) == 20, "Shape size");
+_Static_assert(_Alignof(Shape) == 4, "Shape align");
+
+typedef struct Tuple12 {
+    int32_t field0;
+    uint8_t field1;
+    uint8_t _pad0[3];
+} Tuple12;
+_Static_assert(offsetof(Tuple12, field0) == 0, "Tuple12.field0 offset");
+_Static_assert(offsetof(Tuple12, field1) == 4, "Tuple12.field1 offset");
+_Static_assert(sizeof(Tuple12) == 8, "Tuple12 size");
+_Static_assert(_Alignof(Tuple12) == 4, "Tuple12 align");
+
+/* log */
+typedef void (*SwampHost_log)(uint8_t* result, int32_t valueTypeId, const void* value, int32_t level);
+
+/* map is generic, the arguments are preceded by type IDs and have no fixed layout */
+
+/* move */
+typedef void (*SwampHost_move)(Position* result, const Position* position, const Shape* shape, const struct SwampString* name);
+
+/* reset */
+typedef void (*SwampHost_reset)(int32_t* result, const Tuple12* pair);
+
+#endif
+`
+	if written.String() != strings.TrimLeft(expected, "\n") {
+		t.Errorf("C header mismatch, got:\n%v", written.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var written bytes.Buffer
+	if err := WriteJSON(&written, testBindings(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = `
+{
+  "records": [
+    {
+      "name": "Position",
+      "kind": "record",
+      "size": 12,
+      "align": 4,
+      "fields": [
+        {
+          "name": "speed",
+          "type": "Fixed",
+          "offset": 0,
+          "size": 4,
+          "align": 4
+        },
+        {
+          "name": "x",
+          "type": "Int",
+          "offset": 4,
+          "size": 4,
+          "align": 4
+        },
+        {
+          "name": "y",
+          "type": "Int",
+          "offset": 8,
+          "size": 4,
+          "align": 4
+        }
+      ]
+    }
+  ],
+  "tuples": [
+    {
+      "name": "Tuple12",
+      "kind": "tuple",
+      "size": 8,
+      "align": 4,
+      "fields": [
+        {
+          "name": "field0",
+          "type": "Int",
+          "offset": 0,
+          "size": 4,
+          "align": 4
+        },
+        {
+          "name": "field1",
+          "type": "Bool",
+          "offset": 4,
+          "size": 1,
+          "align": 1
+        }
+      ]
+    }
+  ],
+  "customs": [
+    {
+      "name": "Shape",
+      "size": 20,
+      "align": 4,
+      "variants": [
+        {
+          "name": "Circle",
+          "tag": 0,
+          "fields": [
+            {
+              "name": "field0",
+              "type": "Int",
+              "offset": 4,
+              "size": 4,
+              "align": 4
+            }
+          ]
+        },
+        {
+          "name": "Box",
+          "tag": 1,
+          "fields": [
+            {
+              "name": "field0",
+              "type": "Position",
+              "offset": 4,
+              "size": 12,
+              "align": 4
+            },
+            {
+              "name": "field1",
+              "type": "Bool",
+              "offset": 16,
+              "size": 1,
+              "align": 1
+            }
+          ]
+        }
+      ]
+    }
+  ],
+  "externalFunctions": [
+    {
+      "name": "log",
+      "parameters": [
+        {
+          "name": "value",
+          "type": "Any",
+          "hasTypeId": true
+        },
+        {
+          "name": "level",
+          "type": "Int"
+        }
+      ],
+      "return": {
+        "name": "result",
+        "type": "Bool"
+      }
+    },
+    {
+      "name": "map",
+      "parameters": [],
+      "isGeneric": true
+    },
+    {
+      "name": "move",
+      "parameters": [
+        {
+          "name": "position",
+          "type": "Position"
+        },
+        {
+          "name": "shape",
+          "type": "Shape"
+        },
+        {
+          "name": "name",
+          "type": "String"
+        }
+      ],
+      "return": {
+        "name": "result",
+        "type": "Position"
+      }
+    },
+    {
+      "name": "reset",
+      "parameters": [
+        {
+          "name": "pair",
+          "type": "Tuple12"
+        }
+      ],
+      "return": {
+        "name": "result",
+        "type": "Int"
+      }
+    }
+  ]
+}
+`
+	if written.String() != strings.TrimLeft(expected, "\n") {
+		t.Errorf("JSON mismatch, got:\n%v", written.String())
+	}
+}
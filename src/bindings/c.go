@@ -0,0 +1,194 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package bindings
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/swamp/compiler/src/typeinfo"
+)
+
+func isAggregate(infoType typeinfo.InfoType) bool {
+	switch infoType.(type) {
+	case *typeinfo.RecordType, *typeinfo.TupleType, *typeinfo.CustomType:
+		return true
+	}
+
+	return false
+}
+
+// cType returns the C type for a field or parameter. Everything that is not stored by value is a pointer to a
+// struct that is defined by the runtime.
+func cType(infoType typeinfo.InfoType, name string) string {
+	switch infoType.(type) {
+	case *typeinfo.IntType, *typeinfo.FixedType, *typeinfo.ResourceNameType, *typeinfo.TypeRefIdType:
+		return "int32_t"
+	case *typeinfo.CharacterType:
+		return "uint32_t"
+	case *typeinfo.BoolType:
+		return "uint8_t"
+	case *typeinfo.StringType:
+		return "const struct SwampString*"
+	case *typeinfo.ListType:
+		return "const struct SwampList*"
+	case *typeinfo.ArrayType:
+		return "const struct SwampArray*"
+	case *typeinfo.BlobType:
+		return "const struct SwampBlob*"
+	case *typeinfo.FunctionType:
+		return "const struct SwampFunc*"
+	case *typeinfo.UnmanagedType:
+		return "struct SwampUnmanaged*"
+	case *typeinfo.RecordType, *typeinfo.TupleType, *typeinfo.CustomType:
+		return name
+	}
+
+	return "const void*"
+}
+
+// CType returns the C type of the parameter value as it is stored on the stack.
+func (p Parameter) CType() string {
+	return cType(p.infoType, p.Type)
+}
+
+// IsAggregate returns true if the parameter is a record, tuple or custom type, that is stored by value on the stack.
+func (p Parameter) IsAggregate() bool {
+	return isAggregate(p.infoType)
+}
+
+func cField(field Field) string {
+	declaration := fmt.Sprintf("%v %v;", cType(field.infoType, field.Type), field.Name)
+	if _, isFixed := field.infoType.(*typeinfo.FixedType); isFixed {
+		declaration += " /* Fixed */"
+	}
+
+	return declaration
+}
+
+type cWriter struct {
+	writer io.Writer
+}
+
+func (w cWriter) printf(format string, a ...interface{}) {
+	fmt.Fprintf(w.writer, format, a...)
+}
+
+// writeFields writes the fields with explicit padding between them, and at the end up to size.
+func (w cWriter) writeFields(fields []Field, startOffset int, size int) {
+	offset := startOffset
+	padCount := 0
+	pad := func(to int) {
+		if to > offset {
+			w.printf("    uint8_t _pad%d[%d];\n", padCount, to-offset)
+			padCount++
+			offset = to
+		}
+	}
+
+	for _, field := range fields {
+		pad(field.Offset)
+		w.printf("    %v\n", cField(field))
+		offset = field.Offset + field.Size
+	}
+	pad(size)
+}
+
+func (w cWriter) writeAsserts(name string, fields []Field, size int, align int) {
+	for _, field := range fields {
+		w.printf("_Static_assert(offsetof(%v, %v) == %d, \"%v.%v offset\");\n", name, field.Name, field.Offset, name, field.Name)
+	}
+	w.printf("_Static_assert(sizeof(%v) == %d, \"%v size\");\n", name, size, name)
+	if align != 0 {
+		w.printf("_Static_assert(_Alignof(%v) == %d, \"%v align\");\n", name, align, name)
+	}
+	w.printf("\n")
+}
+
+func (w cWriter) writeStruct(s *Struct) {
+	w.printf("typedef struct %v {\n", s.Name)
+	w.writeFields(s.Fields, 0, s.Size)
+	w.printf("} %v;\n", s.Name)
+	w.writeAsserts(s.Name, s.Fields, s.Size, s.Align)
+}
+
+func (w cWriter) writeCustom(c *Custom) {
+	w.printf("typedef enum %vTag {\n", c.Name)
+	for _, variant := range c.Variants {
+		w.printf("    %vTag_%v = %d,\n", c.Name, variant.Name, variant.Tag)
+	}
+	w.printf("} %vTag;\n\n", c.Name)
+
+	for _, variant := range c.Variants {
+		variantName := c.Name + "_" + variant.Name
+		w.printf("typedef struct %v {\n", variantName)
+		w.printf("    uint8_t tag;\n")
+		w.writeFields(variant.Fields, 1, c.Size)
+		w.printf("} %v;\n", variantName)
+		// a variant can have a smaller alignment than the custom type, only the union is aligned as the custom type
+		w.writeAsserts(variantName, variant.Fields, c.Size, 0)
+	}
+
+	w.printf("typedef union %v {\n    uint8_t tag;\n", c.Name)
+	for _, variant := range c.Variants {
+		w.printf("    %v_%v %v;\n", c.Name, variant.Name, variant.Name)
+	}
+	w.printf("} %v;\n", c.Name)
+	w.writeAsserts(c.Name, nil, c.Size, c.Align)
+}
+
+func (w cWriter) writeExternalFunction(externalFunction *ExternalFunction) {
+	typedefName := "SwampHost_" + Identifier(externalFunction.Name)
+	if externalFunction.IsGeneric {
+		w.printf("/* %v is generic, the arguments are preceded by type IDs and have no fixed layout */\n\n", externalFunction.Name)
+		return
+	}
+
+	var parameters []string
+	if externalFunction.Return != nil {
+		parameters = append(parameters, fmt.Sprintf("%v* %v", externalFunction.Return.CType(), externalFunction.Return.Name))
+	}
+	for _, parameter := range externalFunction.Parameters {
+		if parameter.HasTypeId {
+			parameters = append(parameters, "int32_t "+parameter.Name+"TypeId")
+		}
+		parameterType := parameter.CType()
+		if parameter.IsAggregate() {
+			parameterType = "const " + parameterType + "*"
+		}
+		parameters = append(parameters, parameterType+" "+parameter.Name)
+	}
+
+	w.printf("/* %v */\n", externalFunction.Name)
+	w.printf("typedef void (*%v)(%v);\n\n", typedefName, strings.Join(parameters, ", "))
+}
+
+// WriteC writes a C11 header with a struct for each record and tuple, and a tag enum and a union for each custom type.
+// The offsets, sizes and alignments are checked with `_Static_assert`.
+func WriteC(writer io.Writer, bindings *Bindings, guardName string) {
+	w := cWriter{writer: writer}
+
+	guard := strings.ToUpper(Identifier(guardName)) + "_H"
+	w.printf("/* Generated by swamp bindings. Do not edit. */\n\n#ifndef %v\n#define %v\n\n", guard, guard)
+	w.printf("#include <stddef.h>\n#include <stdint.h>\n\n")
+	w.printf("struct SwampString;\nstruct SwampList;\nstruct SwampArray;\nstruct SwampBlob;\nstruct SwampFunc;\nstruct SwampUnmanaged;\n\n")
+
+	for _, declaration := range bindings.Declarations {
+		switch d := declaration.(type) {
+		case *Struct:
+			w.writeStruct(d)
+		case *Custom:
+			w.writeCustom(d)
+		}
+	}
+
+	for _, externalFunction := range bindings.ExternalFunctions {
+		w.writeExternalFunction(externalFunction)
+	}
+
+	w.printf("#endif\n")
+}
@@ -0,0 +1,47 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package bindings
+
+import (
+	"encoding/json"
+	"io"
+)
+
+func WriteJSON(writer io.Writer, bindings *Bindings) error {
+	output := struct {
+		Records           []*Struct           `json:"records"`
+		Tuples            []*Struct           `json:"tuples"`
+		Customs           []*Custom           `json:"customs"`
+		ExternalFunctions []*ExternalFunction `json:"externalFunctions"`
+	}{
+		Records:           []*Struct{},
+		Tuples:            []*Struct{},
+		Customs:           []*Custom{},
+		ExternalFunctions: bindings.ExternalFunctions,
+	}
+
+	if output.ExternalFunctions == nil {
+		output.ExternalFunctions = []*ExternalFunction{}
+	}
+
+	for _, declaration := range bindings.Declarations {
+		switch d := declaration.(type) {
+		case *Struct:
+			if d.Kind == "tuple" {
+				output.Tuples = append(output.Tuples, d)
+			} else {
+				output.Records = append(output.Records, d)
+			}
+		case *Custom:
+			output.Customs = append(output.Customs, d)
+		}
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(output)
+}
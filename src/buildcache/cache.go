@@ -0,0 +1,159 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package buildcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+)
+
+// DirectoryName is the name of the cache directory that is created in the output directory.
+const DirectoryName = ".swamp-cache"
+
+type Key [sha256.Size]byte
+
+func (k Key) String() string {
+	return hex.EncodeToString(k[:])
+}
+
+// Cache stores the `.swamp-pack` output of packages, keyed by a hash of everything that the output depends on.
+type Cache struct {
+	directory       string
+	compilerVersion string
+}
+
+func NewCache(directory string, compilerVersion string) *Cache {
+	return &Cache{directory: directory, compilerVersion: compilerVersion}
+}
+
+func DirectoryFromOutput(outputDirectory string) string {
+	return path.Join(outputDirectory, DirectoryName)
+}
+
+func (c *Cache) Directory() string {
+	return c.directory
+}
+
+func (c *Cache) CompilerVersion() string {
+	return c.compilerVersion
+}
+
+func (c *Cache) filename(key Key) string {
+	return path.Join(c.directory, fmt.Sprintf("%v.swamp-pack", key))
+}
+
+// Diagnostic is a warning or note that was reported when the output was stored, so that it can be reported again when
+// the output is reused. File is relative to the source root for the files in the solution, and a URI for other files.
+type Diagnostic struct {
+	File        string `json:"file"`
+	StartLine   int    `json:"startLine"`
+	StartColumn int    `json:"startColumn"`
+	EndLine     int    `json:"endLine"`
+	EndColumn   int    `json:"endColumn"`
+	Message     string `json:"message"`
+	IsNote      bool   `json:"isNote"`
+}
+
+func (c *Cache) diagnosticsFilename(key Key) string {
+	return path.Join(c.directory, fmt.Sprintf("%v.diagnostics.json", key))
+}
+
+// Lookup returns the cached output for the key, and the diagnostics that were reported when it was built, if there is
+// one.
+func (c *Cache) Lookup(key Key) ([]byte, []Diagnostic, bool) {
+	octets, readErr := os.ReadFile(c.filename(key))
+	if readErr != nil {
+		return nil, nil, false
+	}
+
+	diagnosticsOctets, diagnosticsErr := os.ReadFile(c.diagnosticsFilename(key))
+	if diagnosticsErr != nil {
+		return nil, nil, false
+	}
+
+	var diagnostics []Diagnostic
+	if err := json.Unmarshal(diagnosticsOctets, &diagnostics); err != nil {
+		return nil, nil, false
+	}
+
+	return octets, diagnostics, true
+}
+
+// Store saves the output and the diagnostics for the key. The diagnostics are stored first, so that a Lookup never
+// finds an output without its diagnostics.
+func (c *Cache) Store(key Key, octets []byte, diagnostics []Diagnostic) error {
+	if diagnostics == nil {
+		diagnostics = []Diagnostic{}
+	}
+
+	diagnosticsOctets, marshalErr := json.Marshal(diagnostics)
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	if err := c.writeFile(c.diagnosticsFilename(key), diagnosticsOctets); err != nil {
+		return err
+	}
+
+	return c.writeFile(c.filename(key), octets)
+}
+
+// writeFile writes the file in the cache directory. The file is renamed into place, so a concurrent Lookup never reads
+// a partial file.
+func (c *Cache) writeFile(filename string, octets []byte) error {
+	if err := os.MkdirAll(c.directory, 0o755); err != nil {
+		return err
+	}
+
+	tempFile, tempErr := os.CreateTemp(c.directory, "store-*")
+	if tempErr != nil {
+		return tempErr
+	}
+
+	_, writeErr := tempFile.Write(octets)
+	closeErr := tempFile.Close()
+	if writeErr != nil || closeErr != nil {
+		os.Remove(tempFile.Name())
+		if writeErr != nil {
+			return writeErr
+		}
+		return closeErr
+	}
+
+	return os.Rename(tempFile.Name(), filename)
+}
+
+// Clean removes the cache directory and everything in it.
+func (c *Cache) Clean() error {
+	return os.RemoveAll(c.directory)
+}
+
+// ExecutableVersion combines the version with a hash of the running executable, so that
+// development builds that all have the same version string do not share cached output.
+func ExecutableVersion(version string) string {
+	executable, executableErr := os.Executable()
+	if executableErr != nil {
+		return version
+	}
+
+	executableFile, openErr := os.Open(executable)
+	if openErr != nil {
+		return version
+	}
+	defer executableFile.Close()
+
+	hasher := sha256.New()
+	if _, copyErr := io.Copy(hasher, executableFile); copyErr != nil {
+		return version
+	}
+
+	return fmt.Sprintf("%v-%x", version, hasher.Sum(nil))
+}
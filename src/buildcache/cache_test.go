@@ -0,0 +1,254 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package buildcache
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/swamp/compiler/src/environment"
+)
+
+func writeFiles(t *testing.T, directory string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		filename := filepath.Join(directory, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// writeSolution writes a package `game` that maps the modules of the package `lib`.
+func writeSolution(t *testing.T) string {
+	t.Helper()
+	directory := t.TempDir()
+	writeFiles(t, directory, map[string]string{
+		"game/.swamp.toml": "[[module]]\nname = \"Lib\"\npath = \"../lib\"\n",
+		"game/Main.swamp":  "main : (x: Int) -> Int =\n    x\n",
+		"lib/.swamp.toml":  "",
+		"lib/Main.swamp":   "double : (x: Int) -> Int =\n    x * 2\n",
+	})
+
+	return directory
+}
+
+func TestCalculateKey(t *testing.T) {
+	const flags = "name:game dataLayout:lp64 optimize:0"
+
+	for _, testCase := range []struct {
+		name          string
+		change        func(t *testing.T, directory string) (string, string)
+		expectChanged bool
+	}{
+		{"unchanged", func(t *testing.T, directory string) (string, string) {
+			return directory, flags
+		}, false},
+		{"changed source", func(t *testing.T, directory string) (string, string) {
+			writeFiles(t, directory, map[string]string{"game/Main.swamp": "main : (x: Int) -> Int =\n    x + 1\n"})
+			return directory, flags
+		}, true},
+		{"added module", func(t *testing.T, directory string) (string, string) {
+			writeFiles(t, directory, map[string]string{"game/Other.swamp": "other : (x: Int) -> Int =\n    x\n"})
+			return directory, flags
+		}, true},
+		{"changed dependency", func(t *testing.T, directory string) (string, string) {
+			writeFiles(t, directory, map[string]string{"lib/Main.swamp": "double : (x: Int) -> Int =\n    x + x\n"})
+			return directory, flags
+		}, true},
+		{"changed settings", func(t *testing.T, directory string) (string, string) {
+			writeFiles(t, directory, map[string]string{"game/.swamp.toml": "[[module]]\nname = \"Library\"\npath = \"../lib\"\n"})
+			return directory, flags
+		}, true},
+		{"changed resource lock", func(t *testing.T, directory string) (string, string) {
+			writeFiles(t, directory, map[string]string{"game/resources.lock": "[[Resource]]\nName = \"hero\"\nID = 0\n"})
+			return directory, flags
+		}, true},
+		{"changed data layout", func(t *testing.T, directory string) (string, string) {
+			return directory, "name:game dataLayout:wasm32 optimize:0"
+		}, true},
+		{"changed optimize level", func(t *testing.T, directory string) (string, string) {
+			return directory, "name:game dataLayout:lp64 optimize:2"
+		}, true},
+		{"file in hidden directory", func(t *testing.T, directory string) (string, string) {
+			writeFiles(t, directory, map[string]string{"game/.swamp-cache/Cached.swamp": "cached"})
+			return directory, flags
+		}, false},
+		{"other file", func(t *testing.T, directory string) (string, string) {
+			writeFiles(t, directory, map[string]string{"game/notes.txt": "notes"})
+			return directory, flags
+		}, false},
+		{"solution in another directory", func(t *testing.T, directory string) (string, string) {
+			moved := filepath.Join(t.TempDir(), "moved")
+			if err := os.Rename(directory, moved); err != nil {
+				t.Fatal(err)
+			}
+			return moved, flags
+		}, false},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			cache := NewCache(t.TempDir(), "1.0")
+			directory := writeSolution(t)
+
+			before, beforeErr := cache.CalculateKey(filepath.Join(directory, "game"), directory, environment.Environment{}, flags)
+			if beforeErr != nil {
+				t.Fatal(beforeErr)
+			}
+
+			changedDirectory, changedFlags := testCase.change(t, directory)
+			after, afterErr := cache.CalculateKey(filepath.Join(changedDirectory, "game"), changedDirectory, environment.Environment{}, changedFlags)
+			if afterErr != nil {
+				t.Fatal(afterErr)
+			}
+
+			if (before != after) != testCase.expectChanged {
+				t.Errorf("expected the key to change: %v, before %v after %v", testCase.expectChanged, before, after)
+			}
+		})
+	}
+}
+
+func TestCalculateKeyThroughArchive(t *testing.T) {
+	directory := t.TempDir()
+	writeFiles(t, directory, map[string]string{
+		"game/.swamp.toml": "[[module]]\nname = \"Engine\"\npath = \"../engine.swamp-lib\"\n",
+		"game/Main.swamp":  "main : (x: Int) -> Int =\n    x\n",
+		"math/Main.swamp":  "double : (x: Int) -> Int =\n    x * 2\n",
+	})
+
+	archiveFile, createErr := os.Create(filepath.Join(directory, "engine.swamp-lib"))
+	if createErr != nil {
+		t.Fatal(createErr)
+	}
+	zipWriter := zip.NewWriter(archiveFile)
+	settingsWriter, writerErr := zipWriter.Create(".swamp.toml")
+	if writerErr != nil {
+		t.Fatal(writerErr)
+	}
+	if _, err := settingsWriter.Write([]byte("[[module]]\nname = \"Math\"\npath = \"../math\"\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := archiveFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewCache(t.TempDir(), "1.0")
+	before, beforeErr := cache.CalculateKey(filepath.Join(directory, "game"), directory, environment.Environment{}, "")
+	if beforeErr != nil {
+		t.Fatal(beforeErr)
+	}
+
+	// The directory is only mapped from the settings inside the archive
+	writeFiles(t, directory, map[string]string{"math/Main.swamp": "double : (x: Int) -> Int =\n    x + x\n"})
+
+	after, afterErr := cache.CalculateKey(filepath.Join(directory, "game"), directory, environment.Environment{}, "")
+	if afterErr != nil {
+		t.Fatal(afterErr)
+	}
+
+	if before == after {
+		t.Errorf("expected a change in a directory that an archive maps to change the key")
+	}
+}
+
+func TestCalculateKeyCompilerVersion(t *testing.T) {
+	directory := writeSolution(t)
+	packageDirectory := filepath.Join(directory, "game")
+
+	first, firstErr := NewCache(t.TempDir(), "1.0").CalculateKey(packageDirectory, directory, environment.Environment{}, "")
+	second, secondErr := NewCache(t.TempDir(), "1.1").CalculateKey(packageDirectory, directory, environment.Environment{}, "")
+	if firstErr != nil || secondErr != nil {
+		t.Fatal(firstErr, secondErr)
+	}
+
+	if first == second {
+		t.Errorf("expected another compiler version to change the key")
+	}
+}
+
+func TestLookupAndStore(t *testing.T) {
+	diagnostics := []Diagnostic{
+		{File: "game/Main.swamp", StartLine: 1, StartColumn: 4, EndLine: 1, EndColumn: 9, Message: "unused"},
+		{File: "file:///libs/Lib.swamp", StartLine: 3, EndLine: 3, EndColumn: 2, Message: "note", IsNote: true},
+	}
+
+	for _, testCase := range []struct {
+		name                string
+		store               []Diagnostic
+		remove              string
+		expectFound         bool
+		expectedDiagnostics []Diagnostic
+	}{
+		{"with diagnostics", diagnostics, "", true, diagnostics},
+		{"without diagnostics", nil, "", true, []Diagnostic{}},
+		{"missing output", diagnostics, ".swamp-pack", false, nil},
+		{"missing diagnostics", diagnostics, ".diagnostics.json", false, nil},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			cache := NewCache(filepath.Join(t.TempDir(), DirectoryName), "1.0")
+			key := Key{1, 2, 3}
+			octets := []byte("pack")
+
+			if _, _, wasFound := cache.Lookup(key); wasFound {
+				t.Fatalf("expected an empty cache")
+			}
+
+			if err := cache.Store(key, octets, testCase.store); err != nil {
+				t.Fatal(err)
+			}
+
+			if testCase.remove != "" {
+				if err := os.Remove(filepath.Join(cache.Directory(), fmt.Sprintf("%v%v", key, testCase.remove))); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			foundOctets, foundDiagnostics, wasFound := cache.Lookup(key)
+			if wasFound != testCase.expectFound {
+				t.Fatalf("expected found %v, got %v", testCase.expectFound, wasFound)
+			}
+			if !wasFound {
+				return
+			}
+			if !bytes.Equal(foundOctets, octets) {
+				t.Errorf("expected the stored output, got %q", foundOctets)
+			}
+			if !reflect.DeepEqual(foundDiagnostics, testCase.expectedDiagnostics) {
+				t.Errorf("expected the diagnostics %+v, got %+v", testCase.expectedDiagnostics, foundDiagnostics)
+			}
+
+			if _, _, otherFound := cache.Lookup(Key{4}); otherFound {
+				t.Errorf("expected another key to miss")
+			}
+		})
+	}
+}
+
+func TestClean(t *testing.T) {
+	cache := NewCache(filepath.Join(t.TempDir(), DirectoryName), "1.0")
+	if err := cache.Store(Key{1}, []byte("pack"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.Clean(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, wasFound := cache.Lookup(Key{1}); wasFound {
+		t.Errorf("expected the cache to be empty after clean")
+	}
+}
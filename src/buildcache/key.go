@@ -0,0 +1,189 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package buildcache
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/swamp/compiler/src/environment"
+	"github.com/swamp/compiler/src/file"
+	"github.com/swamp/compiler/src/pkgstore"
+	"github.com/swamp/compiler/src/resourceid"
+	"github.com/swamp/compiler/src/settings"
+)
+
+func isSourceFile(name string) bool {
+	return filepath.Ext(name) == ".swamp" || filepath.Ext(name) == pkgstore.ArchiveExtension || name == ".swamp.toml" ||
+		name == resourceid.LockFilename
+}
+
+// SourceFiles returns the `.swamp`, `.swamp-lib`, `.swamp.toml` and `resources.lock` files in the directory and its subdirectories, sorted
+// by name. Hidden subdirectories, like the build cache, are skipped. If the directory is a library archive, the archive is returned.
+func SourceFiles(directory string) ([]string, error) {
+	var filenames []string
+	walkErr := filepath.WalkDir(directory, func(filename string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if filename != directory && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isSourceFile(entry.Name()) {
+			filenames = append(filenames, filename)
+		}
+		return nil
+	})
+
+	sort.Strings(filenames)
+
+	return filenames, walkErr
+}
+
+type keyHasher struct {
+	hasher        hash.Hash
+	configuration environment.Environment
+	sourceRoot    string
+	visited       map[string]bool
+}
+
+// relativeToSourceRoot returns the path relative to the source root, so that the key is the same wherever the
+// solution is checked out. The path is used as is if there is no source root, or it is on another volume.
+func (k *keyHasher) relativeToSourceRoot(filename string) string {
+	if k.sourceRoot == "" {
+		return filepath.ToSlash(filename)
+	}
+
+	absoluteFilename, absErr := filepath.Abs(filename)
+	if absErr != nil {
+		return filepath.ToSlash(filename)
+	}
+
+	relativeFilename, relErr := filepath.Rel(k.sourceRoot, absoluteFilename)
+	if relErr != nil {
+		return filepath.ToSlash(absoluteFilename)
+	}
+
+	return filepath.ToSlash(relativeFilename)
+}
+
+func (k *keyHasher) addFile(directory string, filename string) error {
+	relativeName, relErr := filepath.Rel(directory, filename)
+	if relErr != nil {
+		return relErr
+	}
+
+	sourceFile, openErr := os.Open(filename)
+	if openErr != nil {
+		return openErr
+	}
+	defer sourceFile.Close()
+
+	fileHasher := sha256.New()
+	if _, copyErr := io.Copy(fileHasher, sourceFile); copyErr != nil {
+		return copyErr
+	}
+
+	fmt.Fprintf(k.hasher, "file %v %x\n", filepath.ToSlash(relativeName), fileHasher.Sum(nil))
+
+	return nil
+}
+
+// addAssetNames adds the names of the files in the asset directory. Only the names are used, since they decide which
+// resource names are reported as missing.
+func (k *keyHasher) addAssetNames(assetDirectory string) error {
+	if !file.IsDir(assetDirectory) {
+		fmt.Fprintf(k.hasher, "assets %v missing\n", k.relativeToSourceRoot(assetDirectory))
+		return nil
+	}
+
+	fmt.Fprintf(k.hasher, "assets %v\n", k.relativeToSourceRoot(assetDirectory))
+
+	return filepath.WalkDir(assetDirectory, func(filename string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relativeName, relErr := filepath.Rel(assetDirectory, filename)
+		if relErr != nil {
+			return relErr
+		}
+		fmt.Fprintf(k.hasher, "asset %v\n", filepath.ToSlash(relativeName))
+		return nil
+	})
+}
+
+func (k *keyHasher) addDirectory(directory string) error {
+	absoluteDirectory, absErr := filepath.Abs(directory)
+	if absErr != nil {
+		return absErr
+	}
+	if k.visited[absoluteDirectory] {
+		return nil
+	}
+	k.visited[absoluteDirectory] = true
+
+	filenames, filesErr := SourceFiles(absoluteDirectory)
+	if filesErr != nil {
+		return filesErr
+	}
+
+	// The debug file URLs are relative to the source root, so the key depends on where the files are in the solution,
+	// but not on where the solution is
+	fmt.Fprintf(k.hasher, "directory %v %v\n", k.relativeToSourceRoot(absoluteDirectory), len(filenames))
+	for _, filename := range filenames {
+		if err := k.addFile(absoluteDirectory, filename); err != nil {
+			return err
+		}
+	}
+
+	foundSettings, settingsErr := settings.LoadFromDirectory(absoluteDirectory, k.configuration)
+	if settingsErr != nil {
+		return settingsErr
+	}
+
+	if foundSettings.Resources.Assets != "" {
+		if err := k.addAssetNames(foundSettings.Resources.Assets); err != nil {
+			return err
+		}
+	}
+
+	for _, module := range foundSettings.Module {
+		fmt.Fprintf(k.hasher, "module %v %v\n", module.Name, k.relativeToSourceRoot(module.Path))
+		if err := k.addDirectory(module.Path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CalculateKey hashes the source files and `.swamp.toml` of the package, and of all the packages that
+// it maps modules from, together with the compiler version and the flags that affect the output. The directories are
+// hashed relative to the absolute sourceRoot, the same directory that the debug file URLs are relative to.
+func (c *Cache) CalculateKey(packageDirectory string, sourceRoot string, configuration environment.Environment, flags string) (Key, error) {
+	k := &keyHasher{hasher: sha256.New(), configuration: configuration, sourceRoot: sourceRoot, visited: make(map[string]bool)}
+
+	fmt.Fprintf(k.hasher, "compiler %v\nflags %v\n", c.compilerVersion, flags)
+
+	if err := k.addDirectory(packageDirectory); err != nil {
+		return Key{}, err
+	}
+
+	var key Key
+	copy(key[:], k.hasher.Sum(nil))
+
+	return key, nil
+}
@@ -0,0 +1,177 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package swampcompiler
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/swamp/compiler/src/buildcache"
+	"github.com/swamp/compiler/src/decorated/decshared"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	"github.com/swamp/compiler/src/environment"
+	"github.com/swamp/compiler/src/loader"
+	"github.com/swamp/compiler/src/parser"
+	"github.com/swamp/compiler/src/settings"
+	"github.com/swamp/compiler/src/token"
+	"github.com/swamp/compiler/src/verbosity"
+)
+
+func packOutputFilename(outputDirectory string, name string) string {
+	return path.Join(outputDirectory, fmt.Sprintf("%s.swamp-pack", name))
+}
+
+// compileAndLinkWithResources compiles the package with the resource name IDs from `resources.lock`, if it is enabled
+// in the package settings. The lock and the resource manifest are written when there are no compile errors.
+func compileAndLinkWithResources(target Target, options GenerateOptions, configuration environment.Environment, packageSettings settings.Settings, name string,
+	filename string, outputDirectory string, enforceStyle bool, verboseFlag verbosity.Verbosity, showAssembler bool) (*loader.Package, decshared.DecoratedError) {
+	resourceNameLookup, lookupErr := newResourceNameLookup(filename, packageSettings.Resources)
+	if lookupErr != nil {
+		return nil, decorated.NewInternalError(lookupErr)
+	}
+
+	compiledPackage, compileAndLinkErr := CompileAndLink(newGenerator(target, packageSettings, options), resourceNameLookup, options.OptimizeLevel, configuration, options.DataLayout, name, filename, outputDirectory, enforceStyle, verboseFlag, showAssembler)
+	if parser.IsCompileError(compileAndLinkErr) {
+		return compiledPackage, compileAndLinkErr
+	}
+
+	if saveErr := saveResources(resourceNameLookup, filename, packageSettings.Resources, outputDirectory, name); saveErr != nil {
+		return compiledPackage, decorated.AppendError(compileAndLinkErr, decorated.NewInternalError(saveErr))
+	}
+
+	return compiledPackage, compileAndLinkErr
+}
+
+// CompileAndLinkCached reuses the `.swamp-pack` from the cache if the package, and everything it depends on, is unchanged.
+// The returned package is nil when the output was taken from the cache. Output with warnings and notes is stored
+// together with them, and they are returned again when the output is reused. Output with errors is never stored.
+func CompileAndLinkCached(cache *buildcache.Cache, target Target, options GenerateOptions, configuration environment.Environment, name string,
+	filename string, outputDirectory string, enforceStyle bool, verboseFlag verbosity.Verbosity, showAssembler bool) (*loader.Package, decshared.DecoratedError) {
+	packageSettings, settingsErr := settings.LoadFromDirectory(filename, configuration)
+	if settingsErr != nil {
+		return nil, decorated.NewInternalError(settingsErr)
+	}
+
+	// The cache only stores the pack, so a stripped build, that also writes a `.swamp-pdb`, is always generated
+	if cache == nil || target != SwampOpcode || showAssembler || options.ReportDeadCode || options.ReportStack || options.Strip {
+		return compileAndLinkWithResources(target, options, configuration, packageSettings, name, filename, outputDirectory, enforceStyle, verboseFlag, showAssembler)
+	}
+
+	flags := fmt.Sprintf("name:%v target:%v enforceStyle:%v dataLayout:%v optimize:%v", name, target, enforceStyle,
+		options.DataLayout.Name, options.OptimizeLevel)
+	key, keyErr := cache.CalculateKey(filename, options.SourceRoot, configuration, flags)
+	if keyErr != nil {
+		return nil, decorated.NewInternalError(keyErr)
+	}
+
+	outputFilename := packOutputFilename(outputDirectory, name)
+
+	if cachedOctets, cachedDiagnostics, wasFound := cache.Lookup(key); wasFound {
+		if verboseFlag >= verbosity.Low {
+			log.Printf("cache hit for package '%v' (%v)", name, key)
+		}
+		if err := os.WriteFile(outputFilename, cachedOctets, 0o644); err != nil {
+			return nil, decorated.NewInternalError(err)
+		}
+		if err := saveResourcesFromPack(cachedOctets, filename, packageSettings.Resources, outputDirectory, name); err != nil {
+			return nil, decorated.NewInternalError(err)
+		}
+		return nil, cachedWarnings(cachedDiagnostics, options.SourceRoot)
+	}
+
+	if verboseFlag >= verbosity.Low {
+		log.Printf("cache miss for package '%v' (%v)", name, key)
+	}
+
+	compiledPackage, compileAndLinkErr := compileAndLinkWithResources(target, options, configuration, packageSettings, name, filename, outputDirectory, enforceStyle, verboseFlag, showAssembler)
+	if parser.IsCompileError(compileAndLinkErr) {
+		return compiledPackage, compileAndLinkErr
+	}
+
+	packOctets, readErr := os.ReadFile(outputFilename)
+	if readErr != nil {
+		return compiledPackage, decorated.AppendError(compileAndLinkErr, decorated.NewInternalError(readErr))
+	}
+
+	storeKeys := []buildcache.Key{key}
+	if packageSettings.Resources.Lock {
+		// The build can change `resources.lock`, that is part of the key. The output is also stored under the key that
+		// the package has after the build, otherwise the next build would always miss.
+		keyAfterBuild, keyAfterBuildErr := cache.CalculateKey(filename, options.SourceRoot, configuration, flags)
+		if keyAfterBuildErr != nil {
+			return compiledPackage, decorated.AppendError(compileAndLinkErr, decorated.NewInternalError(keyAfterBuildErr))
+		}
+		if keyAfterBuild != key {
+			storeKeys = append(storeKeys, keyAfterBuild)
+		}
+	}
+
+	for _, storeKey := range storeKeys {
+		if storeErr := cache.Store(storeKey, packOctets, cacheDiagnostics(compileAndLinkErr, options.SourceRoot)); storeErr != nil {
+			return compiledPackage, decorated.AppendError(compileAndLinkErr, decorated.NewInternalError(storeErr))
+		}
+	}
+
+	return compiledPackage, compileAndLinkErr
+}
+
+// cacheDiagnostics converts the warnings and notes so that they can be stored in the cache. The local files in the
+// source root are stored relative to it, in the same way as the cache key.
+func cacheDiagnostics(warnings decshared.DecoratedError, sourceRoot string) []buildcache.Diagnostic {
+	var diagnostics []buildcache.Diagnostic
+	for _, warning := range parser.Diagnostics(warnings) {
+		reference := warning.FetchPositionLength()
+		diagnostic := buildcache.Diagnostic{
+			StartLine:   reference.Range.Start().Line(),
+			StartColumn: reference.Range.Start().Column(),
+			EndLine:     reference.Range.End().Line(),
+			EndColumn:   reference.Range.End().Column(),
+			Message:     warning.Error(),
+			IsNote:      parser.TypeOfWarning(warning) == parser.ReportAsSeverityNote,
+		}
+
+		if reference.Document != nil {
+			diagnostic.File = string(reference.Document.Uri)
+			if localPath, localErr := reference.Document.Uri.ToLocalFilePath(); localErr == nil && filepath.IsAbs(localPath) {
+				if relativePath, relErr := filepath.Rel(sourceRoot, localPath); relErr == nil && !strings.HasPrefix(relativePath, "..") {
+					diagnostic.File = filepath.ToSlash(relativePath)
+				}
+			}
+		}
+
+		diagnostics = append(diagnostics, diagnostic)
+	}
+
+	return diagnostics
+}
+
+// cachedWarnings returns the stored diagnostics as warnings and notes, or nil if there are none.
+func cachedWarnings(diagnostics []buildcache.Diagnostic, sourceRoot string) decshared.DecoratedError {
+	var warnings []decshared.DecoratedError
+	for _, diagnostic := range diagnostics {
+		var document *token.SourceFileDocument
+		if strings.HasPrefix(diagnostic.File, "file://") {
+			document = &token.SourceFileDocument{Uri: token.DocumentURI(diagnostic.File)}
+		} else if diagnostic.File != "" {
+			localPath := filepath.Join(sourceRoot, filepath.FromSlash(diagnostic.File))
+			document = &token.SourceFileDocument{Uri: token.MakeDocumentURIFromLocalPath(localPath)}
+		}
+
+		tokenRange := token.MakeRange(token.MakePosition(diagnostic.StartLine, diagnostic.StartColumn, 0),
+			token.MakePosition(diagnostic.EndLine, diagnostic.EndColumn, 0))
+		warnings = append(warnings, decorated.NewCachedWarning(token.MakeSourceFileReference(document, tokenRange), diagnostic.Message, diagnostic.IsNote))
+	}
+
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	return decorated.NewMultiErrors(warnings)
+}
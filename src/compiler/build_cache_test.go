@@ -0,0 +1,176 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package swampcompiler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/swamp/compiler/src/buildcache"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+	"github.com/swamp/compiler/src/environment"
+	"github.com/swamp/compiler/src/optimize"
+	"github.com/swamp/compiler/src/parser"
+	"github.com/swamp/compiler/src/resourceid"
+	"github.com/swamp/compiler/src/verbosity"
+)
+
+// buildCached builds the package in the directory `game` with the cache, and returns true if the output was taken
+// from the cache.
+func buildCached(t *testing.T, cache *buildcache.Cache, sourceRoot string, options GenerateOptions) (bool, error) {
+	t.Helper()
+	options.SourceRoot = sourceRoot
+	compiledPackage, buildErr := CompileAndLinkCached(cache, SwampOpcode, options, environment.Environment{}, "game",
+		filepath.Join(sourceRoot, "game"), t.TempDir(), true, verbosity.None, false)
+	if parser.IsCompileError(buildErr) {
+		t.Fatal(buildErr)
+	}
+	if buildErr == nil {
+		return compiledPackage == nil, nil
+	}
+
+	return compiledPackage == nil, buildErr
+}
+
+func TestCompileAndLinkCached(t *testing.T) {
+	defaultOptions := GenerateOptions{DataLayout: dectype.DataLayoutLP64, OptimizeLevel: optimize.LevelNone}
+
+	for _, testCase := range []struct {
+		name      string
+		change    func(t *testing.T, directory string, options *GenerateOptions)
+		expectHit bool
+	}{
+		{"unchanged", func(t *testing.T, directory string, options *GenerateOptions) {}, true},
+		{"changed source", func(t *testing.T, directory string, options *GenerateOptions) {
+			writeFile(t, directory, "game/Main.swamp", "import Lib\n\n\nmain : (x: Int) -> Int =\n    Lib.double (x + 1)\n")
+		}, false},
+		{"changed dependency", func(t *testing.T, directory string, options *GenerateOptions) {
+			writeFile(t, directory, "lib/Main.swamp", "double : (x: Int) -> Int =\n    x + x\n")
+		}, false},
+		{"changed data layout", func(t *testing.T, directory string, options *GenerateOptions) {
+			options.DataLayout = dectype.DataLayoutILP32
+		}, false},
+		{"changed optimize level", func(t *testing.T, directory string, options *GenerateOptions) {
+			options.OptimizeLevel = optimize.LevelFull
+		}, false},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			directory := writeTestFiles(t, map[string]string{
+				"game/.swamp.toml": "[[module]]\nname = \"Lib\"\npath = \"../lib\"\n",
+				"game/Main.swamp":  "import Lib\n\n\nmain : (x: Int) -> Int =\n    Lib.double x\n",
+				"lib/.swamp.toml":  "",
+				"lib/Main.swamp":   "double : (x: Int) -> Int =\n    x * 2\n",
+			})
+			cache := buildcache.NewCache(t.TempDir(), "test")
+
+			if wasHit, _ := buildCached(t, cache, directory, defaultOptions); wasHit {
+				t.Fatalf("expected the first build to miss")
+			}
+
+			options := defaultOptions
+			testCase.change(t, directory, &options)
+			if wasHit, _ := buildCached(t, cache, directory, options); wasHit != testCase.expectHit {
+				t.Errorf("expected the cache hit to be %v, got %v", testCase.expectHit, wasHit)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, directory string, name string, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(directory, filepath.FromSlash(name)), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompileAndLinkCachedWarnings(t *testing.T) {
+	directory := writeTestFiles(t, map[string]string{
+		"game/.swamp.toml":             "[resources]\nassets = \"assets\"\n",
+		"game/Main.swamp":              "main : (x: Int) -> ResourceName =\n    if x > 0 then @sprites/hero else @sprites/enemy\n",
+		"game/assets/sprites/hero.png": "",
+	})
+	cache := buildcache.NewCache(t.TempDir(), "test")
+	options := GenerateOptions{DataLayout: dectype.DataLayoutLP64}
+
+	// The cached warnings only keep the lines and columns of the positions, not the octet offsets
+	describe := func(warnings error) string {
+		var description string
+		for _, warning := range parser.Diagnostics(warnings) {
+			reference := warning.FetchPositionLength()
+			description += fmt.Sprintf("%v:%v:%v-%v:%v %v\n", reference.Document.Uri, reference.Range.Start().Line(), reference.Range.Start().Column(),
+				reference.Range.End().Line(), reference.Range.End().Column(), warning.Error())
+		}
+		return description
+	}
+
+	wasHit, builtWarnings := buildCached(t, cache, directory, options)
+	if wasHit {
+		t.Fatalf("expected the first build to miss")
+	}
+	missingResourceWarnings := 0
+	for _, warning := range parser.Diagnostics(builtWarnings) {
+		if _, isMissingResource := warning.(*decorated.MissingResourceFileWarning); isMissingResource {
+			missingResourceWarnings++
+		}
+	}
+	if missingResourceWarnings != 1 {
+		t.Fatalf("expected one warning about the missing resource file, got %v", builtWarnings)
+	}
+
+	wasHit, cachedWarnings := buildCached(t, cache, directory, options)
+	if !wasHit {
+		t.Fatalf("expected the second build to hit")
+	}
+
+	if built, cached := describe(builtWarnings), describe(cachedWarnings); built != cached {
+		t.Errorf("expected the cached warnings to be the same as the built ones\nbuilt:\n%vcached:\n%v", built, cached)
+	}
+}
+
+func TestCompileAndLinkCachedResourceLock(t *testing.T) {
+	directory := writeTestFiles(t, map[string]string{
+		"game/.swamp.toml": "[resources]\nlock = true\n",
+		"game/Main.swamp":  "main : (x: Int) -> ResourceName =\n    if x > 0 then @sprites/hero else @sprites/enemy\n",
+	})
+	cache := buildcache.NewCache(t.TempDir(), "test")
+	options := GenerateOptions{DataLayout: dectype.DataLayoutLP64}
+
+	if wasHit, _ := buildCached(t, cache, directory, options); wasHit {
+		t.Fatalf("expected the first build to miss")
+	}
+
+	lock, hasLock, lockErr := resourceid.LoadLock(filepath.Join(directory, "game"))
+	if lockErr != nil || !hasLock {
+		t.Fatalf("expected the first build to write the resource lock %v", lockErr)
+	}
+	if len(lock.Resource) != 2 {
+		t.Errorf("expected two locked resource names, got %v", lock.Resource)
+	}
+
+	// The lock that the first build wrote is part of the key, but must not make the next build miss
+	if wasHit, _ := buildCached(t, cache, directory, options); !wasHit {
+		t.Errorf("expected the build after the resource lock was written to hit")
+	}
+
+	// Without the lock the package has the same key as before the first build, and the lock is restored from the pack
+	if err := os.Remove(filepath.Join(directory, "game", resourceid.LockFilename)); err != nil {
+		t.Fatal(err)
+	}
+	if wasHit, _ := buildCached(t, cache, directory, options); !wasHit {
+		t.Errorf("expected the build without the resource lock to hit")
+	}
+	restoredLock, hasRestoredLock, restoredErr := resourceid.LoadLock(filepath.Join(directory, "game"))
+	if restoredErr != nil || !hasRestoredLock {
+		t.Fatalf("expected the cache hit to restore the resource lock %v", restoredErr)
+	}
+	if !reflect.DeepEqual(restoredLock, lock) {
+		t.Errorf("expected the restored lock %v, got %v", lock, restoredLock)
+	}
+}
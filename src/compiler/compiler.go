@@ -7,6 +7,7 @@ package swampcompiler
 
 import (
 	"fmt"
+	"github.com/swamp/compiler/src/buildcache"
 	"github.com/swamp/compiler/src/semantic"
 	"log"
 	"os"
@@ -23,8 +24,10 @@ import (
 	"github.com/swamp/compiler/src/generate_ir"
 	"github.com/swamp/compiler/src/generate_sp"
 	"github.com/swamp/compiler/src/loader"
+	"github.com/swamp/compiler/src/optimize"
 	"github.com/swamp/compiler/src/parser"
 	"github.com/swamp/compiler/src/resourceid"
+	"github.com/swamp/compiler/src/settings"
 	"github.com/swamp/compiler/src/solution"
 	"github.com/swamp/compiler/src/verbosity"
 )
@@ -65,89 +68,179 @@ const (
 	LlvmIr
 )
 
-func BuildMain(mainSourceFile string, absoluteOutputDirectory string, enforceStyle bool, showAssembler bool, target Target, verboseFlag verbosity.Verbosity) ([]*loader.Package, error) {
+// entryModuleFilename is the file of the module that a package build starts from.
+const entryModuleFilename = "Main.swamp"
+
+// GenerateOptions are the code generation options that are given on the command line.
+type GenerateOptions struct {
+	ReportDeadCode bool
+	ReportStack    bool
+	OptimizeLevel  optimize.Level
+	// SourceRoot is the solution directory, or the package directory if there is no solution. The debug file URLs in
+	// the packs are relative to it.
+	SourceRoot string
+	// Strip writes the debug information to a `.swamp-pdb` instead of the pack.
+	Strip bool
+	// DataLayout decides the size of pointers, and the packs record it if it is not lp64.
+	DataLayout dectype.DataLayout
+}
+
+func newGenerator(target Target, packageSettings settings.Settings, options GenerateOptions) generate.Generator {
+	if target == LlvmIr {
+		return generate_ir.NewGenerator()
+	}
+
+	gen := generate_sp.NewGenerator()
+	if !packageSettings.DeadCode.Disable {
+		gen.SetDeadCodeElimination(packageSettings.DeadCode.Roots, options.ReportDeadCode)
+	}
+	gen.SetStackBudget(packageSettings.Stack.Budget, options.ReportStack)
+	gen.SetSourceRoot(options.SourceRoot)
+	gen.SetStrip(options.Strip)
+
+	return gen
+}
+
+// FindPackageDirectory returns the package directory to compile when there is no solution file.
+// It accepts a package directory, or the entry module file (`Main.swamp`) of a package, and looks for `.swamp.toml` in
+// the same way as CompileMainFindLibraryRoot. An entry module without any package settings is compiled as the package
+// of its directory. Other `.swamp` files are rejected, since a build always starts from the entry module of the package.
+func FindPackageDirectory(fileOrDirectory string) (string, error) {
+	directory := fileOrDirectory
+	isDirectory := file.IsDir(fileOrDirectory)
+	if !isDirectory {
+		if filepath.Ext(fileOrDirectory) != ".swamp" {
+			return "", fmt.Errorf("must be a .swamp file, a package directory or a solution directory %v", fileOrDirectory)
+		}
+		directory = filepath.Dir(fileOrDirectory)
+	}
+
+	libraryDirectory, libraryErr := loader.FindSettingsDirectory(directory)
+	if libraryErr != nil {
+		if isDirectory {
+			return "", fmt.Errorf("must have a solution file or a .swamp.toml %w", libraryErr)
+		}
+		libraryDirectory = directory
+	}
+
+	if !isDirectory {
+		entryModuleFile := filepath.Join(libraryDirectory, entryModuleFilename)
+		if filepath.Clean(fileOrDirectory) != filepath.Clean(entryModuleFile) {
+			return "", fmt.Errorf("'%v' is not the entry module of package '%v', give the package directory or '%v' instead", fileOrDirectory, libraryDirectory, entryModuleFile)
+		}
+	}
+
+	return libraryDirectory, nil
+}
+
+func BuildMain(mainSourceFile string, absoluteOutputDirectory string, enforceStyle bool, showAssembler bool, target Target, options GenerateOptions,
+	jobs int, cache *buildcache.Cache, verboseFlag verbosity.Verbosity) ([]*loader.Package, error) {
 	statInfo, statErr := os.Stat(mainSourceFile)
 	if statErr != nil {
 		return nil, statErr
 	}
 
-	config, _, configErr := environment.LoadFromConfig()
+	config, configErr := environment.LoadResolved(mainSourceFile)
 	if configErr != nil {
 		return nil, configErr
 	}
 
 	if statInfo.IsDir() {
-		resourceNameLookup := resourceid.NewResourceNameLookupImpl()
 		if solutionSettings, err := solution.LoadIfExists(mainSourceFile); err == nil {
-			var packages []*loader.Package
-			var errors decshared.DecoratedError
-			var gen generate.Generator
-
-			if target == LlvmIr {
-				gen = generate_ir.NewGenerator()
-			} else {
-				gen = generate_sp.NewGenerator()
+			solutionPackages, dependenciesErr := SolutionPackagesWithDependencies(mainSourceFile, solutionSettings, config)
+			if dependenciesErr != nil {
+				return nil, dependenciesErr
 			}
 
-			for _, packageSubDirectoryName := range solutionSettings.Packages {
-				absoluteSubDirectory := path.Join(mainSourceFile, packageSubDirectoryName)
-				compiledPackage, compileAndLinkErr := CompileAndLink(gen, resourceNameLookup, config, packageSubDirectoryName, absoluteSubDirectory, absoluteOutputDirectory, enforceStyle, verboseFlag, showAssembler)
-				errors = decorated.AppendError(errors, compileAndLinkErr)
-				if parser.IsCompileError(compileAndLinkErr) {
-					return packages, errors
-				}
+			if options.SourceRoot, err = filepath.Abs(mainSourceFile); err != nil {
+				return nil, err
+			}
+
+			// Each package gets its own generator and resource names, so the output does not depend on the build order.
+			// The resource names are merged into one solution-wide manifest when all packages are built.
+			packages, buildErr := BuildSolutionPackages(solutionPackages, jobs, func(solutionPackage *SolutionPackage) (*loader.Package, decshared.DecoratedError) {
+				return CompileAndLinkCached(cache, target, options, config, solutionPackage.Name, solutionPackage.Directory, absoluteOutputDirectory, enforceStyle, verboseFlag, showAssembler)
+			})
+			if parser.IsCompileError(buildErr) {
+				return packages, buildErr
+			}
 
-				packages = append(packages, compiledPackage)
+			if manifestErr := saveSolutionResourceManifest(absoluteOutputDirectory, filepath.Base(options.SourceRoot), solutionPackages); manifestErr != nil {
+				return packages, decorated.AppendError(buildErr, decorated.NewInternalError(manifestErr))
 			}
-			return packages, errors
-		} else {
-			return nil, fmt.Errorf("must have a solution file in this version")
+
+			return packages, buildErr
 		}
 	}
 
-	return nil, fmt.Errorf("must be directory in this version %v %v", mainSourceFile, absoluteOutputDirectory)
+	packageDirectory, findErr := FindPackageDirectory(mainSourceFile)
+	if findErr != nil {
+		return nil, findErr
+	}
+
+	if options.SourceRoot, findErr = filepath.Abs(packageDirectory); findErr != nil {
+		return nil, findErr
+	}
+
+	packageName := filepath.Base(packageDirectory)
+	compiledPackage, compileAndLinkErr := CompileAndLinkCached(cache, target, options, config, packageName, packageDirectory, absoluteOutputDirectory, enforceStyle, verboseFlag, showAssembler)
+	if parser.IsCompileError(compileAndLinkErr) || compiledPackage == nil {
+		return nil, compileAndLinkErr
+	}
+
+	return []*loader.Package{compiledPackage}, compileAndLinkErr
 }
 
-func BuildMainOnlyCompile(mainSourceFile string, enforceStyle bool, verboseFlag verbosity.Verbosity) ([]*loader.Package, error) {
+func BuildMainOnlyCompile(mainSourceFile string, dataLayout dectype.DataLayout, enforceStyle bool, jobs int, verboseFlag verbosity.Verbosity) ([]*loader.Package, error) {
 	statInfo, statErr := os.Stat(mainSourceFile)
 	if statErr != nil {
 		return nil, statErr
 	}
 
-	config, _, configErr := environment.LoadFromConfig()
+	config, configErr := environment.LoadResolved(mainSourceFile)
 	if configErr != nil {
 		return nil, configErr
 	}
 
-	if !statInfo.IsDir() {
-		return nil, fmt.Errorf("must have a solution file in this version")
-	} else {
+	if statInfo.IsDir() {
 		if solutionSettings, err := solution.LoadIfExists(mainSourceFile); err == nil {
-			var packages []*loader.Package
-			for _, packageSubDirectoryName := range solutionSettings.Packages {
-				absoluteSubDirectory := path.Join(mainSourceFile, packageSubDirectoryName)
-				compiledPackage, err := CompileMainDefaultDocumentProvider(packageSubDirectoryName, absoluteSubDirectory, config, enforceStyle, verboseFlag)
-				if parser.IsCompileError(err) {
-					return packages, err
-				}
-				packages = append(packages, compiledPackage)
+			solutionPackages, dependenciesErr := SolutionPackagesWithDependencies(mainSourceFile, solutionSettings, config)
+			if dependenciesErr != nil {
+				return nil, dependenciesErr
+			}
+
+			packages, compileErr := BuildSolutionPackages(solutionPackages, jobs, func(solutionPackage *SolutionPackage) (*loader.Package, decshared.DecoratedError) {
+				return CompileMainDefaultDocumentProvider(solutionPackage.Name, solutionPackage.Directory, config, dataLayout, enforceStyle, verboseFlag)
+			})
+			if parser.IsCompileError(compileErr) {
+				return packages, compileErr
 			}
 			return packages, nil
 		}
 	}
 
-	return nil, fmt.Errorf("must be directory in this version %v", mainSourceFile)
+	packageDirectory, findErr := FindPackageDirectory(mainSourceFile)
+	if findErr != nil {
+		return nil, findErr
+	}
+
+	compiledPackage, compileErr := CompileMainDefaultDocumentProvider(filepath.Base(packageDirectory), packageDirectory, config, dataLayout, enforceStyle, verboseFlag)
+	if parser.IsCompileError(compileErr) {
+		return nil, compileErr
+	}
+
+	return []*loader.Package{compiledPackage}, nil
 }
 
-func CompileMain(name string, mainSourceFile string, documentProvider loader.DocumentProvider, configuration environment.Environment, enforceStyle bool, verboseFlag verbosity.Verbosity) (*loader.Package, *decorated.Module, decshared.DecoratedError) {
+func CompileMain(name string, mainSourceFile string, documentProvider loader.DocumentProvider, configuration environment.Environment, dataLayout dectype.DataLayout, enforceStyle bool, verboseFlag verbosity.Verbosity) (*loader.Package, *decorated.Module, decshared.DecoratedError) {
 	mainPrefix := mainSourceFile
 	if file.IsDir(mainSourceFile) {
 	} else {
 		mainPrefix = path.Dir(mainSourceFile)
 	}
-	world := loader.NewPackage(loader.LocalFileSystemRoot(mainPrefix), name)
+	world := loader.NewPackage(loader.LocalFileSystemRoot(mainPrefix), name, dataLayout)
 
-	worldDecorator, worldDecoratorErr := loader.NewWorldDecorator(enforceStyle, verboseFlag)
+	worldDecorator, worldDecoratorErr := loader.NewWorldDecorator(enforceStyle, verboseFlag, dataLayout)
 	if parser.IsCompileErr(worldDecoratorErr) {
 		return nil, nil, worldDecoratorErr
 	}
@@ -174,7 +267,16 @@ func CompileMain(name string, mainSourceFile string, documentProvider loader.Doc
 	unusedErrors := CheckUnused(world)
 	appendedError = decorated.AppendError(appendedError, unusedErrors)
 
-	rootModule, err := deccy.CreateDefaultRootModule(true)
+	packageSettings, settingsErr := settings.LoadFromDirectory(mainPrefix, configuration)
+	if settingsErr != nil {
+		return nil, nil, decorated.NewInternalError(settingsErr)
+	}
+	if packageSettings.Resources.Assets != "" {
+		resourceErrors := CheckResourceNames(world, packageSettings.Resources.Assets)
+		appendedError = decorated.AppendError(appendedError, resourceErrors)
+	}
+
+	rootModule, err := deccy.CreateDefaultRootModule(true, dataLayout)
 	if parser.IsCompileError(err) {
 		return nil, nil, err
 	}
@@ -193,7 +295,7 @@ func CompileMain(name string, mainSourceFile string, documentProvider loader.Doc
 	return world, libraryModule, appendedError
 }
 
-func CompileMainFindLibraryRoot(mainSource string, documentProvider loader.DocumentProvider, configuration environment.Environment, enforceStyle bool, verboseFlag verbosity.Verbosity) (*loader.Package, *decorated.Module, error) {
+func CompileMainFindLibraryRoot(mainSource string, documentProvider loader.DocumentProvider, configuration environment.Environment, dataLayout dectype.DataLayout, enforceStyle bool, verboseFlag verbosity.Verbosity) (*loader.Package, *decorated.Module, error) {
 	if !file.IsDir(mainSource) {
 		mainSource = filepath.Dir(mainSource)
 	}
@@ -203,7 +305,7 @@ func CompileMainFindLibraryRoot(mainSource string, documentProvider loader.Docum
 		return nil, nil, fmt.Errorf("couldn't find settings directory when compiling %w", libraryErr)
 	}
 
-	return CompileMain(mainSource, libraryDirectory, documentProvider, configuration, enforceStyle, verboseFlag)
+	return CompileMain(mainSource, libraryDirectory, documentProvider, configuration, dataLayout, enforceStyle, verboseFlag)
 }
 
 type CoreFunctionInfo struct {
@@ -228,6 +330,10 @@ func GenerateAndLink(gen generate.Generator, resourceNameLookup resourceid.Resou
 
 	genErr := gen.GenerateFromPackageAndWriteOutput(compiledPackage, resourceNameLookup, outputDirectory, packageSubDirectory, verboseFlag, showAssembler)
 	if genErr != nil {
+		// Diagnostics from the generator, like an exceeded stack frame budget, already point at the source
+		if decoratedErr, isDecorated := genErr.(decshared.DecoratedError); isDecorated {
+			return decoratedErr
+		}
 		return decorated.NewInternalError(genErr)
 	}
 
@@ -235,10 +341,10 @@ func GenerateAndLink(gen generate.Generator, resourceNameLookup resourceid.Resou
 }
 
 func CompileMainDefaultDocumentProvider(name string, filename string, configuration environment.Environment,
-	enforceStyle bool, verboseFlag verbosity.Verbosity) (*loader.Package, decshared.DecoratedError) {
-	defaultDocumentProvider := loader.NewFileSystemDocumentProvider()
+	dataLayout dectype.DataLayout, enforceStyle bool, verboseFlag verbosity.Verbosity) (*loader.Package, decshared.DecoratedError) {
+	defaultDocumentProvider := loader.NewArchiveDocumentProvider(loader.NewFileSystemDocumentProvider())
 
-	compiledPackage, _, moduleErr := CompileMain(name, filename, defaultDocumentProvider, configuration, enforceStyle, verboseFlag)
+	compiledPackage, _, moduleErr := CompileMain(name, filename, defaultDocumentProvider, configuration, dataLayout, enforceStyle, verboseFlag)
 	if moduleErr != nil {
 		return compiledPackage, moduleErr
 	}
@@ -246,10 +352,10 @@ func CompileMainDefaultDocumentProvider(name string, filename string, configurat
 	return compiledPackage, nil
 }
 
-func CompileAndLink(gen generate.Generator, resourceNameLookup resourceid.ResourceNameLookup, configuration environment.Environment, name string,
+func CompileAndLink(gen generate.Generator, resourceNameLookup resourceid.ResourceNameLookup, optimizeLevel optimize.Level, configuration environment.Environment, dataLayout dectype.DataLayout, name string,
 	filename string, outputFilename string, enforceStyle bool, verboseFlag verbosity.Verbosity, showAssembler bool) (*loader.Package, decshared.DecoratedError) {
 	var errors decshared.DecoratedError
-	compiledPackage, compileErr := CompileMainDefaultDocumentProvider(name, filename, configuration, enforceStyle, verboseFlag)
+	compiledPackage, compileErr := CompileMainDefaultDocumentProvider(name, filename, configuration, dataLayout, enforceStyle, verboseFlag)
 	if parser.IsCompileError(compileErr) {
 		return nil, compileErr
 	}
@@ -258,8 +364,13 @@ func CompileAndLink(gen generate.Generator, resourceNameLookup resourceid.Resour
 		panic("not possible")
 	}
 
+	optimize.Package(compiledPackage, optimizeLevel, verboseFlag)
+
+	// The link error has no position in a document, so it can not be reported together with the warnings
 	linkErr := GenerateAndLink(gen, resourceNameLookup, compiledPackage, outputFilename, name, verboseFlag, showAssembler)
-	errors = decorated.AppendError(errors, linkErr)
+	if linkErr != nil {
+		return compiledPackage, linkErr
+	}
 
 	return compiledPackage, errors
 }
@@ -0,0 +1,280 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package swampcompiler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/swamp/compiler/src/ast"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+	"github.com/swamp/compiler/src/environment"
+	"github.com/swamp/compiler/src/generate_sp"
+	"github.com/swamp/compiler/src/optimize"
+	"github.com/swamp/compiler/src/packinspect"
+	"github.com/swamp/compiler/src/parser"
+	"github.com/swamp/compiler/src/resourceid"
+	"github.com/swamp/compiler/src/token"
+	"github.com/swamp/compiler/src/verbosity"
+)
+
+// writeTestFiles writes the files to a temporary directory, and returns the directory. The user configuration is
+// read from an empty directory, so that the tests do not depend on the environment they are run in.
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	directory := t.TempDir()
+	for name, content := range files {
+		filename := filepath.Join(directory, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(strings.TrimLeft(content, "\n")), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return directory
+}
+
+func findFunctionValue(t *testing.T, module *decorated.Module, name string) *decorated.FunctionValue {
+	t.Helper()
+	identifier := ast.NewVariableIdentifier(token.NewVariableSymbolToken(name, token.NewInternalSourceFileReference(), 0))
+	definition := module.LocalDefinitions().FindDefinitionExpression(identifier)
+	if definition == nil {
+		t.Fatalf("could not find '%v'", name)
+	}
+	functionValue, wasFunction := definition.Expression().(*decorated.FunctionValue)
+	if !wasFunction {
+		t.Fatalf("'%v' is not a function", name)
+	}
+
+	return functionValue
+}
+
+func TestSpecializationSuffixUsesQualifiedTypeNames(t *testing.T) {
+	directory := writeTestFiles(t, map[string]string{
+		".swamp.toml": "",
+		"A/Types.swamp": `
+type alias Value =
+    Int
+`,
+		"B/Types.swamp": `
+type alias Value =
+    Fixed
+`,
+		"Main.swamp": `
+import A.Types
+import B.Types
+
+
+scale : (a: number, b: number) -> number =
+    a * b
+
+
+scaleA : (x: A.Types.Value) -> A.Types.Value =
+    scale x x
+
+
+scaleB : (x: B.Types.Value) -> B.Types.Value =
+    scale x x
+`,
+	})
+
+	compiledPackage, compileErr := CompileMainDefaultDocumentProvider("qualified", directory, environment.Environment{},
+		dectype.DataLayoutLP64, true, verbosity.None)
+	if compileErr != nil && compiledPackage == nil {
+		t.Fatal(compileErr)
+	}
+
+	mainModule := compiledPackage.FindModule(dectype.MakeArtifactFullyQualifiedModuleName(nil))
+	if mainModule == nil {
+		t.Fatal("could not find the main module")
+	}
+
+	var suffixes []string
+	for _, specialization := range findFunctionValue(t, mainModule, "scale").Specializations() {
+		suffixes = append(suffixes, specialization.Suffix())
+	}
+
+	expected := "__A_Types_Value_A_Types_Value_A_Types_Value __B_Types_Value_B_Types_Value_B_Types_Value"
+	if actual := strings.Join(suffixes, " "); actual != expected {
+		t.Errorf("unexpected specializations %v", actual)
+	}
+}
+
+// compileTestPack compiles and links the package in the directory and reads back the written `.swamp-pack`.
+func compileTestPack(t *testing.T, name string, directory string) *packinspect.Pack {
+	t.Helper()
+	outputDirectory := t.TempDir()
+	_, compileErr := CompileAndLink(generate_sp.NewGenerator(), resourceid.NewResourceNameLookupImpl(), optimize.LevelNone,
+		environment.Environment{}, dectype.DataLayoutLP64, name, directory, outputDirectory, true, verbosity.None, false)
+	if parser.IsCompileError(compileErr) {
+		t.Fatal(compileErr)
+	}
+
+	packFilenames, globErr := filepath.Glob(filepath.Join(outputDirectory, "*.swamp-pack"))
+	if globErr != nil || len(packFilenames) != 1 {
+		t.Fatalf("expected a single pack in %v, found %v", outputDirectory, packFilenames)
+	}
+
+	octets, readErr := os.ReadFile(packFilenames[0])
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+
+	pack, packErr := packinspect.Read(octets)
+	if packErr != nil {
+		t.Fatal(packErr)
+	}
+
+	return pack
+}
+
+// disassembleTestFunction returns the instruction texts of the function in the pack.
+func disassembleTestFunction(t *testing.T, pack *packinspect.Pack, name string) []string {
+	t.Helper()
+	for _, function := range pack.Functions {
+		if function.Name != name {
+			continue
+		}
+		instructions, err := pack.Disassemble(function)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var texts []string
+		for _, instruction := range instructions {
+			texts = append(texts, instruction.Text)
+		}
+		return texts
+	}
+
+	t.Fatalf("could not find function '%v'", name)
+	return nil
+}
+
+func TestComparableCallsCompareFunctions(t *testing.T) {
+	directory := writeTestFiles(t, map[string]string{
+		".swamp.toml": "",
+		"Main.swamp": `
+max : (a: comparable, b: comparable) -> comparable =
+    if a > b then a else b
+
+
+maxString : (x: String) -> String =
+    max x "hello"
+
+
+maxList : (x: List Int) -> List Int =
+    max x [ 1, 2 ]
+
+
+isBefore : (a: (Int, String), b: (Int, String)) -> Bool =
+    a < b
+`,
+	})
+
+	pack := compileTestPack(t, "comparable", directory)
+
+	for _, testCase := range []struct {
+		function         string
+		externalFunction string
+		call             string
+		compare          string
+	}{
+		{"max__String_String_String", "String.compare", "ecall", "cpgti"},
+		{"max__List_Int__List_Int__List_Int_", "List.compare", "callexternal_var", "cpgti"},
+		{"isBefore", "Tuple.compare", "callexternal_var", "cplti"},
+	} {
+		t.Run(testCase.function, func(t *testing.T) {
+			var externalFunction *packinspect.ExternalFunction
+			for _, candidate := range pack.ExternalFunctions {
+				if candidate.Name == testCase.externalFunction {
+					externalFunction = candidate
+				}
+			}
+			if externalFunction == nil {
+				t.Fatalf("the pack has no external function '%v'", testCase.externalFunction)
+			}
+
+			code := strings.Join(disassembleTestFunction(t, pack, testCase.function), "\n")
+			loadCompare := fmt.Sprintf(",$%04X", externalFunction.Position)
+			for _, expected := range []string{loadCompare, testCase.call + " ", testCase.compare + " "} {
+				if !strings.Contains(code, expected) {
+					t.Errorf("expected '%v' in:\n%v", expected, code)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildFileArgument(t *testing.T) {
+	directory := writeTestFiles(t, map[string]string{
+		"lib/.swamp.toml": "",
+		"lib/Main.swamp": `
+import Helper
+
+
+main : (x: Int) -> Int =
+    Helper.double x
+`,
+		"lib/Helper.swamp": `
+double : (x: Int) -> Int =
+    x * 2
+`,
+		"single/Main.swamp": `
+main : (x: Int) -> Int =
+    x + 1
+`,
+	})
+
+	for _, testCase := range []struct {
+		name             string
+		path             string
+		packageDirectory string
+		errorContains    string
+	}{
+		{"package directory", "lib", "lib", ""},
+		{"entry module", "lib/Main.swamp", "lib", ""},
+		{"entry module without settings", "single/Main.swamp", "single", ""},
+		{"other module", "lib/Helper.swamp", "", "is not the entry module of package"},
+		{"not a swamp file", "lib/.swamp.toml", "", "must be a .swamp file"},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			path := filepath.Join(directory, filepath.FromSlash(testCase.path))
+			packageDirectory, findErr := FindPackageDirectory(path)
+			if testCase.errorContains != "" {
+				if findErr == nil || !strings.Contains(findErr.Error(), testCase.errorContains) {
+					t.Fatalf("expected an error containing '%v', got %v", testCase.errorContains, findErr)
+				}
+				if _, buildErr := BuildMainOnlyCompile(path, dectype.DataLayoutLP64, true, 1, verbosity.None); buildErr == nil {
+					t.Errorf("expected the build of '%v' to fail", testCase.path)
+				}
+				return
+			}
+			if findErr != nil {
+				t.Fatal(findErr)
+			}
+			if expected := filepath.Join(directory, filepath.FromSlash(testCase.packageDirectory)); packageDirectory != expected {
+				t.Errorf("expected package directory '%v', got '%v'", expected, packageDirectory)
+			}
+
+			packages, buildErr := BuildMainOnlyCompile(path, dectype.DataLayoutLP64, true, 1, verbosity.None)
+			if buildErr != nil {
+				t.Fatal(buildErr)
+			}
+			if len(packages) != 1 {
+				t.Fatalf("expected one package, got %v", len(packages))
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package swampcompiler
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/swamp/compiler/src/loader"
+	"github.com/swamp/compiler/src/verbosity"
+)
+
+// BuildMainReproducible builds the packages, and then builds them again to a temporary directory. It returns an error if
+// any output file is not identical between the two builds. The build cache is not used, since an output from the cache
+// is always identical.
+func BuildMainReproducible(mainSourceFile string, absoluteOutputDirectory string, enforceStyle bool, showAssembler bool, target Target,
+	options GenerateOptions, jobs int, verboseFlag verbosity.Verbosity) ([]*loader.Package, error) {
+	compiledPackages, buildErr := BuildMain(mainSourceFile, absoluteOutputDirectory, enforceStyle, showAssembler, target, options, jobs, nil, verboseFlag)
+	if buildErr != nil {
+		return compiledPackages, buildErr
+	}
+
+	secondOutputDirectory, tempErr := os.MkdirTemp("", "swamp-reproducible")
+	if tempErr != nil {
+		return compiledPackages, tempErr
+	}
+	defer os.RemoveAll(secondOutputDirectory)
+
+	// The reports have already been printed by the first build
+	secondOptions := options
+	secondOptions.ReportDeadCode = false
+	secondOptions.ReportStack = false
+
+	if _, secondBuildErr := BuildMain(mainSourceFile, secondOutputDirectory, enforceStyle, false, target, secondOptions, jobs, nil, verbosity.None); secondBuildErr != nil {
+		return compiledPackages, secondBuildErr
+	}
+
+	if compareErr := compareOutputDirectories(absoluteOutputDirectory, secondOutputDirectory); compareErr != nil {
+		return compiledPackages, compareErr
+	}
+
+	return compiledPackages, nil
+}
+
+// compareOutputDirectories checks that every file in the second output directory is identical to the file with the
+// same name in the first output directory.
+func compareOutputDirectories(firstDirectory string, secondDirectory string) error {
+	entries, readDirErr := os.ReadDir(secondDirectory)
+	if readDirErr != nil {
+		return readDirErr
+	}
+
+	var differentFiles []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		secondOctets, secondErr := os.ReadFile(filepath.Join(secondDirectory, entry.Name()))
+		if secondErr != nil {
+			return secondErr
+		}
+
+		firstOctets, firstErr := os.ReadFile(filepath.Join(firstDirectory, entry.Name()))
+		if firstErr != nil {
+			return firstErr
+		}
+
+		if !bytes.Equal(firstOctets, secondOctets) {
+			differentFiles = append(differentFiles, entry.Name())
+		}
+	}
+
+	if len(differentFiles) > 0 {
+		return fmt.Errorf("build is not reproducible, the output differs between two builds: %v", strings.Join(differentFiles, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package swampcompiler
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/swamp/compiler/src/decorated/decshared"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	"github.com/swamp/compiler/src/file"
+	"github.com/swamp/compiler/src/loader"
+	"github.com/swamp/compiler/src/packinspect"
+	"github.com/swamp/compiler/src/resourceid"
+	"github.com/swamp/compiler/src/settings"
+)
+
+// solutionResourceManifestFilename is the name of the manifest with the resource names of all packages in a solution.
+// It can not be mistaken for the manifest of a package, since those are prefixed with the package name.
+const solutionResourceManifestFilename = "resources.json"
+
+func resourceManifestFilename(outputDirectory string, name string) string {
+	return path.Join(outputDirectory, fmt.Sprintf("%s.resources.json", name))
+}
+
+// resourceFileExists returns true if the asset directory has a file with the resource name, with or without an extension.
+// `@sprites/hero` matches both `sprites/hero` and `sprites/hero.png`.
+func resourceFileExists(assetDirectory string, resourceName string) bool {
+	resourceFilename := filepath.Join(assetDirectory, filepath.FromSlash(resourceName))
+	if file.HasFile(resourceFilename) {
+		return true
+	}
+
+	matches, _ := filepath.Glob(resourceFilename + ".*")
+	for _, match := range matches {
+		if file.HasFile(match) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckResourceNames warns about each resource name literal that has no matching file in the asset directory.
+func CheckResourceNames(world *loader.Package, assetDirectory string) decshared.DecoratedError {
+	var warnings []decshared.DecoratedError
+	for _, module := range world.AllModules() {
+		if module.IsInternal() {
+			continue
+		}
+		for _, node := range module.Nodes() {
+			resourceName, isResourceName := node.(*decorated.ResourceNameLiteral)
+			if !isResourceName {
+				continue
+			}
+			if !resourceFileExists(assetDirectory, resourceName.Value()) {
+				warnings = append(warnings, decorated.NewMissingResourceFileWarning(resourceName, assetDirectory))
+			}
+		}
+	}
+
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	return decorated.NewMultiErrors(warnings)
+}
+
+func newResourceNameLookup(packageDirectory string, resources settings.Resources) (*resourceid.ResourceNameLookupImpl, error) {
+	if !resources.Lock {
+		return resourceid.NewResourceNameLookupImpl(), nil
+	}
+
+	lock, _, lockErr := resourceid.LoadLock(packageDirectory)
+	if lockErr != nil {
+		return nil, lockErr
+	}
+
+	return resourceid.NewResourceNameLookupFromLock(lock)
+}
+
+// saveResourcesFromPack writes `resources.lock` and the resource manifest for a pack that was taken from the build
+// cache. The pack has all resource names in ID order, including the locked names that are no longer used, so the
+// lock is the same as the one that was written when the pack was built. It is restored if it was removed.
+func saveResourcesFromPack(packOctets []byte, packageDirectory string, resources settings.Resources, outputDirectory string, name string) error {
+	pack, packErr := packinspect.Read(packOctets)
+	if packErr != nil {
+		return packErr
+	}
+
+	resourceNameLookup := resourceid.NewResourceNameLookupImpl()
+	for _, resourceName := range pack.ResourceNames {
+		resourceNameLookup.LookupResourceId(resourceName.Name)
+	}
+
+	return saveResources(resourceNameLookup, packageDirectory, resources, outputDirectory, name)
+}
+
+func saveResources(resourceNameLookup *resourceid.ResourceNameLookupImpl, packageDirectory string, resources settings.Resources,
+	outputDirectory string, name string) error {
+	if resources.Lock {
+		if err := resourceNameLookup.Lock().Save(packageDirectory); err != nil {
+			return err
+		}
+	}
+
+	manifest := resourceid.NewManifest(name, resourceNameLookup.SortedResourceNames())
+
+	return manifest.Save(resourceManifestFilename(outputDirectory, name))
+}
+
+// saveSolutionResourceManifest merges the resource manifests of the packages into one solution-wide manifest, in the
+// order of the solution file. Each pack keeps its own resource IDs, and the solution-wide ID of a resource is found
+// from its name.
+func saveSolutionResourceManifest(outputDirectory string, solutionName string, solutionPackages []*SolutionPackage) error {
+	var manifests []resourceid.Manifest
+	for _, solutionPackage := range solutionPackages {
+		manifest, manifestErr := resourceid.LoadManifest(resourceManifestFilename(outputDirectory, solutionPackage.Name))
+		if manifestErr != nil {
+			return manifestErr
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	solutionLookup := resourceid.NewResourceNameLookupFromManifests(manifests)
+	manifest := resourceid.NewManifest(solutionName, solutionLookup.SortedResourceNames())
+
+	return manifest.Save(path.Join(outputDirectory, solutionResourceManifestFilename))
+}
@@ -0,0 +1,261 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package swampcompiler
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/swamp/compiler/src/decorated/decshared"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	"github.com/swamp/compiler/src/environment"
+	"github.com/swamp/compiler/src/file"
+	"github.com/swamp/compiler/src/loader"
+	"github.com/swamp/compiler/src/parser"
+	"github.com/swamp/compiler/src/settings"
+	"github.com/swamp/compiler/src/solution"
+)
+
+type SolutionPackage struct {
+	Name         string
+	Directory    string
+	Dependencies []int
+}
+
+func isSameOrInsideDirectory(directory string, parent string) bool {
+	absoluteDirectory, directoryErr := filepath.Abs(directory)
+	absoluteParent, parentErr := filepath.Abs(parent)
+	if directoryErr != nil || parentErr != nil {
+		return false
+	}
+
+	relative, relErr := filepath.Rel(absoluteParent, absoluteDirectory)
+	if relErr != nil {
+		return false
+	}
+
+	relative = filepath.ToSlash(relative)
+
+	return relative == "." || (relative != ".." && !strings.HasPrefix(relative, "../"))
+}
+
+// SolutionPackagesWithDependencies returns the packages in the solution, in the order of the solution file.
+// A package depends on another package in the solution if one of its `.swamp.toml` module mappings
+// points into the directory of that package.
+func SolutionPackagesWithDependencies(solutionDirectory string, solutionSettings solution.Settings, configuration environment.Environment) ([]*SolutionPackage, error) {
+	var packages []*SolutionPackage
+	for _, packageSubDirectoryName := range solutionSettings.Packages {
+		subDirectory := path.Join(solutionDirectory, packageSubDirectoryName)
+		packages = append(packages, &SolutionPackage{Name: packageSubDirectoryName, Directory: subDirectory})
+	}
+
+	for _, solutionPackage := range packages {
+		packageSettings, settingsErr := settings.LoadFromDirectory(solutionPackage.Directory, configuration)
+		if settingsErr != nil {
+			return nil, fmt.Errorf("could not load settings for package '%v' %w", solutionPackage.Name, settingsErr)
+		}
+
+		for _, module := range packageSettings.Module {
+			for dependencyIndex, dependency := range packages {
+				if dependency == solutionPackage || !isSameOrInsideDirectory(module.Path, dependency.Directory) {
+					continue
+				}
+				solutionPackage.Dependencies = append(solutionPackage.Dependencies, dependencyIndex)
+			}
+		}
+	}
+
+	if cycleErr := checkDependencyCycles(packages); cycleErr != nil {
+		return nil, cycleErr
+	}
+
+	return packages, nil
+}
+
+func checkDependencyCycles(packages []*SolutionPackage) error {
+	const (
+		notVisited = iota
+		visiting
+		visited
+	)
+
+	state := make([]int, len(packages))
+
+	var visit func(index int) error
+	visit = func(index int) error {
+		switch state[index] {
+		case visiting:
+			return fmt.Errorf("package '%v' depends on itself through its module mappings", packages[index].Name)
+		case visited:
+			return nil
+		}
+		state[index] = visiting
+		for _, dependencyIndex := range packages[index].Dependencies {
+			if err := visit(dependencyIndex); err != nil {
+				return err
+			}
+		}
+		state[index] = visited
+		return nil
+	}
+
+	for index := range packages {
+		if err := visit(index); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type solutionPackageResult struct {
+	compiledPackage *loader.Package
+	err             decshared.DecoratedError
+	failed          bool
+}
+
+// BuildSolutionPackages calls build for each package, running at most jobs of them at the same time.
+// A package is not built until all of its dependencies are built, and is skipped if any of them failed to compile.
+// The compiled packages and the errors are returned in the order of the solution file, regardless of the
+// order the packages were built in. Packages that build returns nil for, e.g. taken from the build cache, are left out.
+func BuildSolutionPackages(packages []*SolutionPackage, jobs int, build func(solutionPackage *SolutionPackage) (*loader.Package, decshared.DecoratedError)) ([]*loader.Package, decshared.DecoratedError) {
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+
+	results := make([]solutionPackageResult, len(packages))
+	done := make([]chan struct{}, len(packages))
+	for index := range packages {
+		done[index] = make(chan struct{})
+	}
+
+	slots := make(chan struct{}, jobs)
+
+	var waitGroup sync.WaitGroup
+	for index, solutionPackage := range packages {
+		waitGroup.Add(1)
+		go func(index int, solutionPackage *SolutionPackage) {
+			defer waitGroup.Done()
+			defer close(done[index])
+
+			for _, dependencyIndex := range solutionPackage.Dependencies {
+				<-done[dependencyIndex]
+				if results[dependencyIndex].failed {
+					results[index].failed = true
+					return
+				}
+			}
+
+			slots <- struct{}{}
+			compiledPackage, err := build(solutionPackage)
+			<-slots
+
+			results[index] = solutionPackageResult{
+				compiledPackage: compiledPackage,
+				err:             err,
+				failed:          parser.IsCompileError(err),
+			}
+		}(index, solutionPackage)
+	}
+
+	waitGroup.Wait()
+
+	var compiledPackages []*loader.Package
+	var errors []decshared.DecoratedError
+	for _, result := range results {
+		if result.err != nil {
+			errors = append(errors, result.err)
+		}
+		if result.failed || result.compiledPackage == nil {
+			continue
+		}
+		compiledPackages = append(compiledPackages, result.compiledPackage)
+	}
+
+	switch len(errors) {
+	case 0:
+		return compiledPackages, nil
+	case 1:
+		return compiledPackages, errors[0]
+	}
+
+	return compiledPackages, decorated.NewMultiErrors(errors)
+}
+
+// PackageDirectories returns the package directories of the solution, or the single package directory that the file
+// or directory is in.
+func PackageDirectories(fileOrDirectory string) ([]string, error) {
+	if solutionSettings, err := solution.LoadIfExists(fileOrDirectory); err == nil {
+		var packageDirectories []string
+		for _, packageSubDirectoryName := range solutionSettings.Packages {
+			packageDirectories = append(packageDirectories, path.Join(fileOrDirectory, packageSubDirectoryName))
+		}
+		return packageDirectories, nil
+	}
+
+	packageDirectory, findErr := FindPackageDirectory(fileOrDirectory)
+	if findErr != nil {
+		return nil, findErr
+	}
+
+	return []string{packageDirectory}, nil
+}
+
+// SourceDirectories returns all directories that a build of the file or directory reads source files from.
+// That is the package directories, and all directories that they map modules from, including `${ENV}` packages.
+func SourceDirectories(fileOrDirectory string, configuration environment.Environment) ([]string, error) {
+	packageDirectories, packagesErr := PackageDirectories(fileOrDirectory)
+	if packagesErr != nil {
+		return nil, packagesErr
+	}
+
+	var directories []string
+	wasAdded := make(map[string]bool)
+	for _, packageDirectory := range packageDirectories {
+		reachable, reachableErr := settings.ReachableDirectories(packageDirectory, configuration)
+		if reachableErr != nil {
+			return nil, reachableErr
+		}
+		for _, directory := range reachable {
+			if wasAdded[directory] {
+				continue
+			}
+			wasAdded[directory] = true
+			directories = append(directories, directory)
+		}
+	}
+
+	return directories, nil
+}
+
+// WatchedFiles returns the files, other than the source files, that decide what a build of the file or directory does.
+// That is the solution file, the project environment file and the user environment file. They do not have to exist,
+// so that a build notices when one is created.
+func WatchedFiles(fileOrDirectory string) []string {
+	var watchedFiles []string
+	directory := fileOrDirectory
+	if file.IsDir(fileOrDirectory) {
+		watchedFiles = append(watchedFiles, solution.Filename(fileOrDirectory))
+	} else {
+		directory = filepath.Dir(fileOrDirectory)
+	}
+
+	projectFilename := environment.FindProjectFilename(fileOrDirectory)
+	if projectFilename == "" {
+		projectFilename = filepath.ToSlash(filepath.Join(directory, environment.ProjectFilename))
+	}
+	watchedFiles = append(watchedFiles, projectFilename)
+
+	if userFilename, userErr := environment.EnvironmentTomlFilename(); userErr == nil {
+		watchedFiles = append(watchedFiles, userFilename)
+	}
+
+	return watchedFiles
+}
@@ -0,0 +1,212 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package swampcompiler
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swamp/compiler/src/decorated/decshared"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+	"github.com/swamp/compiler/src/loader"
+	"github.com/swamp/compiler/src/parser"
+	"github.com/swamp/compiler/src/resourceid"
+	"github.com/swamp/compiler/src/token"
+	"github.com/swamp/compiler/src/verbosity"
+)
+
+// testSolutionPackages returns packages a, b, c and d, where c depends on a, and d depends on b and c.
+func testSolutionPackages() []*SolutionPackage {
+	return []*SolutionPackage{
+		{Name: "a"},
+		{Name: "b"},
+		{Name: "c", Dependencies: []int{0}},
+		{Name: "d", Dependencies: []int{1, 2}},
+	}
+}
+
+func packageNames(packages []*loader.Package) []string {
+	var names []string
+	for _, compiledPackage := range packages {
+		names = append(names, compiledPackage.Name())
+	}
+
+	return names
+}
+
+func TestBuildSolutionPackagesOrder(t *testing.T) {
+	// The first packages take the longest, so that they finish after the others when they are built at the same time
+	buildTime := map[string]time.Duration{"a": 30 * time.Millisecond, "b": 20 * time.Millisecond, "c": 10 * time.Millisecond}
+
+	for _, jobs := range []int{1, 2, 4} {
+		t.Run(fmt.Sprintf("jobs %v", jobs), func(t *testing.T) {
+			var mutex sync.Mutex
+			finished := map[string]bool{}
+			running := 0
+			maxRunning := 0
+
+			packages, buildErr := BuildSolutionPackages(testSolutionPackages(), jobs, func(solutionPackage *SolutionPackage) (*loader.Package, decshared.DecoratedError) {
+				mutex.Lock()
+				for _, dependencyName := range map[string][]string{"c": {"a"}, "d": {"b", "c"}}[solutionPackage.Name] {
+					if !finished[dependencyName] {
+						t.Errorf("'%v' was built before its dependency '%v'", solutionPackage.Name, dependencyName)
+					}
+				}
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mutex.Unlock()
+
+				time.Sleep(buildTime[solutionPackage.Name])
+
+				mutex.Lock()
+				running--
+				finished[solutionPackage.Name] = true
+				mutex.Unlock()
+
+				return loader.NewPackage(loader.LocalFileSystemRoot(""), solutionPackage.Name, dectype.DataLayoutLP64), nil
+			})
+			if buildErr != nil {
+				t.Fatal(buildErr)
+			}
+
+			if names := fmt.Sprint(packageNames(packages)); names != "[a b c d]" {
+				t.Errorf("expected the packages in the order of the solution file, got %v", names)
+			}
+			if maxRunning > jobs {
+				t.Errorf("expected at most %v packages to be built at the same time, got %v", jobs, maxRunning)
+			}
+		})
+	}
+}
+
+func TestBuildSolutionPackagesErrors(t *testing.T) {
+	warningB := decorated.NewCachedWarning(token.SourceFileReference{}, "warning in b", false)
+	errorA := decorated.NewInternalError(errors.New("error in a"))
+
+	for _, testCase := range []struct {
+		name           string
+		results        map[string]decshared.DecoratedError
+		expectedBuilt  string
+		expectedNames  string
+		expectedErrors []decshared.DecoratedError
+	}{
+		{
+			name:           "warning",
+			results:        map[string]decshared.DecoratedError{"b": warningB},
+			expectedBuilt:  "[a b c d]",
+			expectedNames:  "[a b c d]",
+			expectedErrors: []decshared.DecoratedError{warningB},
+		},
+		{
+			name:           "error skips the dependent packages",
+			results:        map[string]decshared.DecoratedError{"a": errorA, "b": warningB},
+			expectedBuilt:  "[a b]",
+			expectedNames:  "[b]",
+			expectedErrors: []decshared.DecoratedError{errorA, warningB},
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			built := map[string]bool{}
+
+			packages, buildErr := BuildSolutionPackages(testSolutionPackages(), 4, func(solutionPackage *SolutionPackage) (*loader.Package, decshared.DecoratedError) {
+				mutex.Lock()
+				built[solutionPackage.Name] = true
+				mutex.Unlock()
+
+				// Let the later packages finish first, so that the errors are not in the order they were reported in
+				if solutionPackage.Name == "a" {
+					time.Sleep(20 * time.Millisecond)
+				}
+
+				return loader.NewPackage(loader.LocalFileSystemRoot(""), solutionPackage.Name, dectype.DataLayoutLP64), testCase.results[solutionPackage.Name]
+			})
+
+			var builtNames []string
+			for _, solutionPackage := range testSolutionPackages() {
+				if built[solutionPackage.Name] {
+					builtNames = append(builtNames, solutionPackage.Name)
+				}
+			}
+			if names := fmt.Sprint(builtNames); names != testCase.expectedBuilt {
+				t.Errorf("expected %v to be built, got %v", testCase.expectedBuilt, names)
+			}
+			if names := fmt.Sprint(packageNames(packages)); names != testCase.expectedNames {
+				t.Errorf("expected the packages %v, got %v", testCase.expectedNames, names)
+			}
+
+			var reportedErrors []decshared.DecoratedError
+			switch e := buildErr.(type) {
+			case nil:
+			case *decorated.MultiErrors:
+				reportedErrors = e.Errors()
+			default:
+				reportedErrors = []decshared.DecoratedError{e}
+			}
+			if len(reportedErrors) != len(testCase.expectedErrors) {
+				t.Fatalf("expected %v errors, got %v", len(testCase.expectedErrors), len(reportedErrors))
+			}
+			for index, expected := range testCase.expectedErrors {
+				if reportedErrors[index] != expected {
+					t.Errorf("expected error %v to be '%v', got '%v'", index, expected, reportedErrors[index])
+				}
+			}
+		})
+	}
+}
+
+func TestSolutionResourceManifest(t *testing.T) {
+	directory := writeTestFiles(t, map[string]string{
+		"swamp.solution.toml": `packages = ["first", "second"]` + "\n",
+		"first/.swamp.toml":   "",
+		"first/Main.swamp": `
+main : (x: Int) -> ResourceName =
+    if x > 0 then @sprites/hero else @sprites/enemy
+`,
+		"second/.swamp.toml": "",
+		"second/Main.swamp": `
+main : (x: Int) -> ResourceName =
+    if x > 0 then @sounds/jump else @sprites/hero
+`,
+	})
+
+	for _, jobs := range []int{1, 2} {
+		t.Run(fmt.Sprintf("jobs %v", jobs), func(t *testing.T) {
+			outputDirectory := t.TempDir()
+			options := GenerateOptions{DataLayout: dectype.DataLayoutLP64}
+			if _, buildErr := BuildMain(directory, outputDirectory, true, false, SwampOpcode, options, jobs, nil, verbosity.None); parser.IsCompileErr(buildErr) {
+				t.Fatal(buildErr)
+			}
+
+			manifest, manifestErr := resourceid.LoadManifest(filepath.Join(outputDirectory, solutionResourceManifestFilename))
+			if manifestErr != nil {
+				t.Fatal(manifestErr)
+			}
+
+			expected := "{0 sprites/hero} {1 sprites/enemy} {2 sounds/jump}"
+			var resources string
+			for index, resource := range manifest.Resources {
+				if index > 0 {
+					resources += " "
+				}
+				resources += fmt.Sprint(resource)
+			}
+			if resources != expected {
+				t.Errorf("expected the resources %v, got %v", expected, resources)
+			}
+			if manifest.Package != filepath.Base(directory) {
+				t.Errorf("expected the solution name '%v', got '%v'", filepath.Base(directory), manifest.Package)
+			}
+		})
+	}
+}
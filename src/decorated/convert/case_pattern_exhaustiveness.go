@@ -0,0 +1,326 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package decorator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/swamp/compiler/src/ast"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+)
+
+// The checker is an implementation of the usefulness algorithm in "Warnings for pattern matching" (Maranget, 2007).
+// A pattern row is useful if there is at least one value that it matches, that no row above it matches.
+// A redundant row is a row that is not useful, and a case is exhaustive if a wildcard row would not be useful.
+
+// patternConstructor is something a value can be constructed from, a custom type variant, a tuple or a literal.
+// Constructors with the same family are mutually exclusive. A family of nil means that there are infinitely
+// many constructors (e.g. integer literals), and they can never be covered without a wildcard.
+type patternConstructor struct {
+	name   string
+	arity  int
+	family func() []*patternConstructor
+}
+
+// spacePattern is the simplified form of a pattern that the checker works on. Bindings, records and
+// wildcards all end up as a spacePattern without a constructor.
+type spacePattern struct {
+	constructor *patternConstructor
+	arguments   []*spacePattern
+}
+
+func spaceWildcard() *spacePattern {
+	return &spacePattern{}
+}
+
+func spaceWildcards(count int) []*spacePattern {
+	patterns := make([]*spacePattern, count)
+	for index := range patterns {
+		patterns[index] = spaceWildcard()
+	}
+	return patterns
+}
+
+func variantConstructor(variant *dectype.CustomTypeVariantAtom) *patternConstructor {
+	customType := variant.InCustomType()
+	return &patternConstructor{
+		name: variant.Name().Name(), arity: variant.ParameterCount(),
+		family: func() []*patternConstructor {
+			var constructors []*patternConstructor
+			for _, sibling := range customType.Variants() {
+				constructors = append(constructors, variantConstructor(sibling))
+			}
+			return constructors
+		},
+	}
+}
+
+func tupleConstructor(arity int) *patternConstructor {
+	constructor := &patternConstructor{name: "tuple", arity: arity}
+	constructor.family = func() []*patternConstructor {
+		return []*patternConstructor{constructor}
+	}
+	return constructor
+}
+
+func booleanConstructor(value bool) *patternConstructor {
+	return &patternConstructor{
+		name: fmt.Sprintf("%v", value),
+		family: func() []*patternConstructor {
+			return []*patternConstructor{booleanConstructor(true), booleanConstructor(false)}
+		},
+	}
+}
+
+func literalConstructor(key string) *patternConstructor {
+	return &patternConstructor{name: key}
+}
+
+func spaceVariant(variant *dectype.CustomTypeVariantAtom, arguments []*spacePattern) *spacePattern {
+	return &spacePattern{constructor: variantConstructor(variant), arguments: arguments}
+}
+
+func spaceLiteral(literal decorated.Expression) *spacePattern {
+	switch t := literal.(type) {
+	case *decorated.BooleanLiteral:
+		return &spacePattern{constructor: booleanConstructor(t.Value())}
+	case *decorated.IntegerLiteral:
+		return &spacePattern{constructor: literalConstructor(fmt.Sprintf("%v", t.Value()))}
+	case *decorated.CharacterLiteral:
+		return &spacePattern{constructor: literalConstructor(fmt.Sprintf("'%c'", t.Value()))}
+	case *decorated.StringLiteral:
+		return &spacePattern{constructor: literalConstructor(fmt.Sprintf("%q", t.Value()))}
+	case *decorated.ConstantReference:
+		if integerLiteral, wasInteger := t.Constant().AstConstant().Expression().(*ast.IntegerLiteral); wasInteger {
+			return &spacePattern{constructor: literalConstructor(fmt.Sprintf("%v", integerLiteral.Value()))}
+		}
+	}
+
+	return &spacePattern{constructor: literalConstructor(literal.String())}
+}
+
+func spaceFromCasePattern(pattern decorated.CasePattern) *spacePattern {
+	switch t := pattern.(type) {
+	case *decorated.CasePatternVariant:
+		var arguments []*spacePattern
+		for _, argument := range t.Arguments() {
+			arguments = append(arguments, spaceFromCasePattern(argument))
+		}
+		return spaceVariant(t.VariantReference().CustomTypeVariant(), arguments)
+	case *decorated.CasePatternTuple:
+		var arguments []*spacePattern
+		for _, subPattern := range t.Patterns() {
+			arguments = append(arguments, spaceFromCasePattern(subPattern))
+		}
+		return &spacePattern{constructor: tupleConstructor(len(arguments)), arguments: arguments}
+	case *decorated.CasePatternLiteral:
+		return spaceLiteral(t.Literal())
+	case *decorated.CasePatternAs:
+		return spaceFromCasePattern(t.Pattern())
+	}
+
+	return spaceWildcard()
+}
+
+func specializeRow(row []*spacePattern, constructor *patternConstructor) ([]*spacePattern, bool) {
+	head := row[0]
+	if head.constructor == nil {
+		return append(spaceWildcards(constructor.arity), row[1:]...), true
+	}
+	if head.constructor.name != constructor.name {
+		return nil, false
+	}
+
+	specialized := append([]*spacePattern{}, head.arguments...)
+	return append(specialized, row[1:]...), true
+}
+
+func specializeRows(rows [][]*spacePattern, constructor *patternConstructor) [][]*spacePattern {
+	var specializedRows [][]*spacePattern
+	for _, row := range rows {
+		if specialized, wasMatching := specializeRow(row, constructor); wasMatching {
+			specializedRows = append(specializedRows, specialized)
+		}
+	}
+	return specializedRows
+}
+
+func defaultRows(rows [][]*spacePattern) [][]*spacePattern {
+	var remainingRows [][]*spacePattern
+	for _, row := range rows {
+		if row[0].constructor == nil {
+			remainingRows = append(remainingRows, row[1:])
+		}
+	}
+	return remainingRows
+}
+
+func headConstructors(rows [][]*spacePattern) []*patternConstructor {
+	var constructors []*patternConstructor
+	found := make(map[string]bool)
+	for _, row := range rows {
+		constructor := row[0].constructor
+		if constructor == nil || found[constructor.name] {
+			continue
+		}
+		found[constructor.name] = true
+		constructors = append(constructors, constructor)
+	}
+	return constructors
+}
+
+// missingConstructors returns the constructors of the family that is not in the constructors.
+// If the family is infinite, it always returns nil and false.
+func missingConstructors(constructors []*patternConstructor) ([]*patternConstructor, bool) {
+	if len(constructors) == 0 || constructors[0].family == nil {
+		return nil, false
+	}
+
+	found := make(map[string]bool)
+	for _, constructor := range constructors {
+		found[constructor.name] = true
+	}
+
+	var missing []*patternConstructor
+	for _, constructor := range constructors[0].family() {
+		if !found[constructor.name] {
+			missing = append(missing, constructor)
+		}
+	}
+
+	return missing, true
+}
+
+func isUsefulPattern(rows [][]*spacePattern, vector []*spacePattern) bool {
+	if len(vector) == 0 {
+		return len(rows) == 0
+	}
+
+	head := vector[0]
+	if head.constructor != nil {
+		specialized, _ := specializeRow(vector, head.constructor)
+		return isUsefulPattern(specializeRows(rows, head.constructor), specialized)
+	}
+
+	constructors := headConstructors(rows)
+	missing, isFinite := missingConstructors(constructors)
+	if isFinite && len(missing) == 0 {
+		for _, constructor := range constructors {
+			specialized, _ := specializeRow(vector, constructor)
+			if isUsefulPattern(specializeRows(rows, constructor), specialized) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return isUsefulPattern(defaultRows(rows), vector[1:])
+}
+
+// findMissingPatterns returns an example of values (one per column) that no row matches.
+func findMissingPatterns(rows [][]*spacePattern, columnCount int) ([]*spacePattern, bool) {
+	if columnCount == 0 {
+		return nil, len(rows) == 0
+	}
+
+	constructors := headConstructors(rows)
+	missing, isFinite := missingConstructors(constructors)
+	if isFinite && len(missing) == 0 {
+		for _, constructor := range constructors {
+			witness, wasFound := findMissingPatterns(specializeRows(rows, constructor), constructor.arity+columnCount-1)
+			if wasFound {
+				head := &spacePattern{constructor: constructor, arguments: witness[:constructor.arity]}
+				return append([]*spacePattern{head}, witness[constructor.arity:]...), true
+			}
+		}
+		return nil, false
+	}
+
+	witness, wasFound := findMissingPatterns(defaultRows(rows), columnCount-1)
+	if !wasFound {
+		return nil, false
+	}
+
+	head := spaceWildcard()
+	if len(constructors) > 0 && len(missing) > 0 {
+		head = &spacePattern{constructor: missing[0], arguments: spaceWildcards(missing[0].arity)}
+	}
+
+	return append([]*spacePattern{head}, witness...), true
+}
+
+func describeSpacePattern(pattern *spacePattern, isArgument bool) string {
+	if pattern.constructor == nil {
+		return "_"
+	}
+
+	var argumentStrings []string
+	for _, argument := range pattern.arguments {
+		argumentStrings = append(argumentStrings, describeSpacePattern(argument, pattern.constructor.name != "tuple"))
+	}
+
+	if pattern.constructor.name == "tuple" {
+		return fmt.Sprintf("( %v )", strings.Join(argumentStrings, ", "))
+	}
+
+	if len(argumentStrings) == 0 {
+		return pattern.constructor.name
+	}
+
+	description := fmt.Sprintf("%v %v", pattern.constructor.name, strings.Join(argumentStrings, " "))
+	if isArgument {
+		return fmt.Sprintf("(%v)", description)
+	}
+
+	return description
+}
+
+func spaceRows(patterns []*spacePattern) [][]*spacePattern {
+	var rows [][]*spacePattern
+	for _, pattern := range patterns {
+		rows = append(rows, []*spacePattern{pattern})
+	}
+	return rows
+}
+
+// checkSpacePatterns returns the indices of the redundant patterns and, if not exhaustive, an example of a pattern that is not handled.
+// Guarded patterns (isGuarded can be nil if there are no guards) can fail to match, so they never cover any values.
+func checkSpacePatterns(patterns []*spacePattern, isGuarded []bool) ([]int, string, bool) {
+	var redundantIndices []int
+	var coveringPatterns []*spacePattern
+	for index, pattern := range patterns {
+		if !isUsefulPattern(spaceRows(coveringPatterns), []*spacePattern{pattern}) {
+			redundantIndices = append(redundantIndices, index)
+		}
+		if isGuarded == nil || !isGuarded[index] {
+			coveringPatterns = append(coveringPatterns, pattern)
+		}
+	}
+
+	witness, wasFound := findMissingPatterns(spaceRows(coveringPatterns), 1)
+	if !wasFound {
+		return redundantIndices, "", true
+	}
+
+	return redundantIndices, describeSpacePattern(witness[0], false), false
+}
+
+// unhandledVariants returns the variants of the custom type that are not fully matched by the patterns.
+func unhandledVariants(customType *dectype.CustomTypeAtom, patterns []*spacePattern) []*dectype.CustomTypeVariantAtom {
+	rows := spaceRows(patterns)
+
+	var unhandled []*dectype.CustomTypeVariantAtom
+	for _, variant := range customType.Variants() {
+		vector := []*spacePattern{spaceVariant(variant, spaceWildcards(variant.ParameterCount()))}
+		if isUsefulPattern(rows, vector) {
+			unhandled = append(unhandled, variant)
+		}
+	}
+
+	return unhandled
+}
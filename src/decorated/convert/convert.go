@@ -68,7 +68,7 @@ func DecorateTupleType(tupleType *ast.TupleType, t decorated.TypeAddAndReference
 		convertedParameters = append(convertedParameters, field)
 	}
 
-	return dectype.NewTupleTypeAtom(tupleType, convertedParameters), nil
+	return dectype.NewTupleTypeAtom(t.SourceModule().DataLayout(), tupleType, convertedParameters), nil
 }
 
 func ConvertFromAstToDecorated(astType ast.Type,
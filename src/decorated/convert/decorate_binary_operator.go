@@ -140,7 +140,7 @@ func defToFunctionReference(def *decorated.NamedDecoratedExpression, ident ast.S
 	return decorated.NewFunctionReference(nameWithModuleRef, functionValue)
 }
 
-func decorateHalfOfAFunctionCall(d DecorateStream, left ast.Expression, context *VariableContext) (*ast.FunctionCall, decorated.Expression, []decorated.Expression, decshared.DecoratedError) {
+func decorateHalfOfAFunctionCall(d DecorateStream, left ast.Expression, pipedArgument decorated.Expression, context *VariableContext) (*ast.FunctionCall, decorated.Expression, []decorated.Expression, decshared.DecoratedError) {
 	var arguments []decorated.Expression
 	var functionExpression decorated.Expression
 	var leftAstCall *ast.FunctionCall
@@ -151,13 +151,11 @@ func decorateHalfOfAFunctionCall(d DecorateStream, left ast.Expression, context
 			return nil, nil, nil, funcExprErr
 		}
 		functionExpression = funcExpr
-		for _, astArgument := range t.Arguments() {
-			expr, exprErr := DecorateExpression(d, astArgument, context)
-			if exprErr != nil {
-				return nil, nil, nil, exprErr
-			}
-			arguments = append(arguments, expr)
+		decoratedArguments, argumentsErr := decorateFunctionCallArguments(d, t, funcExpr, t.Arguments(), pipedArgument, context)
+		if argumentsErr != nil {
+			return nil, nil, nil, argumentsErr
 		}
+		arguments = decoratedArguments
 		leftAstCall = t
 	case *ast.VariableIdentifier:
 		def := context.FindNamedDecoratedExpression(t)
@@ -188,7 +186,7 @@ func decoratePipeLeft(d DecorateStream, infix *ast.BinaryOperator, context *Vari
 		return nil, rightErr
 	}
 
-	leftAstCall, functionExpression, arguments, halfErr := decorateHalfOfAFunctionCall(d, left, context)
+	leftAstCall, functionExpression, arguments, halfErr := decorateHalfOfAFunctionCall(d, left, rightDecorated, context)
 	if halfErr != nil {
 		return nil, halfErr
 	}
@@ -216,7 +214,7 @@ func decoratePipeRight(d DecorateStream, infix *ast.BinaryOperator, context *Var
 		return nil, leftErr
 	}
 
-	rightAstCall, functionExpression, arguments, halfErr := decorateHalfOfAFunctionCall(d, right, context)
+	rightAstCall, functionExpression, arguments, halfErr := decorateHalfOfAFunctionCall(d, right, leftDecorated, context)
 	if halfErr != nil {
 		return nil, halfErr
 	}
@@ -245,6 +243,37 @@ func decorateBinaryOperator(d DecorateStream, infix *ast.BinaryOperator, context
 	}
 }
 
+func arithmeticOperatorTypeConstraint(operatorType decorated.ArithmeticOperatorType) dectype.TypeConstraint {
+	if operatorType == decorated.ArithmeticAppend {
+		return dectype.TypeConstraintAppendable
+	}
+
+	return dectype.TypeConstraintNumber
+}
+
+func booleanOperatorTypeConstraint(operatorType decorated.BooleanOperatorType) dectype.TypeConstraint {
+	if operatorType == decorated.BooleanEqual || operatorType == decorated.BooleanNotEqual {
+		return dectype.TypeConstraintNone
+	}
+
+	return dectype.TypeConstraintComparable
+}
+
+// checkOperandTypeConstraint checks that an operand that has a type parameter type is constrained, so it can be
+// used with the operator in every specialization.
+func checkOperandTypeConstraint(infix *ast.BinaryOperator, operand decorated.Expression, constraint dectype.TypeConstraint) decshared.DecoratedError {
+	localType, wasLocalType := dectype.UnaliasWithResolveInvoker(operand.Type()).(*dectype.LocalType)
+	if !wasLocalType || localType.IsInferenceVariable() {
+		return nil
+	}
+
+	if dectype.CheckTypeConstraint(constraint, localType) != nil {
+		return decorated.NewOperatorNeedsTypeConstraint(infix, operand, constraint)
+	}
+
+	return nil
+}
+
 func decorateBinaryOperatorSameType(d DecorateStream, infix *ast.BinaryOperator, context *VariableContext) (decorated.Expression, decshared.DecoratedError) {
 	leftExpression, leftExpressionErr := DecorateExpression(d, infix.Left(), context)
 	if leftExpressionErr != nil {
@@ -279,8 +308,11 @@ func decorateBinaryOperatorSameType(d DecorateStream, infix *ast.BinaryOperator,
 		if compatibleErr != nil {
 			return nil, decorated.NewUnMatchingArithmeticOperatorTypes(infix, leftExpression, rightExpression)
 		}
+		if constraintErr := checkOperandTypeConstraint(infix, leftExpression, arithmeticOperatorTypeConstraint(arithmeticOperatorType)); constraintErr != nil {
+			return nil, constraintErr
+		}
 		opType := leftExpression.Type()
-		opTypeUnreferenced := dectype.UnReference(opType)
+		opTypeUnreferenced := dectype.Unalias(opType)
 		primitive, _ := opTypeUnreferenced.(*dectype.PrimitiveAtom)
 		if primitive != nil {
 			if primitive.AtomName() == "Fixed" {
@@ -300,10 +332,16 @@ func decorateBinaryOperatorSameType(d DecorateStream, infix *ast.BinaryOperator,
 		if incompatibleErr != nil {
 			return nil, decorated.NewUnMatchingBooleanOperatorTypes(infix, leftExpression, rightExpression)
 		}
+		if constraintErr := checkOperandTypeConstraint(infix, leftExpression, booleanOperatorTypeConstraint(booleanOperatorType)); constraintErr != nil {
+			return nil, constraintErr
+		}
 		boolType := d.TypeReferenceMaker().FindBuiltInType("Bool")
 		if boolType == nil {
 			return nil, decorated.NewTypeNotFound("Bool")
 		}
+		if moduleName := compareModuleName(leftExpression.Type()); moduleName != "" && isOrderingOperator(booleanOperatorType) {
+			return decorateCompareCall(d, infix, moduleName, leftExpression, rightExpression, booleanOperatorType, boolType, context)
+		}
 		return decorated.NewBooleanOperator(infix, leftExpression, rightExpression, booleanOperatorType, boolType)
 	}
 
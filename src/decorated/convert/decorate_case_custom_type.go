@@ -27,7 +27,7 @@ func decorateCaseCustomType(d DecorateStream, caseExpression *ast.CaseForCustomT
 		return nil, decorated.NewMustBeCustomType(decoratedTest)
 	}
 
-	handledCustomTypeVariants := make([]bool, customType.VariantCount())
+	var spacePatterns []*spacePattern
 
 	var decoratedConsequences []*decorated.CaseConsequenceForCustomType
 
@@ -35,7 +35,7 @@ func decorateCaseCustomType(d DecorateStream, caseExpression *ast.CaseForCustomT
 
 	var previousConsequenceType dtype.Type
 
-	for _, consequenceField := range caseExpression.Consequences() {
+	for consequenceIndex, consequenceField := range caseExpression.Consequences() {
 		var foundVariant *dectype.CustomTypeVariantAtom
 		var parameters []*decorated.CaseConsequenceParameterForCustomType
 
@@ -47,13 +47,14 @@ func decorateCaseCustomType(d DecorateStream, caseExpression *ast.CaseForCustomT
 				return nil, decorated.NewCaseCouldNotFindCustomVariantType(caseExpression, consequenceField)
 			}
 
-			foundVariantIndex := foundVariant.Index()
-
-			if handledCustomTypeVariants[foundVariantIndex] {
+			consequencePattern := spaceVariant(foundVariant, spaceWildcards(foundVariant.ParameterCount()))
+			if !isUsefulPattern(spaceRows(spacePatterns), []*spacePattern{consequencePattern}) {
 				return nil, decorated.NewAlreadyHandledCustomTypeVariant(caseExpression, consequenceField, foundVariant)
 			}
-
-			handledCustomTypeVariants[foundVariantIndex] = true
+			// A guarded consequence can fail to match, so it doesn't cover the variant
+			if consequenceField.Guard() == nil {
+				spacePatterns = append(spacePatterns, consequencePattern)
+			}
 
 			numberOfVariantArguments := len(foundVariant.ParameterTypes())
 			if numberOfVariantArguments != len(consequenceField.Arguments()) {
@@ -80,6 +81,11 @@ func decorateCaseCustomType(d DecorateStream, caseExpression *ast.CaseForCustomT
 			*/
 		}
 
+		decoratedGuard, decoratedGuardErr := decorateCaseGuard(d, consequenceField.Guard(), consequenceVariableContext)
+		if decoratedGuardErr != nil {
+			return nil, decoratedGuardErr
+		}
+
 		decoratedExpression, decoratedExpressionErr := DecorateExpression(d, consequenceField.Expression(),
 			consequenceVariableContext)
 		if decoratedExpressionErr != nil {
@@ -99,6 +105,12 @@ func decorateCaseCustomType(d DecorateStream, caseExpression *ast.CaseForCustomT
 		previousConsequenceType = decoratedExpression.Type()
 
 		if consequenceField.Identifier().IsDefaultSymbol() {
+			if !isUsefulPattern(spaceRows(spacePatterns), []*spacePattern{spaceWildcard()}) {
+				d.AddDecoratedError(decorated.NewRedundantCasePatternWarning(consequenceField.Identifier().FetchPositionLength()))
+			}
+			for _, unreachableConsequence := range caseExpression.Consequences()[consequenceIndex+1:] {
+				d.AddDecoratedError(decorated.NewRedundantCasePatternWarning(unreachableConsequence.Identifier().FetchPositionLength()))
+			}
 			defaultCase = decoratedExpression
 			break
 		} else {
@@ -114,20 +126,14 @@ func decorateCaseCustomType(d DecorateStream, caseExpression *ast.CaseForCustomT
 			named := dectype.NewNamedDefinitionTypeReference(nil, fieldTypeRef)
 			variantReference := dectype.NewCustomTypeVariantReference(named, foundVariant)
 			decoratedConsequence := decorated.NewCaseConsequenceForCustomType(foundVariant.Index(), variantReference,
-				parameters, decoratedExpression, consequenceField)
+				parameters, decoratedGuard, decoratedExpression, consequenceField)
 			decoratedConsequences = append(decoratedConsequences, decoratedConsequence)
 		}
 	}
 
 	if defaultCase == nil {
-		var unhandledVariants []*dectype.CustomTypeVariantAtom
-		for index, isHandled := range handledCustomTypeVariants {
-			if !isHandled {
-				unhandledVariants = append(unhandledVariants, customType.Variants()[index])
-			}
-		}
-		if len(unhandledVariants) != 0 {
-			return nil, decorated.NewUnhandledCustomTypeVariants(caseExpression, unhandledVariants)
+		if unhandled := unhandledVariants(customType, spacePatterns); len(unhandled) != 0 {
+			return nil, decorated.NewUnhandledCustomTypeVariants(caseExpression, unhandled)
 		}
 	}
 
@@ -0,0 +1,37 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package decorator
+
+import (
+	"github.com/swamp/compiler/src/ast"
+	"github.com/swamp/compiler/src/decorated/decshared"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+)
+
+// decorateCaseGuard decorates the optional guard of a case consequence. The context must include the
+// variables bound by the consequence pattern. Returns nil if there is no guard.
+func decorateCaseGuard(d DecorateStream, guard ast.Expression, context *VariableContext) (decorated.Expression, decshared.DecoratedError) {
+	if guard == nil {
+		return nil, nil
+	}
+
+	decoratedGuard, decoratedGuardErr := DecorateExpression(d, guard, context)
+	if decoratedGuardErr != nil {
+		return nil, decoratedGuardErr
+	}
+
+	boolType := d.TypeReferenceMaker().FindBuiltInType("Bool")
+	if boolType == nil {
+		panic("internal error. Bool type doesn't exist")
+	}
+
+	if boolCompatibleErr := dectype.CompatibleTypes(boolType, decoratedGuard.Type()); boolCompatibleErr != nil {
+		return nil, decorated.NewCaseGuardMustHaveBooleanType(decoratedGuard)
+	}
+
+	return decoratedGuard, nil
+}
@@ -6,7 +6,6 @@
 package decorator
 
 import (
-	"fmt"
 	"log"
 
 	"github.com/swamp/compiler/src/ast"
@@ -14,8 +13,16 @@ import (
 	"github.com/swamp/compiler/src/decorated/dtype"
 	decorated "github.com/swamp/compiler/src/decorated/expression"
 	dectype "github.com/swamp/compiler/src/decorated/types"
+	"github.com/swamp/compiler/src/token"
 )
 
+func casePatternMatchingConsequencePosition(consequence *ast.CaseConsequencePatternMatching) token.SourceFileReference {
+	if consequence.Literal() == nil {
+		return consequence.Expression().FetchPositionLength()
+	}
+	return consequence.Literal().FetchPositionLength()
+}
+
 func decorateCasePatternMatching(d DecorateStream, caseExpression *ast.CaseForPatternMatching, context *VariableContext) (*decorated.CaseForPatternMatching, decshared.DecoratedError) {
 	decoratedTest, decoratedTestErr := DecorateExpression(d, caseExpression.Test(), context)
 	if decoratedTestErr != nil {
@@ -31,7 +38,9 @@ func decorateCasePatternMatching(d DecorateStream, caseExpression *ast.CaseForPa
 
 	var previousConsequenceType dtype.Type
 
-	for _, consequence := range caseExpression.Consequences() {
+	var spacePatterns []*spacePattern
+
+	for consequenceIndex, consequence := range caseExpression.Consequences() {
 		var decoratedLiteralExpression decorated.Expression
 		if consequence.Literal() != nil {
 			consequenceVariableContext := context.MakeVariableContext()
@@ -48,9 +57,19 @@ func decorateCasePatternMatching(d DecorateStream, caseExpression *ast.CaseForPa
 				return nil, decorated.NewUnMatchingTypes(consequence.Expression(), testType,
 					decoratedLiteralExpression.Type(), incompatibleErr)
 			}
+
+			primitiveAtom, wasPrimitive := testType.(*dectype.PrimitiveAtom)
+			if !wasPrimitive || !literalPatternPrimitives[primitiveAtom.AtomName()] {
+				return nil, decorated.NewCasePatternLiteralNotSupported(consequence.Literal(), decoratedTest.Type())
+			}
 		}
 
 		consequenceExpressionContext := context.MakeVariableContext()
+		decoratedGuard, decoratedGuardErr := decorateCaseGuard(d, consequence.Guard(), consequenceExpressionContext)
+		if decoratedGuardErr != nil {
+			return nil, decoratedGuardErr
+		}
+
 		decoratedExpression, decoratedExpressionErr := DecorateExpression(d, consequence.Expression(),
 			consequenceExpressionContext)
 		if decoratedExpressionErr != nil {
@@ -69,18 +88,34 @@ func decorateCasePatternMatching(d DecorateStream, caseExpression *ast.CaseForPa
 		}
 		previousConsequenceType = decoratedExpression.Type()
 
+		consequencePattern := spaceWildcard()
+		if decoratedLiteralExpression != nil {
+			consequencePattern = spaceLiteral(decoratedLiteralExpression)
+		}
+		if !isUsefulPattern(spaceRows(spacePatterns), []*spacePattern{consequencePattern}) {
+			d.AddDecoratedError(decorated.NewRedundantCasePatternWarning(casePatternMatchingConsequencePosition(consequence)))
+		}
+		// A guarded consequence can fail to match, so it doesn't cover any values
+		if decoratedGuard == nil {
+			spacePatterns = append(spacePatterns, consequencePattern)
+		}
+
 		if consequence.Literal() == nil {
+			for _, unreachableConsequence := range caseExpression.Consequences()[consequenceIndex+1:] {
+				d.AddDecoratedError(decorated.NewRedundantCasePatternWarning(casePatternMatchingConsequencePosition(unreachableConsequence)))
+			}
 			defaultCase = decoratedExpression
 			break
 		} else {
 			decoratedConsequence := decorated.NewCaseConsequencePatternMatching(consequence, consequence.Index(), decoratedLiteralExpression,
-				decoratedExpression)
+				decoratedGuard, decoratedExpression)
 			decoratedConsequences = append(decoratedConsequences, decoratedConsequence)
 		}
 	}
 
 	if defaultCase == nil {
-		return nil, decorated.NewInternalError(fmt.Errorf("must have a default case"))
+		_, missingPattern, _ := checkSpacePatterns(spacePatterns, nil)
+		return nil, decorated.NewNonExhaustiveCasePatterns(caseExpression.KeywordCase(), missingPattern)
 	}
 
 	c, err := decorated.NewCaseForPatternMatching(caseExpression, decoratedTest, decoratedConsequences, defaultCase)
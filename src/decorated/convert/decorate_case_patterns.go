@@ -0,0 +1,200 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package decorator
+
+import (
+	"github.com/swamp/compiler/src/ast"
+	"github.com/swamp/compiler/src/decorated/decshared"
+	"github.com/swamp/compiler/src/decorated/dtype"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+)
+
+func decorateCasePatternBinding(identifier *ast.VariableIdentifier, bindingType dtype.Type, boundNames map[string]bool, context *VariableContext) (*decorated.CaseConsequenceParameterForCustomType, decshared.DecoratedError) {
+	if boundNames[identifier.Name()] {
+		return nil, decorated.NewCasePatternDuplicateBinding(identifier)
+	}
+	boundNames[identifier.Name()] = true
+
+	parameter := decorated.NewCaseConsequenceParameterForCustomType(identifier, bindingType)
+	fakeNamedExpression := decorated.NewNamedDecoratedExpression(identifier.Name(), nil, parameter)
+	context.Add(identifier, fakeNamedExpression)
+
+	return parameter, nil
+}
+
+// literalPatternPrimitives are the types that the code generators can match literal patterns against
+var literalPatternPrimitives = map[string]bool{"Bool": true, "Int": true, "Char": true, "String": true}
+
+func decorateCasePatternLiteral(d DecorateStream, astPattern ast.CasePattern, literal ast.Expression, expectedType dtype.Type, context *VariableContext) (decorated.CasePattern, decshared.DecoratedError) {
+	decoratedLiteral, decoratedLiteralErr := DecorateExpression(d, literal, context)
+	if decoratedLiteralErr != nil {
+		return nil, decoratedLiteralErr
+	}
+
+	if incompatibleErr := dectype.CompatibleTypes(expectedType, decoratedLiteral.Type()); incompatibleErr != nil {
+		return nil, decorated.NewUnMatchingTypes(literal, expectedType, decoratedLiteral.Type(), incompatibleErr)
+	}
+
+	primitiveAtom, wasPrimitive := dectype.UnaliasWithResolveInvoker(expectedType).(*dectype.PrimitiveAtom)
+	if !wasPrimitive || !literalPatternPrimitives[primitiveAtom.AtomName()] {
+		return nil, decorated.NewCasePatternLiteralNotSupported(literal, expectedType)
+	}
+
+	return decorated.NewCasePatternLiteral(astPattern, decoratedLiteral, expectedType), nil
+}
+
+func decorateCasePattern(d DecorateStream, pattern ast.CasePattern, expectedType dtype.Type, boundNames map[string]bool, context *VariableContext) (decorated.CasePattern, decshared.DecoratedError) {
+	unaliasedType := dectype.UnaliasWithResolveInvoker(expectedType)
+
+	switch t := pattern.(type) {
+	case *ast.PatternWildcard:
+		return decorated.NewCasePatternWildcard(t, expectedType), nil
+	case *ast.PatternVariable:
+		// For backwards compatibility, a name that refers to a constant is matched as a literal
+		if moduleDefinition := context.parentDefinitions.FindDefinitionExpression(t.Identifier()); moduleDefinition != nil {
+			if _, wasConstant := moduleDefinition.Expression().(*decorated.Constant); wasConstant {
+				return decorateCasePatternLiteral(d, t, t.Identifier(), expectedType, context)
+			}
+		}
+		parameter, bindingErr := decorateCasePatternBinding(t.Identifier(), expectedType, boundNames, context)
+		if bindingErr != nil {
+			return nil, bindingErr
+		}
+		return decorated.NewCasePatternBinding(parameter), nil
+	case *ast.PatternLiteral:
+		return decorateCasePatternLiteral(d, t, t.Literal(), expectedType, context)
+	case *ast.PatternVariant:
+		customType, wasCustomType := unaliasedType.(*dectype.CustomTypeAtom)
+		if !wasCustomType {
+			return nil, decorated.NewCasePatternTypeMismatch(t, expectedType)
+		}
+		foundVariant := customType.FindVariant(t.Identifier().Name())
+		if foundVariant == nil {
+			return nil, decorated.NewCasePatternUnknownVariant(t, customType)
+		}
+		if foundVariant.ParameterCount() != len(t.Arguments()) {
+			return nil, decorated.NewCasePatternWrongArgumentCount(t, foundVariant)
+		}
+		var arguments []decorated.CasePattern
+		for index, argumentType := range foundVariant.ParameterTypes() {
+			argument, argumentErr := decorateCasePattern(d, t.Arguments()[index], argumentType, boundNames, context)
+			if argumentErr != nil {
+				return nil, argumentErr
+			}
+			arguments = append(arguments, argument)
+		}
+		variantTypeRef := ast.NewTypeReference(t.Identifier(), nil)
+		named := dectype.NewNamedDefinitionTypeReference(nil, variantTypeRef)
+		variantReference := dectype.NewCustomTypeVariantReference(named, foundVariant)
+		return decorated.NewCasePatternVariant(t, variantReference, arguments, expectedType), nil
+	case *ast.PatternTuple:
+		tupleType, wasTupleType := unaliasedType.(*dectype.TupleTypeAtom)
+		if !wasTupleType || len(tupleType.Fields()) != len(t.Patterns()) {
+			return nil, decorated.NewCasePatternTypeMismatch(t, expectedType)
+		}
+		var patterns []decorated.CasePattern
+		for index, field := range tupleType.Fields() {
+			subPattern, subPatternErr := decorateCasePattern(d, t.Patterns()[index], field.Type(), boundNames, context)
+			if subPatternErr != nil {
+				return nil, subPatternErr
+			}
+			patterns = append(patterns, subPattern)
+		}
+		return decorated.NewCasePatternTuple(t, tupleType, patterns), nil
+	case *ast.PatternRecord:
+		recordType, wasRecordType := unaliasedType.(*dectype.RecordAtom)
+		if !wasRecordType {
+			return nil, decorated.NewCasePatternTypeMismatch(t, expectedType)
+		}
+		var fields []*decorated.CasePatternRecordField
+		for _, fieldIdentifier := range t.Fields() {
+			recordField := recordType.FindField(fieldIdentifier.Name())
+			if recordField == nil {
+				return nil, decorated.NewCasePatternUnknownRecordField(fieldIdentifier, recordType)
+			}
+			parameter, bindingErr := decorateCasePatternBinding(fieldIdentifier, recordField.Type(), boundNames, context)
+			if bindingErr != nil {
+				return nil, bindingErr
+			}
+			fields = append(fields, decorated.NewCasePatternRecordField(recordField, parameter))
+		}
+		return decorated.NewCasePatternRecord(t, recordType, fields), nil
+	case *ast.PatternAs:
+		subPattern, subPatternErr := decorateCasePattern(d, t.Pattern(), expectedType, boundNames, context)
+		if subPatternErr != nil {
+			return nil, subPatternErr
+		}
+		parameter, bindingErr := decorateCasePatternBinding(t.Identifier(), expectedType, boundNames, context)
+		if bindingErr != nil {
+			return nil, bindingErr
+		}
+		return decorated.NewCasePatternAs(t, subPattern, parameter), nil
+	}
+
+	return nil, decorated.NewCasePatternTypeMismatch(pattern, expectedType)
+}
+
+func decorateCasePatterns(d DecorateStream, caseExpression *ast.CaseForPatterns, context *VariableContext) (*decorated.CaseForPatterns, decshared.DecoratedError) {
+	decoratedTest, decoratedTestErr := DecorateExpression(d, caseExpression.Test(), context)
+	if decoratedTestErr != nil {
+		return nil, decoratedTestErr
+	}
+
+	var decoratedConsequences []*decorated.CaseConsequenceForPatterns
+
+	var spacePatterns []*spacePattern
+	var isGuarded []bool
+
+	var previousConsequenceType dtype.Type
+
+	for _, consequence := range caseExpression.Consequences() {
+		consequenceVariableContext := context.MakeVariableContext()
+
+		decoratedPattern, decoratedPatternErr := decorateCasePattern(d, consequence.Pattern(), decoratedTest.Type(),
+			make(map[string]bool), consequenceVariableContext)
+		if decoratedPatternErr != nil {
+			return nil, decoratedPatternErr
+		}
+
+		decoratedGuard, decoratedGuardErr := decorateCaseGuard(d, consequence.Guard(), consequenceVariableContext)
+		if decoratedGuardErr != nil {
+			return nil, decoratedGuardErr
+		}
+
+		decoratedExpression, decoratedExpressionErr := DecorateExpression(d, consequence.Expression(),
+			consequenceVariableContext)
+		if decoratedExpressionErr != nil {
+			return nil, decoratedExpressionErr
+		}
+
+		if previousConsequenceType != nil {
+			incompatibleErr := dectype.CompatibleTypesCheckCustomType(previousConsequenceType, decoratedExpression.Type())
+			if incompatibleErr != nil {
+				return nil, decorated.NewUnMatchingTypes(consequence.Expression(), previousConsequenceType,
+					decoratedExpression.Type(), incompatibleErr)
+			}
+		}
+		previousConsequenceType = decoratedExpression.Type()
+
+		spacePatterns = append(spacePatterns, spaceFromCasePattern(decoratedPattern))
+		isGuarded = append(isGuarded, decoratedGuard != nil)
+
+		decoratedConsequence := decorated.NewCaseConsequenceForPatterns(consequence, decoratedPattern, decoratedGuard, decoratedExpression)
+		decoratedConsequences = append(decoratedConsequences, decoratedConsequence)
+	}
+
+	redundantIndices, missingPattern, isExhaustive := checkSpacePatterns(spacePatterns, isGuarded)
+	for _, redundantIndex := range redundantIndices {
+		d.AddDecoratedError(decorated.NewRedundantCasePatternWarning(caseExpression.Consequences()[redundantIndex].Pattern().FetchPositionLength()))
+	}
+
+	if !isExhaustive {
+		return nil, decorated.NewNonExhaustiveCasePatterns(caseExpression.KeywordCase(), missingPattern)
+	}
+
+	return decorated.NewCaseForPatterns(caseExpression, decoratedTest, decoratedConsequences)
+}
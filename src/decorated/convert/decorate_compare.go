@@ -0,0 +1,66 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package decorator
+
+import (
+	"github.com/swamp/compiler/src/ast"
+	"github.com/swamp/compiler/src/decorated/decshared"
+	"github.com/swamp/compiler/src/decorated/dtype"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+	"github.com/swamp/compiler/src/token"
+)
+
+// compareModuleName returns the name of the core module with the `compare` function for the type, if there are no
+// opcodes for ordering values of the type.
+func compareModuleName(t dtype.Type) string {
+	switch info := dectype.UnaliasWithResolveInvoker(t).(type) {
+	case *dectype.PrimitiveAtom:
+		if info.AtomName() == "String" || info.AtomName() == "List" {
+			return info.AtomName()
+		}
+	case *dectype.TupleTypeAtom:
+		return "Tuple"
+	}
+
+	return ""
+}
+
+func isOrderingOperator(operatorType decorated.BooleanOperatorType) bool {
+	return operatorType != decorated.BooleanEqual && operatorType != decorated.BooleanNotEqual
+}
+
+// decorateCompareCall decorates an ordering of two values that have no ordering opcodes, as a call to
+// the `compare` function of the core module that is compared with zero, e.g. `String.compare a b < 0` for `a < b`.
+func decorateCompareCall(d DecorateStream, infix *ast.BinaryOperator, moduleName string, left decorated.Expression,
+	right decorated.Expression, operatorType decorated.BooleanOperatorType, boolType dtype.Type, context *VariableContext) (decorated.Expression, decshared.DecoratedError) {
+	source := infix.FetchPositionLength()
+	moduleReference := ast.NewModuleReference([]*ast.ModuleNamePart{ast.NewModuleNamePart(ast.NewTypeIdentifier(token.NewTypeSymbolToken(moduleName, source, 0)))})
+	compareIdentifier := ast.NewQualifiedVariableIdentifierScoped(moduleReference, ast.NewVariableIdentifier(token.NewVariableSymbolToken("compare", source, 0)))
+
+	if context.FindScopedNamedDecoratedExpression(compareIdentifier) == nil {
+		return nil, decorated.NewCompareFunctionNotFound(infix, left, compareIdentifier.Name())
+	}
+
+	compareFunction, compareFunctionErr := DecorateExpression(d, compareIdentifier, context)
+	if compareFunctionErr != nil {
+		return nil, compareFunctionErr
+	}
+
+	call := ast.NewFunctionCall(compareIdentifier, []ast.Expression{infix.Left(), infix.Right()})
+	compareCall, callErr := decorateFunctionCallInternal(d, call, compareFunction, []decorated.Expression{left, right}, context)
+	if callErr != nil {
+		return nil, callErr
+	}
+
+	intType := d.TypeReferenceMaker().FindBuiltInType("Int")
+	if intType == nil {
+		return nil, decorated.NewTypeNotFound("Int")
+	}
+	zero := decorated.NewIntegerLiteral(ast.NewIntegerLiteral(token.NewNumberToken("0", 0, false, source), 0), intType)
+
+	return decorated.NewBooleanOperator(infix, compareCall, zero, operatorType, boolType)
+}
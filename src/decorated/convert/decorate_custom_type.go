@@ -20,7 +20,7 @@ func DecorateCustomType(customTypeDefinition *ast.CustomType,
 	genericLocalTypes := AstParametersToLocalTypes(customTypeDefinition.FindAllLocalTypes())
 	artifactTypeName := typeRepo.SourceModule().FullyQualifiedModuleName().JoinTypeIdentifier(customTypeDefinition.Identifier())
 
-	s := dectype.NewCustomTypePrepare(customTypeDefinition, artifactTypeName, genericLocalTypes)
+	s := dectype.NewCustomTypePrepare(typeRepo.SourceModule().DataLayout(), customTypeDefinition, artifactTypeName, genericLocalTypes)
 
 	for astVariantIndex, astVariant := range customTypeDefinition.Variants() {
 		var astVariantTypes []dtype.Type
@@ -29,6 +29,8 @@ func internalDecorateExpression(d DecorateStream, e ast.Expression, context *Var
 		return decorateCaseCustomType(d, v, context)
 	case *ast.CaseForPatternMatching:
 		return decorateCasePatternMatching(d, v, context)
+	case *ast.CaseForPatterns:
+		return decorateCasePatterns(d, v, context)
 	case *ast.VariableIdentifier:
 		return decorateIdentifier(d, v, context)
 	case *ast.VariableIdentifierScoped:
@@ -73,6 +75,8 @@ func internalDecorateExpression(d DecorateStream, e ast.Expression, context *Var
 		return decorateProbableConstructorCall(d, v)
 	case *ast.FunctionDeclarationExpression:
 		return decorated.NewExternalFunctionDeclarationExpression(v), nil
+	case *ast.Lambda:
+		return nil, decorated.NewLambdaNeedsExpectedFunctionType(v, nil)
 	default:
 		return nil, decorated.NewInternalError(
 			fmt.Errorf("don't know how to decorate %v %T %v", e, e, e.FetchPositionLength()))
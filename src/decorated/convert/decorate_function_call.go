@@ -22,11 +22,11 @@ func getFunctionValueExpression(d DecorateStream, call *ast.FunctionCall, contex
 	return decoratedExpression, nil
 }
 
-func determineEncounteredFunctionTypeAndArguments(d DecorateStream, call *ast.FunctionCall, functionValueExpressionFunctionType *dectype.FunctionAtom, encounteredCallParametersType *dectype.FunctionAtom, context *VariableContext) (*dectype.FunctionAtom, decshared.DecoratedError) {
+func determineEncounteredFunctionTypeAndArguments(d DecorateStream, call *ast.FunctionCall, functionValueExpressionFunctionType *dectype.FunctionAtom, encounteredCallParametersType *dectype.FunctionAtom, context *VariableContext) (*dectype.FunctionAtom, *dectype.FunctionAtom, decshared.DecoratedError) {
 	/* Smash functions */
 	smashedFunctionType, smashErr := dectype.SmashFunctions(functionValueExpressionFunctionType, encounteredCallParametersType)
 	if smashErr != nil {
-		return nil, decorated.NewCouldNotSmashFunctions(call, functionValueExpressionFunctionType, encounteredCallParametersType, smashErr)
+		return nil, nil, decorated.NewCouldNotSmashFunctions(call, functionValueExpressionFunctionType, encounteredCallParametersType, smashErr)
 	}
 	/* end of smash functions */
 
@@ -41,34 +41,34 @@ func determineEncounteredFunctionTypeAndArguments(d DecorateStream, call *ast.Fu
 
 	functionCompatibleErr := dectype.CompatibleTypes(smashedFunctionType, completeCalledFunctionType)
 	if functionCompatibleErr != nil {
-		return nil, decorated.NewFunctionCallTypeMismatch(functionCompatibleErr, call, smashedFunctionType, completeCalledFunctionType)
+		return nil, nil, decorated.NewFunctionCallTypeMismatch(functionCompatibleErr, call, smashedFunctionType, completeCalledFunctionType)
 	}
 
 	resolvedFunctionArguments, _ := completeCalledFunctionType.ParameterAndReturn()
 
 	errorPosLength := call.FunctionExpression().FetchPositionLength()
 	if encounteredCallParametersType.ParameterCount() > len(completeCalledFunctionParameterTypes) {
-		return nil, decorated.NewExtraFunctionArguments(errorPosLength, resolvedFunctionArguments, encounteredCallParametersType.FunctionParameterTypes())
+		return nil, nil, decorated.NewExtraFunctionArguments(errorPosLength, resolvedFunctionArguments, encounteredCallParametersType.FunctionParameterTypes())
 	}
 
-	return completeCalledFunctionType, nil
+	return completeCalledFunctionType, smashedFunctionType, nil
 }
 
 func decorateFunctionCallInternal(d DecorateStream, call *ast.FunctionCall, functionValueExpression decorated.Expression, decoratedEncounteredArgumentExpressions []decorated.Expression, context *VariableContext) (decorated.Expression, decshared.DecoratedError) {
+	var encounteredArgumentTypes []dtype.Type
+	for _, encounteredArgumentExpression := range decoratedEncounteredArgumentExpressions {
+		encounteredArgumentTypes = append(encounteredArgumentTypes, encounteredArgumentExpression.Type())
+	}
+
 	originalFunctionValueType := functionValueExpression.Type()
-	unaliasedType := dectype.UnaliasWithResolveInvoker(originalFunctionValueType)
+	unaliasedType := dectype.UnaliasWithResolveInvoker(inferCalledType(originalFunctionValueType, encounteredArgumentTypes, context))
 	functionValueExpressionFunctionType, wasFunction := unaliasedType.(*dectype.FunctionAtom)
 	if !wasFunction {
 		return nil, decorated.NewExpectedFunctionTypeForCall(functionValueExpression)
 	}
-
-	var encounteredArgumentTypes []dtype.Type
-	for _, encounteredArgumentExpression := range decoratedEncounteredArgumentExpressions {
-		encounteredArgumentTypes = append(encounteredArgumentTypes, encounteredArgumentExpression.Type())
-	}
 	encounteredFunctionCallType := dectype.NewFunctionAtom(nil, encounteredArgumentTypes)
 
-	completeCalledFunctionType, determineErr := determineEncounteredFunctionTypeAndArguments(d, call, functionValueExpressionFunctionType, encounteredFunctionCallType, context)
+	completeCalledFunctionType, smashedFunctionType, determineErr := determineEncounteredFunctionTypeAndArguments(d, call, functionValueExpressionFunctionType, encounteredFunctionCallType, context)
 	if determineErr != nil {
 		return nil, determineErr
 	}
@@ -84,6 +84,10 @@ func decorateFunctionCallInternal(d DecorateStream, call *ast.FunctionCall, func
 	}
 	*/
 
+	if instantiateErr := instantiateLetFunction(d, functionValueExpression, smashedFunctionType, context); instantiateErr != nil {
+		return nil, instantiateErr
+	}
+
 	isCurrying := len(decoratedEncounteredArgumentExpressions) < completeCalledFunctionType.ParameterCount()-1
 	if isCurrying {
 		providedArgumentCount := len(decoratedEncounteredArgumentExpressions)
@@ -94,7 +98,12 @@ func decorateFunctionCallInternal(d DecorateStream, call *ast.FunctionCall, func
 		return decorated.NewCurryFunction(call, curryFunctionType, functionValueExpression, decoratedEncounteredArgumentExpressions), nil
 	}
 
-	return decorated.NewFunctionCall(call, functionValueExpression, completeCalledFunctionType, decoratedEncounteredArgumentExpressions), nil
+	functionCall := decorated.NewFunctionCall(call, functionValueExpression, completeCalledFunctionType, decoratedEncounteredArgumentExpressions)
+	if specializeErr := specializeFunctionCall(d, functionCall, smashedFunctionType); specializeErr != nil {
+		return nil, specializeErr
+	}
+
+	return functionCall, nil
 }
 
 func decorateFunctionCall(d DecorateStream, call *ast.FunctionCall, context *VariableContext) (decorated.Expression, decshared.DecoratedError) {
@@ -103,13 +112,9 @@ func decorateFunctionCall(d DecorateStream, call *ast.FunctionCall, context *Var
 		return nil, functionReferenceErr
 	}
 
-	var decoratedEncounteredArgumentExpressions []decorated.Expression
-	for _, rawExpression := range call.Arguments() {
-		decoratedExpression, decoratedExpressionErr := DecorateExpression(d, rawExpression, context)
-		if decoratedExpressionErr != nil {
-			return nil, decoratedExpressionErr
-		}
-		decoratedEncounteredArgumentExpressions = append(decoratedEncounteredArgumentExpressions, decoratedExpression)
+	decoratedEncounteredArgumentExpressions, argumentsErr := decorateFunctionCallArguments(d, call, functionValueExpression, call.Arguments(), nil, context)
+	if argumentsErr != nil {
+		return nil, argumentsErr
 	}
 
 	return decorateFunctionCallInternal(d, call, functionValueExpression, decoratedEncounteredArgumentExpressions, context)
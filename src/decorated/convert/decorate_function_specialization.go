@@ -0,0 +1,95 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package decorator
+
+import (
+	"fmt"
+
+	"github.com/swamp/compiler/src/ast"
+	"github.com/swamp/compiler/src/decorated/decshared"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+)
+
+// moduleDecorateStream decorates expressions with the definitions and types of another module than the one that is
+// being decorated. It is used to specialize generic functions that are defined in an imported module.
+type moduleDecorateStream struct {
+	DecorateStream
+	module              *decorated.Module
+	typeLookUpAndCreate decorated.TypeAddAndReferenceMaker
+}
+
+func newModuleDecorateStream(d DecorateStream, module *decorated.Module) *moduleDecorateStream {
+	typeLookup := decorated.NewTypeLookup(module.ImportedModules(), module.LocalTypes(), module.ImportedTypes())
+	createAndLookup := decorated.NewTypeCreateAndLookup(typeLookup, module.LocalTypes())
+
+	return &moduleDecorateStream{DecorateStream: d, module: module, typeLookUpAndCreate: createAndLookup}
+}
+
+func (s *moduleDecorateStream) TypeReferenceMaker() decorated.TypeAddAndReferenceMaker {
+	return s.typeLookUpAndCreate
+}
+
+func (s *moduleDecorateStream) NewVariableContext() *VariableContext {
+	return NewVariableContext(s.module.LocalAndImportedDefinitions())
+}
+
+func (s *moduleDecorateStream) FindNamedFunctionValue(identifier *ast.VariableIdentifier) *decorated.FunctionValue {
+	definition := s.module.LocalDefinitions().FindDefinitionExpression(identifier)
+	if definition == nil {
+		return nil
+	}
+	functionValue, _ := definition.Expression().(*decorated.FunctionValue)
+
+	return functionValue
+}
+
+// specializeFunctionCall decorates a called generic function with constrained type parameters again, with the
+// concrete types of the call. The code generator generates the specializations instead of the generic function,
+// so that the operators in the function body get the correct opcodes for the types. Functions without constraints
+// are generated once.
+func specializeFunctionCall(d DecorateStream, functionCall *decorated.FunctionCall, smashedFunctionType *dectype.FunctionAtom) decshared.DecoratedError {
+	functionReference, wasFunctionReference := functionCall.FunctionExpression().(*decorated.FunctionReference)
+	if !wasFunctionReference {
+		return nil
+	}
+
+	generic := functionReference.FunctionValue()
+	if !generic.NeedsSpecialization() || dectype.TypesIsTemplateHasLocalTypes(smashedFunctionType.FunctionParameterTypes()) {
+		return nil
+	}
+
+	// The body must be decorated using the definitions and types of the module that defines the function
+	definingStream := d
+	if generic.FetchPositionLength().Document != functionCall.FetchPositionLength().Document {
+		moduleReference := functionReference.NameReference().ModuleReference()
+		if moduleReference == nil {
+			return decorated.NewInternalError(fmt.Errorf("could not find the module of generic function '%v'", functionReference.Identifier().Name()))
+		}
+		definingStream = newModuleDecorateStream(d, moduleReference.Module())
+	}
+
+	specializedTypes := smashedFunctionType.FunctionParameterTypes()
+	suffix := decorated.SpecializationSuffix(specializedTypes)
+	specialization := generic.FindSpecialization(suffix)
+	if specialization == nil {
+		var parameters []*decorated.FunctionParameterDefinition
+		for index, parameter := range generic.Parameters() {
+			parameters = append(parameters, decorated.NewFunctionParameterDefinition(parameter.Parameter(), specializedTypes[index]))
+		}
+
+		specializedFunctionValue := decorated.NewPrepareFunctionValue(generic.AstFunctionValue(), smashedFunctionType, parameters, generic.CommentBlock())
+		specialization = generic.AddSpecialization(suffix, specializedFunctionValue)
+
+		if err := DefineExpressionInPreparedFunctionValue(&quietDecorateStream{DecorateStream: definingStream}, specializedFunctionValue, definingStream.NewVariableContext()); err != nil {
+			return err
+		}
+	}
+
+	functionCall.SetSpecialization(specialization)
+
+	return nil
+}
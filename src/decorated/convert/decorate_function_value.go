@@ -24,9 +24,7 @@ func CheckForNoLint(commentBlock token.Comment) string {
 }
 */
 
-func createVariableContextFromParameters(context *VariableContext, parameters []*decorated.FunctionParameterDefinition) *VariableContext {
-	newVariableContext := context.MakeVariableContext()
-
+func addParametersToVariableContext(newVariableContext *VariableContext, parameters []*decorated.FunctionParameterDefinition) {
 	for _, parameter := range parameters {
 		if parameter.Parameter().Identifier() == nil {
 			continue
@@ -34,13 +32,18 @@ func createVariableContextFromParameters(context *VariableContext, parameters []
 		namedDecoratedExpression := decorated.NewNamedDecoratedExpression(parameter.Parameter().Identifier().Name(), nil, parameter)
 		newVariableContext.Add(parameter.Parameter().Identifier(), namedDecoratedExpression)
 	}
+}
+
+func createVariableContextFromParameters(context *VariableContext, targetFunctionValue *decorated.FunctionValue) *VariableContext {
+	newVariableContext := context.MakeFunctionVariableContext(targetFunctionValue)
+	addParametersToVariableContext(newVariableContext, targetFunctionValue.Parameters())
 
 	return newVariableContext
 }
 
 func DefineExpressionInPreparedFunctionValue(d DecorateStream, targetFunctionValue *decorated.FunctionValue, context *VariableContext) decshared.DecoratedError {
 	var decoratedExpression decorated.Expression
-	subVariableContext := createVariableContextFromParameters(context, targetFunctionValue.Parameters())
+	subVariableContext := createVariableContextFromParameters(context, targetFunctionValue)
 	functionValueExpression := targetFunctionValue.AstFunctionValue().Expression()
 	convertedDecoratedExpression, decoratedExpressionErr := DecorateExpression(d, functionValueExpression, subVariableContext)
 	if decoratedExpressionErr != nil {
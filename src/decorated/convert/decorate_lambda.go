@@ -0,0 +1,174 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package decorator
+
+import (
+	"fmt"
+
+	"github.com/swamp/compiler/src/ast"
+	"github.com/swamp/compiler/src/decorated/decshared"
+	"github.com/swamp/compiler/src/decorated/dtype"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+)
+
+func decorateLambda(d DecorateStream, lambda *ast.Lambda, expectedType dtype.Type, typeContext *dectype.TypeParameterContextOther, context *VariableContext) (*decorated.Lambda, decshared.DecoratedError) {
+	expectedFunctionType, wasFunction := dectype.UnaliasWithResolveInvoker(expectedType).(*dectype.FunctionAtom)
+	if !wasFunction {
+		return nil, decorated.NewLambdaNeedsExpectedFunctionType(lambda, expectedType)
+	}
+
+	expectedParameterTypes, _ := expectedFunctionType.ParameterAndReturn()
+	if len(expectedParameterTypes) != len(lambda.Parameters()) {
+		return nil, decorated.NewWrongNumberOfLambdaParameters(lambda, expectedFunctionType)
+	}
+
+	var parameters []*decorated.FunctionParameterDefinition
+	for index, astParameter := range lambda.Parameters() {
+		parameterType := expectedParameterTypes[index]
+		if dectype.TypeIsTemplateHasLocalTypes(parameterType) {
+			replacedType, replaceErr := dectype.ReplaceTypeFromContext(parameterType, typeContext)
+			if replaceErr != nil {
+				return nil, decorated.NewCouldNotInferLambdaParameterType(astParameter, replaceErr)
+			}
+			parameterType = replacedType
+		}
+		parameters = append(parameters, decorated.NewFunctionParameterDefinition(astParameter, parameterType))
+	}
+
+	return decorateLambdaWithParameters(d, lambda, parameters, context)
+}
+
+func decorateLambdaWithParameters(d DecorateStream, lambda *ast.Lambda, parameters []*decorated.FunctionParameterDefinition, context *VariableContext) (*decorated.Lambda, decshared.DecoratedError) {
+	inFunction := context.InFunction()
+	if inFunction == nil {
+		return nil, decorated.NewInternalError(fmt.Errorf("lambdas are only supported inside functions %v", lambda.FetchPositionLength().ToCompleteReferenceString()))
+	}
+
+	decoratedLambda := decorated.NewPrepareLambda(lambda, parameters, len(inFunction.Lambdas()))
+	inFunction.AddLambda(decoratedLambda)
+
+	lambdaContext := context.MakeLambdaVariableContext(decoratedLambda)
+	addParametersToVariableContext(lambdaContext, parameters)
+
+	decoratedExpression, decoratedExpressionErr := DecorateExpression(d, lambda.Expression(), lambdaContext)
+	if decoratedExpressionErr != nil {
+		return nil, decoratedExpressionErr
+	}
+
+	for _, param := range parameters {
+		if !param.WasReferenced() && !param.Parameter().IsIgnore() {
+			d.AddDecoratedError(decorated.NewUnusedParameter(param, inFunction))
+		}
+	}
+
+	decoratedLambda.DefineExpression(decoratedExpression)
+
+	return decoratedLambda, nil
+}
+
+// resolveExpectedFunctionType replaces the type parameters in the function type that an argument is expected to have,
+// as far as they are known. The return type is left as it is, since it is often not known until the argument has been
+// instantiated.
+func resolveExpectedFunctionType(expectedType dtype.Type, typeContext *dectype.TypeParameterContextOther) dtype.Type {
+	functionType, wasFunction := dectype.UnaliasWithResolveInvoker(expectedType).(*dectype.FunctionAtom)
+	if !wasFunction {
+		return expectedType
+	}
+
+	parameterTypes, returnType := functionType.ParameterAndReturn()
+
+	var resolvedTypes []dtype.Type
+	for _, parameterType := range parameterTypes {
+		resolvedType, replaceErr := dectype.ReplaceTypeFromContext(parameterType, typeContext)
+		if replaceErr != nil {
+			resolvedType = parameterType
+		}
+		resolvedTypes = append(resolvedTypes, resolvedType)
+	}
+
+	return dectype.NewFunctionAtom(nil, append(resolvedTypes, returnType))
+}
+
+func isGenericLetFunctionReference(expression decorated.Expression) bool {
+	reference, wasReference := expression.(*decorated.LetVariableReference)
+	return wasReference && reference.LetVariable().GenericFunction() != nil
+}
+
+// decorateFunctionCallArguments decorates the arguments to a function call. Lambdas are decorated last,
+// since the types of their parameters are resolved from the other arguments. Generic let functions are instantiated
+// with the types that are resolved in the same way.
+func decorateFunctionCallArguments(d DecorateStream, call *ast.FunctionCall, functionValueExpression decorated.Expression,
+	astArguments []ast.Expression, pipedArgument decorated.Expression, context *VariableContext) ([]decorated.Expression, decshared.DecoratedError) {
+	decoratedArguments := make([]decorated.Expression, len(astArguments))
+	hasLambdas := false
+	for index, rawExpression := range astArguments {
+		if _, isLambda := rawExpression.(*ast.Lambda); isLambda {
+			hasLambdas = true
+			continue
+		}
+		decoratedExpression, decoratedExpressionErr := DecorateExpression(d, rawExpression, context)
+		if decoratedExpressionErr != nil {
+			return nil, decoratedExpressionErr
+		}
+		decoratedArguments[index] = decoratedExpression
+		hasLambdas = hasLambdas || isGenericLetFunctionReference(decoratedExpression)
+	}
+
+	if !hasLambdas {
+		return decoratedArguments, nil
+	}
+
+	functionType, wasFunction := dectype.UnaliasWithResolveInvoker(functionValueExpression.Type()).(*dectype.FunctionAtom)
+	if !wasFunction {
+		return nil, decorated.NewExpectedFunctionTypeForCall(functionValueExpression)
+	}
+
+	var knownArgumentTypes []dtype.Type
+	var encounteredTypes []dtype.Type
+	for _, decoratedArgument := range decoratedArguments {
+		if decoratedArgument == nil || isGenericLetFunctionReference(decoratedArgument) {
+			knownArgumentTypes = append(knownArgumentTypes, nil)
+			continue
+		}
+		knownArgumentTypes = append(knownArgumentTypes, decoratedArgument.Type())
+		encounteredTypes = append(encounteredTypes, decoratedArgument.Type())
+	}
+	if pipedArgument != nil {
+		knownArgumentTypes = append(knownArgumentTypes, pipedArgument.Type())
+		encounteredTypes = append(encounteredTypes, pipedArgument.Type())
+	}
+
+	typeContext, smashErr := dectype.SmashKnownArguments(functionType, knownArgumentTypes)
+	if smashErr != nil {
+		return nil, decorated.NewCouldNotSmashFunctions(call, functionType, dectype.NewFunctionAtom(nil, encounteredTypes), smashErr)
+	}
+
+	expectedParameterTypes, _ := functionType.ParameterAndReturn()
+	for index, rawExpression := range astArguments {
+		if isGenericLetFunctionReference(decoratedArguments[index]) && index < len(expectedParameterTypes) {
+			expectedType := resolveExpectedFunctionType(expectedParameterTypes[index], typeContext)
+			if instantiateErr := instantiateLetFunction(d, decoratedArguments[index], expectedType, context); instantiateErr != nil {
+				return nil, instantiateErr
+			}
+			continue
+		}
+		lambda, isLambda := rawExpression.(*ast.Lambda)
+		if !isLambda {
+			continue
+		}
+		if index >= len(expectedParameterTypes) {
+			return nil, decorated.NewLambdaNeedsExpectedFunctionType(lambda, nil)
+		}
+		decoratedLambda, lambdaErr := decorateLambda(d, lambda, expectedParameterTypes[index], typeContext, context)
+		if lambdaErr != nil {
+			return nil, lambdaErr
+		}
+		decoratedArguments[index] = decoratedLambda
+	}
+
+	return decoratedArguments, nil
+}
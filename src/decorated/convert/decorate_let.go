@@ -20,7 +20,13 @@ func decorateLet(d DecorateStream, let *ast.Let, context *VariableContext) (*dec
 
 	var allLetVariables []*decorated.LetVariable
 	for _, assignment := range let.Assignments() {
-		decoratedExpression, decoratedExpressionErr := DecorateExpression(d, assignment.Expression(), letVariableContext)
+		var decoratedExpression decorated.Expression
+		var decoratedExpressionErr decshared.DecoratedError
+		if lambda, isLambda := assignment.Expression().(*ast.Lambda); isLambda {
+			decoratedExpression, decoratedExpressionErr = decorateLetFunction(d, lambda, letVariableContext)
+		} else {
+			decoratedExpression, decoratedExpressionErr = DecorateExpression(d, assignment.Expression(), letVariableContext)
+		}
 		if decoratedExpressionErr != nil {
 			return nil, decoratedExpressionErr
 		}
@@ -62,6 +68,9 @@ func decorateLet(d DecorateStream, let *ast.Let, context *VariableContext) (*dec
 				}
 			} else {
 				letVar := decorated.NewLetVariable(assignment.Identifiers()[0], decoratedExpression.Type(), assignment.CommentBlock())
+				if lambda, wasLambda := decoratedExpression.(*decorated.Lambda); wasLambda && lambda.IsGeneric() {
+					letVar.SetGenericFunction(lambda)
+				}
 				letVariables = []*decorated.LetVariable{letVar}
 			}
 		}
@@ -93,6 +102,11 @@ func decorateLet(d DecorateStream, let *ast.Let, context *VariableContext) (*dec
 			unusedErr := decorated.NewUnusedLetVariable(letVariable)
 			d.AddDecoratedError(unusedErr)
 		}
+		for _, reference := range letVariable.ConcreteReferences() {
+			if reference.Instance() == nil {
+				return nil, decorated.NewCouldNotInstantiateLetFunction(reference)
+			}
+		}
 	}
 
 	return decorated.NewLet(let, decoratedAssignments, decoratedConsequence), nil
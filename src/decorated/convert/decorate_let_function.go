@@ -0,0 +1,131 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package decorator
+
+import (
+	"fmt"
+
+	"github.com/swamp/compiler/src/ast"
+	"github.com/swamp/compiler/src/decorated/decshared"
+	"github.com/swamp/compiler/src/decorated/dtype"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+)
+
+// decorateLetFunction infers the type of an unannotated function that is bound in a let block.
+// The parameters start out as unbound type variables, that are bound when the body is unified
+// with the types it is used with. The variables that are still unbound after that are generalized
+// to type parameters. Finally the lambda is decorated again, now with the inferred parameter types, which is
+// when errors and warnings are reported. A parameter that is called is bound to a function type of the argument types
+// and a new variable for the return type. A generic lambda is not generated, each use instantiates it instead.
+func decorateLetFunction(d DecorateStream, lambda *ast.Lambda, context *VariableContext) (*decorated.Lambda, decshared.DecoratedError) {
+	inFunction := context.InFunction()
+	if inFunction == nil {
+		return nil, decorated.NewInternalError(fmt.Errorf("let functions are only supported inside functions %v", lambda.FetchPositionLength().ToCompleteReferenceString()))
+	}
+
+	inference := dectype.NewInferenceVariables(lambda.FetchPositionLength())
+
+	var variables []*dectype.LocalType
+	var inferenceParameters []*decorated.FunctionParameterDefinition
+	for _, astParameter := range lambda.Parameters() {
+		variable := inference.New()
+		variables = append(variables, variable)
+		inferenceParameters = append(inferenceParameters, decorated.NewFunctionParameterDefinition(astParameter, variable))
+	}
+
+	lambdaCountBeforeInference := len(inFunction.Lambdas())
+	_, inferErr := decorateLambdaWithParameters(&quietDecorateStream{DecorateStream: d}, lambda, inferenceParameters, context.MakeGenericFunctionVariableContext(inference))
+	inFunction.TruncateLambdas(lambdaCountBeforeInference)
+	if inferErr != nil {
+		return nil, inferErr
+	}
+
+	generalizer := dectype.NewGeneralizer(inference.Variables())
+
+	var parameters []*decorated.FunctionParameterDefinition
+	isGeneric := false
+	for index, astParameter := range lambda.Parameters() {
+		parameterType, generalizeErr := generalizer.Generalize(variables[index])
+		if generalizeErr != nil {
+			return nil, decorated.NewCouldNotInferLambdaParameterType(astParameter, generalizeErr)
+		}
+		isGeneric = isGeneric || dectype.TypeIsTemplateHasLocalTypes(parameterType)
+		parameters = append(parameters, decorated.NewFunctionParameterDefinition(astParameter, parameterType))
+	}
+
+	if !isGeneric {
+		return decorateLambdaWithParameters(d, lambda, parameters, context)
+	}
+
+	genericLambda, genericErr := decorateLambdaWithParameters(d, lambda, parameters, context.MakeGenericFunctionVariableContext(nil))
+	inFunction.TruncateLambdas(lambdaCountBeforeInference)
+
+	return genericLambda, genericErr
+}
+
+// inferCalledType returns the type that an inference variable is bound to. If a parameter of a let function is called
+// before its type is known, it is bound to a function type of the argument types and a new variable for the return type.
+func inferCalledType(calledType dtype.Type, argumentTypes []dtype.Type, context *VariableContext) dtype.Type {
+	variable, wasLocal := calledType.(*dectype.LocalType)
+	if !wasLocal || !variable.IsInferenceVariable() {
+		return calledType
+	}
+
+	if inferred := variable.Inferred(); inferred != nil {
+		return inferred
+	}
+
+	if context.inference == nil {
+		return calledType
+	}
+
+	functionType := dectype.NewFunctionAtom(nil, append(append([]dtype.Type{}, argumentTypes...), context.inference.New()))
+	if err := variable.Unify(functionType); err != nil {
+		return calledType
+	}
+
+	return functionType
+}
+
+// instantiateLetFunction decorates the lambda of a generic let function again, with the concrete types of the
+// function type that the reference is used as, in the same way as specializeFunctionCall does for generic functions.
+// References to generic let functions inside other generic let functions are instantiated when those are.
+func instantiateLetFunction(d DecorateStream, expression decorated.Expression, usedAsType dtype.Type, context *VariableContext) decshared.DecoratedError {
+	reference, wasReference := expression.(*decorated.LetVariableReference)
+	if !wasReference || reference.LetVariable().GenericFunction() == nil || context.inGenericFunction {
+		return nil
+	}
+
+	usedAsFunctionType, wasFunction := dectype.UnaliasWithResolveInvoker(usedAsType).(*dectype.FunctionAtom)
+	if !wasFunction {
+		return nil
+	}
+
+	generic := reference.LetVariable().GenericFunction()
+	parameterTypes := usedAsFunctionType.FunctionParameterTypes()
+	if len(parameterTypes) < len(generic.Parameters()) {
+		return nil
+	}
+	parameterTypes = parameterTypes[:len(generic.Parameters())]
+	if dectype.TypesIsTemplateHasLocalTypes(parameterTypes) {
+		return nil
+	}
+
+	var parameters []*decorated.FunctionParameterDefinition
+	for index, parameter := range generic.Parameters() {
+		parameters = append(parameters, decorated.NewFunctionParameterDefinition(parameter.Parameter(), parameterTypes[index]))
+	}
+
+	instance, instanceErr := decorateLambdaWithParameters(&quietDecorateStream{DecorateStream: d}, generic.AstLambda(), parameters, context)
+	if instanceErr != nil {
+		return instanceErr
+	}
+
+	reference.SetInstance(instance)
+
+	return nil
+}
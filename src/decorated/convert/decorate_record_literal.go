@@ -74,7 +74,7 @@ func decorateRecordLiteral(d DecorateStream, record *ast.RecordLiteral, context
 		}
 	}
 
-	recordType := dectype.NewRecordType(nil, recordTypeFields, nil) // TODO: FIX
+	recordType := dectype.NewRecordType(d.TypeReferenceMaker().SourceModule().DataLayout(), nil, recordTypeFields, nil) // TODO: FIX
 
 	for _, assignment := range record.ParseOrderedAssignments() {
 		decoratedExpression, decoratedExpressionErr := DecorateExpression(d, assignment.Expression(), context)
@@ -34,7 +34,7 @@ func DecorateRecordType(info *ast.Record, t decorated.TypeAddAndReferenceMaker)
 		convertedParameters = append(convertedParameters, convertedParameter)
 	}
 
-	record := dectype.NewRecordType(info, convertedFields, convertedParameters)
+	record := dectype.NewRecordType(t.SourceModule().DataLayout(), info, convertedFields, convertedParameters)
 
 	return record, nil
 }
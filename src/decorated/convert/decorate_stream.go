@@ -23,3 +23,12 @@ type DecorateStream interface {
 	FindNamedFunctionValue(identifier *ast.VariableIdentifier) *decorated.FunctionValue
 	AddDecoratedError(decoratedError decshared.DecoratedError)
 }
+
+// quietDecorateStream discards errors and warnings. It is used when an expression is decorated more than once,
+// so they are only reported once.
+type quietDecorateStream struct {
+	DecorateStream
+}
+
+func (s *quietDecorateStream) AddDecoratedError(decshared.DecoratedError) {
+}
@@ -26,7 +26,7 @@ func decorateTupleLiteral(d DecorateStream, astTuple *ast.TupleLiteral, context
 	}
 
 	// astTupleType := ast.NewTupleType(token.ParenToken{}, token.ParenToken{}, )
-	tupleType := dectype.NewTupleTypeAtom(nil, foundTypes)
+	tupleType := dectype.NewTupleTypeAtom(d.TypeReferenceMaker().SourceModule().DataLayout(), nil, foundTypes)
 
 	return decorated.NewTupleLiteral(astTuple, tupleType, tupleExpressions), nil
 }
@@ -12,12 +12,17 @@ import (
 	"github.com/swamp/compiler/src/ast"
 	"github.com/swamp/compiler/src/decorated/decshared"
 	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
 )
 
 type VariableContext struct {
 	parent            *VariableContext
 	lookup            map[string]*decorated.NamedDecoratedExpression
 	parentDefinitions *decorated.ModuleDefinitionsCombine
+	inFunction        *decorated.FunctionValue
+	lambda            *decorated.Lambda
+	inGenericFunction bool
+	inference         *dectype.InferenceVariables
 }
 
 func NewVariableContext(parentDefinitions *decorated.ModuleDefinitionsCombine) *VariableContext {
@@ -94,6 +99,11 @@ func (c *VariableContext) ResolveVariable(name *ast.VariableIdentifier) (decorat
 	if err != nil {
 		return nil, err
 	}
+	if letVarReference, wasLetVar := someReference.(*decorated.LetVariableReference); wasLetVar {
+		if letVarReference.LetVariable().GenericFunction() != nil && !c.inGenericFunction {
+			letVarReference.LetVariable().AddConcreteReference(letVarReference)
+		}
+	}
 	/*
 		if functionReference, wasConstant := isConstant(someReference); wasConstant {
 			return createConstant(name, functionReference)
@@ -103,11 +113,22 @@ func (c *VariableContext) ResolveVariable(name *ast.VariableIdentifier) (decorat
 	return someReference, nil
 }
 
+// isGenericLetFunction returns true if the definition is a let variable that is bound to a generic lambda. It is not
+// captured by lambdas, since each use instantiates the lambda again where it is used.
+func isGenericLetFunction(definition *decorated.NamedDecoratedExpression) bool {
+	letVariable, wasLetVariable := definition.Expression().(*decorated.LetVariable)
+	return wasLetVariable && letVariable.GenericFunction() != nil
+}
+
 func (c *VariableContext) FindNamedDecoratedExpression(name *ast.VariableIdentifier) *decorated.NamedDecoratedExpression {
 	def := c.lookup[name.Name()]
 	if def == nil {
 		if c.parent != nil {
-			return c.parent.FindNamedDecoratedExpression(name)
+			found := c.parent.FindNamedDecoratedExpression(name)
+			if found != nil && c.lambda != nil && found.ModuleDefinition() == nil && !isGenericLetFunction(found) {
+				c.lambda.AddCapture(name, found)
+			}
+			return found
 		}
 
 		mDef := c.parentDefinitions.FindDefinitionExpression(name)
@@ -171,5 +192,33 @@ func (c *VariableContext) String() string {
 }
 
 func (c *VariableContext) MakeVariableContext() *VariableContext {
-	return &VariableContext{parent: c, lookup: make(map[string]*decorated.NamedDecoratedExpression), parentDefinitions: c.parentDefinitions}
+	return &VariableContext{parent: c, lookup: make(map[string]*decorated.NamedDecoratedExpression), parentDefinitions: c.parentDefinitions, inFunction: c.inFunction, inGenericFunction: c.inGenericFunction, inference: c.inference}
+}
+
+// MakeGenericFunctionVariableContext creates a context for the body of a let function that is decorated before its
+// types are known. References to generic let functions in it do not need to be instantiated. The inference
+// variables are set while the types of the parameters are inferred.
+func (c *VariableContext) MakeGenericFunctionVariableContext(inference *dectype.InferenceVariables) *VariableContext {
+	newContext := c.MakeVariableContext()
+	newContext.inGenericFunction = true
+	newContext.inference = inference
+	return newContext
+}
+
+func (c *VariableContext) MakeFunctionVariableContext(inFunction *decorated.FunctionValue) *VariableContext {
+	newContext := c.MakeVariableContext()
+	newContext.inFunction = inFunction
+	return newContext
+}
+
+// MakeLambdaVariableContext creates a context where all lookups that resolve to local variables
+// outside the lambda are recorded as captures.
+func (c *VariableContext) MakeLambdaVariableContext(lambda *decorated.Lambda) *VariableContext {
+	newContext := c.MakeVariableContext()
+	newContext.lambda = lambda
+	return newContext
+}
+
+func (c *VariableContext) InFunction() *decorated.FunctionValue {
+	return c.inFunction
 }
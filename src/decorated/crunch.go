@@ -30,7 +30,13 @@ func (*NoImportModuleRepository) FetchModuleInPackage(moduleType decorated.Modul
 }
 
 func CompileToModuleOnceForTest(code string, useCores bool, errorsAsWarnings bool) (*decorated.Module, decshared.DecoratedError) {
-	rootModule, rootModuleErr := CreateDefaultRootModule(useCores)
+	return CompileToModuleOnceForTestWithDataLayout(code, useCores, errorsAsWarnings, dectype.DataLayoutLP64)
+}
+
+// CompileToModuleOnceForTestWithDataLayout is like CompileToModuleOnceForTest, but calculates the memory sizes of the
+// types for the dataLayout.
+func CompileToModuleOnceForTestWithDataLayout(code string, useCores bool, errorsAsWarnings bool, dataLayout dectype.DataLayout) (*decorated.Module, decshared.DecoratedError) {
+	rootModule, rootModuleErr := CreateDefaultRootModule(useCores, dataLayout)
 	if parser.IsCompileError(rootModuleErr) {
 		return nil, rootModuleErr
 	}
@@ -138,7 +144,7 @@ func InternalCompileToModule(moduleType decorated.ModuleType, moduleRepository M
 		return nil, programErr
 	}
 
-	module := decorated.NewModule(moduleType, moduleName, tokenizer.Document())
+	module := decorated.NewModule(moduleType, moduleName, tokenizer.Document(), rootModule.DataLayout())
 
 	// relativeModuleName := dectype.MakePackageRelativeModuleName(importModule.FullyQualifiedModuleName().Path())
 	fakeModuleReference := decorated.NewModuleReference(rootModule.FullyQualifiedModuleName().Path(), rootModule)
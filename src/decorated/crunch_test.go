@@ -10,11 +10,12 @@ import (
 	"log"
 	"testing"
 
+	dectype "github.com/swamp/compiler/src/decorated/types"
 	"github.com/swamp/compiler/src/verbosity"
 )
 
 func TestCrunch(t *testing.T) {
-	rootModule, mErr := CreateDefaultRootModule(true)
+	rootModule, mErr := CreateDefaultRootModule(true, dectype.DataLayoutLP64)
 	if parser.IsCompileErr(mErr) {
 		t.Fatal(mErr)
 	}
@@ -1945,3 +1945,250 @@ tester : (b: String) -> Bool =
 [ModuleDef $tester = [FunctionValue ([[Arg $b : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $String]]]]]) -> (Arithmetic [FnCall [FunctionRef [NamedDefinitionReference /first]] [[Integer 2]]] PLUS [Integer 2]) |> [FnCall [FnCall [FunctionRef [NamedDefinitionReference /second]] [[FunctionParamRef [Arg $b : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $String]]]]] (Arithmetic [FnCall [FunctionRef [NamedDefinitionReference /first]] [[Integer 2]]] PLUS [Integer 2])]] [[FunctionParamRef [Arg $b : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $String]]]]]]] |> [FnCall [FnCall [FunctionRef [NamedDefinitionReference /third]] [(Arithmetic [FnCall [FunctionRef [NamedDefinitionReference /first]] [[Integer 2]]] PLUS [Integer 2]) |> [FnCall [FnCall [FunctionRef [NamedDefinitionReference /second]] [[FunctionParamRef [Arg $b : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $String]]]]] (Arithmetic [FnCall [FunctionRef [NamedDefinitionReference /first]] [[Integer 2]]] PLUS [Integer 2])]] [[FunctionParamRef [Arg $b : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $String]]]]]]]]] []]]]
 `)
 }
+
+func TestCaseNestedPatterns(t *testing.T) {
+	testDecorateWithoutDefault(t,
+		`
+checkMaybeTuple : (a: Maybe (Int, Bool)) -> Int =
+    case a of
+        Just ( 0, true ) -> 1
+
+        Just ( x, _ ) -> x
+
+        Nothing -> 0
+`, `
+[ModuleDef $checkMaybeTuple = [FunctionValue ([[Arg [Arg $a: [TypeReference $Maybe [[TupleType [[TypeReference $Int] [TypeReference $Bool]]]]]] : [VariantRef [NamedDefTypeRef :[TypeReference $Maybe]]]<[TupleType [[PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]] [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Bool]]]]]>]]) -> [PCase: [FunctionParamRef [Arg [Arg $a: [TypeReference $Maybe [[TupleType [[TypeReference $Int] [TypeReference $Bool]]]]]] : [VariantRef [NamedDefTypeRef :[TypeReference $Maybe]]]<[TupleType [[PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]] [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Bool]]]]]>]] of [PCaseCons [pvariant Just [[ptuple [[pliteral [Integer 0]] [pliteral [Bool true]]]]]] => [Integer 1]];[PCaseCons [pvariant Just [[ptuple [[pbind x] [pwildcard]]]]] => [functionparamref $x [dcaseparm $x type:[PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]];[PCaseCons [pvariant Nothing []] => [Integer 0]]]]]
+`)
+}
+
+func TestCaseNestedPatternsNotExhaustiveFail(t *testing.T) {
+	testDecorateWithoutDefaultFail(t,
+		`
+checkMaybeTuple : (a: Maybe (Int, Bool)) -> Int =
+    case a of
+        Just ( 0, true ) -> 1
+
+        Nothing -> 0
+`,
+		&decorated.NonExhaustiveCasePatterns{})
+}
+
+func TestCaseNestedPatternsRedundantFail(t *testing.T) {
+	testDecorateWithoutDefaultFail(t,
+		`
+checkMaybeTuple : (a: Maybe (Int, Bool)) -> Int =
+    case a of
+        Just ( x, _ ) -> x
+
+        Just ( 0, true ) -> 1
+
+        Nothing -> 0
+`,
+		&decorated.RedundantCasePatternWarning{})
+}
+
+func TestCaseGuard(t *testing.T) {
+	testDecorateWithoutDefault(t,
+		`
+checkMaybeInt : (a: Maybe Int) -> Int =
+    case a of
+        Just x | x > 10 -> x
+
+        Just y -> y
+
+        Nothing -> 0
+`, `
+[ModuleDef $checkMaybeInt = [FunctionValue ([[Arg [Arg $a: [TypeReference $Maybe [[TypeReference $Int]]]] : [VariantRef [NamedDefTypeRef :[TypeReference $Maybe]]]<[PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]>]]) -> [dcase: [FunctionParamRef [Arg [Arg $a: [TypeReference $Maybe [[TypeReference $Int]]]] : [VariantRef [NamedDefTypeRef :[TypeReference $Maybe]]]<[PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]>]] of [dcasecons [VariantRef [NamedDefTypeRef :[TypeReference $Just]] [Variant $Just [[PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]] ([[dcaseparm $x type:[PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]) | [BoolOp [functionparamref $x [dcaseparm $x type:[PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]] GR [Integer 10]] => [functionparamref $x [dcaseparm $x type:[PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]];[dcasecons [VariantRef [NamedDefTypeRef :[TypeReference $Just]] [Variant $Just [[PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]] ([[dcaseparm $y type:[PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]) => [functionparamref $y [dcaseparm $y type:[PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]];[dcasecons [VariantRef [NamedDefTypeRef :[TypeReference $Nothing]] [Variant $Nothing []]] ([]) => [Integer 0]]]]]
+`)
+}
+
+func TestCaseFixedLiteralFail(t *testing.T) {
+	testDecorateWithoutDefaultFail(t,
+		`
+scale : (a: Fixed) -> Int =
+    case a of
+        1.5 -> 1
+
+        _ -> 0
+`,
+		&decorated.CasePatternLiteralNotSupported{})
+}
+
+func TestCaseNestedFixedLiteralFail(t *testing.T) {
+	testDecorateWithoutDefaultFail(t,
+		`
+scale : (a: Fixed, b: Int) -> Int =
+    case (a, b) of
+        (1.5, 2) -> 1
+
+        _ -> 0
+`,
+		&decorated.CasePatternLiteralNotSupported{})
+}
+
+func TestCaseGuardDoesNotCoverFail(t *testing.T) {
+	testDecorateWithoutDefaultFail(t,
+		`
+checkMaybeInt : (a: Maybe Int) -> Int =
+    case a of
+        Just x | x > 10 -> x
+
+        Nothing -> 0
+`,
+		&decorated.UnhandledCustomTypeVariants{})
+}
+
+func TestCaseGuardMustBeBooleanFail(t *testing.T) {
+	testDecorateWithoutDefaultFail(t,
+		`
+checkMaybeInt : (a: Maybe Int) -> Int =
+    case a of
+        Just x | x -> x
+
+        _ -> 0
+`,
+		&decorated.CaseGuardMustHaveBooleanType{})
+}
+
+func TestLetFunctionInferred(t *testing.T) {
+	testDecorateWithoutDefault(t,
+		`
+scale : (a: Int) -> Int =
+    let
+        double = \x -> x * 2
+    in
+    double a
+`, `
+[ModuleDef $scale = [FunctionValue ([[Arg [Arg $a: [TypeReference $Int]] : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]) -> [Let [[LetAssign [[LetVar $double]] = [Lambda [] [[Arg [Arg $x] : [Primitive Int]]] => (Arithmetic [FunctionParamRef [Arg [Arg $x] : [Primitive Int]]] MULTIPLY [Integer 2])]]] in [FnCall [LetVarRef [LetVar $double]] [[FunctionParamRef [Arg [Arg $a: [TypeReference $Int]] : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]]]]]]
+`)
+}
+
+func TestLetFunctionGeneralized(t *testing.T) {
+	testDecorateWithoutDefault(t,
+		`
+pick : (a: Int) -> Fixed =
+    let
+        first = \x _ -> x
+        _ = first "hello" 3
+    in
+    first 2.5 a
+`, `
+[ModuleDef $pick = [FunctionValue ([[Arg [Arg $a: [TypeReference $Int]] : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]) -> [Let [[LetAssign [[LetVar $first]] = [Lambda [] [[Arg [Arg $x] : [GenericParam a]] [Arg [Arg $_] : [GenericParam b]]] => [FunctionParamRef [Arg [Arg $x] : [GenericParam a]]]]] [LetAssign [[LetVar $_]] = [FnCall [LetVarRef [LetVar $first] [Lambda [] [[Arg [Arg $x] : [Primitive String]] [Arg [Arg $_] : [Primitive Int]]] => [FunctionParamRef [Arg [Arg $x] : [Primitive String]]]]] [[String hello] [Integer 3]]]]] in [FnCall [LetVarRef [LetVar $first] [Lambda [] [[Arg [Arg $x] : [Primitive Fixed]] [Arg [Arg $_] : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]] => [FunctionParamRef [Arg [Arg $x] : [Primitive Fixed]]]]] [[Fixed 2500] [FunctionParamRef [Arg [Arg $a: [TypeReference $Int]] : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]]]]]]
+`)
+}
+
+func TestLetFunctionGeneralizedFunctionParameter(t *testing.T) {
+	testDecorateWithoutDefault(t,
+		`
+apply : (f: Int -> Int, x: Int) -> Int =
+    f x
+
+
+main : (a: Int) -> Int =
+    let
+        identity = \x -> x
+        twice = \f x -> f (f x)
+    in
+    apply identity (twice (\n -> n + 1) a)
+`, `
+[ModuleDef $apply = [FunctionValue ([[Arg [Arg $f: [FnType [TypeReference $Int] -> [TypeReference $Int]]] : [FunctionTypeRef [FunctionType [[PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]] [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]] [Arg [Arg $x: [TypeReference $Int]] : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]) -> [FnCall [FunctionParamRef [Arg [Arg $f: [FnType [TypeReference $Int] -> [TypeReference $Int]]] : [FunctionTypeRef [FunctionType [[PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]] [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]]] [[FunctionParamRef [Arg [Arg $x: [TypeReference $Int]] : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]]]]]
+[ModuleDef $main = [FunctionValue ([[Arg [Arg $a: [TypeReference $Int]] : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]) -> [Let [[LetAssign [[LetVar $identity]] = [Lambda [] [[Arg [Arg $x] : [GenericParam a]]] => [FunctionParamRef [Arg [Arg $x] : [GenericParam a]]]]] [LetAssign [[LetVar $twice]] = [Lambda [] [[Arg [Arg $f] : [FunctionType [[GenericParam c] [GenericParam c]]]] [Arg [Arg $x] : [GenericParam c]]] => [FnCall [FunctionParamRef [Arg [Arg $f] : [FunctionType [[GenericParam c] [GenericParam c]]]]] [[FnCall [FunctionParamRef [Arg [Arg $f] : [FunctionType [[GenericParam c] [GenericParam c]]]]] [[FunctionParamRef [Arg [Arg $x] : [GenericParam c]]]]]]]]]] in [FnCall [FunctionRef [NamedDefinitionReference /apply]] [[LetVarRef [LetVar $identity] [Lambda [] [[Arg [Arg $x] : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]] => [FunctionParamRef [Arg [Arg $x] : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]]] [FnCall [LetVarRef [LetVar $twice] [Lambda [] [[Arg [Arg $f] : [FunctionType [[PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]] [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]] [Arg [Arg $x] : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]] => [FnCall [FunctionParamRef [Arg [Arg $f] : [FunctionType [[PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]] [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]]] [[FnCall [FunctionParamRef [Arg [Arg $f] : [FunctionType [[PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]] [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]]] [[FunctionParamRef [Arg [Arg $x] : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]]]]]]] [[Lambda [] [[Arg [Arg $n] : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]] => (Arithmetic [FunctionParamRef [Arg [Arg $n] : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]] PLUS [Integer 1])] [FunctionParamRef [Arg [Arg $a: [TypeReference $Int]] : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]]]]]]]]
+`)
+}
+
+func TestLetFunctionNotInstantiatedFail(t *testing.T) {
+	testDecorateWithoutDefaultFail(t,
+		`
+pick : (a: Int) -> Int =
+    let
+        identity = \x -> x
+        other = identity
+    in
+    other a
+`,
+		&decorated.CouldNotInstantiateLetFunction{})
+}
+
+func TestLetFunctionInferredFail(t *testing.T) {
+	testDecorateWithoutDefaultFail(t,
+		`
+scale : (a: Int) -> Int =
+    let
+        double = \x -> x * 2
+    in
+    double "hello"
+`,
+		&decorated.CouldNotSmashFunctions{})
+}
+
+func TestTypeConstraintComparable(t *testing.T) {
+	testDecorateWithoutDefault(t,
+		`
+max : (a: comparable, b: comparable) -> comparable =
+    if a > b then a else b
+
+
+main : (x: Int) -> Int =
+    max x 3
+`, `
+[ModuleDef $max = [FunctionValue ([[Arg [Arg $a: [GenericType [TypeParam $comparable]]] : [GenericParam comparable]] [Arg [Arg $b: [GenericType [TypeParam $comparable]]] : [GenericParam comparable]]]) -> [If [BoolOp [FunctionParamRef [Arg [Arg $a: [GenericType [TypeParam $comparable]]] : [GenericParam comparable]]] GR [FunctionParamRef [Arg [Arg $b: [GenericType [TypeParam $comparable]]] : [GenericParam comparable]]]] then [FunctionParamRef [Arg [Arg $a: [GenericType [TypeParam $comparable]]] : [GenericParam comparable]]] else [FunctionParamRef [Arg [Arg $b: [GenericType [TypeParam $comparable]]] : [GenericParam comparable]]]]]]
+[ModuleDef $main = [FunctionValue ([[Arg [Arg $x: [TypeReference $Int]] : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]]) -> [FnCall [FunctionRef [NamedDefinitionReference /max]] [[FunctionParamRef [Arg [Arg $x: [TypeReference $Int]] : [PrimitiveTypeRef [NamedDefTypeRef :[TypeReference $Int]]]]] [Integer 3]]]]]
+`)
+}
+
+func TestTypeConstraintComparableString(t *testing.T) {
+	testDecorateWithoutDefaultFail(t,
+		`
+max : (a: comparable, b: comparable) -> comparable =
+    if a > b then a else b
+
+
+main : (x: String) -> String =
+    max x "hello"
+`,
+		&decorated.CompareFunctionNotFound{})
+}
+
+func TestTypeConstraintComparableTuple(t *testing.T) {
+	testDecorateWithoutDefaultFail(t,
+		`
+isBefore : (a: (Int, String), b: (Int, String)) -> Bool =
+    a < b
+`,
+		&decorated.CompareFunctionNotFound{})
+}
+
+func TestTypeConstraintComparableListFail(t *testing.T) {
+	testDecorateWithoutDefaultFail(t,
+		`
+max : (a: comparable, b: comparable) -> comparable =
+    if a > b then a else b
+
+
+main : (x: List Bool) -> List Bool =
+    max x x
+`,
+		&decorated.CouldNotSmashFunctions{})
+}
+
+func TestTypeConstraintNotSatisfiedFail(t *testing.T) {
+	testDecorateWithoutDefaultFail(t,
+		`
+scale : (a: number, b: number) -> number =
+    a * b
+
+
+main : (x: String) -> String =
+    scale x "hello"
+`,
+		&decorated.CouldNotSmashFunctions{})
+}
+
+func TestOperatorNeedsTypeConstraintFail(t *testing.T) {
+	testDecorateWithoutDefaultFail(t,
+		`
+max : (a: a, b: a) -> a =
+    if a > b then a else b
+`,
+		&decorated.OperatorNeedsTypeConstraint{})
+}
@@ -34,6 +34,7 @@ __externalvarfn indexedMap : ((Int -> a -> b), List a) -> List b
 __externalvarfn find : ((a -> Bool), List a) -> Maybe a
 __externalfn range : (Int, Int) -> List Int
 __externalfn range0 : (Int) -> List Int
+__externalvarfn compare : (Any, Any) -> Int
 `
 
 const mathCode = `
@@ -103,6 +104,7 @@ __externalfn first : (a, b) -> a
 __externalfn second : (a, b) -> b
 __externalfn third : (a, b, c) -> c
 __externalfn forth : (a, b, c, d) -> d
+__externalvarfn compare : (Any, Any) -> Int
 `
 
 const debugCode = `
@@ -125,6 +127,7 @@ __externalfn fromCode : (Int) -> Char
 
 const stringCode = `
 __externalfn fromInt : (Int) -> String
+__externalfn compare : (String, String) -> Int
 `
 
 const typeIdCode = `
@@ -263,11 +266,13 @@ func addCores(globalPrimitiveModule *decorated.Module) decshared.DecoratedError
 	}
 	err = decorated.AppendError(err, stringModuleErr)
 
-	/*
-		maybeModuleErr := compileAndAddToModule(globalPrimitiveModule, "Tuple", tupleCode); maybeModuleErr != nil {
-			return maybeModuleErr
-		}
+	tupleModuleErr := compileAndAddToModule(globalPrimitiveModule, "Tuple", tupleCode)
+	if parser.IsCompileError(tupleModuleErr) {
+		return tupleModuleErr
+	}
+	err = decorated.AppendError(err, tupleModuleErr)
 
+	/*
 		if maybeModuleErr := compileAndAddToModule(globalPrimitiveModule, "TypeRef", typeIdCode); maybeModuleErr != nil {
 			return maybeModuleErr
 		}
@@ -286,7 +291,7 @@ func addPrimitive(types *decorated.ModuleTypes, atom *dectype.PrimitiveAtom) {
 	types.InternalAddPrimitive(atom.PrimitiveName(), atom)
 }
 
-func kickstartPrimitives() *decorated.Module {
+func kickstartPrimitives(dataLayout dectype.DataLayout) *decorated.Module {
 	newSourceFileUri := token.MakeDocumentURI("file://internal/")
 	doc := &token.SourceFileDocument{Uri: newSourceFileUri}
 	sourceFileReference := token.SourceFileReference{Document: doc, Range: token.Range{}}
@@ -294,7 +299,7 @@ func kickstartPrimitives() *decorated.Module {
 	nameTypeIdentifier := ast.NewTypeIdentifier(token.NewTypeSymbolToken("", sourceFileReference, 0))
 	sourceFileDocument := &token.SourceFileDocument{
 		Uri: newSourceFileUri}
-	rootPrimitiveModule := decorated.NewModule(decorated.ModuleTypeNormal, dectype.MakeArtifactFullyQualifiedModuleName(ast.NewModuleReference([]*ast.ModuleNamePart{ast.NewModuleNamePart(nameTypeIdentifier)})), sourceFileDocument)
+	rootPrimitiveModule := decorated.NewModule(decorated.ModuleTypeNormal, dectype.MakeArtifactFullyQualifiedModuleName(ast.NewModuleReference([]*ast.ModuleNamePart{ast.NewModuleNamePart(nameTypeIdentifier)})), sourceFileDocument, dataLayout)
 	rootPrimitiveModule.MarkAsInternal()
 	primitiveModuleLocalTypes := rootPrimitiveModule.LocalTypes()
 
@@ -342,8 +347,10 @@ func kickstartPrimitives() *decorated.Module {
 	return rootPrimitiveModule
 }
 
-func CreateDefaultRootModule(includeCores bool) (*decorated.Module, decshared.DecoratedError) {
-	primitiveModule := kickstartPrimitives()
+// CreateDefaultRootModule creates the module with the primitive types, and the core modules if includeCores is set.
+// All modules that are compiled with the root module use its data layout.
+func CreateDefaultRootModule(includeCores bool, dataLayout dectype.DataLayout) (*decorated.Module, decshared.DecoratedError) {
+	primitiveModule := kickstartPrimitives(dataLayout)
 
 	var err decshared.DecoratedError
 	stdModule, stdModuleErr := compileToModule(primitiveModule, "", stdCode)
@@ -50,6 +50,10 @@ func (a *ArithmeticOperator) OperatorType() ArithmeticOperatorType {
 	return a.operatorType
 }
 
+func (a *ArithmeticOperator) AstBinaryOperator() *ast.BinaryOperator {
+	return a.infix
+}
+
 func (a *ArithmeticOperator) Left() Expression {
 	return a.left
 }
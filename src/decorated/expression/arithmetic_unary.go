@@ -36,6 +36,10 @@ func (a *ArithmeticUnaryOperator) OperatorType() ArithmeticUnaryOperatorType {
 	return a.operatorType
 }
 
+func (a *ArithmeticUnaryOperator) AstUnaryExpression() *ast.UnaryExpression {
+	return a.unary
+}
+
 func (a *ArithmeticUnaryOperator) Left() Expression {
 	return a.left
 }
@@ -57,16 +57,17 @@ func NewCaseConsequenceParameterForCustomType(name *ast.VariableIdentifier, para
 type CaseConsequenceForCustomType struct {
 	variantName    *dectype.CustomTypeVariantReference
 	parameters     []*CaseConsequenceParameterForCustomType
+	guard          Expression
 	expression     Expression
 	internalIndex  int
 	astConsequence *ast.CaseConsequenceForCustomType
 }
 
 func NewCaseConsequenceForCustomType(internalIndex int, variantName *dectype.CustomTypeVariantReference, parameters []*CaseConsequenceParameterForCustomType,
-	expression Expression, astConsequence *ast.CaseConsequenceForCustomType) *CaseConsequenceForCustomType {
+	guard Expression, expression Expression, astConsequence *ast.CaseConsequenceForCustomType) *CaseConsequenceForCustomType {
 	return &CaseConsequenceForCustomType{
 		internalIndex: internalIndex, variantName: variantName, parameters: parameters,
-		expression: expression, astConsequence: astConsequence,
+		guard: guard, expression: expression, astConsequence: astConsequence,
 	}
 }
 
@@ -74,6 +75,11 @@ func (c *CaseConsequenceForCustomType) AstConsequence() *ast.CaseConsequenceForC
 	return c.astConsequence
 }
 
+// Guard is the optional Bool condition that must be true for the consequence to be selected, or nil.
+func (c *CaseConsequenceForCustomType) Guard() Expression {
+	return c.guard
+}
+
 func (c *CaseConsequenceForCustomType) Expression() Expression {
 	return c.expression
 }
@@ -91,7 +97,7 @@ func (c *CaseConsequenceForCustomType) Parameters() []*CaseConsequenceParameterF
 }
 
 func (c *CaseConsequenceForCustomType) String() string {
-	return fmt.Sprintf("[dcasecons %v (%v) => %v]", c.variantName, c.parameters, c.expression)
+	return fmt.Sprintf("[dcasecons %v (%v)%v => %v]", c.variantName, c.parameters, caseGuardToString(c.guard), c.expression)
 }
 
 func caseConsequenceArrayToStringEx(expressions []*CaseConsequenceForCustomType, ch string) string {
@@ -0,0 +1,241 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package decorated
+
+import (
+	"fmt"
+
+	"github.com/swamp/compiler/src/ast"
+	"github.com/swamp/compiler/src/decorated/dtype"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+	"github.com/swamp/compiler/src/token"
+)
+
+// CasePattern is a decorated, type checked, case pattern. The type is the type of the value it is matched against.
+type CasePattern interface {
+	Type() dtype.Type
+	FetchPositionLength() token.SourceFileReference
+	String() string
+}
+
+type CasePatternWildcard struct {
+	astPattern  *ast.PatternWildcard
+	patternType dtype.Type
+}
+
+func NewCasePatternWildcard(astPattern *ast.PatternWildcard, patternType dtype.Type) *CasePatternWildcard {
+	return &CasePatternWildcard{astPattern: astPattern, patternType: patternType}
+}
+
+func (p *CasePatternWildcard) Type() dtype.Type {
+	return p.patternType
+}
+
+func (p *CasePatternWildcard) FetchPositionLength() token.SourceFileReference {
+	return p.astPattern.FetchPositionLength()
+}
+
+func (p *CasePatternWildcard) String() string {
+	return "[pwildcard]"
+}
+
+// CasePatternBinding binds the matched value to a name. It reuses the case consequence parameter,
+// so references and code generation works the same as for the custom type variant parameters.
+type CasePatternBinding struct {
+	parameter *CaseConsequenceParameterForCustomType
+}
+
+func NewCasePatternBinding(parameter *CaseConsequenceParameterForCustomType) *CasePatternBinding {
+	return &CasePatternBinding{parameter: parameter}
+}
+
+func (p *CasePatternBinding) Parameter() *CaseConsequenceParameterForCustomType {
+	return p.parameter
+}
+
+func (p *CasePatternBinding) Type() dtype.Type {
+	return p.parameter.Type()
+}
+
+func (p *CasePatternBinding) FetchPositionLength() token.SourceFileReference {
+	return p.parameter.FetchPositionLength()
+}
+
+func (p *CasePatternBinding) String() string {
+	return fmt.Sprintf("[pbind %v]", p.parameter.Identifier().Name())
+}
+
+type CasePatternLiteral struct {
+	astPattern  ast.CasePattern
+	literal     Expression
+	patternType dtype.Type
+}
+
+func NewCasePatternLiteral(astPattern ast.CasePattern, literal Expression, patternType dtype.Type) *CasePatternLiteral {
+	return &CasePatternLiteral{astPattern: astPattern, literal: literal, patternType: patternType}
+}
+
+func (p *CasePatternLiteral) Literal() Expression {
+	return p.literal
+}
+
+func (p *CasePatternLiteral) Type() dtype.Type {
+	return p.patternType
+}
+
+func (p *CasePatternLiteral) FetchPositionLength() token.SourceFileReference {
+	return p.astPattern.FetchPositionLength()
+}
+
+func (p *CasePatternLiteral) String() string {
+	return fmt.Sprintf("[pliteral %v]", p.literal)
+}
+
+type CasePatternVariant struct {
+	astPattern       *ast.PatternVariant
+	variantReference *dectype.CustomTypeVariantReference
+	arguments        []CasePattern
+	patternType      dtype.Type
+}
+
+func NewCasePatternVariant(astPattern *ast.PatternVariant, variantReference *dectype.CustomTypeVariantReference, arguments []CasePattern, patternType dtype.Type) *CasePatternVariant {
+	return &CasePatternVariant{astPattern: astPattern, variantReference: variantReference, arguments: arguments, patternType: patternType}
+}
+
+func (p *CasePatternVariant) AstPattern() *ast.PatternVariant {
+	return p.astPattern
+}
+
+func (p *CasePatternVariant) VariantReference() *dectype.CustomTypeVariantReference {
+	return p.variantReference
+}
+
+func (p *CasePatternVariant) Arguments() []CasePattern {
+	return p.arguments
+}
+
+func (p *CasePatternVariant) Type() dtype.Type {
+	return p.patternType
+}
+
+func (p *CasePatternVariant) FetchPositionLength() token.SourceFileReference {
+	return p.astPattern.FetchPositionLength()
+}
+
+func (p *CasePatternVariant) String() string {
+	return fmt.Sprintf("[pvariant %v %v]", p.variantReference.AstIdentifier().SomeTypeIdentifier().Name(), p.arguments)
+}
+
+type CasePatternTuple struct {
+	astPattern *ast.PatternTuple
+	tupleType  *dectype.TupleTypeAtom
+	patterns   []CasePattern
+}
+
+func NewCasePatternTuple(astPattern *ast.PatternTuple, tupleType *dectype.TupleTypeAtom, patterns []CasePattern) *CasePatternTuple {
+	return &CasePatternTuple{astPattern: astPattern, tupleType: tupleType, patterns: patterns}
+}
+
+func (p *CasePatternTuple) TupleType() *dectype.TupleTypeAtom {
+	return p.tupleType
+}
+
+func (p *CasePatternTuple) Patterns() []CasePattern {
+	return p.patterns
+}
+
+func (p *CasePatternTuple) Type() dtype.Type {
+	return p.tupleType
+}
+
+func (p *CasePatternTuple) FetchPositionLength() token.SourceFileReference {
+	return p.astPattern.FetchPositionLength()
+}
+
+func (p *CasePatternTuple) String() string {
+	return fmt.Sprintf("[ptuple %v]", p.patterns)
+}
+
+type CasePatternRecordField struct {
+	field     *dectype.RecordField
+	parameter *CaseConsequenceParameterForCustomType
+}
+
+func NewCasePatternRecordField(field *dectype.RecordField, parameter *CaseConsequenceParameterForCustomType) *CasePatternRecordField {
+	return &CasePatternRecordField{field: field, parameter: parameter}
+}
+
+func (p *CasePatternRecordField) Field() *dectype.RecordField {
+	return p.field
+}
+
+func (p *CasePatternRecordField) Parameter() *CaseConsequenceParameterForCustomType {
+	return p.parameter
+}
+
+func (p *CasePatternRecordField) String() string {
+	return p.parameter.Identifier().Name()
+}
+
+type CasePatternRecord struct {
+	astPattern *ast.PatternRecord
+	recordType *dectype.RecordAtom
+	fields     []*CasePatternRecordField
+}
+
+func NewCasePatternRecord(astPattern *ast.PatternRecord, recordType *dectype.RecordAtom, fields []*CasePatternRecordField) *CasePatternRecord {
+	return &CasePatternRecord{astPattern: astPattern, recordType: recordType, fields: fields}
+}
+
+func (p *CasePatternRecord) Fields() []*CasePatternRecordField {
+	return p.fields
+}
+
+func (p *CasePatternRecord) Type() dtype.Type {
+	return p.recordType
+}
+
+func (p *CasePatternRecord) FetchPositionLength() token.SourceFileReference {
+	return p.astPattern.FetchPositionLength()
+}
+
+func (p *CasePatternRecord) String() string {
+	return fmt.Sprintf("[precord %v]", p.fields)
+}
+
+type CasePatternAs struct {
+	astPattern *ast.PatternAs
+	pattern    CasePattern
+	parameter  *CaseConsequenceParameterForCustomType
+}
+
+func NewCasePatternAs(astPattern *ast.PatternAs, pattern CasePattern, parameter *CaseConsequenceParameterForCustomType) *CasePatternAs {
+	return &CasePatternAs{astPattern: astPattern, pattern: pattern, parameter: parameter}
+}
+
+func (p *CasePatternAs) AstPattern() *ast.PatternAs {
+	return p.astPattern
+}
+
+func (p *CasePatternAs) Pattern() CasePattern {
+	return p.pattern
+}
+
+func (p *CasePatternAs) Parameter() *CaseConsequenceParameterForCustomType {
+	return p.parameter
+}
+
+func (p *CasePatternAs) Type() dtype.Type {
+	return p.pattern.Type()
+}
+
+func (p *CasePatternAs) FetchPositionLength() token.SourceFileReference {
+	return p.astPattern.FetchPositionLength()
+}
+
+func (p *CasePatternAs) String() string {
+	return fmt.Sprintf("[pas %v %v]", p.pattern, p.parameter.Identifier().Name())
+}
@@ -17,13 +17,18 @@ import (
 
 type CaseConsequenceForPatternMatching struct {
 	literal        Expression
+	guard          Expression
 	expression     Expression
 	astConsequence *ast.CaseConsequencePatternMatching
 	internalIndex  int
 }
 
-func NewCaseConsequencePatternMatching(astConsequence *ast.CaseConsequencePatternMatching, internalIndex int, literal Expression, expression Expression) *CaseConsequenceForPatternMatching {
-	return &CaseConsequenceForPatternMatching{internalIndex: internalIndex, astConsequence: astConsequence, literal: literal, expression: expression}
+func NewCaseConsequencePatternMatching(astConsequence *ast.CaseConsequencePatternMatching, internalIndex int, literal Expression, guard Expression, expression Expression) *CaseConsequenceForPatternMatching {
+	return &CaseConsequenceForPatternMatching{internalIndex: internalIndex, astConsequence: astConsequence, literal: literal, guard: guard, expression: expression}
+}
+
+func (c *CaseConsequenceForPatternMatching) Guard() Expression {
+	return c.guard
 }
 
 func (c *CaseConsequenceForPatternMatching) Expression() Expression {
@@ -43,7 +48,7 @@ func (c *CaseConsequenceForPatternMatching) AstConsequence() *ast.CaseConsequenc
 }
 
 func (c *CaseConsequenceForPatternMatching) String() string {
-	return fmt.Sprintf("[PMCaseCons %v => %v]", c.literal, c.expression)
+	return fmt.Sprintf("[PMCaseCons %v%v => %v]", c.literal, caseGuardToString(c.guard), c.expression)
 }
 
 func caseConsequencePatternMatchingArrayToStringEx(expressions []*CaseConsequenceForPatternMatching, ch string) string {
@@ -0,0 +1,107 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package decorated
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/swamp/compiler/src/ast"
+	"github.com/swamp/compiler/src/decorated/decshared"
+	"github.com/swamp/compiler/src/decorated/dtype"
+	"github.com/swamp/compiler/src/token"
+)
+
+type CaseConsequenceForPatterns struct {
+	pattern        CasePattern
+	guard          Expression
+	expression     Expression
+	astConsequence *ast.CaseConsequenceForPatterns
+}
+
+func NewCaseConsequenceForPatterns(astConsequence *ast.CaseConsequenceForPatterns, pattern CasePattern, guard Expression, expression Expression) *CaseConsequenceForPatterns {
+	return &CaseConsequenceForPatterns{astConsequence: astConsequence, pattern: pattern, guard: guard, expression: expression}
+}
+
+// caseGuardToString returns the guard in the format used by the case consequences, or an empty string if there is no guard.
+func caseGuardToString(guard Expression) string {
+	if guard == nil {
+		return ""
+	}
+	return fmt.Sprintf(" | %v", guard)
+}
+
+func (c *CaseConsequenceForPatterns) Pattern() CasePattern {
+	return c.pattern
+}
+
+func (c *CaseConsequenceForPatterns) Guard() Expression {
+	return c.guard
+}
+
+func (c *CaseConsequenceForPatterns) Expression() Expression {
+	return c.expression
+}
+
+func (c *CaseConsequenceForPatterns) AstConsequence() *ast.CaseConsequenceForPatterns {
+	return c.astConsequence
+}
+
+func (c *CaseConsequenceForPatterns) String() string {
+	return fmt.Sprintf("[PCaseCons %v%v => %v]", c.pattern, caseGuardToString(c.guard), c.expression)
+}
+
+func caseConsequencePatternsArrayToStringEx(expressions []*CaseConsequenceForPatterns, ch string) string {
+	var out bytes.Buffer
+
+	for index, expression := range expressions {
+		if index > 0 {
+			out.WriteString(ch)
+		}
+		out.WriteString(expression.String())
+	}
+	return out.String()
+}
+
+// CaseForPatterns is a case with nested patterns. The consequences are tested in order and
+// the checker has made sure that together they are exhaustive.
+type CaseForPatterns struct {
+	test    Expression
+	cases   []*CaseConsequenceForPatterns
+	astCase *ast.CaseForPatterns
+}
+
+func NewCaseForPatterns(astCase *ast.CaseForPatterns, test Expression, cases []*CaseConsequenceForPatterns) (*CaseForPatterns, decshared.DecoratedError) {
+	return &CaseForPatterns{astCase: astCase, test: test, cases: cases}, nil
+}
+
+func (i *CaseForPatterns) Type() dtype.Type {
+	return i.cases[0].Expression().Type()
+}
+
+func (i *CaseForPatterns) AstCasePatterns() *ast.CaseForPatterns {
+	return i.astCase
+}
+
+func (i *CaseForPatterns) String() string {
+	return fmt.Sprintf("[PCase: %v of %v]", i.test, caseConsequencePatternsArrayToStringEx(i.cases, ";"))
+}
+
+func (i *CaseForPatterns) Test() Expression {
+	return i.test
+}
+
+func (i *CaseForPatterns) Consequences() []*CaseConsequenceForPatterns {
+	return i.cases
+}
+
+func (i *CaseForPatterns) DebugString() string {
+	return "[dpcase]"
+}
+
+func (i *CaseForPatterns) FetchPositionLength() token.SourceFileReference {
+	return i.astCase.FetchPositionLength()
+}
@@ -7,6 +7,7 @@ package decorated
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/swamp/compiler/src/ast"
 	"github.com/swamp/compiler/src/decorated/decshared"
@@ -970,11 +971,37 @@ type CircularDependencyDetected struct {
 }
 
 func NewCircularDependencyDetected(packageRelativeModuleName dectype.PackageRelativeModuleName, loadedModules []dectype.PackageRelativeModuleName, lastModule dectype.ArtifactFullyQualifiedModuleName) *CircularDependencyDetected {
-	return &CircularDependencyDetected{packageRelativeModuleName: packageRelativeModuleName, loadedModules: loadedModules, lastModule: lastModule}
+	// loadedModules is the stack of modules that are being read, and it changes after the error is created
+	copiedModules := append([]dectype.PackageRelativeModuleName(nil), loadedModules...)
+	return &CircularDependencyDetected{packageRelativeModuleName: packageRelativeModuleName, loadedModules: copiedModules, lastModule: lastModule}
+}
+
+// CyclePath returns the modules in the cycle, starting and ending with the module that was imported again.
+func (e *CircularDependencyDetected) CyclePath() []dectype.PackageRelativeModuleName {
+	start := 0
+	for index, loadedModule := range e.loadedModules {
+		if loadedModule.String() == e.packageRelativeModuleName.String() {
+			start = index
+			break
+		}
+	}
+
+	cycle := append([]dectype.PackageRelativeModuleName(nil), e.loadedModules[start:]...)
+
+	return append(cycle, e.packageRelativeModuleName)
 }
 
 func (e *CircularDependencyDetected) Error() string {
-	return fmt.Sprintf("Circular dependency %v %v %v", e.loadedModules, e.lastModule, e.packageRelativeModuleName.ModuleName.Path().FetchPositionLength().ToCompleteReferenceString())
+	var names []string
+	for _, module := range e.CyclePath() {
+		name := module.String()
+		if name == "" {
+			name = "Main"
+		}
+		names = append(names, name)
+	}
+
+	return fmt.Sprintf("Circular dependency %v (%v) %v", strings.Join(names, " -> "), e.lastModule, e.packageRelativeModuleName.ModuleName.Path().FetchPositionLength().ToCompleteReferenceString())
 }
 
 func (e *CircularDependencyDetected) FetchPositionLength() token.SourceFileReference {
@@ -1206,3 +1233,268 @@ func (e *UnknownTypeInCustomTypeVariant) Error() string {
 func (e *UnknownTypeInCustomTypeVariant) FetchPositionLength() token.SourceFileReference {
 	return e.variant.TypeIdentifier().Symbol().SourceFileReference
 }
+
+type LambdaNeedsExpectedFunctionType struct {
+	lambda   *ast.Lambda
+	expected dtype.Type
+}
+
+func NewLambdaNeedsExpectedFunctionType(lambda *ast.Lambda, expected dtype.Type) *LambdaNeedsExpectedFunctionType {
+	return &LambdaNeedsExpectedFunctionType{lambda: lambda, expected: expected}
+}
+
+func (e *LambdaNeedsExpectedFunctionType) Error() string {
+	if e.expected == nil {
+		return "a lambda can only be used as an argument where a function is expected"
+	}
+	return fmt.Sprintf("a lambda was used where %v was expected", e.expected.HumanReadable())
+}
+
+func (e *LambdaNeedsExpectedFunctionType) FetchPositionLength() token.SourceFileReference {
+	return e.lambda.FetchPositionLength()
+}
+
+type WrongNumberOfLambdaParameters struct {
+	lambda   *ast.Lambda
+	expected *dectype.FunctionAtom
+}
+
+func NewWrongNumberOfLambdaParameters(lambda *ast.Lambda, expected *dectype.FunctionAtom) *WrongNumberOfLambdaParameters {
+	return &WrongNumberOfLambdaParameters{lambda: lambda, expected: expected}
+}
+
+func (e *WrongNumberOfLambdaParameters) Error() string {
+	return fmt.Sprintf("lambda has %d parameters, but %v was expected", len(e.lambda.Parameters()), e.expected.HumanReadable())
+}
+
+func (e *WrongNumberOfLambdaParameters) FetchPositionLength() token.SourceFileReference {
+	return e.lambda.FetchPositionLength()
+}
+
+type CouldNotInferLambdaParameterType struct {
+	parameter *ast.FunctionParameter
+	err       error
+}
+
+func NewCouldNotInferLambdaParameterType(parameter *ast.FunctionParameter, err error) *CouldNotInferLambdaParameterType {
+	return &CouldNotInferLambdaParameterType{parameter: parameter, err: err}
+}
+
+func (e *CouldNotInferLambdaParameterType) Error() string {
+	return fmt.Sprintf("could not infer the type of lambda parameter '%v' %v", e.parameter.Identifier().Name(), e.err)
+}
+
+func (e *CouldNotInferLambdaParameterType) FetchPositionLength() token.SourceFileReference {
+	return e.parameter.FetchPositionLength()
+}
+
+type CouldNotInstantiateLetFunction struct {
+	reference *LetVariableReference
+}
+
+func NewCouldNotInstantiateLetFunction(reference *LetVariableReference) *CouldNotInstantiateLetFunction {
+	return &CouldNotInstantiateLetFunction{reference: reference}
+}
+
+func (e *CouldNotInstantiateLetFunction) Error() string {
+	return fmt.Sprintf("generic let function '%v' must be called or passed as an argument where its parameter types are known", e.reference.LetVariable().Name().Name())
+}
+
+func (e *CouldNotInstantiateLetFunction) FetchPositionLength() token.SourceFileReference {
+	return e.reference.FetchPositionLength()
+}
+
+type CasePatternTypeMismatch struct {
+	pattern      ast.CasePattern
+	expectedType dtype.Type
+}
+
+func NewCasePatternTypeMismatch(pattern ast.CasePattern, expectedType dtype.Type) *CasePatternTypeMismatch {
+	return &CasePatternTypeMismatch{pattern: pattern, expectedType: expectedType}
+}
+
+func (e *CasePatternTypeMismatch) Error() string {
+	return fmt.Sprintf("pattern %v can not match a value of type %v", e.pattern, e.expectedType.HumanReadable())
+}
+
+func (e *CasePatternTypeMismatch) FetchPositionLength() token.SourceFileReference {
+	return e.pattern.FetchPositionLength()
+}
+
+type CasePatternLiteralNotSupported struct {
+	literal      ast.Expression
+	expectedType dtype.Type
+}
+
+func NewCasePatternLiteralNotSupported(literal ast.Expression, expectedType dtype.Type) *CasePatternLiteralNotSupported {
+	return &CasePatternLiteralNotSupported{literal: literal, expectedType: expectedType}
+}
+
+func (e *CasePatternLiteralNotSupported) Error() string {
+	return fmt.Sprintf("literal patterns can only match Bool, Int, Char and String, not %v. Use a guard instead", e.expectedType.HumanReadable())
+}
+
+func (e *CasePatternLiteralNotSupported) FetchPositionLength() token.SourceFileReference {
+	return e.literal.FetchPositionLength()
+}
+
+type CasePatternUnknownVariant struct {
+	pattern    *ast.PatternVariant
+	customType *dectype.CustomTypeAtom
+}
+
+func NewCasePatternUnknownVariant(pattern *ast.PatternVariant, customType *dectype.CustomTypeAtom) *CasePatternUnknownVariant {
+	return &CasePatternUnknownVariant{pattern: pattern, customType: customType}
+}
+
+func (e *CasePatternUnknownVariant) Error() string {
+	return fmt.Sprintf("couldn't find variant %v in custom type %v", e.pattern.Identifier().Name(), e.customType.HumanReadable())
+}
+
+func (e *CasePatternUnknownVariant) FetchPositionLength() token.SourceFileReference {
+	return e.pattern.Identifier().Symbol().SourceFileReference
+}
+
+type CasePatternWrongArgumentCount struct {
+	pattern *ast.PatternVariant
+	variant *dectype.CustomTypeVariantAtom
+}
+
+func NewCasePatternWrongArgumentCount(pattern *ast.PatternVariant, variant *dectype.CustomTypeVariantAtom) *CasePatternWrongArgumentCount {
+	return &CasePatternWrongArgumentCount{pattern: pattern, variant: variant}
+}
+
+func (e *CasePatternWrongArgumentCount) Error() string {
+	return fmt.Sprintf("variant %v has %d parameters, but the pattern has %d", e.variant.Name().Name(), e.variant.ParameterCount(), len(e.pattern.Arguments()))
+}
+
+func (e *CasePatternWrongArgumentCount) FetchPositionLength() token.SourceFileReference {
+	return e.pattern.FetchPositionLength()
+}
+
+type CasePatternUnknownRecordField struct {
+	field      *ast.VariableIdentifier
+	recordType *dectype.RecordAtom
+}
+
+func NewCasePatternUnknownRecordField(field *ast.VariableIdentifier, recordType *dectype.RecordAtom) *CasePatternUnknownRecordField {
+	return &CasePatternUnknownRecordField{field: field, recordType: recordType}
+}
+
+func (e *CasePatternUnknownRecordField) Error() string {
+	return fmt.Sprintf("record %v has no field '%v'", e.recordType.HumanReadable(), e.field.Name())
+}
+
+func (e *CasePatternUnknownRecordField) FetchPositionLength() token.SourceFileReference {
+	return e.field.FetchPositionLength()
+}
+
+type CasePatternDuplicateBinding struct {
+	identifier *ast.VariableIdentifier
+}
+
+func NewCasePatternDuplicateBinding(identifier *ast.VariableIdentifier) *CasePatternDuplicateBinding {
+	return &CasePatternDuplicateBinding{identifier: identifier}
+}
+
+func (e *CasePatternDuplicateBinding) Error() string {
+	return fmt.Sprintf("'%v' is bound more than once in the same pattern", e.identifier.Name())
+}
+
+func (e *CasePatternDuplicateBinding) FetchPositionLength() token.SourceFileReference {
+	return e.identifier.FetchPositionLength()
+}
+
+type NonExhaustiveCasePatterns struct {
+	keywordCase    token.Keyword
+	missingPattern string
+}
+
+func NewNonExhaustiveCasePatterns(keywordCase token.Keyword, missingPattern string) *NonExhaustiveCasePatterns {
+	return &NonExhaustiveCasePatterns{keywordCase: keywordCase, missingPattern: missingPattern}
+}
+
+func (e *NonExhaustiveCasePatterns) MissingPattern() string {
+	return e.missingPattern
+}
+
+func (e *NonExhaustiveCasePatterns) Error() string {
+	return fmt.Sprintf("case is not exhaustive, for example '%v' is not matched", e.missingPattern)
+}
+
+func (e *NonExhaustiveCasePatterns) FetchPositionLength() token.SourceFileReference {
+	return e.keywordCase.FetchPositionLength()
+}
+
+type CaseGuardMustHaveBooleanType struct {
+	guard Expression
+}
+
+func NewCaseGuardMustHaveBooleanType(guard Expression) *CaseGuardMustHaveBooleanType {
+	return &CaseGuardMustHaveBooleanType{guard: guard}
+}
+
+func (e *CaseGuardMustHaveBooleanType) Error() string {
+	return fmt.Sprintf("case guard must have Bool type, but has %v", e.guard.Type().HumanReadable())
+}
+
+func (e *CaseGuardMustHaveBooleanType) FetchPositionLength() token.SourceFileReference {
+	return e.guard.FetchPositionLength()
+}
+
+type OperatorNeedsTypeConstraint struct {
+	operator   *ast.BinaryOperator
+	operand    Expression
+	constraint dectype.TypeConstraint
+}
+
+func NewOperatorNeedsTypeConstraint(operator *ast.BinaryOperator, operand Expression, constraint dectype.TypeConstraint) *OperatorNeedsTypeConstraint {
+	return &OperatorNeedsTypeConstraint{operator: operator, operand: operand, constraint: constraint}
+}
+
+func (e *OperatorNeedsTypeConstraint) Error() string {
+	return fmt.Sprintf("operator %v needs a %v type, but type parameter %v is not constrained to that. Name it '%v' instead",
+		e.operator.OperatorToken().Raw(), e.constraint, e.operand.Type().HumanReadable(), e.constraint)
+}
+
+func (e *OperatorNeedsTypeConstraint) FetchPositionLength() token.SourceFileReference {
+	return e.operand.FetchPositionLength()
+}
+
+type CompareFunctionNotFound struct {
+	operator     *ast.BinaryOperator
+	operand      Expression
+	functionName string
+}
+
+func NewCompareFunctionNotFound(operator *ast.BinaryOperator, operand Expression, functionName string) *CompareFunctionNotFound {
+	return &CompareFunctionNotFound{operator: operator, operand: operand, functionName: functionName}
+}
+
+func (e *CompareFunctionNotFound) Error() string {
+	return fmt.Sprintf("operator %v on %v is done by calling %v, but it could not be found",
+		e.operator.OperatorToken().Raw(), e.operand.Type().HumanReadable(), e.functionName)
+}
+
+func (e *CompareFunctionNotFound) FetchPositionLength() token.SourceFileReference {
+	return e.operator.FetchPositionLength()
+}
+
+type StackFrameBudgetExceeded struct {
+	reference    token.SourceFileReference
+	functionName string
+	octets       uint
+	budget       uint
+}
+
+func NewStackFrameBudgetExceeded(reference token.SourceFileReference, functionName string, octets uint, budget uint) *StackFrameBudgetExceeded {
+	return &StackFrameBudgetExceeded{reference: reference, functionName: functionName, octets: octets, budget: budget}
+}
+
+func (e *StackFrameBudgetExceeded) Error() string {
+	return fmt.Sprintf("the stack frame of '%v' is %d octets, which is over the budget of %d octets", e.functionName, e.octets, e.budget)
+}
+
+func (e *StackFrameBudgetExceeded) FetchPositionLength() token.SourceFileReference {
+	return e.reference
+}
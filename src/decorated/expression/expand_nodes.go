@@ -74,6 +74,14 @@ func expandChildNodesFunctionCall(fn *FunctionCall) []TypeOrToken {
 	return tokens
 }
 
+func expandChildNodesRecurCall(call *RecurCall) []TypeOrToken {
+	var tokens []TypeOrToken
+	for _, argument := range call.Arguments() {
+		tokens = append(tokens, expandChildNodes(argument)...)
+	}
+	return tokens
+}
+
 func expandChildNodesCurryFunction(fn *CurryFunction) []TypeOrToken {
 	var tokens []TypeOrToken
 	tokens = append(tokens, expandChildNodes(fn.FunctionValue())...)
@@ -289,6 +297,15 @@ func expandChildNodesRecordConstructorRecord(constructor *RecordConstructorFromR
 	return tokens
 }
 
+func expandChildNodesLambda(lambda *Lambda) []TypeOrToken {
+	var tokens []TypeOrToken
+	for _, parameter := range lambda.Parameters() {
+		tokens = append(tokens, parameter)
+	}
+	tokens = append(tokens, expandChildNodes(lambda.Expression())...)
+	return tokens
+}
+
 func expandChildNodesGuard(guard *Guard) []TypeOrToken {
 	var tokens []TypeOrToken
 	for _, item := range guard.Items() {
@@ -327,6 +344,9 @@ func expandChildNodesCaseForCustomType(caseForCustomType *CaseCustomType) []Type
 		for _, param := range consequence.Parameters() {
 			tokens = append(tokens, expandChildNodes(param)...)
 		}
+		if consequence.Guard() != nil {
+			tokens = append(tokens, expandChildNodes(consequence.Guard())...)
+		}
 		tokens = append(tokens, expandChildNodes(consequence.Expression())...)
 	}
 
@@ -344,6 +364,9 @@ func expandChildNodesCaseForPatternMatching(caseForCustomType *CaseForPatternMat
 
 	for _, consequence := range caseForCustomType.Consequences() {
 		tokens = append(tokens, expandChildNodes(consequence.Literal())...)
+		if consequence.Guard() != nil {
+			tokens = append(tokens, expandChildNodes(consequence.Guard())...)
+		}
 		tokens = append(tokens, expandChildNodes(consequence.Expression())...)
 	}
 
@@ -352,6 +375,51 @@ func expandChildNodesCaseForPatternMatching(caseForCustomType *CaseForPatternMat
 	return tokens
 }
 
+func expandChildNodesCasePattern(pattern CasePattern) []TypeOrToken {
+	var tokens []TypeOrToken
+
+	switch t := pattern.(type) {
+	case *CasePatternBinding:
+		tokens = append(tokens, expandChildNodes(t.Parameter())...)
+	case *CasePatternLiteral:
+		tokens = append(tokens, expandChildNodes(t.Literal())...)
+	case *CasePatternVariant:
+		tokens = append(tokens, expandChildNodes(t.VariantReference())...)
+		for _, argument := range t.Arguments() {
+			tokens = append(tokens, expandChildNodesCasePattern(argument)...)
+		}
+	case *CasePatternTuple:
+		for _, subPattern := range t.Patterns() {
+			tokens = append(tokens, expandChildNodesCasePattern(subPattern)...)
+		}
+	case *CasePatternRecord:
+		for _, field := range t.Fields() {
+			tokens = append(tokens, expandChildNodes(field.Parameter())...)
+		}
+	case *CasePatternAs:
+		tokens = append(tokens, expandChildNodesCasePattern(t.Pattern())...)
+		tokens = append(tokens, expandChildNodes(t.Parameter())...)
+	}
+
+	return tokens
+}
+
+func expandChildNodesCaseForPatterns(caseForPatterns *CaseForPatterns) []TypeOrToken {
+	var tokens []TypeOrToken
+
+	tokens = append(tokens, expandChildNodes(caseForPatterns.Test())...)
+
+	for _, consequence := range caseForPatterns.Consequences() {
+		tokens = append(tokens, expandChildNodesCasePattern(consequence.Pattern())...)
+		if consequence.Guard() != nil {
+			tokens = append(tokens, expandChildNodes(consequence.Guard())...)
+		}
+		tokens = append(tokens, expandChildNodes(consequence.Expression())...)
+	}
+
+	return tokens
+}
+
 func expandChildNodesBinaryOperator(namedFunctionValue *BinaryOperator) []TypeOrToken {
 	var tokens []TypeOrToken
 	tokens = append(tokens, expandChildNodes(namedFunctionValue.Left())...)
@@ -414,8 +482,12 @@ func expandChildNodes(node Node) []TypeOrToken {
 		return append(tokens, expandChildNodesFunctionReference(t)...)
 	case *FunctionCall:
 		return append(tokens, expandChildNodesFunctionCall(t)...)
+	case *RecurCall:
+		return append(tokens, expandChildNodesRecurCall(t)...)
 	case *CurryFunction:
 		return append(tokens, expandChildNodesCurryFunction(t)...)
+	case *Lambda:
+		return append(tokens, expandChildNodesLambda(t)...)
 	case *Let:
 		return append(tokens, expandChildNodesLet(t)...)
 	case *If:
@@ -446,6 +518,8 @@ func expandChildNodes(node Node) []TypeOrToken {
 		return append(tokens, expandChildNodesCaseForCustomType(t)...)
 	case *CaseForPatternMatching:
 		return append(tokens, expandChildNodesCaseForPatternMatching(t)...)
+	case *CaseForPatterns:
+		return append(tokens, expandChildNodesCaseForPatterns(t)...)
 	case *PipeRightOperator:
 		return expandChildNodes(&t.BinaryOperator)
 	case *PipeLeftOperator:
@@ -34,12 +34,22 @@ type FunctionCall struct {
 	assignments             []Expression
 	smashedFunctionType     *dectype.FunctionAtom
 	astFunctionCall         *ast.FunctionCall
+	specialization          *FunctionSpecialization
 }
 
 func NewFunctionCall(astFunctionCall *ast.FunctionCall, functionValueExpression Expression, smashedFunctionType *dectype.FunctionAtom, assignments []Expression) *FunctionCall {
 	return &FunctionCall{astFunctionCall: astFunctionCall, functionValueExpression: functionValueExpression, assignments: assignments, smashedFunctionType: smashedFunctionType}
 }
 
+// SetSpecialization is used when the call is to a generic function, that has been specialized for the argument types.
+func (c *FunctionCall) SetSpecialization(specialization *FunctionSpecialization) {
+	c.specialization = specialization
+}
+
+func (c *FunctionCall) Specialization() *FunctionSpecialization {
+	return c.specialization
+}
+
 func (c *FunctionCall) AstFunctionCall() *ast.FunctionCall {
 	return c.astFunctionCall
 }
@@ -0,0 +1,130 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package decorated
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/swamp/compiler/src/decorated/dtype"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+)
+
+// FunctionSpecialization is a generic function that has been decorated again, with the concrete types
+// of a function call. The code generator generates one function for each specialization.
+type FunctionSpecialization struct {
+	suffix        string
+	functionValue *FunctionValue
+}
+
+func qualifiedTypeNames(types []dtype.Type) []string {
+	var names []string
+	for _, t := range types {
+		names = append(names, qualifiedTypeName(t))
+	}
+
+	return names
+}
+
+func withTypeArguments(name string, arguments []dtype.Type) string {
+	if len(arguments) == 0 {
+		return name
+	}
+
+	return name + "(" + strings.Join(qualifiedTypeNames(arguments), ",") + ")"
+}
+
+// qualifiedTypeName returns the name of the type, where aliases and custom types are named with their fully qualified
+// names, so that types with the same name in different modules get different names.
+func qualifiedTypeName(t dtype.Type) string {
+	switch info := dectype.UnReference(t).(type) {
+	case *dectype.Alias:
+		return info.ArtifactTypeName().String()
+	case *dectype.CustomTypeAtom:
+		return withTypeArguments(info.ArtifactTypeName().String(), info.Parameters())
+	case *dectype.PrimitiveAtom:
+		return withTypeArguments(info.PrimitiveName().Name(), info.GenericTypes())
+	case *dectype.TupleTypeAtom:
+		return withTypeArguments("Tuple", info.ParameterTypes())
+	case *dectype.FunctionAtom:
+		return withTypeArguments("Function", info.FunctionParameterTypes())
+	case *dectype.RecordAtom:
+		var fields []string
+		for _, field := range info.SortedFields() {
+			fields = append(fields, field.Name()+":"+qualifiedTypeName(field.Type()))
+		}
+		return "Record(" + strings.Join(fields, ",") + ")"
+	case *dectype.InvokerType:
+		if resolved := dectype.UnaliasWithResolveInvoker(info); resolved != dtype.Type(info) {
+			return qualifiedTypeName(resolved)
+		}
+	}
+
+	return t.HumanReadable()
+}
+
+// SpecializationSuffix returns the suffix that is added to the generic function name, e.g. `__Int_Int`. The types are
+// named with their fully qualified names.
+func SpecializationSuffix(parameterTypes []dtype.Type) string {
+	var parts []string
+	for _, parameterType := range parameterTypes {
+		part := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return '_'
+		}, qualifiedTypeName(parameterType))
+		parts = append(parts, part)
+	}
+
+	return "__" + strings.Join(parts, "_")
+}
+
+func (s *FunctionSpecialization) Suffix() string {
+	return s.suffix
+}
+
+func (s *FunctionSpecialization) FunctionValue() *FunctionValue {
+	return s.functionValue
+}
+
+func (s *FunctionSpecialization) String() string {
+	return fmt.Sprintf("[FunctionSpecialization %v %v]", s.suffix, s.functionValue)
+}
+
+// IsGeneric returns true if the function has type parameters.
+func (f *FunctionValue) IsGeneric() bool {
+	return !f.IsSomeKindOfExternal() && dectype.TypeIsTemplateHasLocalTypes(f.forcedFunctionType)
+}
+
+// NeedsSpecialization returns true if the function has type parameters with constraints. The operators in the body
+// depend on the types, so the function must be specialized for each call before it can be generated.
+func (f *FunctionValue) NeedsSpecialization() bool {
+	return f.IsGeneric() && dectype.TypeHasConstraints(f.forcedFunctionType)
+}
+
+func (f *FunctionValue) AddSpecialization(suffix string, functionValue *FunctionValue) *FunctionSpecialization {
+	specialization := &FunctionSpecialization{suffix: suffix, functionValue: functionValue}
+	f.specializations = append(f.specializations, specialization)
+
+	return specialization
+}
+
+func (f *FunctionValue) FindSpecialization(suffix string) *FunctionSpecialization {
+	for _, specialization := range f.specializations {
+		if specialization.suffix == suffix {
+			return specialization
+		}
+	}
+
+	return nil
+}
+
+// Specializations returns all specializations of the generic function, in the order they were encountered.
+func (f *FunctionValue) Specializations() []*FunctionSpecialization {
+	return f.specializations
+}
@@ -64,6 +64,8 @@ type FunctionValue struct {
 	astFunction         *ast.FunctionValue
 	sourceFileReference token.SourceFileReference
 	references          []*FunctionReference
+	lambdas             []*Lambda
+	specializations     []*FunctionSpecialization
 }
 
 func NewPrepareFunctionValue(astFunction *ast.FunctionValue, forcedFunctionType dectype.FunctionTypeLike, parameters []*FunctionParameterDefinition, commentBlock *ast.MultilineComment) *FunctionValue {
@@ -142,3 +144,17 @@ func (f *FunctionValue) AddReferee(ref *FunctionReference) {
 func (f *FunctionValue) References() []*FunctionReference {
 	return f.references
 }
+
+func (f *FunctionValue) AddLambda(lambda *Lambda) {
+	f.lambdas = append(f.lambdas, lambda)
+}
+
+// TruncateLambdas removes the lambdas that were added after the first count lambdas.
+func (f *FunctionValue) TruncateLambdas(count int) {
+	f.lambdas = f.lambdas[:count]
+}
+
+// Lambdas returns all lambdas, including nested ones, that are defined within the function.
+func (f *FunctionValue) Lambdas() []*Lambda {
+	return f.lambdas
+}
@@ -0,0 +1,142 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package decorated
+
+import (
+	"fmt"
+
+	"github.com/swamp/compiler/src/ast"
+	"github.com/swamp/compiler/src/decorated/dtype"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+	"github.com/swamp/compiler/src/token"
+)
+
+// LambdaCapture is a local variable from an enclosing scope that is referenced inside the lambda body.
+type LambdaCapture struct {
+	name       *ast.VariableIdentifier
+	definition *NamedDecoratedExpression
+}
+
+func (c *LambdaCapture) Name() *ast.VariableIdentifier {
+	return c.name
+}
+
+func (c *LambdaCapture) Type() dtype.Type {
+	return c.definition.Expression().Type()
+}
+
+func (c *LambdaCapture) String() string {
+	return fmt.Sprintf("[Capture %v]", c.name.Name())
+}
+
+type Lambda struct {
+	astLambda    *ast.Lambda
+	parameters   []*FunctionParameterDefinition
+	captures     []*LambdaCapture
+	expression   Expression
+	functionType *dectype.FunctionAtom
+	index        int
+}
+
+func NewPrepareLambda(astLambda *ast.Lambda, parameters []*FunctionParameterDefinition, index int) *Lambda {
+	return &Lambda{astLambda: astLambda, parameters: parameters, index: index}
+}
+
+func (l *Lambda) DefineExpression(expression Expression) {
+	var types []dtype.Type
+	for _, parameter := range l.parameters {
+		types = append(types, parameter.Type())
+	}
+	types = append(types, expression.Type())
+
+	l.expression = expression
+	l.functionType = dectype.NewFunctionAtom(nil, types)
+}
+
+func (l *Lambda) AddCapture(name *ast.VariableIdentifier, definition *NamedDecoratedExpression) {
+	for _, capture := range l.captures {
+		if capture.name.Name() == name.Name() {
+			return
+		}
+	}
+	l.captures = append(l.captures, &LambdaCapture{name: name, definition: definition})
+}
+
+func (l *Lambda) AstLambda() *ast.Lambda {
+	return l.astLambda
+}
+
+func (l *Lambda) Parameters() []*FunctionParameterDefinition {
+	return l.parameters
+}
+
+func (l *Lambda) Captures() []*LambdaCapture {
+	return l.captures
+}
+
+// Index is the order in which the lambda was encountered in the enclosing function.
+func (l *Lambda) Index() int {
+	return l.index
+}
+
+func (l *Lambda) Expression() Expression {
+	return l.expression
+}
+
+func (l *Lambda) Type() dtype.Type {
+	return l.functionType
+}
+
+// IsGeneric returns true if the types of the parameters have type parameters.
+func (l *Lambda) IsGeneric() bool {
+	for _, parameter := range l.parameters {
+		if dectype.TypeIsTemplateHasLocalTypes(parameter.Type()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *Lambda) FunctionType() *dectype.FunctionAtom {
+	return l.functionType
+}
+
+// LiftedFunctionType is the type of the generated function, where the captured values
+// are passed as the first arguments.
+func (l *Lambda) LiftedFunctionType() *dectype.FunctionAtom {
+	var types []dtype.Type
+	for _, capture := range l.captures {
+		types = append(types, capture.Type())
+	}
+	types = append(types, l.functionType.FunctionParameterTypes()...)
+
+	return dectype.NewFunctionAtom(nil, types)
+}
+
+// LiftedParameters are the parameters of the generated function. The captured values come first,
+// followed by the lambda parameters.
+func (l *Lambda) LiftedParameters() []*FunctionParameterDefinition {
+	var parameters []*FunctionParameterDefinition
+	for _, capture := range l.captures {
+		astParameter := ast.NewFunctionParameter(capture.name, nil)
+		parameters = append(parameters, NewFunctionParameterDefinition(astParameter, capture.Type()))
+	}
+
+	return append(parameters, l.parameters...)
+}
+
+func (l *Lambda) String() string {
+	return fmt.Sprintf("[Lambda %v %v => %v]", l.captures, l.parameters, l.expression)
+}
+
+func (l *Lambda) HumanReadable() string {
+	return "Lambda"
+}
+
+func (l *Lambda) FetchPositionLength() token.SourceFileReference {
+	return l.astLambda.FetchPositionLength()
+}
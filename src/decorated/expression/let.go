@@ -14,10 +14,12 @@ import (
 )
 
 type LetVariable struct {
-	name         *ast.VariableIdentifier
-	variableType dtype.Type
-	references   []*LetVariableReference
-	comment      *ast.MultilineComment
+	name               *ast.VariableIdentifier
+	variableType       dtype.Type
+	references         []*LetVariableReference
+	comment            *ast.MultilineComment
+	genericFunction    *Lambda
+	concreteReferences []*LetVariableReference
 }
 
 func (l *LetVariable) String() string {
@@ -52,6 +54,26 @@ func (l *LetVariable) References() []*LetVariableReference {
 	return l.references
 }
 
+// SetGenericFunction marks the let variable as bound to a lambda with type parameters. The lambda is not generated,
+// instead each reference is instantiated with the concrete types it is used with.
+func (l *LetVariable) SetGenericFunction(lambda *Lambda) {
+	l.genericFunction = lambda
+}
+
+func (l *LetVariable) GenericFunction() *Lambda {
+	return l.genericFunction
+}
+
+// AddConcreteReference adds a reference to the generic function that is not inside another generic function, so it
+// must be instantiated.
+func (l *LetVariable) AddConcreteReference(ref *LetVariableReference) {
+	l.concreteReferences = append(l.concreteReferences, ref)
+}
+
+func (l *LetVariable) ConcreteReferences() []*LetVariableReference {
+	return l.concreteReferences
+}
+
 func (l *LetVariable) Comment() *ast.MultilineComment {
 	return l.comment
 }
@@ -86,6 +108,10 @@ func (l *LetAssignment) LetVariables() []*LetVariable {
 	return l.letVariables
 }
 
+func (l *LetAssignment) AstLetAssignment() ast.LetAssignment {
+	return l.astLetAssignment
+}
+
 func (l *LetAssignment) WasRecordDestructuring() bool {
 	return l.astLetAssignment.WasRecordDestructuring()
 }
@@ -16,13 +16,20 @@ import (
 type LetVariableReference struct {
 	ident      ast.ScopedOrNormalVariableIdentifier
 	assignment *LetVariable
+	instance   *Lambda
 }
 
 func (g *LetVariableReference) Type() dtype.Type {
+	if g.instance != nil {
+		return g.instance.Type()
+	}
 	return g.assignment.Type()
 }
 
 func (g *LetVariableReference) String() string {
+	if g.instance != nil {
+		return fmt.Sprintf("[LetVarRef %v %v]", g.assignment, g.instance)
+	}
 	return fmt.Sprintf("[LetVarRef %v]", g.assignment)
 }
 
@@ -34,6 +41,22 @@ func (g *LetVariableReference) LetVariable() *LetVariable {
 	return g.assignment
 }
 
+func (g *LetVariableReference) Identifier() ast.ScopedOrNormalVariableIdentifier {
+	return g.ident
+}
+
+// SetInstance sets the lambda of a generic let function that has been decorated again with the concrete types of
+// this reference.
+func (g *LetVariableReference) SetInstance(instance *Lambda) {
+	g.instance = instance
+}
+
+// Instance returns the instantiated lambda if the reference is to a generic let function, otherwise nil. The code
+// generator generates the instance instead of reading the let variable.
+func (g *LetVariableReference) Instance() *Lambda {
+	return g.instance
+}
+
 func NewLetVariableReference(ident ast.ScopedOrNormalVariableIdentifier, assignment *LetVariable) *LetVariableReference {
 	if assignment == nil {
 		panic("cant be nil")
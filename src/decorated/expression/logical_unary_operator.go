@@ -36,6 +36,10 @@ func (a *LogicalUnaryOperator) OperatorType() LogicalUnaryOperatorType {
 	return a.operatorType
 }
 
+func (a *LogicalUnaryOperator) AstUnaryExpression() *ast.UnaryExpression {
+	return a.unary
+}
+
 func (a *LogicalUnaryOperator) Left() Expression {
 	return a.left
 }
@@ -96,14 +96,16 @@ type Module struct {
 	nodes                    []TypeOrToken
 	references               []*ModuleReference
 	moduleType               ModuleType
+	dataLayout               dectype.DataLayout
 }
 
-func NewModule(moduleType ModuleType, fullyQualifiedModuleName dectype.ArtifactFullyQualifiedModuleName, sourceFileUri *token.SourceFileDocument) *Module {
+func NewModule(moduleType ModuleType, fullyQualifiedModuleName dectype.ArtifactFullyQualifiedModuleName, sourceFileUri *token.SourceFileDocument, dataLayout dectype.DataLayout) *Module {
 	m := &Module{
 		fullyQualifiedModuleName: fullyQualifiedModuleName,
 		sourceFileUri:            sourceFileUri,
 		moduleType:               moduleType,
 		importedModules:          NewModuleImports(),
+		dataLayout:               dataLayout,
 	}
 
 	m.exposedTypes = NewExposedTypes(m)
@@ -126,6 +128,11 @@ func (m *Module) ModuleType() ModuleType {
 	return m.moduleType
 }
 
+// DataLayout is the layout of the target that the memory size of the types in the module are calculated for.
+func (m *Module) DataLayout() dectype.DataLayout {
+	return m.dataLayout
+}
+
 func (m *Module) AddReference(ref *ModuleReference) {
 	m.references = append(m.references, ref)
 }
@@ -86,3 +86,60 @@ func (e *UnusedImportStatementWarning) Warning() string {
 func (e *UnusedImportStatementWarning) FetchPositionLength() token.SourceFileReference {
 	return e.definition.FetchPositionLength()
 }
+
+type RedundantCasePatternWarning struct {
+	pattern token.SourceFileReference
+}
+
+func NewRedundantCasePatternWarning(pattern token.SourceFileReference) *RedundantCasePatternWarning {
+	return &RedundantCasePatternWarning{pattern: pattern}
+}
+
+func (e *RedundantCasePatternWarning) Error() string {
+	return "this pattern can never match, all values are already handled by the consequences above"
+}
+
+func (e *RedundantCasePatternWarning) FetchPositionLength() token.SourceFileReference {
+	return e.pattern
+}
+
+type MissingResourceFileWarning struct {
+	resourceName   *ResourceNameLiteral
+	assetDirectory string
+}
+
+func NewMissingResourceFileWarning(resourceName *ResourceNameLiteral, assetDirectory string) *MissingResourceFileWarning {
+	return &MissingResourceFileWarning{resourceName: resourceName, assetDirectory: assetDirectory}
+}
+
+func (e *MissingResourceFileWarning) Error() string {
+	return fmt.Sprintf("resource name '@%v' has no matching file in '%v'", e.resourceName.Value(), e.assetDirectory)
+}
+
+func (e *MissingResourceFileWarning) FetchPositionLength() token.SourceFileReference {
+	return e.resourceName.FetchPositionLength()
+}
+
+// CachedWarning is a warning or note that is reported again when the output of a package is reused from the build
+// cache. Only the message and the position are kept from the original diagnostic.
+type CachedWarning struct {
+	message   string
+	isNote    bool
+	reference token.SourceFileReference
+}
+
+func NewCachedWarning(reference token.SourceFileReference, message string, isNote bool) *CachedWarning {
+	return &CachedWarning{reference: reference, message: message, isNote: isNote}
+}
+
+func (e *CachedWarning) IsNote() bool {
+	return e.isNote
+}
+
+func (e *CachedWarning) Error() string {
+	return e.message
+}
+
+func (e *CachedWarning) FetchPositionLength() token.SourceFileReference {
+	return e.reference
+}
@@ -20,6 +20,15 @@ func ReplaceTypeFromContext(originalTarget dtype.Type, lookup Lookup) (dtype.Typ
 	target := Unalias(originalTarget)
 	switch info := originalTarget.(type) {
 	case *LocalType:
+		if info.isInference {
+			// Inference variables are only replaced when they are generalized
+			if inferred := info.Inferred(); inferred != nil {
+				return ReplaceTypeFromContext(inferred, lookup)
+			}
+			if _, isGeneralizing := lookup.(*Generalizer); !isGeneralizing {
+				return info, nil
+			}
+		}
 		newType, newTypeErr := lookup.LookupType(info.identifier.Name())
 		if newTypeErr != nil {
 			return nil, newTypeErr
@@ -59,6 +68,8 @@ func ReplaceTypeFromContext(originalTarget dtype.Type, lookup Lookup) (dtype.Typ
 		return originalTarget, nil
 	case *FunctionTypeReference:
 		return originalTarget, nil
+	case *FunctionAtom:
+		return replaceFunctionFromContext(info, lookup)
 	default:
 		log.Printf("warning: not sure what to do with %T %v. Returning same type for now", originalTarget, originalTarget)
 		return nil, fmt.Errorf("not sure what to do with %T %v. Returning same type for now", target, target)
@@ -94,7 +105,22 @@ func replaceRecordFromContext(record *RecordAtom, lookup Lookup) (*RecordAtom, e
 		genericTypes = record.genericTypes
 	}
 
-	return NewRecordType(record.AstRecord(), replacedFields, genericTypes), nil
+	return NewRecordType(record.DataLayout(), record.AstRecord(), replacedFields, genericTypes), nil
+}
+
+func replaceFunctionFromContext(functionAtom *FunctionAtom, lookup Lookup) (*FunctionAtom, error) {
+	var convertedTypes []dtype.Type
+
+	for _, param := range functionAtom.parameterTypes {
+		converted, convertedErr := ReplaceTypeFromContext(param, lookup)
+		if convertedErr != nil {
+			return nil, convertedErr
+		}
+
+		convertedTypes = append(convertedTypes, converted)
+	}
+
+	return NewFunctionAtom(functionAtom.astFunctionType, convertedTypes), nil
 }
 
 func replaceInvokerTypeFromContext(invoker *InvokerType, lookup Lookup) (*InvokerType, error) {
@@ -132,7 +158,7 @@ func replaceCustomTypeFromContext(customType *CustomTypeAtom, lookup Lookup) (*C
 		replacedGenerics = append(replacedGenerics, foundGeneric)
 	}
 
-	newCustomType := NewCustomTypePrepare(customType.astCustomType, customType.artifactTypeName, replacedGenerics)
+	newCustomType := NewCustomTypePrepare(customType.DataLayout(), customType.astCustomType, customType.artifactTypeName, replacedGenerics)
 	for _, field := range customType.Variants() {
 		var variantParameters []dtype.Type
 		for _, param := range field.parameterFields {
@@ -167,7 +193,7 @@ func replaceTupleTypeFromContext(tupleType *TupleTypeAtom, lookup Lookup) (dtype
 		convertedTypes = append(convertedTypes, field)
 	}
 
-	return NewTupleTypeAtom(tupleType.astTupleType, convertedTypes), nil
+	return NewTupleTypeAtom(tupleType.DataLayout(), tupleType.astTupleType, convertedTypes), nil
 }
 
 func replaceCustomTypeVariantFromContext(inCustomType *CustomTypeAtom, customTypeVariant *CustomTypeVariantAtom, lookup Lookup) (*CustomTypeVariantAtom, error) {
@@ -23,6 +23,7 @@ type CustomTypeAtom struct {
 	references       []*CustomTypeReference
 	memorySize       MemorySize
 	memoryAlign      MemoryAlign
+	dataLayout       DataLayout
 }
 
 func (s *CustomTypeAtom) GenericNames() []*dtype.TypeArgumentName {
@@ -58,6 +59,11 @@ func (s *CustomTypeAtom) MemoryAlignment() MemoryAlign {
 	return s.memoryAlign
 }
 
+// DataLayout is the layout that the variant fields and the memory size are calculated for.
+func (s *CustomTypeAtom) DataLayout() DataLayout {
+	return s.dataLayout
+}
+
 func (s *CustomTypeAtom) String() string {
 
 	return fmt.Sprintf("[CustomType %v%v %v]", s.artifactTypeName, genericNamesString(s.GenericNames()), s.variants)
@@ -95,7 +101,7 @@ func (s *CustomTypeAtom) ArtifactTypeName() ArtifactFullyQualifiedTypeName {
 	return s.artifactTypeName
 }
 
-func calculateTotalSizeAndAlignment(variants []*CustomTypeVariantAtom) (MemorySize, MemoryAlign) {
+func calculateTotalSizeAndAlignment(layout DataLayout, variants []*CustomTypeVariantAtom) (MemorySize, MemoryAlign) {
 	maxVariantSize := MemorySize(1)
 	maxVariantAlign := MemoryAlign(1)
 	for _, variant := range variants {
@@ -107,7 +113,7 @@ func calculateTotalSizeAndAlignment(variants []*CustomTypeVariantAtom) (MemorySi
 			if wasLocalType {
 				return 0, 0
 			}
-			memorySize, memoryAlign := GetMemorySizeAndAlignment(fieldType)
+			memorySize, memoryAlign := GetMemorySizeAndAlignment(layout, fieldType)
 			if memorySize == 0 || memoryAlign == 0 {
 				panic("illegal size or align values")
 			}
@@ -145,12 +151,12 @@ func calculateTotalSizeAndAlignment(variants []*CustomTypeVariantAtom) (MemorySi
 	return maxVariantSize, maxVariantAlign
 }
 
-func NewCustomTypePrepare(astCustomType *ast.CustomType, artifactTypeName ArtifactFullyQualifiedTypeName,
+func NewCustomTypePrepare(layout DataLayout, astCustomType *ast.CustomType, artifactTypeName ArtifactFullyQualifiedTypeName,
 	generics []dtype.Type) *CustomTypeAtom {
 
 	s := &CustomTypeAtom{
 		astCustomType: astCustomType, artifactTypeName: artifactTypeName,
-		parameters: generics,
+		parameters: generics, dataLayout: layout,
 	}
 
 	return s
@@ -169,7 +175,7 @@ func (s *CustomTypeAtom) FinalizeVariants(variants []*CustomTypeVariantAtom) {
 		nameToField[key] = variant
 	}
 
-	memorySize, memoryAlign := calculateTotalSizeAndAlignment(variants)
+	memorySize, memoryAlign := calculateTotalSizeAndAlignment(s.dataLayout, variants)
 	if memorySize == 0 {
 		memorySize = 1
 	}
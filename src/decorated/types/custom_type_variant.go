@@ -79,7 +79,7 @@ func NewCustomTypeVariant(index int, inCustomType *CustomTypeAtom, astCustomType
 			memorySize = 0
 			memoryAlign = 0
 		} else {
-			memorySize, memoryAlign = GetMemorySizeAndAlignment(paramType)
+			memorySize, memoryAlign = GetMemorySizeAndAlignment(inCustomType.DataLayout(), paramType)
 			rest := pos % MemoryOffset(memoryAlign)
 			if rest != 0 {
 				pos += MemoryOffset(uint(memoryAlign) - uint(rest))
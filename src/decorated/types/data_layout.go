@@ -0,0 +1,44 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package dectype
+
+import (
+	"fmt"
+
+	opcode_sp_type "github.com/swamp/opcodes/type"
+)
+
+// DataLayout is the memory layout of the target that the code is generated for. Only the size and alignment of the
+// pointers (String, List, Array, Blob, Any, functions and unmanaged values) differ between the targets.
+type DataLayout struct {
+	Name         string
+	PointerSize  MemorySize
+	PointerAlign MemoryAlign
+}
+
+var (
+	DataLayoutLP64   = DataLayout{Name: "lp64", PointerSize: MemorySize(opcode_sp_type.Sizeof64BitPointer), PointerAlign: MemoryAlign(opcode_sp_type.Alignof64BitPointer)}
+	DataLayoutILP32  = DataLayout{Name: "ilp32", PointerSize: 4, PointerAlign: 4}
+	DataLayoutWasm32 = DataLayout{Name: "wasm32", PointerSize: 4, PointerAlign: 4}
+)
+
+func DataLayouts() []DataLayout {
+	return []DataLayout{DataLayoutLP64, DataLayoutILP32, DataLayoutWasm32}
+}
+
+func FindDataLayout(name string) (DataLayout, error) {
+	for _, layout := range DataLayouts() {
+		if layout.Name == name {
+			return layout, nil
+		}
+	}
+
+	return DataLayout{}, fmt.Errorf("unknown data layout '%v'", name)
+}
+
+func (l DataLayout) PointerSizeAndAlignment() (MemorySize, MemoryAlign) {
+	return l.PointerSize, l.PointerAlign
+}
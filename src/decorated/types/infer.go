@@ -0,0 +1,166 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package dectype
+
+import (
+	"fmt"
+
+	"github.com/swamp/compiler/src/ast"
+	"github.com/swamp/compiler/src/decorated/dtype"
+	"github.com/swamp/compiler/src/token"
+)
+
+func inferenceVariableName(index int) string {
+	name := string(rune('a' + index%26))
+	if index >= 26 {
+		name += fmt.Sprintf("%d", index/26)
+	}
+	return name
+}
+
+// InferenceVariables creates the unbound type variables that are used when inferring the type of a let function,
+// named a, b, c and so on.
+type InferenceVariables struct {
+	source    token.SourceFileReference
+	variables []*LocalType
+}
+
+func NewInferenceVariables(source token.SourceFileReference) *InferenceVariables {
+	return &InferenceVariables{source: source}
+}
+
+// New creates an unbound type variable.
+func (v *InferenceVariables) New() *LocalType {
+	identifier := ast.NewVariableIdentifier(token.NewVariableSymbolToken(inferenceVariableName(len(v.variables)), v.source, 0))
+	variable := NewInferenceLocalType(ast.NewTypeParameter(identifier))
+	v.variables = append(v.variables, variable)
+
+	return variable
+}
+
+// Variables returns all type variables that have been created.
+func (v *InferenceVariables) Variables() []*LocalType {
+	return v.variables
+}
+
+// Generalizer replaces inference variables with the types they were bound to. Variables that are
+// still unbound are free, and are generalized into normal local types (type parameters), which are
+// replaced with the concrete types at each use.
+type Generalizer struct {
+	variables   map[string]*LocalType
+	generalized map[*LocalType]*LocalType
+}
+
+func NewGeneralizer(variables []*LocalType) *Generalizer {
+	lookup := make(map[string]*LocalType)
+	for _, variable := range variables {
+		lookup[variable.identifier.Name()] = variable
+	}
+	return &Generalizer{variables: lookup, generalized: make(map[*LocalType]*LocalType)}
+}
+
+func (g *Generalizer) LookupType(name string) (dtype.Type, error) {
+	variable, wasFound := g.variables[name]
+	if !wasFound {
+		identifier := ast.NewVariableIdentifier(token.NewVariableSymbolToken(name, token.NewInternalSourceFileReference(), 0))
+		return NewLocalType(ast.NewTypeParameter(identifier)), nil
+	}
+
+	inferred := variable.Inferred()
+	if inferred == nil {
+		free := variable.representative().(*LocalType)
+		generalized, wasGeneralized := g.generalized[free]
+		if !wasGeneralized {
+			generalized = NewGeneralizedLocalType(free.identifier)
+			g.generalized[free] = generalized
+		}
+		return generalized, nil
+	}
+
+	if !TypeIsTemplateHasLocalTypes(inferred) {
+		return inferred, nil
+	}
+
+	return ReplaceTypeFromContext(inferred, g)
+}
+
+// Generalize returns the type with all inference variables replaced.
+func (g *Generalizer) Generalize(t dtype.Type) (dtype.Type, error) {
+	if !TypeIsTemplateHasLocalTypes(t) {
+		return t, nil
+	}
+
+	return ReplaceTypeFromContext(t, g)
+}
+
+func collectUnboundInferenceVariables(t dtype.Type, visited map[dtype.Type]bool, found []*LocalType) []*LocalType {
+	if t == nil || visited[t] {
+		return found
+	}
+	visited[t] = true
+
+	collect := func(types []dtype.Type) {
+		for _, subType := range types {
+			found = collectUnboundInferenceVariables(subType, visited, found)
+		}
+	}
+
+	switch info := t.(type) {
+	case *LocalType:
+		if !info.isInference {
+			return found
+		}
+		if info.inferred == nil {
+			return append(found, info)
+		}
+		return collectUnboundInferenceVariables(info.inferred, visited, found)
+	case *InvokerType:
+		collect(info.Params())
+	case *FunctionAtom:
+		collect(info.FunctionParameterTypes())
+	case *TupleTypeAtom:
+		collect(info.ParameterTypes())
+	case *PrimitiveAtom:
+		collect(info.GenericTypes())
+	case *RecordAtom:
+		for _, field := range info.SortedFields() {
+			found = collectUnboundInferenceVariables(field.Type(), visited, found)
+		}
+	case *CustomTypeAtom:
+		// Only generic custom types can be instantiated with inference variables
+		if len(info.Parameters()) == 0 {
+			return found
+		}
+		collect(info.Parameters())
+		for _, variant := range info.Variants() {
+			collect(variant.ParameterTypes())
+		}
+	case *CustomTypeVariantAtom:
+		collect(info.ParameterTypes())
+	default:
+		return collectUnboundInferenceVariables(t.Next(), visited, found)
+	}
+
+	return found
+}
+
+// unboundInferenceVariables returns the inference variables that the types refer to and that are not bound yet.
+func unboundInferenceVariables(types ...dtype.Type) []*LocalType {
+	visited := make(map[dtype.Type]bool)
+	var found []*LocalType
+	for _, t := range types {
+		found = collectUnboundInferenceVariables(t, visited, found)
+	}
+
+	return found
+}
+
+// unbind undoes the bindings of inference variables that were unbound before a unification that failed.
+func unbind(variables []*LocalType) {
+	for _, variable := range variables {
+		variable.inferred = nil
+	}
+}
@@ -16,6 +16,9 @@ import (
 type LocalType struct {
 	identifier    *ast.TypeParameter
 	wasReferenced bool
+	isInference   bool
+	isGeneralized bool
+	inferred      dtype.Type
 }
 
 func (u *LocalType) String() string {
@@ -38,7 +41,80 @@ func (u *LocalType) AtomName() string {
 	return u.identifier.Name()
 }
 
-func (u *LocalType) IsEqual(_ dtype.Atom) error {
+func (u *LocalType) IsEqual(other dtype.Atom) error {
+	if !u.isInference {
+		return nil
+	}
+
+	otherType, wasType := other.(dtype.Type)
+	if !wasType {
+		return fmt.Errorf("can not infer %v from %v", u.identifier.Name(), other.AtomName())
+	}
+
+	return u.Unify(otherType)
+}
+
+// IsInferenceVariable returns true if the local type is a placeholder that is bound by unification,
+// instead of being a type parameter that is given by the user.
+func (u *LocalType) IsInferenceVariable() bool {
+	return u.isInference
+}
+
+// representative follows the bindings of inference variables and returns the type that it currently stands for.
+// IsGeneralized returns true if the local type is an inference variable of a let function that was still unbound
+// after inference. Let functions are only generated when instantiated with concrete types, so a generalized type can be
+// used where any type parameter is expected.
+func (u *LocalType) IsGeneralized() bool {
+	return u.isGeneralized
+}
+
+func (u *LocalType) representative() dtype.Type {
+	var current dtype.Type = u
+	for {
+		local, wasLocal := current.(*LocalType)
+		if !wasLocal || !local.isInference || local.inferred == nil {
+			return current
+		}
+		current = local.inferred
+	}
+}
+
+// Inferred returns the type that the inference variable has been bound to, or nil if it is still unbound.
+func (u *LocalType) Inferred() dtype.Type {
+	representative := u.representative()
+	if local, wasLocal := representative.(*LocalType); wasLocal && local.isInference {
+		return nil
+	}
+
+	return representative
+}
+
+// Unify binds the inference variable to the other type, or checks that the other type is compatible with
+// the type that it is already bound to. The binding is not undone if the unification fails, use CompatibleTypes
+// for that.
+func (u *LocalType) Unify(other dtype.Type) error {
+	representative := u.representative()
+	local, wasLocal := representative.(*LocalType)
+	if !wasLocal || !local.isInference {
+		return CompatibleTypes(representative, other)
+	}
+
+	otherRepresentative := other
+	if otherLocal, otherWasLocal := other.(*LocalType); otherWasLocal && otherLocal.isInference {
+		otherRepresentative = otherLocal.representative()
+		if otherRepresentative == local {
+			return nil
+		}
+	}
+
+	for _, unbound := range unboundInferenceVariables(otherRepresentative) {
+		if unbound == local {
+			return fmt.Errorf("%v can not be %v, it would be an infinite type", local.identifier.Name(), otherRepresentative.HumanReadable())
+		}
+	}
+
+	local.inferred = otherRepresentative
+
 	return nil
 }
 
@@ -65,3 +141,13 @@ func (u *LocalType) MarkAsReferenced() {
 func NewLocalType(identifier *ast.TypeParameter) *LocalType {
 	return &LocalType{identifier: identifier}
 }
+
+// NewInferenceLocalType creates an unbound type variable that is used when inferring the type of an unannotated function.
+func NewInferenceLocalType(identifier *ast.TypeParameter) *LocalType {
+	return &LocalType{identifier: identifier, isInference: true}
+}
+
+// NewGeneralizedLocalType creates a type parameter for an inference variable that is still unbound after inference.
+func NewGeneralizedLocalType(identifier *ast.TypeParameter) *LocalType {
+	return &LocalType{identifier: identifier, isGeneralized: true}
+}
@@ -24,6 +24,7 @@ type RecordAtom struct {
 	record            *ast.Record
 	memorySize        MemorySize
 	memoryAlign       MemoryAlign
+	dataLayout        DataLayout
 }
 
 func (s *RecordAtom) MemorySize() MemorySize {
@@ -34,6 +35,11 @@ func (s *RecordAtom) MemoryAlignment() MemoryAlign {
 	return s.memoryAlign
 }
 
+// DataLayout is the layout that the field offsets and the memory size were calculated for.
+func (s *RecordAtom) DataLayout() DataLayout {
+	return s.dataLayout
+}
+
 func (s *RecordAtom) GenericTypes() []dtype.Type {
 	return s.genericTypes
 }
@@ -62,7 +68,7 @@ func (s *RecordAtom) FetchPositionLength() token.SourceFileReference {
 	return s.record.FetchPositionLength()
 }
 
-func GetMemorySizeAndAlignmentInternal(p dtype.Type) (MemorySize, MemoryAlign) {
+func GetMemorySizeAndAlignmentInternal(layout DataLayout, p dtype.Type) (MemorySize, MemoryAlign) {
 	if p == nil {
 		panic(fmt.Errorf("nil is not allowed"))
 	}
@@ -75,11 +81,11 @@ func GetMemorySizeAndAlignmentInternal(p dtype.Type) (MemorySize, MemoryAlign) {
 			name := t.PrimitiveName().Name()
 			switch name {
 			case "List":
-				return MemorySize(opcode_sp_type.Sizeof64BitPointer), MemoryAlign(opcode_sp_type.Alignof64BitPointer)
+				return layout.PointerSizeAndAlignment()
 			case "Array":
-				return MemorySize(opcode_sp_type.Sizeof64BitPointer), MemoryAlign(opcode_sp_type.Alignof64BitPointer)
+				return layout.PointerSizeAndAlignment()
 			case "Blob":
-				return MemorySize(opcode_sp_type.Sizeof64BitPointer), MemoryAlign(opcode_sp_type.Alignof64BitPointer)
+				return layout.PointerSizeAndAlignment()
 			case "Bool":
 				return MemorySize(opcode_sp_type.SizeofSwampBool), MemoryAlign(opcode_sp_type.AlignOfSwampBool)
 			case "Int":
@@ -93,9 +99,9 @@ func GetMemorySizeAndAlignmentInternal(p dtype.Type) (MemorySize, MemoryAlign) {
 			case "Char":
 				return MemorySize(opcode_sp_type.SizeofSwampInt), MemoryAlign(opcode_sp_type.AlignOfSwampInt)
 			case "String":
-				return MemorySize(opcode_sp_type.Sizeof64BitPointer), MemoryAlign(opcode_sp_type.Alignof64BitPointer)
+				return layout.PointerSizeAndAlignment()
 			case "Any":
-				return MemorySize(opcode_sp_type.Sizeof64BitPointer), MemoryAlign(opcode_sp_type.Alignof64BitPointer)
+				return layout.PointerSizeAndAlignment()
 			}
 			panic(fmt.Errorf("do not know primitive atom of '%s' %v %T", name, p, unaliased))
 		}
@@ -104,9 +110,9 @@ func GetMemorySizeAndAlignmentInternal(p dtype.Type) (MemorySize, MemoryAlign) {
 	case *CustomTypeVariantAtom:
 		return t.MemorySize(), t.MemoryAlignment()
 	case *FunctionAtom:
-		return MemorySize(opcode_sp_type.Sizeof64BitPointer), MemoryAlign(opcode_sp_type.Alignof64BitPointer)
+		return layout.PointerSizeAndAlignment()
 	case *UnmanagedType:
-		return MemorySize(opcode_sp_type.Sizeof64BitPointer), MemoryAlign(opcode_sp_type.Alignof64BitPointer)
+		return layout.PointerSizeAndAlignment()
 	case *TupleTypeAtom:
 		return t.MemorySize(), t.MemoryAlignment()
 	case *LocalType:
@@ -116,8 +122,8 @@ func GetMemorySizeAndAlignmentInternal(p dtype.Type) (MemorySize, MemoryAlign) {
 	}
 }
 
-func GetMemorySizeAndAlignment(p dtype.Type) (MemorySize, MemoryAlign) {
-	memorySize, memoryAlign := GetMemorySizeAndAlignmentInternal(p)
+func GetMemorySizeAndAlignment(layout DataLayout, p dtype.Type) (MemorySize, MemoryAlign) {
+	memorySize, memoryAlign := GetMemorySizeAndAlignmentInternal(layout, p)
 	if memoryAlign == 0 {
 		panic(fmt.Errorf("unsupported Type %T %v", p, p))
 	}
@@ -125,12 +131,12 @@ func GetMemorySizeAndAlignment(p dtype.Type) (MemorySize, MemoryAlign) {
 	return memorySize, memoryAlign
 }
 
-func calculateFieldOffsetsAndRecordMemorySizeAndAlign(fields []*RecordField) (MemorySize, MemoryAlign) {
+func calculateFieldOffsetsAndRecordMemorySizeAndAlign(layout DataLayout, fields []*RecordField) (MemorySize, MemoryAlign) {
 	offset := MemoryOffset(0)
 	maxMemoryAlign := MemoryAlign(0)
 
 	for _, field := range fields {
-		memorySize, memoryAlign := GetMemorySizeAndAlignment(field.fieldType)
+		memorySize, memoryAlign := GetMemorySizeAndAlignment(layout, field.fieldType)
 		rest := MemoryAlign(uint32(offset) % uint32(memoryAlign))
 		if rest != 0 {
 			offset += MemoryOffset(memoryAlign - rest)
@@ -159,7 +165,7 @@ func (a ByFieldName) Len() int           { return len(a) }
 func (a ByFieldName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a ByFieldName) Less(i, j int) bool { return a[i].Name() < a[j].Name() }
 
-func NewRecordType(info *ast.Record, fields []*RecordField, genericTypes []dtype.Type) *RecordAtom {
+func NewRecordType(layout DataLayout, info *ast.Record, fields []*RecordField, genericTypes []dtype.Type) *RecordAtom {
 	sortedFields := make([]*RecordField, len(fields))
 	copy(sortedFields, fields)
 	sort.Sort(ByFieldName(sortedFields))
@@ -182,12 +188,12 @@ func NewRecordType(info *ast.Record, fields []*RecordField, genericTypes []dtype
 		panic(fmt.Errorf("not allowed to contain local types without parameters %v %v", info, fields))
 	}
 
-	memorySize, memoryAlign := calculateFieldOffsetsAndRecordMemorySizeAndAlign(sortedFields)
+	memorySize, memoryAlign := calculateFieldOffsetsAndRecordMemorySizeAndAlign(layout, sortedFields)
 
 	return &RecordAtom{
 		sortedFields: sortedFields, record: info, parsedOrderFields: fields,
 		nameToField: nameToField, genericTypes: genericTypes,
-		memorySize: memorySize, memoryAlign: memoryAlign,
+		memorySize: memorySize, memoryAlign: memoryAlign, dataLayout: layout,
 	}
 }
 
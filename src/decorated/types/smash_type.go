@@ -157,7 +157,7 @@ func fillContextFromRecordType(context *TypeParameterContextOther, original *Rec
 		return original, nil
 	}
 
-	return NewRecordType(original.AstRecord(), other.SortedFields(), converted), nil
+	return NewRecordType(original.DataLayout(), original.AstRecord(), other.SortedFields(), converted), nil
 }
 
 func fillContextFromCustomTypeVariant2(context *TypeParameterContextOther, originalVariant *CustomTypeVariantAtom, otherVariant *CustomTypeVariantAtom) (*CustomTypeVariantAtom, error) {
@@ -206,7 +206,7 @@ func fillContextFromCustomType(context *TypeParameterContextOther, original *Cus
 		replacedGenerics = append(replacedGenerics, foundGeneric)
 	}
 
-	customType := NewCustomTypePrepare(original.astCustomType, ArtifactFullyQualifiedTypeName{ModuleName{path: nil}}, replacedGenerics)
+	customType := NewCustomTypePrepare(original.DataLayout(), original.astCustomType, ArtifactFullyQualifiedTypeName{ModuleName{path: nil}}, replacedGenerics)
 	wasConverted := false
 	var convertedVariants []*CustomTypeVariantAtom
 	for index, originalVariant := range original.Variants() {
@@ -322,7 +322,7 @@ func fillContextFromTuples(context *TypeParameterContextOther, original *TupleTy
 		converted = append(converted, field)
 	}
 
-	return NewTupleTypeAtom(original.astTupleType, converted), nil
+	return NewTupleTypeAtom(original.DataLayout(), original.astTupleType, converted), nil
 }
 
 func smashTypes(context *TypeParameterContextOther, originalUnchanged dtype.Type, otherUnchanged dtype.Type) (dtype.Type, error) {
@@ -340,12 +340,37 @@ func smashTypes(context *TypeParameterContextOther, originalUnchanged dtype.Type
 		panic("other was nil")
 	}
 
+	if inferenceType, wasInference := original.(*LocalType); wasInference && inferenceType.IsInferenceVariable() {
+		if inferred := inferenceType.Inferred(); inferred != nil {
+			return smashTypes(context, inferred, otherUnchanged)
+		}
+		if err := inferenceType.Unify(otherUnchanged); err != nil {
+			return nil, err
+		}
+		return otherUnchanged, nil
+	}
+
+	if inferenceType, wasInference := other.(*LocalType); wasInference && inferenceType.IsInferenceVariable() {
+		if inferred := inferenceType.Inferred(); inferred != nil {
+			return smashTypes(context, originalUnchanged, inferred)
+		}
+		if _, originalWasLocalType := original.(*LocalType); !originalWasLocalType {
+			if err := inferenceType.Unify(originalUnchanged); err != nil {
+				return nil, err
+			}
+			return originalUnchanged, nil
+		}
+	}
+
 	localType, wasLocalType := original.(*LocalType)
 	if wasLocalType {
-		_, wasLocalType := other.(*LocalType)
-		if wasLocalType {
+		otherLocalType, wasLocalType := other.(*LocalType)
+		if wasLocalType && !otherLocalType.IsInferenceVariable() && !otherLocalType.IsGeneralized() {
 			return nil, fmt.Errorf("not great")
 		}
+		if constraintErr := CheckTypeConstraint(localType.Constraint(), otherUnchanged); constraintErr != nil {
+			return nil, constraintErr
+		}
 		return context.SpecialSet(localType.identifier.Name(), otherUnchanged)
 	}
 
@@ -473,11 +498,36 @@ func smashTypes(context *TypeParameterContextOther, originalUnchanged dtype.Type
 	return nil, fmt.Errorf("unhandled type: %T %v", original, original)
 }
 
+// SmashKnownArguments fills a type parameter context from the arguments that are already known.
+// nil entries are skipped, e.g. lambdas that can not be decorated until the context is known.
+func SmashKnownArguments(original *FunctionAtom, knownArgumentTypes []dtype.Type) (*TypeParameterContextOther, error) {
+	context := NewTypeParameterContextOther()
+
+	unbound := unboundInferenceVariables(append([]dtype.Type{original}, knownArgumentTypes...)...)
+	for index, knownArgumentType := range knownArgumentTypes {
+		if knownArgumentType == nil {
+			continue
+		}
+		if index >= len(original.parameterTypes)-1 {
+			unbind(unbound)
+			return nil, fmt.Errorf("too many arguments")
+		}
+		if _, err := smashTypes(context, original.parameterTypes[index], knownArgumentType); err != nil {
+			unbind(unbound)
+			return nil, err
+		}
+	}
+
+	return context, nil
+}
+
 func SmashFunctions(original *FunctionAtom, otherFunc *FunctionAtom) (*FunctionAtom, error) {
 	context := NewTypeParameterContextOther()
 
+	unbound := unboundInferenceVariables(original, otherFunc)
 	result, resultErr := fillContextFromFunctions(context, original, otherFunc)
 	if resultErr != nil {
+		unbind(unbound)
 		return nil, resultErr
 	}
 
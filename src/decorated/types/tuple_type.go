@@ -14,12 +14,12 @@ import (
 	"github.com/swamp/compiler/src/token"
 )
 
-func calculateTupleFieldOffsetsAndRecordMemorySizeAndAlign(fields []*TupleTypeField) (MemorySize, MemoryAlign) {
+func calculateTupleFieldOffsetsAndRecordMemorySizeAndAlign(layout DataLayout, fields []*TupleTypeField) (MemorySize, MemoryAlign) {
 	offset := MemoryOffset(0)
 	maxMemoryAlign := MemoryAlign(0)
 
 	for _, field := range fields {
-		memorySize, memoryAlign := GetMemorySizeAndAlignment(field.fieldType)
+		memorySize, memoryAlign := GetMemorySizeAndAlignment(layout, field.fieldType)
 		rest := MemoryAlign(uint32(offset) % uint32(memoryAlign))
 		if rest != 0 {
 			offset += MemoryOffset(memoryAlign - rest)
@@ -48,9 +48,10 @@ type TupleTypeAtom struct {
 	astTupleType    *ast.TupleType
 	memorySize      MemorySize
 	memoryAlign     MemoryAlign
+	dataLayout      DataLayout
 }
 
-func NewTupleTypeAtom(astTupleType *ast.TupleType, parameterFields []*TupleTypeField) *TupleTypeAtom {
+func NewTupleTypeAtom(layout DataLayout, astTupleType *ast.TupleType, parameterFields []*TupleTypeField) *TupleTypeAtom {
 	for _, param := range parameterFields {
 		if reflect.TypeOf(param) == nil {
 			panic("function atom: nil parameter type")
@@ -62,14 +63,19 @@ func NewTupleTypeAtom(astTupleType *ast.TupleType, parameterFields []*TupleTypeF
 		parameterTypes = append(parameterTypes, param.Type())
 	}
 
-	memorySize, memoryAlign := calculateTupleFieldOffsetsAndRecordMemorySizeAndAlign(parameterFields)
+	memorySize, memoryAlign := calculateTupleFieldOffsetsAndRecordMemorySizeAndAlign(layout, parameterFields)
 
 	return &TupleTypeAtom{
 		parameterFields: parameterFields, parameterTypes: parameterTypes, astTupleType: astTupleType,
-		memorySize: memorySize, memoryAlign: memoryAlign,
+		memorySize: memorySize, memoryAlign: memoryAlign, dataLayout: layout,
 	}
 }
 
+// DataLayout is the layout that the field offsets and the memory size were calculated for.
+func (u *TupleTypeAtom) DataLayout() DataLayout {
+	return u.dataLayout
+}
+
 func (u *TupleTypeAtom) MemorySize() MemorySize {
 	return u.memorySize
 }
@@ -0,0 +1,158 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package dectype
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/swamp/compiler/src/decorated/dtype"
+)
+
+// TypeConstraint limits which types a type parameter can be replaced with. The constraint is given
+// by the name of the type parameter, e.g. `number` or `comparable2`.
+//
+//	number: Int and Fixed
+//	comparable: Int, Fixed, Char, String, and Lists and tuples of comparable types
+//	appendable: String and List
+type TypeConstraint uint8
+
+const (
+	TypeConstraintNone TypeConstraint = iota
+	TypeConstraintNumber
+	TypeConstraintComparable
+	TypeConstraintAppendable
+)
+
+func (c TypeConstraint) String() string {
+	switch c {
+	case TypeConstraintNone:
+		return "none"
+	case TypeConstraintNumber:
+		return "number"
+	case TypeConstraintComparable:
+		return "comparable"
+	case TypeConstraintAppendable:
+		return "appendable"
+	}
+
+	panic(fmt.Errorf("unknown type constraint %d", c))
+}
+
+// TypeConstraintFromName returns the constraint for a type parameter name. A constraint name can be followed by
+// digits, so that more than one type parameter with the same constraint can be used in the same type.
+func TypeConstraintFromName(name string) TypeConstraint {
+	withoutDigits := strings.TrimRight(name, "0123456789")
+	switch withoutDigits {
+	case "number":
+		return TypeConstraintNumber
+	case "comparable":
+		return TypeConstraintComparable
+	case "appendable":
+		return TypeConstraintAppendable
+	}
+
+	return TypeConstraintNone
+}
+
+func (u *LocalType) Constraint() TypeConstraint {
+	return TypeConstraintFromName(u.identifier.Name())
+}
+
+func constraintSatisfiesConstraint(existing TypeConstraint, constraint TypeConstraint) bool {
+	if existing == constraint {
+		return true
+	}
+
+	return existing == TypeConstraintNumber && constraint == TypeConstraintComparable
+}
+
+func primitiveSatisfiesConstraint(primitive *PrimitiveAtom, constraint TypeConstraint) bool {
+	switch primitive.PrimitiveName().Name() {
+	case "Int", "Fixed":
+		return constraint == TypeConstraintNumber || constraint == TypeConstraintComparable
+	case "Char":
+		return constraint == TypeConstraintComparable
+	case "String":
+		return constraint == TypeConstraintAppendable || constraint == TypeConstraintComparable
+	case "List":
+		if constraint == TypeConstraintComparable {
+			return typesSatisfyConstraint(primitive.GenericTypes(), constraint)
+		}
+		return constraint == TypeConstraintAppendable
+	}
+
+	return false
+}
+
+func typesSatisfyConstraint(types []dtype.Type, constraint TypeConstraint) bool {
+	for _, t := range types {
+		if CheckTypeConstraint(constraint, t) != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CheckTypeConstraint checks that the type can be used for a type parameter with the constraint.
+func CheckTypeConstraint(constraint TypeConstraint, t dtype.Type) error {
+	if constraint == TypeConstraintNone || IsAny(t) {
+		return nil
+	}
+
+	satisfied := false
+	switch info := UnaliasWithResolveInvoker(t).(type) {
+	case *LocalType:
+		satisfied = info.IsInferenceVariable() || info.IsGeneralized() || constraintSatisfiesConstraint(info.Constraint(), constraint)
+	case *PrimitiveAtom:
+		satisfied = primitiveSatisfiesConstraint(info, constraint)
+	case *TupleTypeAtom:
+		satisfied = constraint == TypeConstraintComparable && typesSatisfyConstraint(info.ParameterTypes(), constraint)
+	}
+
+	if !satisfied {
+		return fmt.Errorf("type %v is not %v", t.HumanReadable(), constraint)
+	}
+
+	return nil
+}
+
+func typesHaveConstraints(types []dtype.Type) bool {
+	for _, t := range types {
+		if TypeHasConstraints(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TypeHasConstraints returns true if the type refers to a type parameter with a constraint, e.g. `List number`.
+func TypeHasConstraints(t dtype.Type) bool {
+	switch info := UnaliasWithResolveInvoker(t).(type) {
+	case *LocalType:
+		return info.Constraint() != TypeConstraintNone
+	case *FunctionAtom:
+		return typesHaveConstraints(info.FunctionParameterTypes())
+	case *TupleTypeAtom:
+		return typesHaveConstraints(info.ParameterTypes())
+	case *PrimitiveAtom:
+		return typesHaveConstraints(info.GenericTypes())
+	case *InvokerType:
+		return typesHaveConstraints(info.Params())
+	case *CustomTypeAtom:
+		return typesHaveConstraints(info.Parameters())
+	case *RecordAtom:
+		for _, field := range info.SortedFields() {
+			if TypeHasConstraints(field.Type()) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -33,6 +33,12 @@ func compareAtoms(pureExpected dtype.Atom, pureActual dtype.Atom) error {
 		panic(fmt.Errorf("pureExpected is nil"))
 	}
 
+	if actualLocal, wasLocal := pureActual.(*LocalType); wasLocal && actualLocal.IsInferenceVariable() {
+		if _, expectedWasLocal := pureExpected.(*LocalType); !expectedWasLocal {
+			return actualLocal.IsEqual(pureExpected)
+		}
+	}
+
 	equalErr := pureExpected.IsEqual(pureActual)
 	if equalErr != nil {
 		return fmt.Errorf("*** NOT EQUAL:\n %v\nvs\n %v\n %w", pureExpected.AtomName(), pureActual.AtomName(), equalErr)
@@ -75,7 +81,20 @@ func CompatibleTypesCheckCustomType(expectedType dtype.Type, actualType dtype.Ty
 	return CompatibleTypes(expectedType, actualType)
 }
 
+// CompatibleTypes checks that the actual type can be used where the expected type is. Unbound inference variables are
+// unified with the type they are compared with. If the types are not compatible, the variables that were bound during
+// the check are unbound again, so a failed check has no side effects.
 func CompatibleTypes(expectedType dtype.Type, actualType dtype.Type) error {
+	unbound := unboundInferenceVariables(expectedType, actualType)
+	if err := compatibleTypes(expectedType, actualType); err != nil {
+		unbind(unbound)
+		return err
+	}
+
+	return nil
+}
+
+func compatibleTypes(expectedType dtype.Type, actualType dtype.Type) error {
 	if expectedType == nil {
 		panic(fmt.Sprintf("shouldn't happen. expected is nil, actualType is %v", actualType))
 	}
@@ -0,0 +1,73 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package depgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// WriteDOT writes the graph in the Graphviz DOT format. Modules are grouped in a cluster per package,
+// and edges in cycles are red.
+func WriteDOT(writer io.Writer, g *Graph) error {
+	fmt.Fprintf(writer, "digraph swamp {\n\trankdir=LR;\n\tnode [shape=box];\n")
+
+	var packageOrder []string
+	nodesInPackage := make(map[string][]int)
+	for index, node := range g.Nodes {
+		if _, wasFound := nodesInPackage[node.Package]; !wasFound {
+			packageOrder = append(packageOrder, node.Package)
+		}
+		nodesInPackage[node.Package] = append(nodesInPackage[node.Package], index)
+	}
+
+	for clusterIndex, packageName := range packageOrder {
+		fmt.Fprintf(writer, "\tsubgraph cluster_%d {\n\t\tlabel=%v;\n", clusterIndex, strconv.Quote(packageName))
+		for _, index := range nodesInPackage[packageName] {
+			fmt.Fprintf(writer, "\t\tn%d [label=%v];\n", index, strconv.Quote(g.Nodes[index].Name))
+		}
+		fmt.Fprintf(writer, "\t}\n")
+	}
+
+	for _, edge := range g.Edges {
+		attributes := ""
+		if edge.InCycle {
+			attributes = " [color=red]"
+		}
+		fmt.Fprintf(writer, "\tn%d -> n%d%v;\n", edge.From, edge.To, attributes)
+	}
+
+	_, err := fmt.Fprintf(writer, "}\n")
+
+	return err
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart. Edges in cycles are red.
+func WriteMermaid(writer io.Writer, g *Graph) error {
+	fmt.Fprintf(writer, "flowchart LR\n")
+
+	for index, node := range g.Nodes {
+		fmt.Fprintf(writer, "    n%d[%v]\n", index, strconv.Quote(node.ID()))
+	}
+
+	for edgeIndex, edge := range g.Edges {
+		fmt.Fprintf(writer, "    n%d --> n%d\n", edge.From, edge.To)
+		if edge.InCycle {
+			fmt.Fprintf(writer, "    linkStyle %d stroke:red\n", edgeIndex)
+		}
+	}
+
+	return nil
+}
+
+func WriteJSON(writer io.Writer, g *Graph) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(g)
+}
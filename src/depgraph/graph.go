@@ -0,0 +1,360 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package depgraph
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	"github.com/swamp/compiler/src/loader"
+	"github.com/swamp/compiler/src/parser"
+)
+
+// CorePackageName is the package name used for the modules that are created by the compiler, e.g. `Int` and `List`.
+const CorePackageName = "core"
+
+type Node struct {
+	Name    string `json:"name"`
+	Package string `json:"package"`
+}
+
+// ID is unique within the graph, since two packages can have modules with the same name.
+func (n *Node) ID() string {
+	return n.Package + "/" + n.Name
+}
+
+type Edge struct {
+	From    int  `json:"from"`
+	To      int  `json:"to"`
+	InCycle bool `json:"inCycle,omitempty"`
+}
+
+// Graph is the import graph. An edge goes from the importing module to the imported module.
+type Graph struct {
+	Nodes []*Node `json:"nodes"`
+	Edges []Edge  `json:"edges"`
+}
+
+func moduleName(module *decorated.Module) string {
+	name := module.FullyQualifiedModuleName().String()
+	if name == "" {
+		return "Main"
+	}
+	return name
+}
+
+func modulePackageName(compiledPackage *loader.Package, module *decorated.Module) string {
+	if module.IsInternal() {
+		return CorePackageName
+	}
+
+	if module.ModuleType() == decorated.ModuleTypeNormal {
+		return compiledPackage.Name()
+	}
+
+	// Modules from mapped libraries are prefixed with the name of the mapping
+	mappingName, _, _ := strings.Cut(module.FullyQualifiedModuleName().String(), ".")
+
+	return mappingName
+}
+
+type graphBuilder struct {
+	graph       *Graph
+	nodeIndices map[string]int
+	edgeExists  map[[2]int]bool
+}
+
+func (b *graphBuilder) node(name string, packageName string) int {
+	node := &Node{Name: name, Package: packageName}
+	index, wasFound := b.nodeIndices[node.ID()]
+	if wasFound {
+		return index
+	}
+
+	index = len(b.graph.Nodes)
+	b.graph.Nodes = append(b.graph.Nodes, node)
+	b.nodeIndices[node.ID()] = index
+
+	return index
+}
+
+func (b *graphBuilder) edge(from int, to int) {
+	key := [2]int{from, to}
+	if b.edgeExists[key] {
+		return
+	}
+	b.edgeExists[key] = true
+	b.graph.Edges = append(b.graph.Edges, Edge{From: from, To: to})
+}
+
+func newGraphBuilder() *graphBuilder {
+	return &graphBuilder{graph: &Graph{Nodes: []*Node{}, Edges: []Edge{}}, nodeIndices: make(map[string]int), edgeExists: make(map[[2]int]bool)}
+}
+
+// FromPackages creates the import graph for all modules in the compiled packages, including the imported core modules.
+func FromPackages(compiledPackages []*loader.Package) *Graph {
+	builder := newGraphBuilder()
+
+	for _, compiledPackage := range compiledPackages {
+		for _, module := range compiledPackage.AllModules() {
+			from := builder.node(moduleName(module), modulePackageName(compiledPackage, module))
+			for _, importedModule := range module.ImportedModules().AllInOrderModules() {
+				referenced := importedModule.ReferencedModule()
+				to := builder.node(moduleName(referenced), modulePackageName(compiledPackage, referenced))
+				if from != to {
+					builder.edge(from, to)
+				}
+			}
+		}
+	}
+
+	builder.graph.sort()
+
+	return builder.graph
+}
+
+// cyclePackageName returns the name of the package with the source file where the cycle was detected.
+func cyclePackageName(cycleErr *decorated.CircularDependencyDetected) string {
+	document := cycleErr.FetchPositionLength().Document
+	if document == nil {
+		return ""
+	}
+
+	localPath, pathErr := document.Uri.ToLocalFilePath()
+	if pathErr != nil {
+		return ""
+	}
+
+	packageDirectory, findErr := loader.FindSettingsDirectory(filepath.Dir(localPath))
+	if findErr != nil {
+		return filepath.Base(filepath.Dir(localPath))
+	}
+
+	return filepath.Base(packageDirectory)
+}
+
+// WithCircularDependencies returns the graph with the import cycles that are reported in the compile error, and
+// how many cycles there were. The modules in a cycle can not be compiled, so the cycle is only found in the error.
+func (g *Graph) WithCircularDependencies(compileErr error) (*Graph, int) {
+	builder := newGraphBuilder()
+	for _, node := range g.Nodes {
+		builder.node(node.Name, node.Package)
+	}
+	for _, edge := range g.Edges {
+		builder.edge(edge.From, edge.To)
+	}
+
+	cycleCount := 0
+	for _, diagnostic := range parser.Diagnostics(compileErr) {
+		cycleErr, wasCycle := diagnostic.(*decorated.CircularDependencyDetected)
+		if !wasCycle {
+			continue
+		}
+
+		packageName := cyclePackageName(cycleErr)
+		previous := -1
+		for _, module := range cycleErr.CyclePath() {
+			name := module.String()
+			if name == "" {
+				name = "Main"
+			}
+			current := builder.node(name, packageName)
+			if previous != -1 && previous != current {
+				builder.edge(previous, current)
+			}
+			previous = current
+		}
+		cycleCount++
+	}
+
+	builder.graph.sort()
+
+	return builder.graph, cycleCount
+}
+
+// sort orders the nodes by ID and the edges by node, so that the output is the same for every run.
+func (g *Graph) sort() {
+	order := make([]int, len(g.Nodes))
+	for index := range order {
+		order[index] = index
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return g.Nodes[order[i]].ID() < g.Nodes[order[j]].ID()
+	})
+
+	newIndex := make([]int, len(g.Nodes))
+	sortedNodes := make([]*Node, len(g.Nodes))
+	for sortedIndex, oldIndex := range order {
+		newIndex[oldIndex] = sortedIndex
+		sortedNodes[sortedIndex] = g.Nodes[oldIndex]
+	}
+	g.Nodes = sortedNodes
+
+	for index := range g.Edges {
+		g.Edges[index].From = newIndex[g.Edges[index].From]
+		g.Edges[index].To = newIndex[g.Edges[index].To]
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+}
+
+// CollapseByPackage returns a graph with one node per package. There is an edge between two packages if any module
+// in the first package imports a module in the second.
+func (g *Graph) CollapseByPackage() *Graph {
+	builder := newGraphBuilder()
+	packageNodes := make([]int, len(g.Nodes))
+	for index, node := range g.Nodes {
+		packageNodes[index] = builder.node(node.Package, node.Package)
+	}
+
+	for _, edge := range g.Edges {
+		from := packageNodes[edge.From]
+		to := packageNodes[edge.To]
+		if from != to {
+			builder.edge(from, to)
+		}
+	}
+
+	builder.graph.sort()
+
+	return builder.graph
+}
+
+// FindNode finds a node by ID, or by name if only one package has a module with that name.
+func (g *Graph) FindNode(name string) (int, error) {
+	found := -1
+	for index, node := range g.Nodes {
+		if node.ID() == name {
+			return index, nil
+		}
+		if node.Name == name {
+			if found != -1 {
+				return -1, fmt.Errorf("'%v' is in more than one package, use <package>/%v", name, name)
+			}
+			found = index
+		}
+	}
+
+	if found == -1 {
+		return -1, fmt.Errorf("no module named '%v'", name)
+	}
+
+	return found, nil
+}
+
+// ReverseDependencies returns the part of the graph with the node and every module that imports it,
+// directly or indirectly.
+func (g *Graph) ReverseDependencies(nodeIndex int) *Graph {
+	importedBy := make(map[int][]int)
+	for _, edge := range g.Edges {
+		importedBy[edge.To] = append(importedBy[edge.To], edge.From)
+	}
+
+	included := map[int]bool{nodeIndex: true}
+	pending := []int{nodeIndex}
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, importer := range importedBy[current] {
+			if !included[importer] {
+				included[importer] = true
+				pending = append(pending, importer)
+			}
+		}
+	}
+
+	builder := newGraphBuilder()
+	for _, edge := range g.Edges {
+		if included[edge.From] && included[edge.To] {
+			from := builder.node(g.Nodes[edge.From].Name, g.Nodes[edge.From].Package)
+			to := builder.node(g.Nodes[edge.To].Name, g.Nodes[edge.To].Package)
+			builder.edge(from, to)
+		}
+	}
+	builder.node(g.Nodes[nodeIndex].Name, g.Nodes[nodeIndex].Package)
+
+	builder.graph.sort()
+
+	return builder.graph
+}
+
+// MarkCycles sets InCycle for every edge that is part of a cycle, and returns how many edges that was.
+// An edge is in a cycle if both of its nodes are in the same strongly connected component.
+func (g *Graph) MarkCycles() int {
+	outgoing := make([][]int, len(g.Nodes))
+	for _, edge := range g.Edges {
+		outgoing[edge.From] = append(outgoing[edge.From], edge.To)
+	}
+
+	// Tarjan's algorithm
+	component := make([]int, len(g.Nodes))
+	lowLink := make([]int, len(g.Nodes))
+	visitIndex := make([]int, len(g.Nodes))
+	onStack := make([]bool, len(g.Nodes))
+	for index := range visitIndex {
+		visitIndex[index] = -1
+	}
+	var stack []int
+	nextIndex := 0
+	componentCount := 0
+
+	var connect func(node int)
+	connect = func(node int) {
+		visitIndex[node] = nextIndex
+		lowLink[node] = nextIndex
+		nextIndex++
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, next := range outgoing[node] {
+			if visitIndex[next] == -1 {
+				connect(next)
+				if lowLink[next] < lowLink[node] {
+					lowLink[node] = lowLink[next]
+				}
+			} else if onStack[next] && visitIndex[next] < lowLink[node] {
+				lowLink[node] = visitIndex[next]
+			}
+		}
+
+		if lowLink[node] == visitIndex[node] {
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component[top] = componentCount
+				if top == node {
+					break
+				}
+			}
+			componentCount++
+		}
+	}
+
+	for node := range g.Nodes {
+		if visitIndex[node] == -1 {
+			connect(node)
+		}
+	}
+
+	count := 0
+	for index, edge := range g.Edges {
+		inCycle := component[edge.From] == component[edge.To]
+		g.Edges[index].InCycle = inCycle
+		if inCycle {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,259 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package depgraph
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	swampcompiler "github.com/swamp/compiler/src/compiler"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+	"github.com/swamp/compiler/src/parser"
+	"github.com/swamp/compiler/src/verbosity"
+)
+
+// writeTestPackage writes a package `game`, where `Main` imports `Physics`. With a cycle, `Physics` and
+// `Collision` import each other.
+func writeTestPackage(t *testing.T, withCycle bool) string {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	files := map[string]string{
+		".swamp.toml":   "",
+		"Main.swamp":    "import Physics\n\n\nmain : (x: Int) -> Int =\n    Physics.step x\n",
+		"Physics.swamp": "step : (x: Int) -> Int =\n    x + 1\n",
+	}
+	if withCycle {
+		files["Physics.swamp"] = "import Collision\n\n\nstep : (x: Int) -> Int =\n    Collision.check x\n"
+		files["Collision.swamp"] = "import Physics\n\n\ncheck : (x: Int) -> Int =\n    Physics.step x\n"
+	}
+
+	directory := filepath.Join(t.TempDir(), "game")
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return directory
+}
+
+func buildTestGraph(t *testing.T, withCycle bool) (*Graph, error) {
+	t.Helper()
+	compiledPackages, compileErr := swampcompiler.BuildMainOnlyCompile(writeTestPackage(t, withCycle), dectype.DataLayoutLP64, true, 1, verbosity.None)
+
+	return FromPackages(compiledPackages), compileErr
+}
+
+// packageEdges returns the edges between the modules of the package, as `from -> to` IDs.
+func packageEdges(g *Graph, packageName string) []string {
+	var edges []string
+	for _, edge := range g.Edges {
+		from := g.Nodes[edge.From]
+		to := g.Nodes[edge.To]
+		if from.Package == packageName && to.Package == packageName {
+			edges = append(edges, from.ID()+" -> "+to.ID())
+		}
+	}
+
+	return edges
+}
+
+func TestFromPackages(t *testing.T) {
+	graph, compileErr := buildTestGraph(t, false)
+	if compileErr != nil {
+		t.Fatal(compileErr)
+	}
+
+	if edges := packageEdges(graph, "game"); !reflect.DeepEqual(edges, []string{"game/Main -> game/Physics"}) {
+		t.Errorf("unexpected edges %v", edges)
+	}
+
+	mainIndex, findErr := graph.FindNode("game/Main")
+	if findErr != nil {
+		t.Fatal(findErr)
+	}
+	intIndex, intErr := graph.FindNode("Int")
+	if intErr != nil {
+		t.Fatal(intErr)
+	}
+	if graph.Nodes[intIndex].Package != CorePackageName {
+		t.Errorf("expected 'Int' to be in the core package, got '%v'", graph.Nodes[intIndex].Package)
+	}
+	if !hasEdge(graph, mainIndex, intIndex) {
+		t.Errorf("expected 'Main' to import 'Int'")
+	}
+
+	if _, ambiguousErr := graph.FindNode("Main"); ambiguousErr == nil {
+		t.Errorf("expected 'Main' to be in more than one package")
+	}
+
+	if count := graph.MarkCycles(); count != 0 {
+		t.Errorf("expected no edges in cycles, got %v", count)
+	}
+
+	if withCycles, cycleCount := graph.WithCircularDependencies(compileErr); cycleCount != 0 || len(withCycles.Edges) != len(graph.Edges) {
+		t.Errorf("expected no cycles to be added, got %v", cycleCount)
+	}
+}
+
+func hasEdge(g *Graph, from int, to int) bool {
+	for _, edge := range g.Edges {
+		if edge.From == from && edge.To == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestCollapseAndReverse(t *testing.T) {
+	graph, compileErr := buildTestGraph(t, false)
+	if compileErr != nil {
+		t.Fatal(compileErr)
+	}
+
+	collapsed := graph.CollapseByPackage()
+	expectedNodes := []*Node{{Name: CorePackageName, Package: CorePackageName}, {Name: "game", Package: "game"}}
+	if !reflect.DeepEqual(collapsed.Nodes, expectedNodes) || !reflect.DeepEqual(collapsed.Edges, []Edge{{From: 1, To: 0}}) {
+		t.Errorf("expected only 'game' to import 'core', got %+v", collapsed.Edges)
+	}
+
+	physicsIndex, findErr := graph.FindNode("Physics")
+	if findErr != nil {
+		t.Fatal(findErr)
+	}
+	reverse := graph.ReverseDependencies(physicsIndex)
+	if edges := packageEdges(reverse, "game"); len(reverse.Nodes) != 2 || !reflect.DeepEqual(edges, []string{"game/Main -> game/Physics"}) {
+		t.Errorf("expected only 'Main' to import 'Physics', got %v", edges)
+	}
+}
+
+func TestWithCircularDependencies(t *testing.T) {
+	graph, compileErr := buildTestGraph(t, true)
+	if compileErr == nil {
+		t.Fatal("expected the import cycle to fail the build")
+	}
+
+	var cyclePaths [][]string
+	for _, diagnostic := range parser.Diagnostics(compileErr) {
+		if cycleErr, wasCycle := diagnostic.(*decorated.CircularDependencyDetected); wasCycle {
+			var names []string
+			for _, module := range cycleErr.CyclePath() {
+				names = append(names, module.String())
+			}
+			cyclePaths = append(cyclePaths, names)
+		}
+	}
+	if !reflect.DeepEqual(cyclePaths, [][]string{{"Physics", "Collision", "Physics"}}) {
+		t.Errorf("unexpected cycle paths %v", cyclePaths)
+	}
+
+	withCycles, cycleCount := graph.WithCircularDependencies(compileErr)
+	if cycleCount != 1 {
+		t.Fatalf("expected one cycle, got %v", cycleCount)
+	}
+
+	if count := withCycles.MarkCycles(); count != 2 {
+		t.Errorf("expected two edges in the cycle, got %v", count)
+	}
+
+	var writtenDOT bytes.Buffer
+	if err := WriteDOT(&writtenDOT, withCycles); err != nil {
+		t.Fatal(err)
+	}
+	const expectedDOT = `digraph swamp {
+	rankdir=LR;
+	node [shape=box];
+	subgraph cluster_0 {
+		label="game";
+		n0 [label="Collision"];
+		n1 [label="Physics"];
+	}
+	n0 -> n1 [color=red];
+	n1 -> n0 [color=red];
+}
+`
+	if writtenDOT.String() != expectedDOT {
+		t.Errorf("expected:\n%v\ngot:\n%v", expectedDOT, writtenDOT.String())
+	}
+
+	var writtenMermaid bytes.Buffer
+	if err := WriteMermaid(&writtenMermaid, withCycles); err != nil {
+		t.Fatal(err)
+	}
+	const expectedMermaid = `flowchart LR
+    n0["game/Collision"]
+    n1["game/Physics"]
+    n0 --> n1
+    linkStyle 0 stroke:red
+    n1 --> n0
+    linkStyle 1 stroke:red
+`
+	if writtenMermaid.String() != expectedMermaid {
+		t.Errorf("expected:\n%v\ngot:\n%v", expectedMermaid, writtenMermaid.String())
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	graph := &Graph{
+		Nodes: []*Node{{Name: "Main", Package: "game"}, {Name: "Physics", Package: "game"}, {Name: "Int", Package: CorePackageName}},
+		Edges: []Edge{{From: 0, To: 1}, {From: 0, To: 2}, {From: 1, To: 2}},
+	}
+	if count := graph.MarkCycles(); count != 0 {
+		t.Fatalf("expected no edges in cycles, got %v", count)
+	}
+
+	for _, testCase := range []struct {
+		name     string
+		write    func(*bytes.Buffer, *Graph) error
+		expected string
+	}{
+		{"dot", func(b *bytes.Buffer, g *Graph) error { return WriteDOT(b, g) }, `digraph swamp {
+	rankdir=LR;
+	node [shape=box];
+	subgraph cluster_0 {
+		label="game";
+		n0 [label="Main"];
+		n1 [label="Physics"];
+	}
+	subgraph cluster_1 {
+		label="core";
+		n2 [label="Int"];
+	}
+	n0 -> n1;
+	n0 -> n2;
+	n1 -> n2;
+}
+`},
+		{"mermaid", func(b *bytes.Buffer, g *Graph) error { return WriteMermaid(b, g) }, `flowchart LR
+    n0["game/Main"]
+    n1["game/Physics"]
+    n2["core/Int"]
+    n0 --> n1
+    n0 --> n2
+    n1 --> n2
+`},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			var written bytes.Buffer
+			if err := testCase.write(&written, graph); err != nil {
+				t.Fatal(err)
+			}
+			if written.String() != testCase.expected {
+				t.Errorf("expected:\n%v\ngot:\n%v", testCase.expected, written.String())
+			}
+		})
+	}
+}
@@ -14,17 +14,20 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/swamp/compiler/src/file"
 )
 
 type Package struct {
-	Name string
-	Path string
+	Name   string
+	Path   string
+	Source string `toml:"-"`
 }
 
 type Environment struct {
 	Version string
+	Store   string
 	Package []*Package
 }
 
@@ -35,6 +38,13 @@ func (c Environment) Lookup(name string) string {
 		}
 	}
 
+	// A package that only comes from an environment variable has a lower case name
+	for _, x := range c.Package {
+		if strings.HasPrefix(x.Source, variableSourcePrefix) && VariableName(x.Name) == VariableName(name) {
+			return x.Path
+		}
+	}
+
 	return ""
 }
 
@@ -0,0 +1,200 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package environment
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/swamp/compiler/src/file"
+)
+
+// ProjectFilename is the name of the project environment file, that is placed next to the solution file.
+// The packages in it override the packages in the user environment file.
+const ProjectFilename = "swamp.env.toml"
+
+const variableSourcePrefix = "environment variable "
+
+// StoreVariable is the process environment variable that overrides the package store directory.
+const StoreVariable = "SWAMP_STORE"
+
+// VariablePrefix is the prefix of process environment variables that override packages, e.g. `SWAMP_PKG_ENGINE`.
+const VariablePrefix = "SWAMP_PKG_"
+
+// VariableName returns the name of the process environment variable that overrides the package.
+func VariableName(packageName string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		return '_'
+	}, packageName)
+
+	return VariablePrefix + name
+}
+
+// FindProjectFilename looks for a project environment file in the directory of the file or directory, and then in
+// the directories above it. It returns an empty string if there is none.
+func FindProjectFilename(fileOrDirectory string) string {
+	directory, absErr := filepath.Abs(fileOrDirectory)
+	if absErr != nil {
+		return ""
+	}
+	if !file.IsDir(directory) {
+		directory = filepath.Dir(directory)
+	}
+
+	for {
+		projectFilename := filepath.Join(directory, ProjectFilename)
+		if file.HasFile(projectFilename) {
+			return filepath.ToSlash(projectFilename)
+		}
+		parent := filepath.Dir(directory)
+		if parent == directory {
+			return ""
+		}
+		directory = parent
+	}
+}
+
+func loadFile(filename string) (Environment, error) {
+	tomlFile, openErr := os.Open(filename)
+	if openErr != nil {
+		return Environment{}, fmt.Errorf("couldn't load environment file %s %w", filename, openErr)
+	}
+	defer tomlFile.Close()
+
+	loaded, loadErr := Load(bufio.NewReader(tomlFile))
+	if loadErr != nil {
+		return Environment{}, fmt.Errorf("couldn't load environment file %s %w", filename, loadErr)
+	}
+
+	return loaded, nil
+}
+
+// Unset removes the package. It returns false if there was no package with that name.
+func (c *Environment) Unset(name string) bool {
+	for index, x := range c.Package {
+		if x.Name == name {
+			c.Package = append(c.Package[:index], c.Package[index+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Environment) setFromSource(name string, packagePath string, source string) {
+	c.AddOrSet(name, packagePath)
+	for _, x := range c.Package {
+		if x.Name == name {
+			x.Source = source
+		}
+	}
+}
+
+// LoadResolved loads the user environment file, and overlays the project environment file that is found from the
+// file or directory, and then the `SWAMP_PKG_<NAME>` process environment variables. The Source of each package
+// tells where the value came from.
+func LoadResolved(fileOrDirectory string) (Environment, error) {
+	resolved := Environment{}
+
+	userFilename, userFilenameErr := EnvironmentTomlFilename()
+	if userFilenameErr != nil {
+		return Environment{}, userFilenameErr
+	}
+
+	if file.HasFile(userFilename) {
+		userEnvironment, userErr := loadFile(userFilename)
+		if userErr != nil {
+			return Environment{}, userErr
+		}
+		resolved.Version = userEnvironment.Version
+		resolved.Store = userEnvironment.Store
+		for _, x := range userEnvironment.Package {
+			resolved.setFromSource(x.Name, x.Path, userFilename)
+		}
+	}
+
+	if projectFilename := FindProjectFilename(fileOrDirectory); projectFilename != "" {
+		projectEnvironment, projectErr := loadFile(projectFilename)
+		if projectErr != nil {
+			return Environment{}, projectErr
+		}
+		if projectEnvironment.Store != "" {
+			resolved.Store = projectEnvironment.Store
+			if !filepath.IsAbs(resolved.Store) {
+				resolved.Store = path.Join(path.Dir(projectFilename), resolved.Store)
+			}
+		}
+		for _, x := range projectEnvironment.Package {
+			packagePath := x.Path
+			if !filepath.IsAbs(packagePath) {
+				packagePath = path.Join(path.Dir(projectFilename), packagePath)
+			}
+			resolved.setFromSource(x.Name, packagePath, projectFilename)
+		}
+	}
+
+	if storeDirectory := os.Getenv(StoreVariable); storeDirectory != "" {
+		resolved.Store = filepath.ToSlash(storeDirectory)
+	}
+
+	var variables []string
+	for _, variable := range os.Environ() {
+		if strings.HasPrefix(variable, VariablePrefix) {
+			variables = append(variables, variable)
+		}
+	}
+	sort.Strings(variables)
+
+	for _, variable := range variables {
+		variableName, packagePath, _ := strings.Cut(variable, "=")
+		if packagePath == "" {
+			continue
+		}
+
+		// The variable overrides the package that it was named from, otherwise it is a new package
+		name := strings.ToLower(strings.TrimPrefix(variableName, VariablePrefix))
+		for _, x := range resolved.Package {
+			if VariableName(x.Name) == variableName {
+				name = x.Name
+				break
+			}
+		}
+
+		absolutePath, absErr := filepath.Abs(packagePath)
+		if absErr != nil {
+			return Environment{}, absErr
+		}
+		resolved.setFromSource(name, filepath.ToSlash(absolutePath), variableSourcePrefix+variableName)
+	}
+
+	return resolved, nil
+}
+
+// StoreDirectory returns the directory of the package store that versioned modules are resolved from.
+// It defaults to a `store` directory next to the user environment file.
+func (c Environment) StoreDirectory() (string, error) {
+	if c.Store != "" {
+		return c.Store, nil
+	}
+
+	userFilename, userFilenameErr := EnvironmentTomlFilename()
+	if userFilenameErr != nil {
+		return "", userFilenameErr
+	}
+
+	return path.Join(path.Dir(userFilename), "store"), nil
+}
@@ -0,0 +1,213 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupEnvironment makes the user environment file and the package variables only come from the test. It returns the
+// root directory, that has the user configuration in `config` and the project in `project`.
+func setupEnvironment(t *testing.T, userSource string, projectSource string, variables map[string]string) string {
+	t.Helper()
+	root := filepath.ToSlash(t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "config"))
+	t.Setenv("HOME", root)
+	t.Setenv(StoreVariable, "")
+	for _, variable := range os.Environ() {
+		if name, _, _ := strings.Cut(variable, "="); strings.HasPrefix(name, VariablePrefix) {
+			t.Setenv(name, "")
+		}
+	}
+	for name, value := range variables {
+		t.Setenv(name, strings.ReplaceAll(value, "{root}", root))
+	}
+
+	for filename, source := range map[string]string{"config/swamp/env.toml": userSource, "project/" + ProjectFilename: projectSource} {
+		if source == "" {
+			continue
+		}
+		fullFilename := filepath.Join(root, filepath.FromSlash(filename))
+		if err := os.MkdirAll(filepath.Dir(fullFilename), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullFilename, []byte(strings.ReplaceAll(source, "{root}", root)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, "project", "game"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	return root
+}
+
+func TestLoadResolved(t *testing.T) {
+	const (
+		userFile    = "{root}/config/swamp/env.toml"
+		projectFile = "{root}/project/" + ProjectFilename
+	)
+
+	type expectedPackage struct {
+		path   string
+		source string
+	}
+
+	for _, testCase := range []struct {
+		name          string
+		user          string
+		project       string
+		variables     map[string]string
+		expected      map[string]expectedPackage
+		expectedStore string
+	}{
+		{
+			name:     "user file",
+			user:     "[[package]]\nname = \"engine\"\npath = \"/user/engine\"\n",
+			expected: map[string]expectedPackage{"engine": {"/user/engine", userFile}},
+		},
+		{
+			name:    "project file overrides user file",
+			user:    "[[package]]\nname = \"engine\"\npath = \"/user/engine\"\n\n[[package]]\nname = \"tools\"\npath = \"/user/tools\"\n",
+			project: "[[package]]\nname = \"engine\"\npath = \"libs/engine\"\n",
+			expected: map[string]expectedPackage{
+				"engine": {"{root}/project/libs/engine", projectFile},
+				"tools":  {"/user/tools", userFile},
+			},
+		},
+		{
+			name:      "variable overrides project file",
+			user:      "[[package]]\nname = \"engine\"\npath = \"/user/engine\"\n",
+			project:   "[[package]]\nname = \"engine\"\npath = \"/project/engine\"\n",
+			variables: map[string]string{"SWAMP_PKG_ENGINE": "{root}/variable/engine"},
+			expected:  map[string]expectedPackage{"engine": {"{root}/variable/engine", "environment variable SWAMP_PKG_ENGINE"}},
+		},
+		{
+			name:      "variable keeps the name of the package it overrides",
+			project:   "[[package]]\nname = \"my-Engine\"\npath = \"/project/engine\"\n",
+			variables: map[string]string{"SWAMP_PKG_MY_ENGINE": "{root}/variable/engine"},
+			expected:  map[string]expectedPackage{"my-Engine": {"{root}/variable/engine", "environment variable SWAMP_PKG_MY_ENGINE"}},
+		},
+		{
+			name:      "variable without a package in the files",
+			variables: map[string]string{"SWAMP_PKG_AUDIO_KIT": "{root}/audio"},
+			expected:  map[string]expectedPackage{"audio_kit": {"{root}/audio", "environment variable SWAMP_PKG_AUDIO_KIT"}},
+		},
+		{
+			name:      "empty variable is ignored",
+			user:      "[[package]]\nname = \"engine\"\npath = \"/user/engine\"\n",
+			variables: map[string]string{"SWAMP_PKG_ENGINE": ""},
+			expected:  map[string]expectedPackage{"engine": {"/user/engine", userFile}},
+		},
+		{
+			name:          "store from user file",
+			user:          "store = \"/user/store\"\n",
+			expected:      map[string]expectedPackage{},
+			expectedStore: "/user/store",
+		},
+		{
+			name:          "store from project file",
+			user:          "store = \"/user/store\"\n",
+			project:       "store = \"store\"\n",
+			expected:      map[string]expectedPackage{},
+			expectedStore: "{root}/project/store",
+		},
+		{
+			name:          "store from variable",
+			user:          "store = \"/user/store\"\n",
+			project:       "store = \"store\"\n",
+			variables:     map[string]string{StoreVariable: "/variable/store"},
+			expected:      map[string]expectedPackage{},
+			expectedStore: "/variable/store",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			root := setupEnvironment(t, testCase.user, testCase.project, testCase.variables)
+			replaceRoot := func(s string) string {
+				return strings.ReplaceAll(s, "{root}", root)
+			}
+
+			// The project file is found from a directory below it
+			resolved, resolveErr := LoadResolved(filepath.Join(root, "project", "game"))
+			if resolveErr != nil {
+				t.Fatal(resolveErr)
+			}
+
+			if len(resolved.Package) != len(testCase.expected) {
+				t.Errorf("expected %v packages, got %v", len(testCase.expected), len(resolved.Package))
+			}
+			for _, resolvedPackage := range resolved.Package {
+				expected, wasFound := testCase.expected[resolvedPackage.Name]
+				if !wasFound {
+					t.Errorf("unexpected package '%v'", resolvedPackage.Name)
+					continue
+				}
+				if resolvedPackage.Path != replaceRoot(expected.path) {
+					t.Errorf("expected '%v' to have the path '%v', got '%v'", resolvedPackage.Name, replaceRoot(expected.path), resolvedPackage.Path)
+				}
+				if resolvedPackage.Source != replaceRoot(expected.source) {
+					t.Errorf("expected '%v' to come from '%v', got '%v'", resolvedPackage.Name, replaceRoot(expected.source), resolvedPackage.Source)
+				}
+			}
+
+			expectedStore := replaceRoot(testCase.expectedStore)
+			if expectedStore == "" {
+				expectedStore = root + "/config/swamp/store"
+			}
+			if store, storeErr := resolved.StoreDirectory(); storeErr != nil || store != expectedStore {
+				t.Errorf("expected the store '%v', got '%v' %v", expectedStore, store, storeErr)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	root := setupEnvironment(t, "[[package]]\nname = \"engine\"\npath = \"/user/engine\"\n", "",
+		map[string]string{"SWAMP_PKG_AUDIO_KIT": "/variable/audio"})
+
+	resolved, resolveErr := LoadResolved(root)
+	if resolveErr != nil {
+		t.Fatal(resolveErr)
+	}
+
+	for _, testCase := range []struct {
+		name     string
+		expected string
+	}{
+		{"engine", "/user/engine"},
+		{"Engine", ""},
+		{"audio_kit", "/variable/audio"},
+		// Only the packages from variables are found from another name that gives the same variable
+		{"audio-kit", "/variable/audio"},
+		{"Audio.Kit", "/variable/audio"},
+		{"missing", ""},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			if found := resolved.Lookup(testCase.name); found != testCase.expected {
+				t.Errorf("expected '%v', got '%v'", testCase.expected, found)
+			}
+		})
+	}
+}
+
+func TestUnset(t *testing.T) {
+	environment := Environment{}
+	environment.AddOrSet("engine", "/engine")
+	environment.AddOrSet("tools", "/tools")
+
+	if !environment.Unset("engine") {
+		t.Errorf("expected 'engine' to be removed")
+	}
+	if environment.Unset("engine") {
+		t.Errorf("expected 'engine' to already be removed")
+	}
+	if environment.Lookup("engine") != "" || environment.Lookup("tools") != "/tools" {
+		t.Errorf("expected only 'tools' to be left, got %v", environment.Package)
+	}
+}
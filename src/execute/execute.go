@@ -18,7 +18,9 @@ import (
 
 	"github.com/fatih/color"
 	swampcompiler "github.com/swamp/compiler/src/compiler"
+	dectype "github.com/swamp/compiler/src/decorated/types"
 	"github.com/swamp/compiler/src/environment"
+	"github.com/swamp/compiler/src/optimize"
 	"github.com/swamp/compiler/src/resourceid"
 	"github.com/swamp/compiler/src/verbosity"
 )
@@ -89,7 +91,7 @@ func ExecuteSwamp(swampCode string) (string, error) {
 
 	gen := generate_sp.NewGenerator()
 	gen.PrepareForNewPackage()
-	_, compileErr := swampcompiler.CompileAndLink(gen, resourceNameLookup, environment.Environment{}, "temp", tempSwampFilename, tempOutputFile, enforceStyle, verbose, showAssembly)
+	_, compileErr := swampcompiler.CompileAndLink(gen, resourceNameLookup, optimize.LevelNone, environment.Environment{}, dectype.DataLayoutLP64, "temp", tempSwampFilename, tempOutputFile, enforceStyle, verbose, showAssembly)
 	if parser.IsCompileError(compileErr) {
 		return "", compileErr
 	}
@@ -127,7 +127,7 @@ func generateFunctionType(irModule *ir.Module, repo *IrTypeRepo, functionType *d
 // Example %1 = bitcast %CustomTypeName* %x to %CustomTypeName_VariantName*
 func generateCustomType(irModule *ir.Module, repo *IrTypeRepo, customType *dectype.CustomTypeAtom) error {
 
-	memSize, _ := dectype.GetMemorySizeAndAlignment(customType)
+	memSize, _ := dectype.GetMemorySizeAndAlignment(customType.DataLayout(), customType)
 	maximumPaddedSize := memSize - 1
 	unionPayloadArray := types.NewArray(uint64(maximumPaddedSize), types.I8)
 	completeUnionStruct := types.NewStruct(types.I8, unionPayloadArray)
@@ -37,11 +37,9 @@ func handleArithmeticMultiple(code *assembler_sp.Code, e *decorated.ArithmeticOp
 	var memoryAlign dectype.MemoryAlign
 	switch {
 	case dectype.IsListLike(e.Left().Type()) && e.OperatorType() == decorated.ArithmeticAppend:
-		memorySize = dectype.MemorySize(opcode_sp_type.Sizeof64BitPointer)
-		memoryAlign = dectype.MemoryAlign(opcode_sp_type.Alignof64BitPointer)
+		memorySize, memoryAlign = genContext.context.dataLayout.PointerSizeAndAlignment()
 	case leftPrimitive != nil && leftPrimitive.AtomName() == "String" && e.OperatorType() == decorated.ArithmeticAppend:
-		memorySize = dectype.MemorySize(opcode_sp_type.Sizeof64BitPointer)
-		memoryAlign = dectype.MemoryAlign(opcode_sp_type.Alignof64BitPointer)
+		memorySize, memoryAlign = genContext.context.dataLayout.PointerSizeAndAlignment()
 	case dectype.IsIntLike(e.Left().Type()):
 		memorySize = dectype.MemorySize(opcode_sp_type.SizeofSwampInt)
 		memoryAlign = dectype.MemoryAlign(opcode_sp_type.AlignOfSwampInt)
@@ -27,7 +27,7 @@ func generateArray(code *assembler_sp.Code, target assembler_sp.TargetStackPosRa
 	}
 	primitive, _ := array.Type().(*dectype.PrimitiveAtom)
 	firstPrimitiveType := primitive.GenericTypes()[0]
-	itemSize, itemAlign := dectype.GetMemorySizeAndAlignment(firstPrimitiveType)
+	itemSize, itemAlign := dectype.GetMemorySizeAndAlignment(genContext.context.dataLayout, firstPrimitiveType)
 
 	filePosition := genContext.toFilePosition(array.FetchPositionLength())
 	code.ArrayLiteral(target.Pos, variables, assembler_sp.StackRange(itemSize), opcode_sp_type.MemoryAlign(itemAlign), filePosition)
@@ -37,7 +37,7 @@ func generateArray(code *assembler_sp.Code, target assembler_sp.TargetStackPosRa
 
 func handleArray(code *assembler_sp.Code,
 	array *decorated.ArrayLiteral, genContext *generateContext) (assembler_sp.SourceStackPosRange, error) {
-	posRange := genContext.context.stackMemory.Allocate(uint(opcode_sp_type.Sizeof64BitPointer), uint32(opcode_sp_type.Alignof64BitPointer), "listLiteral")
+	posRange := allocatePointer(genContext.context, "listLiteral")
 	if err := generateArray(code, posRange, array, genContext); err != nil {
 		return assembler_sp.SourceStackPosRange{}, err
 	}
@@ -6,6 +6,8 @@
 package generate_sp
 
 import (
+	"fmt"
+
 	"github.com/swamp/assembler/lib/assembler_sp"
 	"github.com/swamp/compiler/src/decorated/decshared"
 	"github.com/swamp/compiler/src/decorated/dtype"
@@ -13,14 +15,14 @@ import (
 	"github.com/swamp/opcodes/opcode_sp"
 )
 
-func allocateForType(stackMemory *assembler_sp.StackMemoryMapper, debugName string, variableType dtype.Type) (assembler_sp.SourceStackPosRange, decshared.DecoratedError) {
-	targetPosRange := allocMemoryForType(stackMemory, variableType, debugName)
+func allocateForType(context *Context, debugName string, variableType dtype.Type) (assembler_sp.SourceStackPosRange, decshared.DecoratedError) {
+	targetPosRange := allocMemoryForType(context, variableType, debugName)
 	sourcePosRange := targetToSourceStackPosRange(targetPosRange)
 	return sourcePosRange, nil
 }
 
-func allocateVariable(code *assembler_sp.Code, scopeVariables *assembler_sp.ScopeVariables, stackMemory *assembler_sp.StackMemoryMapper, variableName *decorated.FunctionParameterDefinition, variableType dtype.Type, variableTypeID assembler_sp.TypeID) (assembler_sp.SourceStackPosRange, error) {
-	sourcePosRange, allocErr := allocateForType(stackMemory, "variable:"+variableName.Parameter().Name(), variableType)
+func allocateVariable(code *assembler_sp.Code, context *Context, variableName *decorated.FunctionParameterDefinition, variableType dtype.Type, variableTypeID assembler_sp.TypeID) (assembler_sp.SourceStackPosRange, error) {
+	sourcePosRange, allocErr := allocateForType(context, "variable:"+variableName.Parameter().Name(), variableType)
 	if allocErr != nil {
 		return assembler_sp.SourceStackPosRange{}, allocErr
 	}
@@ -28,7 +30,7 @@ func allocateVariable(code *assembler_sp.Code, scopeVariables *assembler_sp.Scop
 	if !variableName.Parameter().IsIgnore() {
 		startLabel := code.Label("dfsfdj", "fjskdjf")
 		variableTypeString := assembler_sp.TypeString(variableType.HumanReadable())
-		if _, err := scopeVariables.DefineVariable(assembler_sp.VariableName(variableName.Parameter().Name()), sourcePosRange, variableTypeID, variableTypeString, startLabel); err != nil {
+		if _, err := context.scopeVariables.DefineVariable(assembler_sp.VariableName(variableName.Parameter().Name()), sourcePosRange, variableTypeID, variableTypeString, startLabel); err != nil {
 			return assembler_sp.SourceStackPosRange{}, err
 		}
 	}
@@ -46,10 +48,30 @@ func generateCaseCustomType(code *assembler_sp.Code, target assembler_sp.TargetS
 
 	var consequencesCodes []*assembler_sp.Code
 
+	// All labels must be known before generating, since a guard jumps to a later consequence if it fails
+	var consequenceLabels []*assembler_sp.Label
 	for _, consequence := range caseExpr.Consequences() {
+		consequencesCode := assembler_sp.NewCode()
+		labelVariableName := assembler_sp.VariableName(
+			consequence.VariantReference().AstIdentifier().SomeTypeIdentifier().Name())
+		consequenceLabels = append(consequenceLabels, consequencesCode.Label(labelVariableName, "case"))
+		consequencesCodes = append(consequencesCodes, consequencesCode)
+	}
+
+	var defaultCode *assembler_sp.Code
+	var defaultLabel *assembler_sp.Label
+	if caseExpr.DefaultCase() != nil {
+		defaultCode = assembler_sp.NewCode()
+		defaultLabel = defaultCode.Label("default", "default")
+	}
+
+	handledVariants := make(map[int]bool)
+
+	for consequenceIndex, consequence := range caseExpr.Consequences() {
 		consequenceContext := genContext.MakeScopeContext("case")
 
-		consequencesCode := assembler_sp.NewCode()
+		consequencesCode := consequencesCodes[consequenceIndex]
+		caseLabel := consequenceLabels[consequenceIndex]
 
 		fields := consequence.VariantReference().CustomTypeVariant().Fields()
 		for index, param := range consequence.Parameters() {
@@ -71,21 +93,35 @@ func generateCaseCustomType(code *assembler_sp.Code, target assembler_sp.TargetS
 			}
 		}
 
-		labelVariableName := assembler_sp.VariableName(
-			consequence.VariantReference().AstIdentifier().SomeTypeIdentifier().Name())
-
-		caseLabel := consequencesCode.Label(labelVariableName, "case")
+		if consequence.Guard() != nil {
+			guardFailedLabel := defaultLabel
+			for laterIndex, laterConsequence := range caseExpr.Consequences()[consequenceIndex+1:] {
+				if laterConsequence.InternalIndex() == consequence.InternalIndex() {
+					guardFailedLabel = consequenceLabels[consequenceIndex+1+laterIndex]
+					break
+				}
+			}
+			if guardFailedLabel == nil {
+				return fmt.Errorf("guarded case consequence %v must be followed by a consequence for the same variant", consequence)
+			}
+			guardVar, guardErr := generateExpressionWithSourceVar(consequencesCode, consequence.Guard(), consequenceContext, "case-guard")
+			if guardErr != nil {
+				return guardErr
+			}
+			consequencesCode.BranchFalse(guardVar.Pos, guardFailedLabel, genContext.toFilePosition(consequence.Guard().FetchPositionLength()))
+		}
 
 		caseExprErr := generateExpression(consequencesCode, target, consequence.Expression(), false, consequenceContext)
 		if caseExprErr != nil {
 			return caseExprErr
 		}
 
-		asmConsequence := assembler_sp.NewCaseConsequence(uint8(consequence.InternalIndex()), caseLabel)
-
-		consequences = append(consequences, asmConsequence)
-
-		consequencesCodes = append(consequencesCodes, consequencesCode)
+		// Only the first consequence for a variant is jumped to directly, the others are reached when guards fail
+		if !handledVariants[consequence.InternalIndex()] {
+			handledVariants[consequence.InternalIndex()] = true
+			asmConsequence := assembler_sp.NewCaseConsequence(uint8(consequence.InternalIndex()), caseLabel)
+			consequences = append(consequences, asmConsequence)
+		}
 
 		endOfScopeLabel := consequencesCode.Label("end", "end of consequence scope")
 		consequenceContext.context.scopeVariables.StopScope(endOfScopeLabel)
@@ -95,20 +131,18 @@ func generateCaseCustomType(code *assembler_sp.Code, target assembler_sp.TargetS
 	var defaultCase *assembler_sp.CaseConsequence
 
 	if caseExpr.DefaultCase() != nil {
-		consequencesCode := assembler_sp.NewCode()
 		defaultContext := genContext.MakeScopeContext("case default")
 
 		decoratedDefault := caseExpr.DefaultCase()
-		defaultLabel := consequencesCode.Label("default", "default")
-		caseExprErr := generateExpression(consequencesCode, target, decoratedDefault, true, defaultContext)
+		caseExprErr := generateExpression(defaultCode, target, decoratedDefault, true, defaultContext)
 		if caseExprErr != nil {
 			return caseExprErr
 		}
 		defaultCase = assembler_sp.NewCaseConsequence(0xff, defaultLabel)
-		consequencesCodes = append(consequencesCodes, consequencesCode)
+		consequencesCodes = append(consequencesCodes, defaultCode)
 		//		endLabel := consequencesBlockCode.Label(nil, "if-end")
 		// defaultContext.context.Free()
-		endOfScopeLabel := consequencesCode.Label("enddefault", "end of consequence scope")
+		endOfScopeLabel := defaultCode.Label("enddefault", "end of consequence scope")
 		defaultContext.context.scopeVariables.StopScope(endOfScopeLabel)
 	}
 
@@ -140,7 +174,7 @@ func generateCaseCustomType(code *assembler_sp.Code, target assembler_sp.TargetS
 
 func handleCaseCustomType(code *assembler_sp.Code,
 	caseCustomType *decorated.CaseCustomType, genContext *generateContext) (assembler_sp.SourceStackPosRange, error) {
-	posRange := allocMemoryForType(genContext.context.stackMemory, caseCustomType.Type(), "caseCustomTypeLiteral")
+	posRange := allocMemoryForType(genContext.context, caseCustomType.Type(), "caseCustomTypeLiteral")
 	if err := generateCaseCustomType(code, posRange, caseCustomType, genContext); err != nil {
 		return assembler_sp.SourceStackPosRange{}, err
 	}
@@ -22,6 +22,24 @@ const (
 	PatternMatchingTypeString
 )
 
+func patternMatchingIntValue(literal decorated.Expression) (int32, error) {
+	switch t := literal.(type) {
+	case *decorated.IntegerLiteral:
+		return t.Value(), nil
+	case *decorated.CharacterLiteral:
+		return t.Value(), nil
+	case *decorated.ConstantReference:
+		constExpression := t.Constant().AstConstant().Expression()
+		integerLiteral, wasIntegerLiteral := constExpression.(*ast.IntegerLiteral)
+		if !wasIntegerLiteral {
+			return 0, fmt.Errorf("couldnt find a good integer constant")
+		}
+		return integerLiteral.Value(), nil
+	}
+
+	return 0, fmt.Errorf("unsupported int literal or int constant %T", literal)
+}
+
 func generateCasePatternMatchingInt(code *assembler_sp.Code, target assembler_sp.TargetStackPosRange, caseExpr *decorated.CaseForPatternMatching, matchingType PatternMatchingType, genContext *generateContext) error {
 	testVar, testErr := generateExpressionWithSourceVar(code, caseExpr.Test(), genContext, "cast-test")
 	if testErr != nil {
@@ -31,51 +49,63 @@ func generateCasePatternMatchingInt(code *assembler_sp.Code, target assembler_sp
 	var consequences []*assembler_sp.CaseConsequencePatternMatchingInt
 	var consequencesCodes []*assembler_sp.Code
 
+	// All labels must be known before generating, since a guard jumps to a later consequence if it fails
+	var consequenceLabels []*assembler_sp.Label
+	var intValues []int32
 	for _, consequence := range caseExpr.Consequences() {
-		consequenceContext := genContext.MakeScopeContext("case pattern matching")
+		intValue, intValueErr := patternMatchingIntValue(consequence.Literal())
+		if intValueErr != nil {
+			return intValueErr
+		}
+		intValues = append(intValues, intValue)
+
 		consequencesCode := assembler_sp.NewCode()
+		labelVariableName := assembler_sp.VariableName("a1")
+		consequenceLabels = append(consequenceLabels, consequencesCode.Label(labelVariableName, "case"))
+		consequencesCodes = append(consequencesCodes, consequencesCode)
+	}
 
-		intValue := int32(0)
-		intLiteral, wasIntLiteral := consequence.Literal().(*decorated.IntegerLiteral)
-		if wasIntLiteral {
-			intValue = intLiteral.Value()
-		} else {
-			characterLiteral, wasCharacterLiteral := consequence.Literal().(*decorated.CharacterLiteral)
-			if !wasCharacterLiteral {
-				constantReference, wasConstantReference := consequence.Literal().(*decorated.ConstantReference)
-				if !wasConstantReference {
-					return fmt.Errorf("unsupported int literal or int constant %T", consequence.Literal())
-				}
-				constExpression := constantReference.Constant().AstConstant().Expression()
-				integerLiteral, wasIntegerLiteral := constExpression.(*ast.IntegerLiteral)
-				if !wasIntegerLiteral {
-					return fmt.Errorf("couldnt find a good integer constant")
+	defaultCode := assembler_sp.NewCode()
+	defaultLabel := defaultCode.Label("default", "default")
+
+	handledValues := make(map[int32]bool)
+
+	for consequenceIndex, consequence := range caseExpr.Consequences() {
+		consequenceContext := genContext.MakeScopeContext("case pattern matching")
+		consequencesCode := consequencesCodes[consequenceIndex]
+		intValue := intValues[consequenceIndex]
+
+		if consequence.Guard() != nil {
+			guardFailedLabel := defaultLabel
+			for laterIndex, laterValue := range intValues[consequenceIndex+1:] {
+				if laterValue == intValue {
+					guardFailedLabel = consequenceLabels[consequenceIndex+1+laterIndex]
+					break
 				}
-				intValue = integerLiteral.Value()
-			} else {
-				intValue = characterLiteral.Value()
 			}
+			guardVar, guardErr := generateExpressionWithSourceVar(consequencesCode, consequence.Guard(), consequenceContext, "case-guard")
+			if guardErr != nil {
+				return guardErr
+			}
+			consequencesCode.BranchFalse(guardVar.Pos, guardFailedLabel, genContext.toFilePosition(consequence.Guard().FetchPositionLength()))
 		}
 
-		labelVariableName := assembler_sp.VariableName("a1")
-		caseLabel := consequencesCode.Label(labelVariableName, "case")
-
 		caseExprErr := generateExpression(consequencesCode, target, consequence.Expression(), false, consequenceContext)
 		if caseExprErr != nil {
 			return caseExprErr
 		}
 
-		asmConsequence := assembler_sp.NewCaseConsequencePatternMatchingInt(intValue, caseLabel)
-		consequences = append(consequences, asmConsequence)
-
-		consequencesCodes = append(consequencesCodes, consequencesCode)
+		// Only the first consequence for a value is jumped to directly, the others are reached when guards fail
+		if !handledValues[intValue] {
+			handledValues[intValue] = true
+			asmConsequence := assembler_sp.NewCaseConsequencePatternMatchingInt(intValue, consequenceLabels[consequenceIndex])
+			consequences = append(consequences, asmConsequence)
+		}
 	}
 
-	defaultCode := assembler_sp.NewCode()
 	defaultContext := genContext.MakeScopeContext("default case pattern matching")
 
 	decoratedDefault := caseExpr.DefaultCase()
-	defaultLabel := defaultCode.Label("default", "default")
 	caseExprErr := generateExpression(defaultCode, target, decoratedDefault, false, defaultContext)
 	if caseExprErr != nil {
 		return caseExprErr
@@ -138,7 +168,7 @@ func generateCasePatternMatchingMultiple(code *assembler_sp.Code, target assembl
 
 func handleCasePatternMatchingMultiple(code *assembler_sp.Code,
 	caseExpr *decorated.CaseForPatternMatching, genContext *generateContext) (assembler_sp.SourceStackPosRange, error) {
-	posRange := allocMemoryForType(genContext.context.stackMemory, caseExpr.Type(), "casePatternMatchingResult")
+	posRange := allocMemoryForType(genContext.context, caseExpr.Type(), "casePatternMatchingResult")
 	if err := generateCasePatternMatchingMultiple(code, posRange, caseExpr, genContext); err != nil {
 		return assembler_sp.SourceStackPosRange{}, err
 	}
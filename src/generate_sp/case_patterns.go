@@ -0,0 +1,528 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package generate_sp
+
+import (
+	"fmt"
+
+	"github.com/swamp/assembler/lib/assembler_sp"
+	"github.com/swamp/compiler/src/decorated/dtype"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+	"github.com/swamp/opcodes/instruction_sp"
+	"github.com/swamp/opcodes/opcode_sp"
+	opcode_sp_type "github.com/swamp/opcodes/type"
+)
+
+// The case patterns are compiled into a decision tree. Each node tests one occurrence (a part of the
+// test value) and every leaf jumps to the code for a consequence. The consequences are only generated once.
+
+type casePatternOccurrence struct {
+	posRange    assembler_sp.SourceStackPosRange
+	patternType dtype.Type
+}
+
+// casePatternRow is the remaining patterns for a consequence, one for each occurrence. A nil pattern matches anything.
+type casePatternRow struct {
+	patterns    []decorated.CasePattern
+	consequence int
+}
+
+type casePatternCompiler struct {
+	genContext        *generateContext
+	consequenceLabels []*assembler_sp.Label
+	filePosition      opcode_sp.FilePosition
+}
+
+func subOccurrence(occurrence casePatternOccurrence, offset dectype.MemoryOffset, size dectype.MemorySize, subType dtype.Type) casePatternOccurrence {
+	return casePatternOccurrence{
+		posRange: assembler_sp.SourceStackPosRange{
+			Pos:  assembler_sp.SourceStackPos(uint(occurrence.posRange.Pos) + uint(offset)),
+			Size: assembler_sp.SourceStackRange(size),
+		},
+		patternType: subType,
+	}
+}
+
+func stripCasePatternAs(pattern decorated.CasePattern) decorated.CasePattern {
+	for {
+		asPattern, wasAs := pattern.(*decorated.CasePatternAs)
+		if !wasAs {
+			return pattern
+		}
+		pattern = asPattern.Pattern()
+	}
+}
+
+func isIrrefutableCasePattern(pattern decorated.CasePattern) bool {
+	switch t := stripCasePatternAs(pattern).(type) {
+	case nil:
+		return true
+	case *decorated.CasePatternWildcard:
+		return true
+	case *decorated.CasePatternBinding:
+		return true
+	case *decorated.CasePatternRecord:
+		return true
+	case *decorated.CasePatternTuple:
+		for _, subPattern := range t.Patterns() {
+			if !isIrrefutableCasePattern(subPattern) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+func replaceCasePatternColumn(patterns []decorated.CasePattern, column int, replacement []decorated.CasePattern) []decorated.CasePattern {
+	result := append([]decorated.CasePattern{}, patterns[:column]...)
+	result = append(result, replacement...)
+	return append(result, patterns[column+1:]...)
+}
+
+func replaceCasePatternOccurrence(occurrences []casePatternOccurrence, column int, replacement []casePatternOccurrence) []casePatternOccurrence {
+	result := append([]casePatternOccurrence{}, occurrences[:column]...)
+	result = append(result, replacement...)
+	return append(result, occurrences[column+1:]...)
+}
+
+func casePatternLiteralKey(literal decorated.Expression) (string, error) {
+	switch t := literal.(type) {
+	case *decorated.BooleanLiteral:
+		return fmt.Sprintf("%v", t.Value()), nil
+	case *decorated.StringLiteral:
+		return t.Value(), nil
+	}
+
+	intValue, intValueErr := patternMatchingIntValue(literal)
+	if intValueErr != nil {
+		return "", intValueErr
+	}
+
+	return fmt.Sprintf("%v", intValue), nil
+}
+
+// specializeLiteralRows returns the rows that match the literal key in the column, with the column removed.
+func specializeLiteralRows(rows []casePatternRow, column int, key string) ([]casePatternRow, error) {
+	var specializedRows []casePatternRow
+	for _, row := range rows {
+		head := stripCasePatternAs(row.patterns[column])
+		if literalPattern, wasLiteral := head.(*decorated.CasePatternLiteral); wasLiteral {
+			literalKey, keyErr := casePatternLiteralKey(literalPattern.Literal())
+			if keyErr != nil {
+				return nil, keyErr
+			}
+			if literalKey != key {
+				continue
+			}
+		} else if !isIrrefutableCasePattern(head) {
+			return nil, fmt.Errorf("unexpected pattern %v for literal", head)
+		}
+		specializedRows = append(specializedRows, casePatternRow{patterns: replaceCasePatternColumn(row.patterns, column, nil), consequence: row.consequence})
+	}
+
+	return specializedRows, nil
+}
+
+func specializeVariantRows(rows []casePatternRow, column int, variant *dectype.CustomTypeVariantAtom) []casePatternRow {
+	var specializedRows []casePatternRow
+	for _, row := range rows {
+		head := stripCasePatternAs(row.patterns[column])
+		var replacement []decorated.CasePattern
+		if variantPattern, wasVariant := head.(*decorated.CasePatternVariant); wasVariant {
+			if variantPattern.VariantReference().CustomTypeVariant().Name().Name() != variant.Name().Name() {
+				continue
+			}
+			replacement = variantPattern.Arguments()
+		} else {
+			replacement = make([]decorated.CasePattern, variant.ParameterCount())
+		}
+		specializedRows = append(specializedRows, casePatternRow{patterns: replaceCasePatternColumn(row.patterns, column, replacement), consequence: row.consequence})
+	}
+
+	return specializedRows
+}
+
+func defaultCasePatternRows(rows []casePatternRow, column int) []casePatternRow {
+	var remainingRows []casePatternRow
+	for _, row := range rows {
+		if isIrrefutableCasePattern(row.patterns[column]) {
+			remainingRows = append(remainingRows, casePatternRow{patterns: replaceCasePatternColumn(row.patterns, column, nil), consequence: row.consequence})
+		}
+	}
+
+	return remainingRows
+}
+
+func (c *casePatternCompiler) compileTuple(rows []casePatternRow, occurrences []casePatternOccurrence, column int, tupleType *dectype.TupleTypeAtom) (*assembler_sp.Code, *assembler_sp.Label, error) {
+	var fieldOccurrences []casePatternOccurrence
+	for _, field := range tupleType.Fields() {
+		fieldOccurrences = append(fieldOccurrences, subOccurrence(occurrences[column], field.MemoryOffset(), field.MemorySize(), field.Type()))
+	}
+
+	var expandedRows []casePatternRow
+	for _, row := range rows {
+		head := stripCasePatternAs(row.patterns[column])
+		replacement := make([]decorated.CasePattern, len(fieldOccurrences))
+		if tuplePattern, wasTuple := head.(*decorated.CasePatternTuple); wasTuple {
+			replacement = tuplePattern.Patterns()
+		}
+		expandedRows = append(expandedRows, casePatternRow{patterns: replaceCasePatternColumn(row.patterns, column, replacement), consequence: row.consequence})
+	}
+
+	return c.compile(expandedRows, replaceCasePatternOccurrence(occurrences, column, fieldOccurrences))
+}
+
+func (c *casePatternCompiler) compileVariant(rows []casePatternRow, occurrences []casePatternOccurrence, column int, customType *dectype.CustomTypeAtom) (*assembler_sp.Code, *assembler_sp.Label, error) {
+	code := assembler_sp.NewCode()
+	label := code.Label("pattern variant", "decision")
+
+	var consequences []*assembler_sp.CaseConsequence
+	var childCodes []*assembler_sp.Code
+	allVariantsTested := true
+
+	for _, variant := range customType.Variants() {
+		wasTested := false
+		for _, row := range rows {
+			variantPattern, wasVariant := stripCasePatternAs(row.patterns[column]).(*decorated.CasePatternVariant)
+			if wasVariant && variantPattern.VariantReference().CustomTypeVariant().Name().Name() == variant.Name().Name() {
+				wasTested = true
+				break
+			}
+		}
+		if !wasTested {
+			allVariantsTested = false
+			continue
+		}
+
+		var fieldOccurrences []casePatternOccurrence
+		for _, field := range variant.Fields() {
+			fieldOccurrences = append(fieldOccurrences, subOccurrence(occurrences[column], field.MemoryOffset(), field.MemorySize(), field.Type()))
+		}
+
+		childCode, childLabel, childErr := c.compile(specializeVariantRows(rows, column, variant), replaceCasePatternOccurrence(occurrences, column, fieldOccurrences))
+		if childErr != nil {
+			return nil, nil, childErr
+		}
+		consequences = append(consequences, assembler_sp.NewCaseConsequence(uint8(variant.Index()), childLabel))
+		childCodes = append(childCodes, childCode)
+	}
+
+	var defaultCase *assembler_sp.CaseConsequence
+	if !allVariantsTested {
+		childCode, childLabel, childErr := c.compile(defaultCasePatternRows(rows, column), replaceCasePatternOccurrence(occurrences, column, nil))
+		if childErr != nil {
+			return nil, nil, childErr
+		}
+		defaultCase = assembler_sp.NewCaseConsequence(0xff, childLabel)
+		childCodes = append(childCodes, childCode)
+	}
+
+	code.CaseEnum(occurrences[column].posRange.Pos, consequences, defaultCase, c.filePosition)
+	for _, childCode := range childCodes {
+		code.Copy(childCode)
+	}
+
+	return code, label, nil
+}
+
+func (c *casePatternCompiler) compileLiteral(rows []casePatternRow, occurrences []casePatternOccurrence, column int) (*assembler_sp.Code, *assembler_sp.Label, error) {
+	primitiveAtom, wasPrimitive := dectype.UnaliasWithResolveInvoker(occurrences[column].patternType).(*dectype.PrimitiveAtom)
+	if !wasPrimitive {
+		return nil, nil, fmt.Errorf("literal patterns must match a primitive type %v", occurrences[column].patternType)
+	}
+
+	code := assembler_sp.NewCode()
+	label := code.Label("pattern literal", "decision")
+	remainingOccurrences := replaceCasePatternOccurrence(occurrences, column, nil)
+	testPos := occurrences[column].posRange.Pos
+
+	var keys []string
+	literals := make(map[string]decorated.Expression)
+	for _, row := range rows {
+		literalPattern, wasLiteral := stripCasePatternAs(row.patterns[column]).(*decorated.CasePatternLiteral)
+		if !wasLiteral {
+			continue
+		}
+		key, keyErr := casePatternLiteralKey(literalPattern.Literal())
+		if keyErr != nil {
+			return nil, nil, keyErr
+		}
+		if _, alreadyFound := literals[key]; !alreadyFound {
+			keys = append(keys, key)
+			literals[key] = literalPattern.Literal()
+		}
+	}
+
+	var childCodes []*assembler_sp.Code
+	compileKey := func(key string) (*assembler_sp.Label, error) {
+		specializedRows, specializeErr := specializeLiteralRows(rows, column, key)
+		if specializeErr != nil {
+			return nil, specializeErr
+		}
+		childCode, childLabel, childErr := c.compile(specializedRows, remainingOccurrences)
+		if childErr != nil {
+			return nil, childErr
+		}
+		childCodes = append(childCodes, childCode)
+		return childLabel, nil
+	}
+
+	compileDefault := func() (*assembler_sp.Label, error) {
+		childCode, childLabel, childErr := c.compile(defaultCasePatternRows(rows, column), remainingOccurrences)
+		if childErr != nil {
+			return nil, childErr
+		}
+		childCodes = append(childCodes, childCode)
+		return childLabel, nil
+	}
+
+	switch primitiveAtom.PrimitiveName().Name() {
+	case "Bool":
+		trueLabel, trueErr := compileKey("true")
+		if trueErr != nil {
+			return nil, nil, trueErr
+		}
+		falseLabel, falseErr := compileKey("false")
+		if falseErr != nil {
+			return nil, nil, falseErr
+		}
+		code.BranchTrue(testPos, trueLabel, c.filePosition)
+		code.Jump(falseLabel, c.filePosition)
+	case "Int", "Char":
+		var consequences []*assembler_sp.CaseConsequencePatternMatchingInt
+		for _, key := range keys {
+			intValue, intValueErr := patternMatchingIntValue(literals[key])
+			if intValueErr != nil {
+				return nil, nil, intValueErr
+			}
+			childLabel, childErr := compileKey(key)
+			if childErr != nil {
+				return nil, nil, childErr
+			}
+			consequences = append(consequences, assembler_sp.NewCaseConsequencePatternMatchingInt(intValue, childLabel))
+		}
+		defaultLabel, defaultErr := compileDefault()
+		if defaultErr != nil {
+			return nil, nil, defaultErr
+		}
+		code.CasePatternMatchingInt(testPos, consequences, defaultLabel, c.filePosition)
+	case "String":
+		for _, key := range keys {
+			literalVar, literalErr := generateExpressionWithSourceVar(code, literals[key], c.genContext, "pattern-string")
+			if literalErr != nil {
+				return nil, nil, literalErr
+			}
+			wasEqual := c.genContext.context.stackMemory.Allocate(uint(opcode_sp_type.SizeofSwampBool), uint32(opcode_sp_type.AlignOfSwampBool), "patternStringEqual")
+			code.StringBinaryOperator(wasEqual.Pos, testPos, literalVar.Pos, instruction_sp.BinaryOperatorBooleanStringEqual, c.filePosition)
+			childLabel, childErr := compileKey(key)
+			if childErr != nil {
+				return nil, nil, childErr
+			}
+			code.BranchTrue(targetToSourceStackPosRange(wasEqual).Pos, childLabel, c.filePosition)
+		}
+		defaultLabel, defaultErr := compileDefault()
+		if defaultErr != nil {
+			return nil, nil, defaultErr
+		}
+		code.Jump(defaultLabel, c.filePosition)
+	default:
+		return nil, nil, fmt.Errorf("not supported pattern matching type %v", primitiveAtom.PrimitiveName())
+	}
+
+	for _, childCode := range childCodes {
+		code.Copy(childCode)
+	}
+
+	return code, label, nil
+}
+
+func (c *casePatternCompiler) compile(rows []casePatternRow, occurrences []casePatternOccurrence) (*assembler_sp.Code, *assembler_sp.Label, error) {
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("case patterns are not exhaustive")
+	}
+
+	column := -1
+	for index, pattern := range rows[0].patterns {
+		if !isIrrefutableCasePattern(pattern) {
+			column = index
+			break
+		}
+	}
+
+	if column == -1 {
+		code := assembler_sp.NewCode()
+		label := code.Label("pattern match", "decision")
+		code.Jump(c.consequenceLabels[rows[0].consequence], c.filePosition)
+		return code, label, nil
+	}
+
+	switch t := stripCasePatternAs(rows[0].patterns[column]).(type) {
+	case *decorated.CasePatternTuple:
+		return c.compileTuple(rows, occurrences, column, t.TupleType())
+	case *decorated.CasePatternVariant:
+		return c.compileVariant(rows, occurrences, column, t.VariantReference().CustomTypeVariant().InCustomType())
+	case *decorated.CasePatternLiteral:
+		return c.compileLiteral(rows, occurrences, column)
+	}
+
+	return nil, nil, fmt.Errorf("unsupported case pattern %v", rows[0].patterns[column])
+}
+
+func defineCasePatternVariable(parameter *decorated.CaseConsequenceParameterForCustomType, posRange assembler_sp.SourceStackPosRange, context *generateContext, validFromLabel *assembler_sp.Label) error {
+	typeID, lookupErr := context.lookup.Lookup(parameter.Type())
+	if lookupErr != nil {
+		return lookupErr
+	}
+	typeString := assembler_sp.TypeString(parameter.Type().HumanReadable())
+	_, defineErr := context.context.scopeVariables.DefineVariable(assembler_sp.VariableName(parameter.Identifier().Name()), posRange, assembler_sp.TypeID(typeID), typeString, validFromLabel)
+
+	return defineErr
+}
+
+// defineCasePatternBindings defines all the names bound by the pattern as variables in the consequence scope.
+func defineCasePatternBindings(pattern decorated.CasePattern, occurrence casePatternOccurrence, context *generateContext, validFromLabel *assembler_sp.Label) error {
+	switch t := pattern.(type) {
+	case *decorated.CasePatternBinding:
+		return defineCasePatternVariable(t.Parameter(), occurrence.posRange, context, validFromLabel)
+	case *decorated.CasePatternAs:
+		if err := defineCasePatternVariable(t.Parameter(), occurrence.posRange, context, validFromLabel); err != nil {
+			return err
+		}
+		return defineCasePatternBindings(t.Pattern(), occurrence, context, validFromLabel)
+	case *decorated.CasePatternVariant:
+		fields := t.VariantReference().CustomTypeVariant().Fields()
+		for index, argument := range t.Arguments() {
+			field := fields[index]
+			if err := defineCasePatternBindings(argument, subOccurrence(occurrence, field.MemoryOffset(), field.MemorySize(), field.Type()), context, validFromLabel); err != nil {
+				return err
+			}
+		}
+	case *decorated.CasePatternTuple:
+		fields := t.TupleType().Fields()
+		for index, subPattern := range t.Patterns() {
+			field := fields[index]
+			if err := defineCasePatternBindings(subPattern, subOccurrence(occurrence, field.MemoryOffset(), field.MemorySize(), field.Type()), context, validFromLabel); err != nil {
+				return err
+			}
+		}
+	case *decorated.CasePatternRecord:
+		for _, recordField := range t.Fields() {
+			field := recordField.Field()
+			fieldOccurrence := subOccurrence(occurrence, field.MemoryOffset(), field.MemorySize(), field.Type())
+			if err := defineCasePatternVariable(recordField.Parameter(), fieldOccurrence.posRange, context, validFromLabel); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func generateCasePatterns(code *assembler_sp.Code, target assembler_sp.TargetStackPosRange, caseExpr *decorated.CaseForPatterns, genContext *generateContext) error {
+	testVar, testErr := generateExpressionWithSourceVar(code, caseExpr.Test(), genContext, "case-patterns-test")
+	if testErr != nil {
+		return testErr
+	}
+
+	testOccurrence := casePatternOccurrence{posRange: testVar, patternType: caseExpr.Test().Type()}
+
+	var consequenceLabels []*assembler_sp.Label
+	var consequencesCodes []*assembler_sp.Code
+	var rows []casePatternRow
+
+	for index, consequence := range caseExpr.Consequences() {
+		consequencesCode := assembler_sp.NewCode()
+		consequenceLabels = append(consequenceLabels, consequencesCode.Label("pattern consequence", "case"))
+		consequencesCodes = append(consequencesCodes, consequencesCode)
+		rows = append(rows, casePatternRow{patterns: []decorated.CasePattern{consequence.Pattern()}, consequence: index})
+	}
+
+	compiler := &casePatternCompiler{
+		genContext: genContext, consequenceLabels: consequenceLabels,
+		filePosition: genContext.toFilePosition(caseExpr.Test().FetchPositionLength()),
+	}
+
+	decisionCode, _, decisionErr := compiler.compile(rows, []casePatternOccurrence{testOccurrence})
+	if decisionErr != nil {
+		return decisionErr
+	}
+
+	var fallbackCodes []*assembler_sp.Code
+
+	for index, consequence := range caseExpr.Consequences() {
+		consequenceContext := genContext.MakeScopeContext("case patterns")
+		consequencesCode := consequencesCodes[index]
+		caseLabel := consequenceLabels[index]
+
+		if err := defineCasePatternBindings(consequence.Pattern(), testOccurrence, consequenceContext, caseLabel); err != nil {
+			return err
+		}
+
+		if consequence.Guard() != nil {
+			// If the guard fails, the matching continues with the consequences below. The unguarded consequences
+			// above can never match at this point, but are included so the fallback decision tree is exhaustive.
+			var fallbackRows []casePatternRow
+			for _, row := range rows[:index] {
+				if caseExpr.Consequences()[row.consequence].Guard() == nil {
+					fallbackRows = append(fallbackRows, row)
+				}
+			}
+			fallbackRows = append(fallbackRows, rows[index+1:]...)
+
+			fallbackCode, fallbackLabel, fallbackErr := compiler.compile(fallbackRows, []casePatternOccurrence{testOccurrence})
+			if fallbackErr != nil {
+				return fallbackErr
+			}
+			fallbackCodes = append(fallbackCodes, fallbackCode)
+
+			guardVar, guardErr := generateExpressionWithSourceVar(consequencesCode, consequence.Guard(), consequenceContext, "case-guard")
+			if guardErr != nil {
+				return guardErr
+			}
+			consequencesCode.BranchFalse(guardVar.Pos, fallbackLabel, genContext.toFilePosition(consequence.Guard().FetchPositionLength()))
+		}
+
+		caseExprErr := generateExpression(consequencesCode, target, consequence.Expression(), false, consequenceContext)
+		if caseExprErr != nil {
+			return caseExprErr
+		}
+
+		endOfScopeLabel := consequencesCode.Label("end", "end of consequence scope")
+		consequenceContext.context.scopeVariables.StopScope(endOfScopeLabel)
+	}
+
+	lastConsequence := consequencesCodes[len(consequencesCodes)-1]
+	endLabel := lastConsequence.Label("case end", "caseend")
+
+	for index, consequenceCode := range consequencesCodes {
+		if index != len(consequencesCodes)-1 {
+			consequenceCode.Jump(endLabel, opcode_sp.FilePosition{})
+		}
+	}
+
+	code.Copy(decisionCode)
+	for _, fallbackCode := range fallbackCodes {
+		code.Copy(fallbackCode)
+	}
+	for _, consequenceCode := range consequencesCodes {
+		code.Copy(consequenceCode)
+	}
+
+	return nil
+}
+
+func handleCasePatterns(code *assembler_sp.Code,
+	caseExpr *decorated.CaseForPatterns, genContext *generateContext) (assembler_sp.SourceStackPosRange, error) {
+	posRange := allocMemoryForType(genContext.context, caseExpr.Type(), "casePatternsResult")
+	if err := generateCasePatterns(code, posRange, caseExpr, genContext); err != nil {
+		return assembler_sp.SourceStackPosRange{}, err
+	}
+
+	return targetToSourceStackPosRange(posRange), nil
+}
@@ -8,46 +8,111 @@ package generate_sp
 import (
 	"github.com/swamp/assembler/lib/assembler_sp"
 	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
 )
 
 type Context struct {
 	constants      *assembler_sp.PackageConstants
 	scopeVariables *assembler_sp.ScopeVariables
 	stackMemory    *assembler_sp.StackMemoryMapper
+	frame          *stackFrame
 	inFunction     *decorated.FunctionValue
+	functionName   string
+	definitions    *definitionGraph
+	dataLayout     dectype.DataLayout
 }
 
-func NewContext(packageConstants *assembler_sp.PackageConstants, debugString string) *Context {
+func NewContext(packageConstants *assembler_sp.PackageConstants, dataLayout dectype.DataLayout, debugString string) *Context {
 	return &Context{
 		constants:      packageConstants,
+		dataLayout:     dataLayout,
 		inFunction:     nil,
 		scopeVariables: assembler_sp.NewFunctionVariables(debugString),
 		stackMemory:    assembler_sp.NewStackMemoryMapper(32 * 1024),
+		frame:          &stackFrame{},
 	}
 }
 
 func (c *Context) MakeScopeContext(debugString string) *Context {
 	newContext := &Context{
 		constants:      c.constants,
+		definitions:    c.definitions,
+		dataLayout:     c.dataLayout,
 		inFunction:     c.inFunction,
+		functionName:   c.functionName,
 		scopeVariables: assembler_sp.NewFunctionVariablesWithParent(c.scopeVariables, debugString),
 		stackMemory:    c.stackMemory,
+		frame:          c.frame,
 	}
 
 	return newContext
 }
 
-func (c *Context) MakeFunctionContext(inFunction *decorated.FunctionValue, debugString string) *Context {
+func (c *Context) MakeFunctionContext(inFunction *decorated.FunctionValue, fullyQualifiedFunctionName string) *Context {
 	newContext := &Context{
 		constants:      c.constants,
+		definitions:    c.definitions,
+		dataLayout:     c.dataLayout,
 		inFunction:     inFunction,
+		functionName:   fullyQualifiedFunctionName,
+		scopeVariables: assembler_sp.NewFunctionVariables(fullyQualifiedFunctionName),
+		stackMemory:    assembler_sp.NewStackMemoryMapper(32 * 1024),
+		frame:          &stackFrame{},
+	}
+
+	return newContext
+}
+
+// MakeLambdaContext creates a context for a lifted lambda. functionName is the name of the
+// function that the lambda was defined in, since all nested lambdas are named after it.
+func (c *Context) MakeLambdaContext(functionName string, debugString string) *Context {
+	newContext := &Context{
+		constants:      c.constants,
+		definitions:    c.definitions,
+		dataLayout:     c.dataLayout,
+		inFunction:     nil,
+		functionName:   functionName,
 		scopeVariables: assembler_sp.NewFunctionVariables(debugString),
 		stackMemory:    assembler_sp.NewStackMemoryMapper(32 * 1024),
+		frame:          &stackFrame{},
 	}
 
 	return newContext
 }
 
+// stackPosition returns the current position of the stack memory. The memory that is allocated after it can be
+// reused with releaseStackMemory.
+func (c *Context) stackPosition() assembler_sp.TargetStackPos {
+	return c.stackMemory.Tell()
+}
+
+// releaseStackMemory rewinds the stack memory to a position that was returned by stackPosition. Nothing that was
+// allocated after that position may be used after the call.
+func (c *Context) releaseStackMemory(pos assembler_sp.TargetStackPos) {
+	c.frame.reached(c.stackMemory.Tell())
+	c.stackMemory.Set(pos)
+}
+
+// stackFrameOctets returns the peak size of the stack memory in the function so far.
+func (c *Context) stackFrameOctets() uint {
+	c.frame.reached(c.stackMemory.Tell())
+
+	return uint(c.frame.peak)
+}
+
+// FindFunction finds the prepared function and records that the function that is generated refers to it.
+func (c *Context) FindFunction(name assembler_sp.VariableName) *assembler_sp.Constant {
+	c.addReference(string(name))
+
+	return c.constants.FindFunction(name)
+}
+
+func (c *Context) addReference(fullyQualifiedName string) {
+	if c.definitions != nil {
+		c.definitions.addReference(c.functionName, fullyQualifiedName)
+	}
+}
+
 func (c *Context) Constants() *assembler_sp.PackageConstants {
 	return c.constants
 }
@@ -11,7 +11,6 @@ import (
 	"github.com/swamp/assembler/lib/assembler_sp"
 	decorated "github.com/swamp/compiler/src/decorated/expression"
 	dectype "github.com/swamp/compiler/src/decorated/types"
-	opcode_sp_type "github.com/swamp/opcodes/type"
 )
 
 func generateCurry(code *assembler_sp.Code, target assembler_sp.TargetStackPosRange, call *decorated.CurryFunction,
@@ -20,7 +19,7 @@ func generateCurry(code *assembler_sp.Code, target assembler_sp.TargetStackPosRa
 		panic(fmt.Errorf("we can not call functions that has local types %v", call.FunctionAtom()))
 	}
 
-	beforePos := genContext.context.stackMemory.Tell()
+	beforePos := genContext.context.stackPosition()
 
 	functionRegister, functionGenErr := generateExpressionWithSourceVar(code,
 		call.FunctionValue(), genContext, "functioncall")
@@ -37,18 +36,18 @@ func generateCurry(code *assembler_sp.Code, target assembler_sp.TargetStackPosRa
 
 	genContext.context.stackMemory.AlignUpForMax()
 
-	allocMemoryForType(genContext.context.stackMemory, invokedReturnType, "curry return")
+	allocMemoryForType(genContext.context, invokedReturnType, "curry return")
 
 	arguments := make([]assembler_sp.TargetStackPosRange, len(call.ArgumentsToSave()))
 	for index, arg := range call.ArgumentsToSave() {
-		arguments[index] = allocMemoryForType(genContext.context.stackMemory, arg.Type(), fmt.Sprintf("arg %d", index))
+		arguments[index] = allocMemoryForType(genContext.context, arg.Type(), fmt.Sprintf("arg %d", index))
 	}
 
 	if len(call.ArgumentsToSave()) == 0 {
 		return fmt.Errorf("you must have arguments to save to create a curry function")
 	}
 
-	_, firstAlign := dectype.GetMemorySizeAndAlignment(call.ArgumentsToSave()[0].Type())
+	_, firstAlign := dectype.GetMemorySizeAndAlignment(genContext.context.dataLayout, call.ArgumentsToSave()[0].Type())
 
 	for index, arg := range call.ArgumentsToSave() {
 		argReg := arguments[index]
@@ -67,14 +66,14 @@ func generateCurry(code *assembler_sp.Code, target assembler_sp.TargetStackPosRa
 	filePosition := genContext.toFilePosition(call.FetchPositionLength())
 	code.Curry(target.Pos, uint16(indexIntoTypeInformationChunk), assembler_sp.MemoryAlign(firstAlign), functionRegister.Pos, completeArgumentRange, filePosition)
 
-	genContext.context.stackMemory.Set(beforePos)
+	genContext.context.releaseStackMemory(beforePos)
 
 	return nil
 }
 
 func handleCurry(code *assembler_sp.Code, call *decorated.CurryFunction,
 	genContext *generateContext) (assembler_sp.SourceStackPosRange, error) {
-	targetPosRange := genContext.context.stackMemory.Allocate(uint(opcode_sp_type.Sizeof64BitPointer), uint32(opcode_sp_type.Alignof64BitPointer), "")
+	targetPosRange := allocatePointer(genContext.context, "")
 
 	if err := generateCurry(code, targetPosRange, call, genContext); err != nil {
 		return assembler_sp.SourceStackPosRange{}, err
@@ -19,7 +19,7 @@ func generateCustomTypeVariantConstructor(code *assembler_sp.Code, target assemb
 	unaliasedTypeVariant := dectype.UnaliasWithResolveInvoker(constructor.Type())
 	smashedVariant := unaliasedTypeVariant.(*dectype.CustomTypeVariantAtom)
 
-	variantMemorySize, _ := dectype.GetMemorySizeAndAlignment(smashedVariant.InCustomType())
+	variantMemorySize, _ := dectype.GetMemorySizeAndAlignment(genContext.context.dataLayout, smashedVariant.InCustomType())
 	if uint(variantMemorySize) > uint(target.Size) {
 		log.Printf("smashedVariant:%v\n\nsmashedCustomType:%v\n\n", smashedVariant, smashedVariant.InCustomType())
 		return fmt.Errorf("internal error, target size is not exactly right at %v, target is:%v and unionMemorySize is:%v", constructor.FetchPositionLength().ToCompleteReferenceString(), target.Size, variantMemorySize)
@@ -48,7 +48,7 @@ func handleCustomTypeVariantConstructor(code *assembler_sp.Code,
 	customTypeVariantConstructor *decorated.CustomTypeVariantConstructor, genContext *generateContext) (assembler_sp.SourceStackPosRange, error) {
 	unaliasedTypeVariant := dectype.UnaliasWithResolveInvoker(customTypeVariantConstructor.Type())
 	smashedVariant := unaliasedTypeVariant.(*dectype.CustomTypeVariantAtom)
-	posRange := allocMemoryForType(genContext.context.stackMemory, smashedVariant.InCustomType(), "variant constructor target")
+	posRange := allocMemoryForType(genContext.context, smashedVariant.InCustomType(), "variant constructor target")
 	if err := generateCustomTypeVariantConstructor(code, posRange, customTypeVariantConstructor, genContext); err != nil {
 		return assembler_sp.SourceStackPosRange{}, err
 	}
@@ -0,0 +1,191 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package generate_sp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/swamp/compiler/src/ast"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+	"github.com/swamp/compiler/src/loader"
+	"github.com/swamp/compiler/src/token"
+)
+
+type DefinitionKind uint8
+
+const (
+	DefinitionKindFunction DefinitionKind = iota
+	DefinitionKindExternalFunction
+	DefinitionKindConstant
+)
+
+func (k DefinitionKind) String() string {
+	switch k {
+	case DefinitionKindFunction:
+		return "function"
+	case DefinitionKindExternalFunction:
+		return "external function"
+	case DefinitionKindConstant:
+		return "constant"
+	}
+
+	panic(fmt.Errorf("unknown definition kind %d", k))
+}
+
+// DefaultDeadCodeRoots are the definitions in the main module that are always kept, since the runtime starts the program with them.
+var DefaultDeadCodeRoots = []string{"main", "init"}
+
+// GeneratedDefinition is a function or constant that was generated. A function includes its lambdas and the
+// opcode octets are for all of them.
+type GeneratedDefinition struct {
+	Name         string
+	Kind         DefinitionKind
+	OpcodeOctets int
+}
+
+// definitionGraph records the definitions that are generated and the definitions that each of them refers to.
+// A lambda is generated with the function that it is defined in, so the references in the lambda are recorded for that function.
+type definitionGraph struct {
+	definitions map[string]*GeneratedDefinition
+	references  map[string][]string
+}
+
+func newDefinitionGraph() *definitionGraph {
+	return &definitionGraph{definitions: make(map[string]*GeneratedDefinition), references: make(map[string][]string)}
+}
+
+func (g *definitionGraph) addDefinition(name string, kind DefinitionKind) {
+	g.definitions[name] = &GeneratedDefinition{Name: name, Kind: kind}
+}
+
+func (g *definitionGraph) addReference(from string, to string) {
+	g.references[from] = append(g.references[from], to)
+}
+
+func (g *definitionGraph) addOpcodeOctets(name string, octetCount int) {
+	if definition := g.definitions[name]; definition != nil {
+		definition.OpcodeOctets += octetCount
+	}
+}
+
+// reachable returns all definitions that can be reached from the roots.
+func (g *definitionGraph) reachable(roots []string) map[string]bool {
+	found := make(map[string]bool)
+	queue := append([]string(nil), roots...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if found[name] || g.definitions[name] == nil {
+			continue
+		}
+		found[name] = true
+		queue = append(queue, g.references[name]...)
+	}
+
+	return found
+}
+
+// definitionFilter is the set of definitions that are prepared and generated. A nil filter includes all definitions.
+type definitionFilter map[string]bool
+
+func (f definitionFilter) includes(fullyQualifiedName string) bool {
+	return f == nil || f[fullyQualifiedName]
+}
+
+// mainModule is the module in the package root, `Main.swamp`, that has the entry points. The package root has no module namespace.
+func mainModule(compilePackage *loader.Package) *decorated.Module {
+	return compilePackage.FindModule(dectype.MakeArtifactFullyQualifiedModuleName(nil))
+}
+
+// deadCodeRoots returns the fully qualified names of the roots that are defined. Roots in the settings must be defined,
+// but the default roots are optional.
+func deadCodeRoots(compilePackage *loader.Package, graph *definitionGraph, configuredRoots []string) ([]string, error) {
+	var roots []string
+
+	if main := mainModule(compilePackage); main != nil {
+		for _, defaultRoot := range DefaultDeadCodeRoots {
+			name := main.FullyQualifiedModuleName().JoinLocalName(ast.NewVariableIdentifier(token.NewVariableSymbolToken(defaultRoot, token.SourceFileReference{}, 0)))
+			if graph.definitions[name] != nil {
+				roots = append(roots, name)
+			}
+		}
+	}
+
+	for _, configuredRoot := range configuredRoots {
+		if graph.definitions[configuredRoot] == nil {
+			return nil, fmt.Errorf("dead code root '%v' is not a function or constant in package '%v'", configuredRoot, compilePackage.Name())
+		}
+		roots = append(roots, configuredRoot)
+	}
+
+	return roots, nil
+}
+
+// DeadCodeReport describes what was removed by the dead code elimination. Constants are not written to the pack,
+// their values are used where they are referenced, so the unused constants are only listed and are not in Removed.
+type DeadCodeReport struct {
+	PackageName         string
+	Roots               []string
+	NoRoots             bool
+	Removed             []GeneratedDefinition
+	UnusedConstants     []string
+	TypeInfoCountBefore int
+	TypeInfoCountAfter  int
+	DynamicOctetsBefore int
+	DynamicOctetsAfter  int
+	PackOctetsBefore    int
+	PackOctetsAfter     int
+}
+
+func newDeadCodeReport(packageName string, graph *definitionGraph, roots []string, reachable map[string]bool) *DeadCodeReport {
+	report := &DeadCodeReport{PackageName: packageName, Roots: roots}
+	for name, definition := range graph.definitions {
+		if reachable[name] {
+			continue
+		}
+		if definition.Kind == DefinitionKindConstant {
+			report.UnusedConstants = append(report.UnusedConstants, name)
+		} else {
+			report.Removed = append(report.Removed, *definition)
+		}
+	}
+
+	sort.Slice(report.Removed, func(i, j int) bool {
+		return report.Removed[i].Name < report.Removed[j].Name
+	})
+	sort.Strings(report.UnusedConstants)
+
+	return report
+}
+
+func (r *DeadCodeReport) String() string {
+	if r.NoRoots {
+		return fmt.Sprintf("dead code in '%v': no roots found, nothing was removed", r.PackageName)
+	}
+
+	var lines []string
+
+	lines = append(lines, fmt.Sprintf("dead code in '%v' (roots: %v)", r.PackageName, strings.Join(r.Roots, ", ")))
+	for _, removed := range r.Removed {
+		if removed.Kind == DefinitionKindFunction {
+			lines = append(lines, fmt.Sprintf("  removed %v '%v' (%d octets of opcodes)", removed.Kind, removed.Name, removed.OpcodeOctets))
+		} else {
+			lines = append(lines, fmt.Sprintf("  removed %v '%v'", removed.Kind, removed.Name))
+		}
+	}
+	for _, unusedConstant := range r.UnusedConstants {
+		lines = append(lines, fmt.Sprintf("  unused constant '%v' (constants are not in the pack)", unusedConstant))
+	}
+
+	lines = append(lines, fmt.Sprintf("  type information: %d -> %d entries", r.TypeInfoCountBefore, r.TypeInfoCountAfter))
+	lines = append(lines, fmt.Sprintf("  constants and strings: %d -> %d octets", r.DynamicOctetsBefore, r.DynamicOctetsAfter))
+	lines = append(lines, fmt.Sprintf("  pack: %d -> %d octets (%d octets saved)", r.PackOctetsBefore, r.PackOctetsAfter, r.PackOctetsBefore-r.PackOctetsAfter))
+
+	return strings.Join(lines, "\n")
+}
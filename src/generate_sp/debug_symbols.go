@@ -0,0 +1,115 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package generate_sp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	raff "github.com/piot/raff-go/src"
+	"github.com/swamp/assembler/lib/assembler_sp"
+	"github.com/swamp/compiler/src/packinspect"
+	"github.com/swamp/compiler/src/pdb"
+	"github.com/swamp/opcodes/opcode_sp"
+)
+
+// buildIDOctetCount is the number of octets of the sha256 hash that is used as build ID.
+const buildIDOctetCount = 16
+
+// debugSymbols collects the debug information that is left out of a stripped pack, so that it can be written to the
+// `.swamp-pdb` instead.
+type debugSymbols struct {
+	functions []*pdb.Function
+	file      *pdb.File
+}
+
+func (s *debugSymbols) addFunction(name string, opcodeOctetCount int, debugLines []opcode_sp.OpcodeInfo, variables []opcode_sp.VariableInfo) error {
+	if opcodeOctetCount > math.MaxUint16 {
+		return fmt.Errorf("function '%v' has too many opcode octets (%d) for the debug information", name, opcodeOctetCount)
+	}
+
+	lineOctets, serializeErr := opcode_sp.SerializeDebugLines(debugLines)
+	if serializeErr != nil {
+		return serializeErr
+	}
+
+	lines, decodeErr := packinspect.DecodeDebugLines(lineOctets)
+	if decodeErr != nil {
+		return decodeErr
+	}
+
+	function := &pdb.Function{Name: name, OpcodeOctetCount: uint16(opcodeOctetCount), DebugLines: lines, Variables: []pdb.Variable{}}
+	for _, variable := range variables {
+		function.Variables = append(function.Variables, pdb.Variable{
+			Name:                variable.Name,
+			StartOpcodePosition: uint16(variable.StartOpcodePosition),
+			EndOpcodePosition:   uint16(variable.EndOpcodePosition),
+			ScopeID:             variable.ScopeID,
+			TypeID:              variable.TypeID,
+			StackPosition:       uint32(variable.StackPositionRange.Position),
+			StackSize:           uint32(variable.StackPositionRange.Range),
+		})
+	}
+
+	s.functions = append(s.functions, function)
+
+	return nil
+}
+
+// clearFunctionDebugLines sets the debug lines of the function struct to a null pointer. The struct is allocated with
+// the opcode pointer in the place of the debug lines, and a zero sized debug lines struct can not be allocated.
+// The debug scopes are already null if they are never defined.
+func clearFunctionDebugLines(constants *assembler_sp.PackageConstants, funcConstant *assembler_sp.Constant) {
+	var nullPointerAndSize [16]byte
+	position := assembler_sp.SourceDynamicMemoryPos(uint(funcConstant.PosRange().Position) + assembler_sp.SwampFuncDebugLinesOffset)
+	constants.DynamicMemory().Overwrite(position, nullPointerAndSize[:], "stripped debug lines")
+}
+
+// strip creates the `.swamp-pdb` content for the stripped pack and adds a `bid0` chunk with the build ID to the pack.
+// The build ID is a hash of the stripped pack and the debug information, so the same input always gets the same ID.
+func (s *debugSymbols) strip(packOctets []byte, fileUrls []*assembler_sp.FileUrl) ([]byte, error) {
+	file := &pdb.File{Version: pdb.Version, FileUrls: []string{}, Functions: s.functions}
+	for _, fileUrl := range fileUrls {
+		file.FileUrls = append(file.FileUrls, fileUrl.File)
+	}
+
+	debugOctets, marshalErr := json.Marshal(file)
+	if marshalErr != nil {
+		return nil, marshalErr
+	}
+
+	hash := sha256.New()
+	hash.Write(packOctets)
+	hash.Write(debugOctets)
+	buildID := hash.Sum(nil)[:buildIDOctetCount]
+
+	file.BuildID = hex.EncodeToString(buildID)
+	s.file = file
+
+	buf := bytes.NewBuffer(append([]byte(nil), packOctets...))
+	if err := raff.WriteChunk(buf, raff.MakeFourOctets(0xF0, 0x9F, 0x86, 0x94), raff.MakeFourOctets('b', 'i', 'd', '0'), buildID); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeFile writes the `.swamp-pdb` for the last stripped pack.
+func (s *debugSymbols) writeFile(filename string, packageName string) error {
+	s.file.Package = packageName
+
+	var buf bytes.Buffer
+	if err := pdb.Write(&buf, s.file); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, buf.Bytes(), 0o644)
+}
@@ -12,7 +12,13 @@ import (
 	decorated "github.com/swamp/compiler/src/decorated/expression"
 )
 
+// generateExpression generates the expression into a target that is already allocated. The memory for the let
+// variables and temporaries of the expression is not used after the result is in the target, so it is released
+// and reused by the expressions after it, e.g. the next branch of an `if` or `case`.
 func generateExpression(code *assembler_sp.Code, target assembler_sp.TargetStackPosRange, expr decorated.Expression, leafNode bool, genContext *generateContext) error {
+	stackPos := genContext.context.stackPosition()
+	defer genContext.context.releaseStackMemory(stackPos)
+
 	switch e := expr.(type) {
 	case *decorated.Let:
 		return generateLet(code, target, e, genContext)
@@ -53,6 +59,9 @@ func generateExpression(code *assembler_sp.Code, target assembler_sp.TargetStack
 	case *decorated.CaseForPatternMatching:
 		return generateCasePatternMatchingMultiple(code, target, e, genContext)
 
+	case *decorated.CaseForPatterns:
+		return generateCasePatterns(code, target, e, genContext)
+
 	case *decorated.RecordLiteral:
 		return generateRecordLiteral(code, target, e, genContext)
 
@@ -101,6 +110,9 @@ func generateExpression(code *assembler_sp.Code, target assembler_sp.TargetStack
 	case *decorated.CurryFunction:
 		return generateCurry(code, target, e, genContext)
 
+	case *decorated.Lambda:
+		return generateLambda(code, target, e, genContext)
+
 	case *decorated.StringInterpolation:
 		return generateExpression(code, target, e.Expression(), leafNode, genContext)
 
@@ -111,6 +123,7 @@ func generateExpression(code *assembler_sp.Code, target assembler_sp.TargetStack
 		return generateConstant(code, target, e, genContext)
 
 	case *decorated.ConstantReference:
+		genContext.context.addReference(e.NameReference().FullyQualifiedName())
 		return generateExpression(code, target, e.Constant(), leafNode, genContext)
 
 	case *decorated.FunctionParameterReference:
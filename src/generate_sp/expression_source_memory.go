@@ -20,7 +20,7 @@ func generateExpressionWithSourceVar(code *assembler_sp.Code, expr decorated.Exp
 	case *decorated.StringLiteral:
 		constant := genContext.context.Constants().AllocateStringConstant(t.Value())
 
-		return constantToSourceStackPosRange(code, genContext.context.stackMemory, constant)
+		return constantToSourceStackPosRange(code, genContext.context, constant)
 
 	case *decorated.TypeIdLiteral:
 		{
@@ -70,6 +70,9 @@ func generateExpressionWithSourceVar(code *assembler_sp.Code, expr decorated.Exp
 			return targetToSourceStackPosRange(boolStorage), nil
 		}
 	case *decorated.LetVariableReference:
+		if instance := t.Instance(); instance != nil {
+			return handleLambda(code, instance, genContext)
+		}
 		letVariableReferenceName := t.LetVariable().Name().Name()
 
 		return genContext.context.scopeVariables.FindVariable(letVariableReferenceName)
@@ -78,6 +81,7 @@ func generateExpressionWithSourceVar(code *assembler_sp.Code, expr decorated.Exp
 
 		return genContext.context.scopeVariables.FindVariable(parameterReferenceName)
 	case *decorated.ConstantReference:
+		genContext.context.addReference(t.NameReference().FullyQualifiedName())
 		return generateExpressionWithSourceVar(code, t.Constant(), genContext, "constant reference")
 	case *decorated.Constant:
 		return generateExpressionWithSourceVar(code, t.Expression(), genContext, "constant")
@@ -88,7 +92,7 @@ func generateExpressionWithSourceVar(code *assembler_sp.Code, expr decorated.Exp
 
 		return genContext.context.scopeVariables.FindVariable(parameterReferenceName)
 	case *decorated.FunctionReference:
-		return handleFunctionReference(code, t, genContext.context.stackMemory, genContext.context.constants)
+		return handleFunctionReference(code, t, genContext.context)
 	case *decorated.FunctionCall:
 		return handleFunctionCall(code, t, false, genContext)
 	case *decorated.RecordLiteral:
@@ -117,6 +121,8 @@ func generateExpressionWithSourceVar(code *assembler_sp.Code, expr decorated.Exp
 		return handleBitwise(code, t, genContext)
 	case *decorated.CurryFunction:
 		return handleCurry(code, t, genContext)
+	case *decorated.Lambda:
+		return handleLambda(code, t, genContext)
 	case *decorated.RecordLookups:
 		return handleRecordLookup(code, t, genContext)
 	case *decorated.TupleLiteral:
@@ -125,6 +131,8 @@ func generateExpressionWithSourceVar(code *assembler_sp.Code, expr decorated.Exp
 		return handleCaseCustomType(code, t, genContext)
 	case *decorated.CaseForPatternMatching:
 		return handleCasePatternMatchingMultiple(code, t, genContext)
+	case *decorated.CaseForPatterns:
+		return handleCasePatterns(code, t, genContext)
 	case *decorated.PipeLeftOperator:
 		return handlePipeLeft(code, t, genContext)
 	case *decorated.PipeRightOperator:
@@ -133,6 +141,8 @@ func generateExpressionWithSourceVar(code *assembler_sp.Code, expr decorated.Exp
 		return handleGuard(code, t, genContext)
 	case *decorated.If:
 		return handleIf(code, t, genContext)
+	case *decorated.Let:
+		return handleLet(code, t, genContext)
 	case *decorated.CastOperator:
 		return generateExpressionWithSourceVar(code, t.Expression(), genContext, "cast")
 	}
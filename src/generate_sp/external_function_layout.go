@@ -0,0 +1,88 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package generate_sp
+
+import (
+	"github.com/swamp/assembler/lib/assembler_sp"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+	opcode_sp_type "github.com/swamp/opcodes/type"
+)
+
+// ExternalFunctionStackSlot is one of the stack ranges that the host reads the arguments from.
+// A type id slot is inserted before arguments that need the type id, and is the only slot for type references.
+type ExternalFunctionStackSlot struct {
+	Range          assembler_sp.SourceStackPosRange
+	ParameterIndex int
+	IsTypeId       bool
+}
+
+// ExternalFunctionStackLayout is where the return value and the arguments of an external function are placed on the stack,
+// relative to the start of the call frame. Functions with local types have no fixed layout, so all ranges are zero.
+type ExternalFunctionStackLayout struct {
+	Return        assembler_sp.SourceStackPosRange
+	Slots         []ExternalFunctionStackSlot
+	HasLocalTypes bool
+}
+
+func (l ExternalFunctionStackLayout) SlotRanges() []assembler_sp.SourceStackPosRange {
+	ranges := make([]assembler_sp.SourceStackPosRange, len(l.Slots))
+	for index, slot := range l.Slots {
+		ranges[index] = slot.Range
+	}
+
+	return ranges
+}
+
+// CalculateExternalFunctionStackLayout returns the stack layout that the external function is called with. The return value is
+// placed first, followed by each argument aligned to its own alignment. The sizes are calculated for the dataLayout.
+func CalculateExternalFunctionStackLayout(dataLayout dectype.DataLayout, functionType dectype.FunctionTypeLike, parameterCount int) ExternalFunctionStackLayout {
+	if dectype.TypeIsTemplateHasLocalTypes(functionType) {
+		slots := make([]ExternalFunctionStackSlot, parameterCount)
+		for index := range slots {
+			slots[index].ParameterIndex = index
+		}
+		return ExternalFunctionStackLayout{Slots: slots, HasLocalTypes: true}
+	}
+
+	pos := dectype.MemoryOffset(0)
+	returnSize, _ := dectype.GetMemorySizeAndAlignment(dataLayout, functionType.ReturnType())
+	layout := ExternalFunctionStackLayout{
+		Return: assembler_sp.SourceStackPosRange{
+			Pos:  assembler_sp.SourceStackPos(pos),
+			Size: assembler_sp.SourceStackRange(returnSize),
+		},
+	}
+
+	pos += dectype.MemoryOffset(returnSize)
+
+	parameterTypes, _ := functionType.ParameterAndReturn()
+
+	for parameterIndex, param := range parameterTypes {
+		unaliased := dectype.Unalias(param)
+		if dectype.ArgumentNeedsTypeIdInsertedBefore(unaliased) || dectype.IsTypeIdRef(unaliased) {
+			pos = align(pos, dectype.MemoryAlign(opcode_sp_type.AlignOfSwampInt))
+			typeIndexPosRange := assembler_sp.SourceStackPosRange{
+				Pos:  assembler_sp.SourceStackPos(pos),
+				Size: assembler_sp.SourceStackRange(opcode_sp_type.SizeofSwampInt),
+			}
+			layout.Slots = append(layout.Slots, ExternalFunctionStackSlot{Range: typeIndexPosRange, ParameterIndex: parameterIndex, IsTypeId: true})
+			pos += dectype.MemoryOffset(typeIndexPosRange.Size)
+			if dectype.IsTypeIdRef(unaliased) {
+				continue
+			}
+		}
+		size, alignment := dectype.GetMemorySizeAndAlignment(dataLayout, param)
+		pos = align(pos, alignment)
+		posRange := assembler_sp.SourceStackPosRange{
+			Pos:  assembler_sp.SourceStackPos(pos),
+			Size: assembler_sp.SourceStackRange(size),
+		}
+		layout.Slots = append(layout.Slots, ExternalFunctionStackSlot{Range: posRange, ParameterIndex: parameterIndex})
+		pos += dectype.MemoryOffset(size)
+	}
+
+	return layout
+}
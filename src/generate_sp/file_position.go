@@ -6,6 +6,10 @@
 package generate_sp
 
 import (
+	"net/url"
+	"path"
+	"path/filepath"
+
 	"github.com/swamp/assembler/lib/assembler_sp"
 	"github.com/swamp/compiler/src/token"
 	"github.com/swamp/opcodes/opcode_sp"
@@ -31,3 +35,39 @@ func toFilePosition(cache *assembler_sp.FileUrlCache, source token.SourceFileRef
 		},
 	}
 }
+
+// relativeFileUrls returns the file URLs with the local files relative to the root directory, e.g.
+// `file:///home/user/game/Main.swamp` is `Main.swamp` for the root `/home/user/game`. The IDs are kept.
+func relativeFileUrls(fileUrls []*assembler_sp.FileUrl, rootDirectory string) []*assembler_sp.FileUrl {
+	if rootDirectory == "" {
+		return fileUrls
+	}
+
+	relativeUrls := make([]*assembler_sp.FileUrl, len(fileUrls))
+	for index, fileUrl := range fileUrls {
+		relativeUrls[index] = &assembler_sp.FileUrl{File: relativeFileUrl(fileUrl.File, rootDirectory), ID: fileUrl.ID}
+	}
+
+	return relativeUrls
+}
+
+// relativeFileUrl returns the path of a local file relative to the root directory, with forward slashes. Anything
+// that is not a local file with an absolute path, e.g. the internal modules, is returned as it is.
+func relativeFileUrl(fileUrl string, rootDirectory string) string {
+	parsedUrl, parseErr := url.Parse(fileUrl)
+	if parseErr != nil || parsedUrl.Scheme != "file" || parsedUrl.Host != "" || !path.IsAbs(parsedUrl.Path) {
+		return fileUrl
+	}
+
+	localPath, localPathErr := token.DocumentURI(fileUrl).ToLocalFilePath()
+	if localPathErr != nil {
+		return fileUrl
+	}
+
+	relativePath, relativeErr := filepath.Rel(rootDirectory, filepath.FromSlash(localPath))
+	if relativeErr != nil {
+		return fileUrl
+	}
+
+	return filepath.ToSlash(relativePath)
+}
@@ -17,23 +17,31 @@ import (
 func generateFunction(fullyQualifiedVariableName *decorated.FullyQualifiedPackageVariableName,
 	f *decorated.FunctionValue, funcContext *Context,
 	lookup typeinfo.TypeLookup, resourceNameLookup resourceid.ResourceNameLookup, fileCache *assembler_sp.FileUrlCache, verboseFlag verbosity.Verbosity) (*Function, error) {
-	code := assembler_sp.NewCode()
-
 	//functionType := f.Type().(*dectype.FunctionTypeReference).FunctionAtom()
 	functionType := f.Type().(*dectype.FunctionAtom)
+
+	return generateFunctionFromParts(fullyQualifiedVariableName, functionType, f.Parameters(), f.Expression(), funcContext,
+		lookup, resourceNameLookup, fileCache, verboseFlag)
+}
+
+func generateFunctionFromParts(fullyQualifiedVariableName *decorated.FullyQualifiedPackageVariableName,
+	functionType *dectype.FunctionAtom, parameters []*decorated.FunctionParameterDefinition, expression decorated.Expression, funcContext *Context,
+	lookup typeinfo.TypeLookup, resourceNameLookup resourceid.ResourceNameLookup, fileCache *assembler_sp.FileUrlCache, verboseFlag verbosity.Verbosity) (*Function, error) {
+	code := assembler_sp.NewCode()
+
 	unaliasedReturnType := dectype.UnaliasWithResolveInvoker(functionType.ReturnType())
-	returnValueSourcePointer, allocateVariableErr := allocateForType(funcContext.stackMemory, "__return", unaliasedReturnType)
+	returnValueSourcePointer, allocateVariableErr := allocateForType(funcContext, "__return", unaliasedReturnType)
 	if allocateVariableErr != nil {
 		return nil, allocateVariableErr
 	}
 	returnValueTargetPointer := sourceToTargetStackPosRange(returnValueSourcePointer)
 
-	for _, parameter := range f.Parameters() {
+	for _, parameter := range parameters {
 		parameterTypeID, lookupErr := lookup.Lookup(parameter.Type())
 		if lookupErr != nil {
 			return nil, lookupErr
 		}
-		if _, err := allocateVariable(code, funcContext.scopeVariables, funcContext.stackMemory, parameter, parameter.Type(), assembler_sp.TypeID(parameterTypeID)); err != nil {
+		if _, err := allocateVariable(code, funcContext, parameter, parameter.Type(), assembler_sp.TypeID(parameterTypeID)); err != nil {
 			return nil, err
 		}
 	}
@@ -46,12 +54,12 @@ func generateFunction(fullyQualifiedVariableName *decorated.FullyQualifiedPackag
 		fileCache:          fileCache,
 	}
 
-	genErr := generateExpression(code, returnValueTargetPointer, f.Expression(), true, genContext)
+	genErr := generateExpression(code, returnValueTargetPointer, expression, true, genContext)
 	if genErr != nil {
 		return nil, genErr
 	}
 
-	filePosition := genContext.toFilePosition(f.Expression().FetchPositionLength())
+	filePosition := genContext.toFilePosition(expression.FetchPositionLength())
 
 	endLabel := code.Label("end", "end of func")
 	code.Return(filePosition)
@@ -66,16 +74,17 @@ func generateFunction(fullyQualifiedVariableName *decorated.FullyQualifiedPackag
 		//code.PrintOut()
 	}
 
-	parameterTypes, _ := f.ForcedFunctionType().ParameterAndReturn()
+	parameterTypes, _ := functionType.ParameterAndReturn()
 	parameterCount := uint(len(parameterTypes))
 
-	signature, lookupErr := lookup.Lookup(f.Type())
+	signature, lookupErr := lookup.Lookup(functionType)
 	if lookupErr != nil {
 		return nil, lookupErr
 	}
 
 	functionConstant := NewFunction(fullyQualifiedVariableName, TypeRef(signature),
 		opcodes, parameterCount, debugLineInfos)
+	functionConstant.frameOctets = funcContext.stackFrameOctets()
 
 	return functionConstant, nil
 }
@@ -36,14 +36,26 @@ func handleFunctionCall(code *assembler_sp.Code, call *decorated.FunctionCall, i
 	insideFunction := genContext.context.inFunction
 
 	callExpressionFunctionValue, expressionIsFunctionValue := fn.(*decorated.FunctionReference)
+	specialization := call.Specialization()
 	callSelf := false
-	if expressionIsFunctionValue {
+	if specialization != nil {
+		originalFunctionType = dectype.UnaliasWithResolveInvoker(specialization.FunctionValue().Type()).(*dectype.FunctionAtom)
+		callSelf = insideFunction == specialization.FunctionValue()
+	} else if expressionIsFunctionValue {
 		callSelf = insideFunction == callExpressionFunctionValue.FunctionValue()
+	} else if letVariableReference, wasLetVariable := fn.(*decorated.LetVariableReference); wasLetVariable && letVariableReference.Instance() != nil {
+		originalFunctionType = letVariableReference.Instance().FunctionType()
 	}
 
 	var functionRegister assembler_sp.SourceStackPosRange
 
-	if !callSelf {
+	if specialization != nil && !callSelf {
+		var functionGenErr error
+		functionRegister, functionGenErr = handleSpecializedFunctionReference(code, callExpressionFunctionValue, specialization, genContext.context)
+		if functionGenErr != nil {
+			return assembler_sp.SourceStackPosRange{}, functionGenErr
+		}
+	} else if !callSelf {
 		var functionGenErr error
 		functionRegister, functionGenErr = generateExpressionWithSourceVar(code, fn, genContext, "functioncall")
 		if functionGenErr != nil {
@@ -58,7 +70,7 @@ func handleFunctionCall(code *assembler_sp.Code, call *decorated.FunctionCall, i
 	genContext.context.stackMemory.AlignUpForMax()
 
 	invokedReturnType := dectype.UnaliasWithResolveInvoker(functionAtom.ReturnType())
-	returnValue, returnValueAlign := allocMemoryForTypeEx(genContext.context.stackMemory, invokedReturnType, "returnValue")
+	returnValue, returnValueAlign := allocMemoryForTypeEx(genContext.context, invokedReturnType, "returnValue")
 	if uint(returnValue.Size) == 0 {
 		panic(fmt.Errorf("how can it have zero size in return? %v", returnValue))
 	}
@@ -80,7 +92,7 @@ func handleFunctionCall(code *assembler_sp.Code, call *decorated.FunctionCall, i
 			arguments = append(arguments, anySourcePosGen)
 			argumentsAlign = append(argumentsAlign, dectype.MemoryAlign(opcode_sp_type.AlignOfSwampInt))
 		}
-		argPosRange, align := allocMemoryForTypeEx(genContext.context.stackMemory, arg.Type(), fmt.Sprintf("arg %d", index))
+		argPosRange, align := allocMemoryForTypeEx(genContext.context, arg.Type(), fmt.Sprintf("arg %d", index))
 
 		arguments = append(arguments, argPosRange)
 		argumentsAlign = append(argumentsAlign, align)
@@ -156,7 +168,7 @@ func handleFunctionCall(code *assembler_sp.Code, call *decorated.FunctionCall, i
 		}
 	} else {
 		if callSelf {
-			returnSize, _ := dectype.GetMemorySizeAndAlignment(insideFunction.ForcedFunctionType().ReturnType())
+			returnSize, _ := dectype.GetMemorySizeAndAlignment(genContext.context.dataLayout, insideFunction.ForcedFunctionType().ReturnType())
 			pos := dectype.MemoryOffset(returnSize)
 			pos = align(pos, argumentsAlign[0])
 			firstArgumentStackPosition := assembler_sp.TargetStackPos(pos)
@@ -13,25 +13,22 @@ import (
 )
 
 func handleFunctionReference(code *assembler_sp.Code,
-	t *decorated.FunctionReference,
-	stackMemory *assembler_sp.StackMemoryMapper,
-	constants *assembler_sp.PackageConstants) (assembler_sp.SourceStackPosRange, error) {
+	t *decorated.FunctionReference, context *Context) (assembler_sp.SourceStackPosRange, error) {
 	ident := t.NameReference().FullyQualifiedName()
 	functionReferenceName := assembler_sp.VariableName(ident)
-	foundConstant := constants.FindFunction(functionReferenceName)
+	foundConstant := context.FindFunction(functionReferenceName)
 	if foundConstant == nil {
 		return assembler_sp.SourceStackPosRange{}, fmt.Errorf("generatesp: %v couldn't find function reference '%s' %v", t.FetchPositionLength().ToReferenceString(), functionReferenceName, t)
 	}
 
-	return constantToSourceStackPosRange(code, stackMemory, foundConstant)
+	return constantToSourceStackPosRange(code, context, foundConstant)
 }
 
 func generateFunctionReference(code *assembler_sp.Code, target assembler_sp.TargetStackPosRange,
 	getVar *decorated.FunctionReference, genContext *generateContext) error {
 	ident := getVar.NameReference().FullyQualifiedName()
 	varName := assembler_sp.VariableName(ident)
-	constants := genContext.context.Constants()
-	functionConstant := constants.FindFunction(varName)
+	functionConstant := genContext.context.FindFunction(varName)
 	if functionConstant == nil {
 		panic(fmt.Errorf("can not find function:%v", varName))
 	}
@@ -42,3 +39,15 @@ func generateFunctionReference(code *assembler_sp.Code, target assembler_sp.Targ
 
 	return nil
 }
+
+// handleSpecializedFunctionReference loads the function that was generated for the specialization of the generic function.
+func handleSpecializedFunctionReference(code *assembler_sp.Code,
+	t *decorated.FunctionReference, specialization *decorated.FunctionSpecialization, context *Context) (assembler_sp.SourceStackPosRange, error) {
+	functionReferenceName := assembler_sp.VariableName(t.NameReference().FullyQualifiedName() + specialization.Suffix())
+	foundConstant := context.FindFunction(functionReferenceName)
+	if foundConstant == nil {
+		return assembler_sp.SourceStackPosRange{}, fmt.Errorf("generatesp: %v couldn't find specialized function '%s' %v", t.FetchPositionLength().ToReferenceString(), functionReferenceName, t)
+	}
+
+	return constantToSourceStackPosRange(code, context, foundConstant)
+}
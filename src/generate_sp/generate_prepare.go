@@ -9,6 +9,7 @@ import (
 	"fmt"
 
 	"github.com/swamp/assembler/lib/assembler_sp"
+	"github.com/swamp/compiler/src/ast"
 	"github.com/swamp/compiler/src/decorated/decshared"
 	decorated "github.com/swamp/compiler/src/decorated/expression"
 	dectype "github.com/swamp/compiler/src/decorated/types"
@@ -17,7 +18,49 @@ import (
 	opcode_sp_type "github.com/swamp/opcodes/type"
 )
 
-func preparePackageConstants(compiledPackage *loader.Package, packageConstants *assembler_sp.PackageConstants, typeInformationChunk typeinfo.TypeLookup) decshared.DecoratedError {
+func prepareFunctionConstant(fullyQualifiedName string, functionType dectype.FunctionTypeLike, parameters []*decorated.FunctionParameterDefinition,
+	dataLayout dectype.DataLayout, packageConstants *assembler_sp.PackageConstants, typeInformationChunk typeinfo.TypeLookup) decshared.DecoratedError {
+	returnSize, returnAlign := dectype.GetMemorySizeAndAlignment(dataLayout, functionType.ReturnType())
+	parameterCount := uint(len(parameters))
+
+	functionTypeIndex, lookupErr := typeInformationChunk.Lookup(functionType)
+	if lookupErr != nil {
+		return decorated.NewInternalError(lookupErr)
+	}
+
+	pos := dectype.MemoryOffset(0)
+	for _, param := range parameters {
+		paramSize, paramAlign := dectype.GetMemorySizeAndAlignment(dataLayout, param.Type())
+		pos = align(pos, paramAlign)
+		pos += dectype.MemoryOffset(paramSize)
+	}
+	parameterOctetSize := dectype.MemorySize(pos)
+	if _, err := packageConstants.AllocatePrepareFunctionConstant(fullyQualifiedName, opcode_sp_type.MemorySize(returnSize), opcode_sp_type.MemoryAlign(returnAlign), parameterCount, opcode_sp_type.MemorySize(parameterOctetSize), uint(functionTypeIndex)); err != nil {
+		return decorated.NewInternalError(err)
+	}
+
+	return nil
+}
+
+func prepareFunctionAndLambdaConstants(module *decorated.Module, identifier *ast.VariableIdentifier, functionValue *decorated.FunctionValue,
+	packageConstants *assembler_sp.PackageConstants, typeInformationChunk typeinfo.TypeLookup) decshared.DecoratedError {
+	fullyQualifiedName := module.FullyQualifiedName(identifier)
+	if err := prepareFunctionConstant(fullyQualifiedName.String(), functionValue.ForcedFunctionType(), functionValue.Parameters(), module.DataLayout(), packageConstants, typeInformationChunk); err != nil {
+		return err
+	}
+
+	for _, lambda := range functionValue.Lambdas() {
+		lambdaName := module.FullyQualifiedName(lambdaIdentifier(identifier, lambda))
+		if err := prepareFunctionConstant(lambdaName.String(), lambda.LiftedFunctionType(), lambda.LiftedParameters(), module.DataLayout(), packageConstants, typeInformationChunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func preparePackageConstants(compiledPackage *loader.Package, packageConstants *assembler_sp.PackageConstants, typeInformationChunk typeinfo.TypeLookup,
+	filter definitionFilter) decshared.DecoratedError {
 	for _, module := range compiledPackage.AllModules() {
 		for _, named := range module.LocalDefinitions().Definitions() {
 			unknownExpression := named.Expression()
@@ -25,80 +68,28 @@ func preparePackageConstants(compiledPackage *loader.Package, packageConstants *
 			if maybeFunction != nil {
 				fullyQualifiedName := module.FullyQualifiedName(named.Identifier())
 				isExternal := maybeFunction.IsSomeKindOfExternal()
+				if !maybeFunction.NeedsSpecialization() && !filter.includes(fullyQualifiedName.String()) {
+					continue
+				}
 				if isExternal {
-					var paramPosRanges []assembler_sp.SourceStackPosRange
-					hasLocalTypes := dectype.TypeIsTemplateHasLocalTypes(maybeFunction.ForcedFunctionType())
-					// parameterCount := len(maybeFunction.Parameters())
-					pos := dectype.MemoryOffset(0)
-					if hasLocalTypes {
-						returnPosRange := assembler_sp.SourceStackPosRange{
-							Pos:  assembler_sp.SourceStackPos(0),
-							Size: assembler_sp.SourceStackRange(0),
-						}
-						paramPosRanges = make([]assembler_sp.SourceStackPosRange, len(maybeFunction.Parameters()))
-						if _, err := packageConstants.AllocatePrepareExternalFunctionConstant(fullyQualifiedName.String(), returnPosRange, paramPosRanges); err != nil {
-							return decorated.NewInternalError(err)
-						}
-						continue
-					}
-					returnSize, _ := dectype.GetMemorySizeAndAlignment(maybeFunction.ForcedFunctionType().ReturnType())
-					returnPosRange := assembler_sp.SourceStackPosRange{
-						Pos:  assembler_sp.SourceStackPos(pos),
-						Size: assembler_sp.SourceStackRange(returnSize),
+					layout := CalculateExternalFunctionStackLayout(compiledPackage.DataLayout(), maybeFunction.ForcedFunctionType(), len(maybeFunction.Parameters()))
+					if _, err := packageConstants.AllocatePrepareExternalFunctionConstant(fullyQualifiedName.String(), layout.Return, layout.SlotRanges()); err != nil {
+						return decorated.NewInternalError(err)
 					}
-
-					pos += dectype.MemoryOffset(returnSize)
-
-					parameterTypes, _ := maybeFunction.ForcedFunctionType().ParameterAndReturn()
-
-					for _, param := range parameterTypes {
-						unaliased := dectype.Unalias(param)
-						if dectype.ArgumentNeedsTypeIdInsertedBefore(unaliased) || dectype.IsTypeIdRef(unaliased) {
-							pos = align(pos, dectype.MemoryAlign(opcode_sp_type.AlignOfSwampInt))
-							typeIndexPosRange := assembler_sp.SourceStackPosRange{
-								Pos:  assembler_sp.SourceStackPos(pos),
-								Size: assembler_sp.SourceStackRange(opcode_sp_type.SizeofSwampInt),
-							}
-							paramPosRanges = append(paramPosRanges, typeIndexPosRange)
-							pos += dectype.MemoryOffset(typeIndexPosRange.Size)
-							if dectype.IsTypeIdRef(unaliased) {
-								continue
-							}
+				} else if maybeFunction.NeedsSpecialization() {
+					for _, specialization := range maybeFunction.Specializations() {
+						identifier := specializationIdentifier(named.Identifier(), specialization)
+						if !filter.includes(module.FullyQualifiedName(identifier).String()) {
+							continue
 						}
-						size, alignment := dectype.GetMemorySizeAndAlignment(param)
-						pos = align(pos, alignment)
-						posRange := assembler_sp.SourceStackPosRange{
-							Pos:  assembler_sp.SourceStackPos(pos),
-							Size: assembler_sp.SourceStackRange(size),
+						if err := prepareFunctionAndLambdaConstants(module, identifier, specialization.FunctionValue(), packageConstants, typeInformationChunk); err != nil {
+							return err
 						}
-						paramPosRanges = append(paramPosRanges, posRange)
-						pos += dectype.MemoryOffset(size)
-					}
-
-					if _, err := packageConstants.AllocatePrepareExternalFunctionConstant(fullyQualifiedName.String(), returnPosRange, paramPosRanges); err != nil {
-						return decorated.NewInternalError(err)
 					}
 				} else {
-					// parameterTypes, _ := maybeFunction.ForcedFunctionType().ParameterAndReturn()
-					returnSize, returnAlign := dectype.GetMemorySizeAndAlignment(maybeFunction.ForcedFunctionType().ReturnType())
-					parameterCount := uint(len(maybeFunction.Parameters())) // parameterTypes
-
-					functionTypeIndex, lookupErr := typeInformationChunk.Lookup(maybeFunction.ForcedFunctionType())
-					if lookupErr != nil {
-						return decorated.NewInternalError(lookupErr)
-					}
-
-					pos := dectype.MemoryOffset(0)
-					for _, param := range maybeFunction.Parameters() {
-						paramSize, paramAlign := dectype.GetMemorySizeAndAlignment(param.Type())
-						pos = align(pos, paramAlign)
-						pos += dectype.MemoryOffset(paramSize)
-					}
-					parameterOctetSize := dectype.MemorySize(pos)
-					if _, err := packageConstants.AllocatePrepareFunctionConstant(fullyQualifiedName.String(), opcode_sp_type.MemorySize(returnSize), opcode_sp_type.MemoryAlign(returnAlign), parameterCount, opcode_sp_type.MemorySize(parameterOctetSize), uint(functionTypeIndex)); err != nil {
-						return decorated.NewInternalError(err)
+					if err := prepareFunctionAndLambdaConstants(module, named.Identifier(), maybeFunction, packageConstants, typeInformationChunk); err != nil {
+						return err
 					}
-
 				}
 			} else {
 				if _, isConstant := unknownExpression.(*decorated.Constant); !isConstant {
@@ -13,10 +13,12 @@ import (
 	"strings"
 
 	"github.com/swamp/assembler/lib/assembler_sp"
+	"github.com/swamp/compiler/src/ast"
 	"github.com/swamp/compiler/src/decorated/dtype"
 	decorated "github.com/swamp/compiler/src/decorated/expression"
 	dectype "github.com/swamp/compiler/src/decorated/types"
 	"github.com/swamp/compiler/src/loader"
+	"github.com/swamp/compiler/src/pdb"
 	"github.com/swamp/compiler/src/resourceid"
 	"github.com/swamp/compiler/src/typeinfo"
 	"github.com/swamp/compiler/src/verbosity"
@@ -39,6 +41,7 @@ type Function struct {
 	debugLines          []opcode_sp.OpcodeInfo
 	debugVariables      []opcode_sp.VariableInfo
 	debugParameterCount uint
+	frameOctets         uint
 }
 
 type ExternalFunction struct {
@@ -69,17 +72,42 @@ func (f *Function) DebugLines() []opcode_sp.OpcodeInfo {
 	return f.debugLines
 }
 
+// FrameOctets is the peak stack memory that the function uses.
+func (f *Function) FrameOctets() uint {
+	return f.frameOctets
+}
+
 type Generator struct {
 	code              *assembler_sp.Code
 	packageConstants  *assembler_sp.PackageConstants
 	functionConstants []*assembler_sp.Constant
 	lookup            typeinfo.TypeLookup
 	chunk             *typeinfo.Chunk
+	dataLayout        dectype.DataLayout
 	fileUrlCache      *assembler_sp.FileUrlCache
+	definitions       *definitionGraph
+	deadCode          *deadCodeSettings
+	deadCodeReport    *DeadCodeReport
+	stack             *stackSettings
+	stackFrames       []StackFrame
+	stackReport       *StackReport
+	sourceRoot        string
+	strip             bool
+	symbols           *debugSymbols
+}
+
+type stackSettings struct {
+	budget uint
+	report bool
+}
+
+type deadCodeSettings struct {
+	roots  []string
+	report bool
 }
 
 func NewGenerator() *Generator {
-	g := &Generator{chunk: &typeinfo.Chunk{}, fileUrlCache: assembler_sp.NewFileUrlCache()}
+	g := &Generator{chunk: typeinfo.NewChunk(dectype.DataLayoutLP64), dataLayout: dectype.DataLayoutLP64, fileUrlCache: assembler_sp.NewFileUrlCache()}
 	g.lookup = g.chunk
 	return g
 }
@@ -88,7 +116,48 @@ func (g *Generator) PrepareForNewPackage() {
 	g.code = assembler_sp.NewCode()
 	g.packageConstants = assembler_sp.NewPackageConstants()
 	g.functionConstants = nil
+	g.stackFrames = nil
+	g.symbols = nil
+	if g.strip {
+		g.symbols = &debugSymbols{}
+	}
 	g.fileUrlCache = assembler_sp.NewFileUrlCache()
+	g.chunk = typeinfo.NewChunk(g.dataLayout)
+	g.lookup = g.chunk
+}
+
+// SetDeadCodeElimination removes the definitions that can not be reached from `main` and `init` in the main module,
+// or from the roots, before the package is packed. The removed definitions are printed if report is set.
+func (g *Generator) SetDeadCodeElimination(roots []string, report bool) {
+	g.deadCode = &deadCodeSettings{roots: roots, report: report}
+}
+
+// DeadCodeReport returns what was removed from the last package, or nil if dead code elimination is not enabled.
+func (g *Generator) DeadCodeReport() *DeadCodeReport {
+	return g.deadCodeReport
+}
+
+// SetStackBudget fails the generation if a function needs a larger stack frame than budget octets. A zero budget has
+// no limit. The peak frame size of each function is printed if report is set.
+func (g *Generator) SetStackBudget(budget uint, report bool) {
+	g.stack = &stackSettings{budget: budget, report: report}
+}
+
+// SetSourceRoot sets the directory that the debug file URLs are written relative to, so that the pack is the same
+// wherever the source is. The URLs are absolute if no source root is set.
+func (g *Generator) SetSourceRoot(directory string) {
+	g.sourceRoot = directory
+}
+
+// SetStrip leaves the debug lines, the debug scopes and the file URLs out of the pack. They are written to a
+// `.swamp-pdb` next to the pack instead, and the pack gets a build ID that is also in the `.swamp-pdb`.
+func (g *Generator) SetStrip(strip bool) {
+	g.strip = strip
+}
+
+// StackReport returns the peak stack frames of the last package.
+func (g *Generator) StackReport() *StackReport {
+	return g.stackReport
 }
 
 func (g *Generator) PackageConstants() *assembler_sp.PackageConstants {
@@ -113,22 +182,22 @@ func generateConstant(code *assembler_sp.Code, target assembler_sp.TargetStackPo
 	return generateExpression(code, target, constant.Expression(), true, context)
 }
 
-func allocMemoryForType(stackMemory *assembler_sp.StackMemoryMapper, typeToAlloc dtype.Type,
+func allocMemoryForType(context *Context, typeToAlloc dtype.Type,
 	debugString string) assembler_sp.TargetStackPosRange {
-	memorySize, alignment := dectype.GetMemorySizeAndAlignment(typeToAlloc)
+	memorySize, alignment := dectype.GetMemorySizeAndAlignment(context.dataLayout, typeToAlloc)
 	if uint(memorySize) == 0 || uint(alignment) == 0 {
 		panic(fmt.Errorf("can not allocate zero memory or align for type %T %v", typeToAlloc, typeToAlloc))
 	}
-	return stackMemory.Allocate(uint(memorySize), uint32(alignment), debugString)
+	return context.stackMemory.Allocate(uint(memorySize), uint32(alignment), debugString)
 }
 
-func allocMemoryForTypeEx(stackMemory *assembler_sp.StackMemoryMapper, typeToAlloc dtype.Type,
+func allocMemoryForTypeEx(context *Context, typeToAlloc dtype.Type,
 	debugString string) (assembler_sp.TargetStackPosRange, dectype.MemoryAlign) {
-	memorySize, alignment := dectype.GetMemorySizeAndAlignment(typeToAlloc)
+	memorySize, alignment := dectype.GetMemorySizeAndAlignment(context.dataLayout, typeToAlloc)
 	if uint(memorySize) == 0 || uint(alignment) == 0 {
 		panic(fmt.Errorf("can not allocate zero memory or align for type %T %v", typeToAlloc, typeToAlloc))
 	}
-	return stackMemory.Allocate(uint(memorySize), uint32(alignment), debugString), alignment
+	return context.stackMemory.Allocate(uint(memorySize), uint32(alignment), debugString), alignment
 }
 
 func generateRecurCall(code *assembler_sp.Code, call *decorated.RecurCall, genContext *generateContext) error {
@@ -145,10 +214,12 @@ func createTargetWithMemoryOffsetAndSize(target assembler_sp.TargetStackPosRange
 	}
 }
 
-const (
-	PointerSize  = 8
-	PointerAlign = 8
-)
+// allocatePointer allocates a pointer on the stack, with the size and alignment of the target data layout.
+func allocatePointer(context *Context, debugString string) assembler_sp.TargetStackPosRange {
+	pointerSize, pointerAlign := context.dataLayout.PointerSizeAndAlignment()
+
+	return context.stackMemory.Allocate(uint(pointerSize), uint32(pointerAlign), debugString)
+}
 
 func targetToSourceStackPosRange(functionPointer assembler_sp.TargetStackPosRange) assembler_sp.SourceStackPosRange {
 	sourcePosRange := assembler_sp.SourceStackPosRange{
@@ -168,16 +239,23 @@ func sourceToTargetStackPosRange(functionPointer assembler_sp.SourceStackPosRang
 	return targetPosRange
 }
 
-func constantToSourceStackPosRange(code *assembler_sp.Code, stackMemory *assembler_sp.StackMemoryMapper, constant *assembler_sp.Constant) (assembler_sp.SourceStackPosRange, error) {
-	functionPointer := stackMemory.Allocate(PointerSize, PointerAlign, "functionReference:"+constant.String())
+func constantToSourceStackPosRange(code *assembler_sp.Code, context *Context, constant *assembler_sp.Constant) (assembler_sp.SourceStackPosRange, error) {
+	functionPointer := allocatePointer(context, "functionReference:"+constant.String())
 	code.LoadZeroMemoryPointer(functionPointer.Pos, constant.PosRange().Position, opcode_sp.FilePosition{})
 
 	return targetToSourceStackPosRange(functionPointer), nil
 }
 
 func (g *Generator) Before(compilePackage *loader.Package) error {
+	return g.before(compilePackage, nil)
+}
+
+func (g *Generator) before(compilePackage *loader.Package, filter definitionFilter) error {
+	g.dataLayout = compilePackage.DataLayout()
 	g.PrepareForNewPackage()
-	err := typeinfo.GeneratePackageToChunk(compilePackage, g.chunk)
+	err := typeinfo.GeneratePackageDefinitionsToChunk(compilePackage, g.chunk, func(name *decorated.FullyQualifiedPackageVariableName) bool {
+		return filter.includes(name.String())
+	})
 	if err != nil {
 		return decorated.NewInternalError(err)
 	}
@@ -185,15 +263,18 @@ func (g *Generator) Before(compilePackage *loader.Package) error {
 	return nil
 }
 
-func (g *Generator) GenerateFromPackage(compilePackage *loader.Package, resourceNameLookup resourceid.ResourceNameLookup, verboseFlag verbosity.Verbosity) error {
-	g.Before(compilePackage)
+func (g *Generator) generateDefinitions(compilePackage *loader.Package, resourceNameLookup resourceid.ResourceNameLookup,
+	filter definitionFilter, verboseFlag verbosity.Verbosity) error {
+	if err := g.before(compilePackage, filter); err != nil {
+		return err
+	}
 
-	allConstantsErr := preparePackageConstants(compilePackage, g.packageConstants, g.lookup)
+	allConstantsErr := preparePackageConstants(compilePackage, g.packageConstants, g.lookup, filter)
 	if allConstantsErr != nil {
 		return allConstantsErr
 	}
 	for _, mod := range compilePackage.AllModules() {
-		standardError := g.GenerateModule(mod, resourceNameLookup, verboseFlag)
+		standardError := g.GenerateModule(mod, resourceNameLookup, filter, verboseFlag)
 		if standardError != nil {
 			return decorated.NewInternalError(standardError)
 		}
@@ -202,6 +283,78 @@ func (g *Generator) GenerateFromPackage(compilePackage *loader.Package, resource
 	return nil
 }
 
+func (g *Generator) GenerateFromPackage(compilePackage *loader.Package, resourceNameLookup resourceid.ResourceNameLookup, verboseFlag verbosity.Verbosity) error {
+	var generateErr error
+	if g.deadCode == nil {
+		generateErr = g.generateDefinitions(compilePackage, resourceNameLookup, nil, verboseFlag)
+	} else {
+		generateErr = g.generateWithoutDeadCode(compilePackage, resourceNameLookup, verboseFlag)
+	}
+	if generateErr != nil {
+		return generateErr
+	}
+
+	return g.checkStackFrames(compilePackage.Name())
+}
+
+// checkStackFrames creates the stack report for the package, prints it if requested and checks the budget.
+func (g *Generator) checkStackFrames(packageName string) error {
+	g.stackReport = &StackReport{PackageName: packageName, Frames: g.stackFrames}
+	if g.stack == nil {
+		return nil
+	}
+
+	g.stackReport.Budget = g.stack.budget
+	if g.stack.report {
+		fmt.Println(g.stackReport)
+	}
+
+	return g.stackReport.checkBudget()
+}
+
+// generateWithoutDeadCode generates all definitions once to find out what they refer to, and then generates the package
+// again with only the definitions that can be reached from the roots. The first pass uses its own resource names, so
+// that resource names that are only used by removed definitions are not included.
+func (g *Generator) generateWithoutDeadCode(compilePackage *loader.Package, resourceNameLookup resourceid.ResourceNameLookup, verboseFlag verbosity.Verbosity) error {
+	g.deadCodeReport = nil
+	g.definitions = newDefinitionGraph()
+	allErr := g.generateDefinitions(compilePackage, resourceid.NewResourceNameLookupImpl(), nil, verboseFlag)
+	graph := g.definitions
+	g.definitions = nil
+	if allErr != nil {
+		return allErr
+	}
+
+	roots, rootsErr := deadCodeRoots(compilePackage, graph, g.deadCode.roots)
+	if rootsErr != nil {
+		return rootsErr
+	}
+
+	if len(roots) == 0 {
+		g.deadCodeReport = &DeadCodeReport{PackageName: compilePackage.Name(), NoRoots: true}
+		return g.generateDefinitions(compilePackage, resourceNameLookup, nil, verboseFlag)
+	}
+
+	reachable := graph.reachable(roots)
+	report := newDeadCodeReport(compilePackage.Name(), graph, roots, reachable)
+	report.TypeInfoCountBefore = len(g.chunk.InfoTypes())
+
+	packedBefore, packErr := g.pack(resourceid.NewResourceNameLookupImpl(), false, verbosity.None)
+	if packErr != nil {
+		return packErr
+	}
+	report.PackOctetsBefore = len(packedBefore)
+	report.DynamicOctetsBefore = len(g.packageConstants.DynamicMemory().Octets())
+
+	if err := g.generateDefinitions(compilePackage, resourceNameLookup, reachable, verboseFlag); err != nil {
+		return err
+	}
+	report.TypeInfoCountAfter = len(g.chunk.InfoTypes())
+	g.deadCodeReport = report
+
+	return nil
+}
+
 func (g *Generator) GenerateFromPackageAndWriteOutput(compilePackage *loader.Package, resourceNameLookup resourceid.ResourceNameLookup, absoluteOutputDirectory string, packageSubDirectory string, verboseFlag verbosity.Verbosity, showAssembler bool) error {
 	if generateErr := g.GenerateFromPackage(compilePackage, resourceNameLookup, verboseFlag); generateErr != nil {
 		return generateErr
@@ -209,16 +362,20 @@ func (g *Generator) GenerateFromPackageAndWriteOutput(compilePackage *loader.Pac
 	return g.After(resourceNameLookup, absoluteOutputDirectory, packageSubDirectory, showAssembler, verboseFlag)
 }
 
-func (g *Generator) After(resourceNameLookup resourceid.ResourceNameLookup, absoluteOutputDirectory string, packageSubDirectory string, showAssembler bool, verboseFlag verbosity.Verbosity) error {
+// pack finalizes the constants and returns the octets of the `.swamp-pack`.
+func (g *Generator) pack(resourceNameLookup resourceid.ResourceNameLookup, showAssembler bool, verboseFlag verbosity.Verbosity) ([]byte, error) {
 	constants := g.packageConstants
 	if verboseFlag >= verbosity.High {
 		constants.DynamicMemory().DebugOutput()
 	}
 
-	constants.AllocateDebugInfoFiles(g.fileUrlCache.FileUrls())
+	fileUrls := relativeFileUrls(g.fileUrlCache.FileUrls(), g.sourceRoot)
+	if g.symbols == nil {
+		constants.AllocateDebugInfoFiles(fileUrls)
+	}
 	typeInformationOctets, typeInformationErr := typeinfo.ChunkToOctets(g.chunk)
 	if typeInformationErr != nil {
-		return decorated.NewInternalError(typeInformationErr)
+		return nil, decorated.NewInternalError(typeInformationErr)
 	}
 
 	if verboseFlag >= verbosity.High {
@@ -252,7 +409,16 @@ func (g *Generator) After(resourceNameLookup resourceid.ResourceNameLookup, abso
 
 	dynamicMemoryOctets := constants.DynamicMemory().Octets()
 
-	packed, packedErr := Pack(constants.Constants(), dynamicMemoryOctets, typeInformationOctets)
+	packed, packErr := Pack(constants.Constants(), dynamicMemoryOctets, typeInformationOctets, g.dataLayout)
+	if packErr != nil || g.symbols == nil {
+		return packed, packErr
+	}
+
+	return g.symbols.strip(packed, fileUrls)
+}
+
+func (g *Generator) After(resourceNameLookup resourceid.ResourceNameLookup, absoluteOutputDirectory string, packageSubDirectory string, showAssembler bool, verboseFlag verbosity.Verbosity) error {
+	packed, packedErr := g.pack(resourceNameLookup, showAssembler, verboseFlag)
 	if packedErr != nil {
 		return packedErr
 	}
@@ -265,79 +431,190 @@ func (g *Generator) After(resourceNameLookup resourceid.ResourceNameLookup, abso
 
 	log.Printf("wrote output file '%v'", outputFilename)
 
+	if g.symbols != nil {
+		symbolsFilename := pdb.Filename(absoluteOutputDirectory, packageSubDirectory)
+		if err := g.symbols.writeFile(symbolsFilename, packageSubDirectory); err != nil {
+			return decorated.NewInternalError(err)
+		}
+
+		log.Printf("wrote debug symbols file '%v' (build id %v)", symbolsFilename, g.symbols.file.BuildID)
+	}
+
+	if g.deadCodeReport != nil {
+		g.deadCodeReport.PackOctetsAfter = len(packed)
+		g.deadCodeReport.DynamicOctetsAfter = len(g.packageConstants.DynamicMemory().Octets())
+		if g.deadCode.report {
+			fmt.Println(g.deadCodeReport)
+		}
+	}
+
 	return nil
 }
 
+// defineGeneratedFunction defines the opcodes and the debug information of the function. The debug information is added
+// to symbols instead of the pack if the package is stripped.
+func defineGeneratedFunction(moduleContext *Context, functionContext *Context, preparedFuncConstant *assembler_sp.Constant,
+	fullyQualifiedName *decorated.FullyQualifiedPackageVariableName, generatedFunctionInfo *Function, symbols *debugSymbols, verboseFlag verbosity.Verbosity) error {
+	if verboseFlag >= verbosity.High {
+		log.Printf("---------- generated code for '%v'", fullyQualifiedName.String())
+		functionContext.scopeVariables.DebugOutput(0)
+		lines := swampdisasmsp.Disassemble(generatedFunctionInfo.opcodes, false)
+		log.Printf("lines:\n%v\n", lines)
+	}
+
+	moduleContext.Constants().DefineFunctionOpcodes(preparedFuncConstant, generatedFunctionInfo.opcodes)
+	if functionContext.definitions != nil {
+		functionContext.definitions.addOpcodeOctets(functionContext.functionName, len(generatedFunctionInfo.opcodes))
+	}
+
+	generatedFunctionInfo.debugVariables = assembler_sp.GenerateVariablesWithScope(functionContext.scopeVariables, 1)
+	if verboseFlag >= verbosity.High {
+		assembler_sp.VariableInfosDebugOutput(generatedFunctionInfo.debugVariables)
+	}
+
+	if symbols != nil {
+		if err := symbols.addFunction(fullyQualifiedName.String(), len(generatedFunctionInfo.opcodes), generatedFunctionInfo.debugLines, generatedFunctionInfo.debugVariables); err != nil {
+			return err
+		}
+		clearFunctionDebugLines(moduleContext.Constants(), preparedFuncConstant)
+
+		return nil
+	}
+
+	debugLinesOctets, debugLinesErr := opcode_sp.SerializeDebugLines(generatedFunctionInfo.debugLines)
+	if debugLinesErr != nil {
+		return debugLinesErr
+	}
+
+	moduleContext.Constants().DefineFunctionDebugLines(preparedFuncConstant, uint(len(generatedFunctionInfo.debugLines)), debugLinesOctets)
+	moduleContext.Constants().DefineFunctionDebugScopes(preparedFuncConstant, generatedFunctionInfo.debugVariables)
+
+	return nil
+}
+
+// generateFunctionAndLambdas generates the function and the lambdas that are defined in it. It returns the prepared constants for all of them.
+func (g *Generator) generateFunctionAndLambdas(module *decorated.Module, moduleContext *Context, identifier *ast.VariableIdentifier,
+	functionValue *decorated.FunctionValue, resourceNameLookup resourceid.ResourceNameLookup, verboseFlag verbosity.Verbosity) ([]*assembler_sp.Constant, error) {
+	var functionConstants []*assembler_sp.Constant
+
+	fullyQualifiedName := module.FullyQualifiedName(identifier)
+	preparedFuncConstant := moduleContext.Constants().FindFunction(assembler_sp.VariableName(fullyQualifiedName.String()))
+	if preparedFuncConstant == nil {
+		panic(fmt.Errorf("could not find function that should have been prepared %v", fullyQualifiedName))
+	}
+	functionConstants = append(functionConstants, preparedFuncConstant)
+
+	if verboseFlag >= verbosity.Mid {
+		log.Printf("--------------------------- GenerateAllLocalDefinedFunctions function %v --------------------------\n", fullyQualifiedName)
+	}
+
+	rootContext := moduleContext.MakeFunctionContext(functionValue, fullyQualifiedName.String())
+
+	generatedFunctionInfo, genFuncErr := generateFunction(fullyQualifiedName, functionValue,
+		rootContext, g.lookup, resourceNameLookup, g.fileUrlCache, verboseFlag)
+	if genFuncErr != nil {
+		return nil, genFuncErr
+	}
+
+	if generatedFunctionInfo == nil {
+		panic(fmt.Sprintf("problem %v\n", functionValue))
+	}
+
+	if err := defineGeneratedFunction(moduleContext, rootContext, preparedFuncConstant, fullyQualifiedName, generatedFunctionInfo, g.symbols, verboseFlag); err != nil {
+		return nil, err
+	}
+	g.stackFrames = append(g.stackFrames, StackFrame{Name: fullyQualifiedName.String(), Octets: generatedFunctionInfo.FrameOctets(),
+		Reference: identifier.FetchPositionLength()})
+
+	for _, lambda := range functionValue.Lambdas() {
+		lambdaName := module.FullyQualifiedName(lambdaIdentifier(identifier, lambda))
+		preparedLambdaConstant := moduleContext.Constants().FindFunction(assembler_sp.VariableName(lambdaName.String()))
+		if preparedLambdaConstant == nil {
+			panic(fmt.Errorf("could not find lambda that should have been prepared %v", lambdaName))
+		}
+		functionConstants = append(functionConstants, preparedLambdaConstant)
+
+		lambdaContext := moduleContext.MakeLambdaContext(fullyQualifiedName.String(), lambdaName.String())
+
+		generatedLambdaInfo, genLambdaErr := generateFunctionFromParts(lambdaName, lambda.LiftedFunctionType(), lambda.LiftedParameters(),
+			lambda.Expression(), lambdaContext, g.lookup, resourceNameLookup, g.fileUrlCache, verboseFlag)
+		if genLambdaErr != nil {
+			return nil, genLambdaErr
+		}
+
+		if err := defineGeneratedFunction(moduleContext, lambdaContext, preparedLambdaConstant, lambdaName, generatedLambdaInfo, g.symbols, verboseFlag); err != nil {
+			return nil, err
+		}
+		g.stackFrames = append(g.stackFrames, StackFrame{Name: lambdaName.String(), Octets: generatedLambdaInfo.FrameOctets(),
+			Reference: lambda.FetchPositionLength()})
+	}
+
+	return functionConstants, nil
+}
+
 func (g *Generator) GenerateModule(module *decorated.Module,
-	resourceNameLookup resourceid.ResourceNameLookup, verboseFlag verbosity.Verbosity) error {
-	moduleContext := NewContext(g.packageConstants, "root")
+	resourceNameLookup resourceid.ResourceNameLookup, filter definitionFilter, verboseFlag verbosity.Verbosity) error {
+	moduleContext := NewContext(g.packageConstants, g.dataLayout, "root")
+	moduleContext.definitions = g.definitions
 
 	var functionConstants []*assembler_sp.Constant
 
 	for _, named := range module.LocalDefinitions().Definitions() {
 		unknownType := named.Expression()
+		fullyQualifiedName := module.FullyQualifiedName(named.Identifier())
 		_, isConstant := unknownType.(*decorated.Constant)
 		if isConstant {
+			if g.definitions != nil {
+				g.definitions.addDefinition(fullyQualifiedName.String(), DefinitionKindConstant)
+			}
 			continue
 		}
-		fullyQualifiedName := module.FullyQualifiedName(named.Identifier())
-		preparedFuncConstant := moduleContext.Constants().FindFunction(assembler_sp.VariableName(fullyQualifiedName.String()))
-		if preparedFuncConstant == nil {
-			panic(fmt.Errorf("could not find function that should have been prepared %v", fullyQualifiedName))
-		}
-		functionConstants = append(functionConstants, preparedFuncConstant)
 		maybeFunction, _ := unknownType.(*decorated.FunctionValue)
+		if maybeFunction != nil && maybeFunction.NeedsSpecialization() {
+			for _, specialization := range maybeFunction.Specializations() {
+				identifier := specializationIdentifier(named.Identifier(), specialization)
+				specializationName := module.FullyQualifiedName(identifier).String()
+				if !filter.includes(specializationName) {
+					continue
+				}
+				if g.definitions != nil {
+					g.definitions.addDefinition(specializationName, DefinitionKindFunction)
+				}
+				generatedConstants, genErr := g.generateFunctionAndLambdas(module, moduleContext, identifier,
+					specialization.FunctionValue(), resourceNameLookup, verboseFlag)
+				if genErr != nil {
+					return genErr
+				}
+				functionConstants = append(functionConstants, generatedConstants...)
+			}
+			continue
+		}
 		if maybeFunction != nil {
-			if maybeFunction.IsSomeKindOfExternal() {
+			if !filter.includes(fullyQualifiedName.String()) {
 				continue
 			}
-			if verboseFlag >= verbosity.Mid {
-				log.Printf("--------------------------- GenerateAllLocalDefinedFunctions function %v --------------------------\n", fullyQualifiedName)
-			}
-
-			rootContext := moduleContext.MakeFunctionContext(maybeFunction, fullyQualifiedName.String())
-
-			generatedFunctionInfo, genFuncErr := generateFunction(fullyQualifiedName, maybeFunction,
-				rootContext, g.lookup, resourceNameLookup, g.fileUrlCache, verboseFlag)
-			if genFuncErr != nil {
-				return genFuncErr
-			}
-
-			if generatedFunctionInfo == nil {
-				panic(fmt.Sprintf("problem %v\n", maybeFunction))
-			}
-
-			if verboseFlag >= verbosity.High {
-				log.Printf("---------- generated code for '%v'", fullyQualifiedName.String())
-				rootContext.scopeVariables.DebugOutput(0)
-				lines := swampdisasmsp.Disassemble(generatedFunctionInfo.opcodes, false)
-				log.Printf("lines:\n%v\n", lines)
+			if maybeFunction.IsSomeKindOfExternal() {
+				if g.definitions != nil {
+					g.definitions.addDefinition(fullyQualifiedName.String(), DefinitionKindExternalFunction)
+				}
+				preparedFuncConstant := moduleContext.Constants().FindFunction(assembler_sp.VariableName(fullyQualifiedName.String()))
+				if preparedFuncConstant == nil {
+					panic(fmt.Errorf("could not find function that should have been prepared %v", fullyQualifiedName))
+				}
+				functionConstants = append(functionConstants, preparedFuncConstant)
+				continue
 			}
 
-			moduleContext.Constants().DefineFunctionOpcodes(preparedFuncConstant, generatedFunctionInfo.opcodes)
-
-			debugLinesOctets, debugLinesErr := opcode_sp.SerializeDebugLines(generatedFunctionInfo.debugLines)
-			if debugLinesErr != nil {
-				return debugLinesErr
+			if g.definitions != nil {
+				g.definitions.addDefinition(fullyQualifiedName.String(), DefinitionKindFunction)
 			}
-
-			moduleContext.Constants().DefineFunctionDebugLines(preparedFuncConstant, uint(len(generatedFunctionInfo.debugLines)), debugLinesOctets)
-
-			generatedFunctionInfo.debugVariables = assembler_sp.GenerateVariablesWithScope(rootContext.scopeVariables, 1)
-			if verboseFlag >= verbosity.High {
-				assembler_sp.VariableInfosDebugOutput(generatedFunctionInfo.debugVariables)
+			generatedConstants, genErr := g.generateFunctionAndLambdas(module, moduleContext, named.Identifier(), maybeFunction, resourceNameLookup, verboseFlag)
+			if genErr != nil {
+				return genErr
 			}
-			moduleContext.Constants().DefineFunctionDebugScopes(preparedFuncConstant, generatedFunctionInfo.debugVariables)
-
+			functionConstants = append(functionConstants, generatedConstants...)
 		} else {
-			maybeConstant, _ := unknownType.(*decorated.Constant)
-			if maybeConstant != nil {
-				if verboseFlag >= verbosity.Mid {
-					log.Printf("--------------------------- GenerateAllLocalDefinedFunctions function %v --------------------------\n", fullyQualifiedName)
-				}
-			} else {
-				return fmt.Errorf("generate: unknown type %T", unknownType)
-			}
+			return fmt.Errorf("generate: unknown type %T", unknownType)
 		}
 	}
 
@@ -6,7 +6,20 @@
 package generate_sp
 
 import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
+
+	raff "github.com/piot/raff-go/src"
+	"github.com/swamp/assembler/lib/assembler_sp"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+	"github.com/swamp/compiler/src/optimize"
+	"github.com/swamp/compiler/src/packinspect"
+	"github.com/swamp/compiler/src/typeinfo"
+	swamppack "github.com/swamp/pack/lib"
 )
 
 func TestIntEqual(t *testing.T) {
@@ -206,13 +219,13 @@ func [constantfn DynPos 0010:104 func:isWinner]
 0000: ldz 24,$00EF
 0009: cpeqs 20,8,24
 0016: brfa 20 [label @004c]
-001d: ldi 36,2
-0026: muli 32,16,36
-0033: ldi 40,100
-003c: cpgti 0,32,40
+001d: ldi 24,2
+0026: muli 20,16,24
+0033: ldi 28,100
+003c: cpgti 0,20,28
 0049: jmp [label @0062]
-004c: ldi 44,100
-0055: cpgti 0,16,44
+004c: ldi 20,100
+0055: cpgti 0,16,20
 0062: ret
 
 func [constantfn DynPos 0080:104 func:main]
@@ -299,3 +312,806 @@ func [constantfn DynPos 0008:104 func:some]
 0035: ret
 `)
 }
+
+func TestLambda(t *testing.T) {
+	testGenerateWithoutCores(t,
+		`
+apply : (fn: (Int -> Int), a: Int) -> Int =
+    fn a
+
+
+main : (x: Int) -> Int =
+    apply (\a -> a + x) 3
+`, `
+func [constantfn DynPos 0008:104 func:apply]
+0000: cpy 28,(16:4)
+000b: call 24 8
+0014: cpy 0,(24:4)
+001f: ret
+
+func [constantfn DynPos 0078:104 func:main]
+0000: ldz 8,$0008
+0009: ldz 40,$00F0
+0012: cpy 52,(4:4)
+001d: curry 24,40,(52:4) (typeId:1, align:4)
+002f: ldi 32,3
+0038: call 16 8
+0041: cpy 0,(16:4)
+004c: ret
+
+func [constantfn DynPos 00F0:104 func:main__lambda0]
+0000: addi 0,8,4
+000d: ret
+`)
+}
+
+func TestLambdaInferredFromLocalType(t *testing.T) {
+	testGenerateWithoutCores(t,
+		`
+__externalvarfn map : ((a -> b), List a) -> List b
+
+
+main : (limit: Int) -> List Bool =
+    [ 1, 2, 3 ]
+        |> map (\v -> v > limit)
+`, `
+func [constantfn DynPos 0008:144 funcExternal:map]
+
+
+func [constantfn DynPos 00A0:104 func:main]
+0000: ldz 16,$0008
+0009: ldz 48,$0118
+0012: cpy 60,(8:4)
+001d: curry 32,48,(60:4) (typeId:8, align:4)
+002f: ldi 48,1
+0038: ldi 52,2
+0041: ldi 56,3
+004a: crl 40 [48 52 56] (4, 4)
+005f: callexternal_var 24 16 [{0 8} {8 8} {16 8}]
+0075: cpy 0,(24:8)
+0080: ret
+
+func [constantfn DynPos 0118:104 func:main__lambda0]
+0000: cpgti 0,8,4
+000d: ret
+`)
+}
+
+func TestCaseNestedPatterns(t *testing.T) {
+	testGenerateWithoutCores(t,
+		`
+type Shape =
+    Point
+    | Circle Int
+
+
+area : (a: (Shape, Int)) -> Int =
+    case a of
+        ( Circle 0, _ ) -> 0
+
+        ( Circle r, scale ) -> r * scale
+
+        ( Point, _ ) -> 1
+	`, `
+func [constantfn DynPos 0008:104 func:area]
+0000: cse 4 [[0 [label @000c]] [1 [label offset @000f]]]
+000c: jmp [label @003f]
+000f: jmppmi 8 [[0 [label @001d]]] [label offset @0020]
+001d: jmp [label @0023]
+0020: jmp [label @002f]
+0023: ldi 0,0
+002c: jmp [label @0048]
+002f: muli 0,8,12
+003c: jmp [label @0048]
+003f: ldi 0,1
+0048: ret
+`)
+}
+
+func TestCaseGuard(t *testing.T) {
+	testGenerateWithoutCores(t,
+		`
+type Motion =
+    Standing
+    | Running Int
+
+
+speed : (a: Motion) -> Int =
+    case a of
+        Running s | s > 10 -> 10
+
+        Running s -> s
+
+        Standing -> 0
+	`, `
+func [constantfn DynPos 0008:104 func:speed]
+0000: cse 4 [[1 [label @000c]] [0 [label offset @0043]]]
+000c: ldi 16,10
+0015: cpgti 12,8,16
+0022: brfa 12 [label @0035]
+0029: ldi 0,10
+0032: jmp [label @004c]
+0035: cpy 0,(8:4)
+0040: jmp [label @004c]
+0043: ldi 0,0
+004c: ret
+`)
+}
+
+func TestLetFunctionInferred(t *testing.T) {
+	testGenerateWithoutCores(t,
+		`
+main : (x: Int) -> Int =
+    let
+        addX = \a -> a + x
+    in
+    addX 3
+`, `
+func [constantfn DynPos 0008:104 func:main]
+0000: ldz 16,$0080
+0009: cpy 28,(4:4)
+0014: curry 8,16,(28:4) (typeId:1, align:4)
+0026: ldi 20,3
+002f: call 16 8
+0038: cpy 0,(16:4)
+0043: ret
+
+func [constantfn DynPos 0080:104 func:main__lambda0]
+0000: addi 0,8,4
+000d: ret
+`)
+}
+
+func TestTypeConstraintSpecialization(t *testing.T) {
+	testGenerateWithoutCores(t,
+		`
+scale : (a: number, b: number) -> number =
+    a * b
+
+
+scaleInt : (x: Int) -> Int =
+    scale x 3
+
+
+scaleFixed : (x: Fixed) -> Fixed =
+    scale x 2.5
+`, `
+func [constantfn DynPos 0018:104 func:scale__Int_Int_Int]
+0000: muli 0,4,8
+000d: ret
+
+func [constantfn DynPos 00A0:104 func:scale__Fixed_Fixed_Fixed]
+0000: fxmul 0,4,8
+000d: ret
+
+func [constantfn DynPos 0118:104 func:scaleInt]
+0000: ldz 8,$0018
+0009: cpy 20,(4:4)
+0014: ldi 24,3
+001d: call 16 8
+0026: cpy 0,(16:4)
+0031: ret
+
+func [constantfn DynPos 0190:104 func:scaleFixed]
+0000: ldz 8,$00A0
+0009: cpy 20,(4:4)
+0014: ldi 24,2500
+001d: call 16 8
+0026: cpy 0,(16:4)
+0031: ret
+`)
+}
+
+func TestTypeInformationRoundTrip(t *testing.T) {
+	testTypeInfoRoundTrip(t,
+		`
+type alias Position =
+    { x : Int
+    , y : Fixed
+    }
+
+
+type Shape a =
+    Circle Position Int
+    | Tagged a
+    | Empty
+
+
+type alias Scene =
+    { shapes : List (Shape String)
+    , ids : Array Char
+    , pair : (Bool, Blob)
+    , onHit : (Int -> Bool)
+    }
+
+
+first : (_: Scene) -> Int =
+    2
+`, `
+0: Char
+1: Array <0>
+2: Int
+3: Bool
+4: function (params:2, 3)
+5: Blob
+6: tuple (16, 8) [3 @0 (1, 1), 5 @8 (8, 8)]
+7: String
+8: Fixed
+9: record (8, 4) [x:2 @0 (4, 4), y:8 @4 (4, 4)]
+10: alias Position -> 9
+11: variant Circle in 14 (16, 8) [10 @4 (8, 4), 2 @12 (4, 4)]
+12: variant Tagged in 14 (16, 8) [7 @8 (8, 8)]
+13: variant Empty in 14 (16, 8) []
+14: custom Shape<7> (16, 8) variants:11, 12, 13
+15: List <14>
+16: record (40, 8) [ids:1 @0 (8, 8), onHit:4 @8 (8, 8), pair:6 @16 (16, 8), shapes:15 @32 (8, 8)]
+17: alias Scene -> 16
+18: function (params:17, 2)
+`)
+}
+
+func TestTypeInformationDeserializeErrors(t *testing.T) {
+	for _, octets := range [][]byte{
+		{0, 1, 0, 0, 0},                   // unsupported version
+		{0, 2, 0, 0, 1},                   // missing type
+		{0, 2, 0, 0, 1, 0xff},             // unknown type id
+		{0, 2, 0, 0, 1, 6, 0, 1, 0, 0, 0}, // list item type outside of the chunk
+	} {
+		if _, err := typeinfo.DeserializeFromOctets(octets); err == nil {
+			t.Errorf("expected an error for %x", octets)
+		}
+	}
+}
+
+func TestExternalFunctionStackLayout(t *testing.T) {
+	testExternalFunctionStackLayout(t,
+		`
+type alias Position =
+    { x : Int
+    , y : Int
+    }
+
+
+__externalfn log : (value: Any, level: Int) -> Bool
+
+
+__externalfn move : (position: Position, enabled: Bool, name: String) -> Position
+
+
+__externalvarfn map : ((a -> b), List a) -> List b
+`, `
+log: return:0+1 0typeId:4+4 0:8+8 1:16+4
+move: return:0+8 0:8+8 1:16+1 2:24+8
+map: generic parameters:2
+`)
+}
+
+func TestDeadCodeElimination(t *testing.T) {
+	testDeadCodeElimination(t,
+		`
+limit : Int =
+    10
+
+
+unusedLimit : Int =
+    20
+
+
+neverUsed : Int =
+    30
+
+
+__externalfn called : (a: Int) -> Int
+
+
+__externalfn notCalled : (a: Int) -> Int
+
+
+double : (a: Int) -> Int =
+    a * 2
+
+
+onlyUsedByUnused : (a: Int) -> Int =
+    a + 1
+
+
+unused : (a: Int) -> Int =
+    onlyUsedByUnused (notCalled a)
+
+
+keptByRoot : (a: Int) -> Int =
+    a - unusedLimit
+
+
+main : (a: Int) -> Int =
+    called (double limit) + a
+`, []string{"keptByRoot"}, `
+roots: main keptByRoot
+removed external function notCalled
+removed function onlyUsedByUnused
+removed function unused
+unused constant neverUsed
+`)
+}
+
+func TestOptimizeConstantFolding(t *testing.T) {
+	testGenerateOptimized(t, optimize.LevelBasic,
+		`
+scale : (a: Int) -> Int =
+    a * (2 * 3 - 1)
+
+
+isBig : (a: Int) -> Bool =
+    a > 2 && 1 < 2
+
+
+greeting : (_: Int) -> String =
+    "hello, " ++ "world"
+`, `
+[constantstring DynPos 0241:16 hello, world]
+func [constantfn DynPos 0008:104 func:scale]
+0000: ldi 8,5
+0009: muli 0,4,8
+0016: ret
+
+func [constantfn DynPos 0078:104 func:isBig]
+0000: ldi 8,2
+0009: cpgti 0,4,8
+0016: ret
+
+func [constantfn DynPos 00F0:104 func:greeting]
+0000: ldz 0,$0241
+0009: ret
+`)
+}
+
+func TestOptimizeDeadLet(t *testing.T) {
+	testGenerateOptimized(t, optimize.LevelBasic,
+		`
+main : (a: Int) -> Int =
+    let
+        x = 2 + 3
+        y = x * 2
+    in
+    a + y
+`, `
+func [constantfn DynPos 0008:104 func:main]
+0000: ldi 8,10
+0009: addi 0,4,8
+0016: ret
+`)
+}
+
+func TestOptimizeIfElimination(t *testing.T) {
+	testGenerateOptimized(t, optimize.LevelBasic,
+		`
+main : (a: Int) -> Int =
+    if 3 > 1 then
+        a
+    else
+        a * 2
+`, `
+func [constantfn DynPos 0008:104 func:main]
+0000: cpy 0,(4:4)
+000b: ret
+`)
+}
+
+func TestOptimizeInlining(t *testing.T) {
+	testGenerateOptimized(t, optimize.LevelFull,
+		`
+add : (a: Int, b: Int) -> Int =
+    a + b
+
+
+main : (a: Int) -> Int =
+    add a 3 * add 1 2
+`, `
+func [constantfn DynPos 0008:104 func:add]
+0000: addi 0,4,8
+000d: ret
+
+func [constantfn DynPos 0078:104 func:main]
+0000: ldi 12,3
+0009: addi 8,4,12
+0016: ldi 12,3
+001f: muli 0,8,12
+002c: ret
+`)
+}
+
+func TestOptimizeLetSinking(t *testing.T) {
+	testGenerateOptimized(t, optimize.LevelFull,
+		`
+main : (a: Int) -> Int =
+    let
+        doubled = a * 2
+        tripled = a * 3
+    in
+    if a > 10 then
+        doubled
+    else
+        tripled
+`, `
+func [constantfn DynPos 0008:104 func:main]
+0000: ldi 12,10
+0009: cpgti 8,4,12
+0016: brfa 8 [label @0041]
+001d: ldi 12,2
+0026: muli 8,4,12
+0033: cpy 0,(8:4)
+003e: jmp [label @0062]
+0041: ldi 12,3
+004a: muli 8,4,12
+0057: cpy 0,(8:4)
+0062: ret
+`)
+}
+
+func TestStackFrames(t *testing.T) {
+	testStackFrames(t,
+		`
+main : (a: Int) -> Int =
+    if a > 10 then
+        a * 2 + 1
+    else
+        a * 3 - 1
+`, 0, `
+main 20
+`, "")
+}
+
+func TestStackFrameBudget(t *testing.T) {
+	budgetErr := testStackFrames(t,
+		`
+small : (a: Int) -> Int =
+    a
+
+
+main : (a: Int) -> Int =
+    a * 2 + small a
+`, 16, `
+small 8
+main 32
+`, "the stack frame of 'main' is 32 octets, which is over the budget of 16 octets")
+
+	exceeded, wasExceeded := budgetErr.(*decorated.StackFrameBudgetExceeded)
+	if !wasExceeded {
+		t.Fatalf("expected a stack frame budget diagnostic, got %T", budgetErr)
+	}
+	if line := exceeded.FetchPositionLength().Range.Start().Line(); line != 4 {
+		t.Errorf("expected the diagnostic at the declaration of 'main', got line %v", line)
+	}
+}
+
+func TestRelativeFileUrls(t *testing.T) {
+	fileUrls := []*assembler_sp.FileUrl{
+		{File: "file:///home/user/game/Main.swamp", ID: 0},
+		{File: "file:///home/user/game/Game/Logic.swamp", ID: 1},
+		{File: "file:///home/user/shared/Math.swamp", ID: 2},
+		{File: "file://Blob_internal", ID: 3},
+	}
+
+	var files []string
+	for _, fileUrl := range relativeFileUrls(fileUrls, "/home/user/game") {
+		files = append(files, fmt.Sprintf("%d:%v", fileUrl.ID, fileUrl.File))
+	}
+
+	actual := strings.Join(files, " ")
+	expected := "0:Main.swamp 1:Game/Logic.swamp 2:../shared/Math.swamp 3:file://Blob_internal"
+	if actual != expected {
+		t.Errorf("relative file urls mismatch, got:\n%v\n", actual)
+	}
+}
+
+func TestStripDebugInformation(t *testing.T) {
+	testStrip(t,
+		`
+main : (a: Int) -> Int =
+    a * 2 + 1
+`, "main", 0x0c, "file://fortest.swamp:2:5")
+}
+
+func TestLetFunctionGeneralized(t *testing.T) {
+	testGenerateWithoutCores(t,
+		`
+pick : (a: Int) -> (Int, String) =
+    let
+        identity = \x -> x
+    in
+    ( identity a, identity "hello" )
+`, `
+[constantstring DynPos 0166:16 hello]
+func [constantfn DynPos 0008:104 func:pick]
+0000: ldz 24,$0080
+0009: cpy 36,(16:4)
+0014: call 32 24
+001d: cpy 0,(32:4)
+0028: ldz 24,$00F8
+0031: ldz 40,$0166
+003a: call 32 24
+0043: cpy 8,(32:8)
+004e: ret
+
+func [constantfn DynPos 0080:104 func:pick__lambda0]
+0000: cpy 0,(4:4)
+000b: ret
+
+func [constantfn DynPos 00F8:104 func:pick__lambda1]
+0000: cpy 0,(8:8)
+000b: ret
+`)
+}
+
+func TestUnconstrainedGenericNotSpecialized(t *testing.T) {
+	testGenerateWithoutCores(t,
+		`
+count : (_: List a) -> Int =
+    2
+
+
+main : (Bool) -> Int =
+    count [ 1, 2, 3 ]
+`, `
+func [constantfn DynPos 0008:104 func:count]
+0000: ldi 0,2
+0009: ret
+
+func [constantfn DynPos 0078:104 func:main]
+0000: ldz 8,$0008
+0009: ldi 32,1
+0012: ldi 36,2
+001b: ldi 40,3
+0024: crl 24 [32 36 40] (4, 4)
+0039: call 16 8
+0042: cpy 0,(16:4)
+004d: ret
+`)
+}
+
+func TestPackRoundTrip(t *testing.T) {
+	testPackRoundTrip(t,
+		`
+__externalfn scale : (a: Int) -> Int
+
+
+greeting : (a: Int) -> String =
+    if a > 0 then
+        "hello"
+    else
+        "bye"
+
+
+sprite : (a: Int) -> ResourceName =
+    if a > 0 then
+        @sprites/hero
+    else
+        @sprites/enemy
+
+
+main : (a: Int) -> Int =
+    scale a * 2 + 1
+`, `
+layout lp64
+string "hello"
+string "bye"
+resource sprites/hero
+resource sprites/enemy
+file file://fortest.swamp
+external scale return:0+4 parameters:4+4
+func greeting parameters:1+4 return:8 align:8
+  0000 file://fortest.swamp:5:12
+  0009 file://fortest.swamp:5:8
+  0016 file://fortest.swamp:1:1
+  001d file://fortest.swamp:6:9
+  0026 file://fortest.swamp:1:1
+  0029 file://fortest.swamp:8:9
+  0032 file://fortest.swamp:5:10
+func sprite parameters:1+4 return:4 align:4
+  0000 file://fortest.swamp:12:12
+  0009 file://fortest.swamp:12:8
+  0016 file://fortest.swamp:1:1
+  001d file://fortest.swamp:13:9
+  0026 file://fortest.swamp:1:1
+  0029 file://fortest.swamp:15:9
+  0032 file://fortest.swamp:12:10
+func main parameters:1+4 return:4 align:4
+  0000 file://fortest.swamp:1:1
+  0009 file://fortest.swamp:19:11
+  0014 file://fortest.swamp:19:5
+  001d file://fortest.swamp:19:15
+  0026 file://fortest.swamp:19:5
+  0033 file://fortest.swamp:19:19
+  003c file://fortest.swamp:19:5
+  0049 file://fortest.swamp:19:5
+`)
+}
+
+const dataLayoutTestCode = `
+type alias Position =
+    { x : Int
+    , y : Fixed
+    }
+
+
+type Shape a =
+    Circle Position Int
+    | Tagged a
+    | Empty
+
+
+type alias Scene =
+    { shapes : List (Shape String)
+    , ids : Array Char
+    , pair : (Bool, Blob)
+    , onHit : (Int -> Bool)
+    }
+
+
+first : (_: Scene) -> Int =
+    2
+`
+
+func TestTypeInformation32BitDataLayouts(t *testing.T) {
+	// Only the pointers are smaller, so the records and variants with pointers shrink and get a smaller alignment
+	for _, dataLayout := range []dectype.DataLayout{dectype.DataLayoutILP32, dectype.DataLayoutWasm32} {
+		t.Run(dataLayout.Name, func(t *testing.T) {
+			testTypeInfoRoundTripWithDataLayout(t, dataLayout, dataLayoutTestCode, `
+0: Char
+1: Array <0>
+2: Int
+3: Bool
+4: function (params:2, 3)
+5: Blob
+6: tuple (8, 4) [3 @0 (1, 1), 5 @4 (4, 4)]
+7: String
+8: Fixed
+9: record (8, 4) [x:2 @0 (4, 4), y:8 @4 (4, 4)]
+10: alias Position -> 9
+11: variant Circle in 14 (16, 4) [10 @4 (8, 4), 2 @12 (4, 4)]
+12: variant Tagged in 14 (16, 4) [7 @4 (4, 4)]
+13: variant Empty in 14 (16, 4) []
+14: custom Shape<7> (16, 4) variants:11, 12, 13
+15: List <14>
+16: record (20, 4) [ids:1 @0 (4, 4), onHit:4 @4 (4, 4), pair:6 @8 (8, 4), shapes:15 @16 (4, 4)]
+17: alias Scene -> 16
+18: function (params:17, 2)
+`)
+		})
+	}
+}
+
+func TestExternalFunctionStackLayout32BitDataLayouts(t *testing.T) {
+	for _, dataLayout := range []dectype.DataLayout{dectype.DataLayoutILP32, dectype.DataLayoutWasm32} {
+		t.Run(dataLayout.Name, func(t *testing.T) {
+			testExternalFunctionStackLayoutWithDataLayout(t, dataLayout,
+				`
+type alias Position =
+    { x : Int
+    , y : Int
+    }
+
+
+__externalfn log : (value: Any, level: Int) -> Bool
+
+
+__externalfn move : (position: Position, enabled: Bool, name: String) -> Position
+
+
+__externalvarfn map : ((a -> b), List a) -> List b
+`, `
+log: return:0+1 0typeId:4+4 0:8+4 1:12+4
+move: return:0+8 0:8+8 1:16+1 2:20+4
+map: generic parameters:2
+`)
+		})
+	}
+}
+
+// packChunkNames returns the names of the chunks in the pack, and the payload of the first one.
+func packChunkNames(t *testing.T, packed []byte) ([]string, []byte) {
+	t.Helper()
+	reader := bytes.NewReader(packed)
+	if err := raff.ReadHeader(reader); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	var firstPayload []byte
+	for reader.Len() > 0 {
+		header, payload, chunkErr := raff.ReadChunk(reader)
+		if chunkErr != nil {
+			t.Fatal(chunkErr)
+		}
+		if names == nil {
+			firstPayload = payload
+		}
+		names = append(names, raff.NameToString(header.Name))
+	}
+
+	return names, firstPayload
+}
+
+const packTestCode = `
+type alias Player =
+    { name : String
+    , score : Int
+    , items : List Int
+    , alive : Bool
+    }
+
+
+__externalfn log : (value: Any, level: Int) -> Bool
+
+
+__externalfn greet : (player: Player, times: Int) -> String
+
+
+score : (player: Player) -> Int =
+    if log player.name 1 then
+        player.score
+    else
+        player.score * 2 + 1
+
+
+main : (a: Int) -> Int =
+    score { name = greet { name = "hero", score = a, items = [ 1, 2, 3 ], alive = a > 0 } 2, score = a, items = [], alive = a < 0 }
+`
+
+func TestPackHeader32BitDataLayouts(t *testing.T) {
+	for _, testCase := range []struct {
+		dataLayout      dectype.DataLayout
+		expectedPayload []byte
+	}{
+		{dectype.DataLayoutILP32, []byte{4, 4, 5, 'i', 'l', 'p', '3', '2'}},
+		{dectype.DataLayoutWasm32, []byte{4, 4, 6, 'w', 'a', 's', 'm', '3', '2'}},
+	} {
+		t.Run(testCase.dataLayout.Name, func(t *testing.T) {
+			_, packed := testGeneratePack(t, testCase.dataLayout, packTestCode)
+
+			names, headerPayload := packChunkNames(t, packed)
+			if expectedNames := []string{"spk6", "sti0", "dme1", "ldg0"}; !reflect.DeepEqual(names, expectedNames) {
+				t.Errorf("expected the chunks %v, got %v", expectedNames, names)
+			}
+			if !bytes.Equal(headerPayload, testCase.expectedPayload) {
+				t.Errorf("expected the header payload %x, got %x", testCase.expectedPayload, headerPayload)
+			}
+
+			inspected, readErr := packinspect.Read(packed)
+			if readErr != nil {
+				t.Fatal(readErr)
+			}
+			expectedLayout := packinspect.DataLayout{Name: testCase.dataLayout.Name, PointerSize: 4, PointerAlign: 4, Recorded: true}
+			if inspected.DataLayout != expectedLayout {
+				t.Errorf("expected the data layout %+v, got %+v", expectedLayout, inspected.DataLayout)
+			}
+		})
+	}
+}
+
+func TestPackLP64Unchanged(t *testing.T) {
+	gen, packed := testGeneratePack(t, dectype.DataLayoutLP64, packTestCode)
+
+	if names, _ := packChunkNames(t, packed); !reflect.DeepEqual(names, []string{"spk5", "sti0", "dme1", "ldg0"}) {
+		t.Errorf("expected lp64 to keep the spk5 header, got %v", names)
+	}
+
+	// Runtimes that only know spk5 must be able to read the pack as before, so it must be exactly what the spk5
+	// writer produces for the same constants
+	ledgerOctets, ledgerErr := PackLedger(gen.packageConstants.Constants())
+	if ledgerErr != nil {
+		t.Fatal(ledgerErr)
+	}
+	typeInformationOctets, typeInformationErr := typeinfo.ChunkToOctets(gen.chunk)
+	if typeInformationErr != nil {
+		t.Fatal(typeInformationErr)
+	}
+	spk5Packed, spk5Err := swamppack.Pack(ledgerOctets, gen.packageConstants.DynamicMemory().Octets(), typeInformationOctets)
+	if spk5Err != nil {
+		t.Fatal(spk5Err)
+	}
+
+	if !bytes.Equal(packed, spk5Packed) {
+		t.Errorf("expected the lp64 pack to be byte-identical to the spk5 pack")
+	}
+}
@@ -77,7 +77,7 @@ func generateGuard(code *assembler_sp.Code, target assembler_sp.TargetStackPosRa
 
 func handleGuard(code *assembler_sp.Code, guardExpr *decorated.Guard,
 	genContext *generateContext) (assembler_sp.SourceStackPosRange, error) {
-	targetPosRange := allocMemoryForType(genContext.context.stackMemory, guardExpr.Type(), "guard target")
+	targetPosRange := allocMemoryForType(genContext.context, guardExpr.Type(), "guard target")
 
 	if err := generateGuard(code, targetPosRange, guardExpr, genContext); err != nil {
 		return assembler_sp.SourceStackPosRange{}, err
@@ -12,11 +12,15 @@ import (
 )
 
 func generateIf(code *assembler_sp.Code, target assembler_sp.TargetStackPosRange, ifExpr *decorated.If, genContext *generateContext) error {
+	conditionStackPos := genContext.context.stackPosition()
 	conditionVar, testErr := generateExpressionWithSourceVar(code, ifExpr.Condition(), genContext, "if-condition")
 	if testErr != nil {
 		return testErr
 	}
 
+	// The condition is only read by the branch, before the consequence or the alternative, so they can reuse its memory
+	genContext.context.releaseStackMemory(conditionStackPos)
+
 	consequenceCode := assembler_sp.NewCode()
 	consequenceContext2 := genContext.MakeScopeContext("if consequenceContext")
 
@@ -51,7 +55,7 @@ func generateIf(code *assembler_sp.Code, target assembler_sp.TargetStackPosRange
 
 func handleIf(code *assembler_sp.Code, guardExpr *decorated.If,
 	genContext *generateContext) (assembler_sp.SourceStackPosRange, error) {
-	targetPosRange := allocMemoryForType(genContext.context.stackMemory, guardExpr.Type(), "if target")
+	targetPosRange := allocMemoryForType(genContext.context, guardExpr.Type(), "if target")
 
 	if err := generateIf(code, targetPosRange, guardExpr, genContext); err != nil {
 		return assembler_sp.SourceStackPosRange{}, err
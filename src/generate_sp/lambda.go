@@ -0,0 +1,99 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package generate_sp
+
+import (
+	"fmt"
+
+	"github.com/swamp/assembler/lib/assembler_sp"
+	"github.com/swamp/compiler/src/ast"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+	"github.com/swamp/compiler/src/token"
+)
+
+func lambdaSuffix(lambda *decorated.Lambda) string {
+	return fmt.Sprintf("__lambda%d", lambda.Index())
+}
+
+// lambdaIdentifier is the name of the lifted function, derived from the function where the lambda was defined.
+func lambdaIdentifier(functionIdentifier *ast.VariableIdentifier, lambda *decorated.Lambda) *ast.VariableIdentifier {
+	name := functionIdentifier.Name() + lambdaSuffix(lambda)
+	return ast.NewVariableIdentifier(token.NewVariableSymbolToken(name, lambda.FetchPositionLength(), 0))
+}
+
+func generateLambda(code *assembler_sp.Code, target assembler_sp.TargetStackPosRange, lambda *decorated.Lambda,
+	genContext *generateContext) error {
+	functionName := assembler_sp.VariableName(genContext.context.functionName + lambdaSuffix(lambda))
+	functionConstant := genContext.context.Constants().FindFunction(functionName)
+	if functionConstant == nil {
+		return fmt.Errorf("generatesp: %v couldn't find lambda function '%s'", lambda.FetchPositionLength().ToReferenceString(), functionName)
+	}
+
+	filePosition := genContext.toFilePosition(lambda.FetchPositionLength())
+
+	if len(lambda.Captures()) == 0 {
+		code.LoadZeroMemoryPointer(target.Pos, functionConstant.PosRange().Position, filePosition)
+		return nil
+	}
+
+	beforePos := genContext.context.stackPosition()
+
+	functionRegister, functionErr := constantToSourceStackPosRange(code, genContext.context, functionConstant)
+	if functionErr != nil {
+		return functionErr
+	}
+
+	indexIntoTypeInformationChunk, lookupErr := genContext.lookup.Lookup(lambda.Type())
+	if lookupErr != nil {
+		return lookupErr
+	}
+
+	invokedReturnType := dectype.UnaliasWithResolveInvoker(lambda.FunctionType().ReturnType())
+
+	genContext.context.stackMemory.AlignUpForMax()
+
+	allocMemoryForType(genContext.context, invokedReturnType, "lambda return")
+
+	captures := lambda.Captures()
+	arguments := make([]assembler_sp.TargetStackPosRange, len(captures))
+	for index, capture := range captures {
+		arguments[index] = allocMemoryForType(genContext.context, capture.Type(), fmt.Sprintf("capture %v", capture.Name().Name()))
+	}
+
+	_, firstAlign := dectype.GetMemorySizeAndAlignment(genContext.context.dataLayout, captures[0].Type())
+
+	for index, capture := range captures {
+		sourcePosRange, lookupVariableErr := handleNormalVariableLookup(genContext.context.scopeVariables, capture.Name().Name())
+		if lookupVariableErr != nil {
+			return lookupVariableErr
+		}
+		code.CopyMemory(arguments[index].Pos, sourcePosRange, filePosition)
+	}
+
+	lastArgument := arguments[len(arguments)-1]
+	completeArgumentRange := assembler_sp.SourceStackPosRange{
+		Pos:  assembler_sp.SourceStackPos(arguments[0].Pos),
+		Size: assembler_sp.SourceStackRange((uint(lastArgument.Pos) + uint(lastArgument.Size)) - uint(arguments[0].Pos)),
+	}
+
+	code.Curry(target.Pos, uint16(indexIntoTypeInformationChunk), assembler_sp.MemoryAlign(firstAlign), functionRegister.Pos, completeArgumentRange, filePosition)
+
+	genContext.context.releaseStackMemory(beforePos)
+
+	return nil
+}
+
+func handleLambda(code *assembler_sp.Code, lambda *decorated.Lambda,
+	genContext *generateContext) (assembler_sp.SourceStackPosRange, error) {
+	targetPosRange := allocatePointer(genContext.context, "")
+
+	if err := generateLambda(code, targetPosRange, lambda, genContext); err != nil {
+		return assembler_sp.SourceStackPosRange{}, err
+	}
+
+	return targetToSourceStackPosRange(targetPosRange), nil
+}
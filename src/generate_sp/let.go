@@ -28,6 +28,11 @@ func generateLet(code *assembler_sp.Code, target assembler_sp.TargetStackPosRang
 
 	var variablesInThisScope []*assembler_sp.VariableImpl
 	for assignmentIndex, assignment := range let.Assignments() {
+		// Generic let functions are generated where they are used
+		if lambda, wasLambda := assignment.Expression().(*decorated.Lambda); wasLambda && lambda.IsGeneric() {
+			continue
+		}
+
 		sourceVar, sourceErr := generateExpressionWithSourceVar(code, assignment.Expression(), letContext, "let source")
 		if sourceErr != nil {
 			return sourceErr
@@ -108,3 +113,14 @@ func generateLet(code *assembler_sp.Code, target assembler_sp.TargetStackPosRang
 
 	return nil
 }
+
+func handleLet(code *assembler_sp.Code, let *decorated.Let,
+	genContext *generateContext) (assembler_sp.SourceStackPosRange, error) {
+	targetPosRange := allocMemoryForType(genContext.context, let.Type(), "let target")
+
+	if err := generateLet(code, targetPosRange, let, genContext); err != nil {
+		return assembler_sp.SourceStackPosRange{}, err
+	}
+
+	return targetToSourceStackPosRange(targetPosRange), nil
+}
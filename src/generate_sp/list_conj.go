@@ -23,7 +23,7 @@ func generateListCons(code *assembler_sp.Code, target assembler_sp.TargetStackPo
 		return rightErr
 	}
 
-	itemSize, itemAlign := dectype.GetMemorySizeAndAlignment(operator.Left().Type())
+	itemSize, itemAlign := dectype.GetMemorySizeAndAlignment(genContext.context.dataLayout, operator.Left().Type())
 
 	filePosition := genContext.toFilePosition(operator.FetchPositionLength())
 	code.ListConj(target.Pos, leftVar.Pos, assembler_sp.StackItemSize(itemSize), opcode_sp_type.MemoryAlign(itemAlign), rightVar.Pos, filePosition)
@@ -32,7 +32,7 @@ func generateListCons(code *assembler_sp.Code, target assembler_sp.TargetStackPo
 }
 
 func handleListCons(code *assembler_sp.Code, operator *decorated.ConsOperator, genContext *generateContext) (assembler_sp.SourceStackPosRange, error) {
-	posRange := genContext.context.stackMemory.Allocate(uint(opcode_sp_type.Sizeof64BitPointer), uint32(opcode_sp_type.Alignof64BitPointer), "list struct pointer")
+	posRange := allocatePointer(genContext.context, "list struct pointer")
 	if err := generateListCons(code, posRange, operator, genContext); err != nil {
 		return assembler_sp.SourceStackPosRange{}, err
 	}
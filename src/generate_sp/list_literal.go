@@ -27,7 +27,7 @@ func generateList(code *assembler_sp.Code, target assembler_sp.TargetStackPosRan
 	}
 	primitive, _ := list.Type().(*dectype.PrimitiveAtom)
 	firstPrimitiveType := primitive.GenericTypes()[0]
-	itemSize, itemAlign := dectype.GetMemorySizeAndAlignment(firstPrimitiveType)
+	itemSize, itemAlign := dectype.GetMemorySizeAndAlignment(genContext.context.dataLayout, firstPrimitiveType)
 	filePosition := genContext.toFilePosition(list.FetchPositionLength())
 	code.ListLiteral(target.Pos, variables, assembler_sp.StackRange(itemSize), opcode_sp_type.MemoryAlign(itemAlign), filePosition)
 	return nil
@@ -35,7 +35,7 @@ func generateList(code *assembler_sp.Code, target assembler_sp.TargetStackPosRan
 
 func handleList(code *assembler_sp.Code,
 	list *decorated.ListLiteral, genContext *generateContext) (assembler_sp.SourceStackPosRange, error) {
-	posRange := genContext.context.stackMemory.Allocate(uint(opcode_sp_type.Sizeof64BitPointer), uint32(opcode_sp_type.Alignof64BitPointer), "listLiteral")
+	posRange := allocatePointer(genContext.context, "listLiteral")
 	if err := generateList(code, posRange, list, genContext); err != nil {
 		return assembler_sp.SourceStackPosRange{}, err
 	}
@@ -14,7 +14,7 @@ import (
 
 func generateStringLiteral(code *assembler_sp.Code, target assembler_sp.TargetStackPosRange, str *decorated.StringLiteral,
 	genContext *generateContext) error {
-	if target.Size != assembler_sp.StackRange(opcode_sp_type.Sizeof64BitPointer) {
+	if pointerSize, _ := genContext.context.dataLayout.PointerSizeAndAlignment(); target.Size != assembler_sp.StackRange(pointerSize) {
 		panic("wrong size")
 	}
 	constants := genContext.context.constants
@@ -6,12 +6,31 @@
 package generate_sp
 
 import (
+	"bytes"
 	"encoding/binary"
+	"fmt"
 
+	raff "github.com/piot/raff-go/src"
 	"github.com/swamp/assembler/lib/assembler_sp"
+	dectype "github.com/swamp/compiler/src/decorated/types"
 	swamppack "github.com/swamp/pack/lib"
 )
 
+type packChunk struct {
+	icon    raff.FourOctets
+	name    raff.FourOctets
+	payload []byte
+}
+
+// PackHeaderPayload is the payload of the `spk6` chunk, that is used instead of `spk5` for all layouts except lp64.
+// Runtimes that only know `spk5` refuse those packs, since they assume 64-bit pointers:
+// pointer size (u8), pointer align (u8), name length (u8) and the name of the layout.
+func PackHeaderPayload(layout dectype.DataLayout) []byte {
+	payload := []byte{byte(layout.PointerSize), byte(layout.PointerAlign), byte(len(layout.Name))}
+
+	return append(payload, layout.Name...)
+}
+
 func PackLedger(constants []*assembler_sp.Constant) ([]byte, error) {
 	var octets []byte
 
@@ -31,11 +50,33 @@ func PackLedger(constants []*assembler_sp.Constant) ([]byte, error) {
 	return octets, nil
 }
 
-func Pack(constants []*assembler_sp.Constant, dynamicMemory []byte, typeInfoPayload []byte) ([]byte, error) {
+func Pack(constants []*assembler_sp.Constant, dynamicMemory []byte, typeInfoPayload []byte, layout dectype.DataLayout) ([]byte, error) {
 	ledgerOctets, ledgerErr := PackLedger(constants)
 	if ledgerErr != nil {
 		return nil, ledgerErr
 	}
 
-	return swamppack.Pack(ledgerOctets, dynamicMemory, typeInfoPayload)
+	if layout == dectype.DataLayoutLP64 {
+		return swamppack.Pack(ledgerOctets, dynamicMemory, typeInfoPayload)
+	}
+
+	chunks := []packChunk{
+		{icon: raff.MakeFourOctets(0xF0, 0x9F, 0x93, 0xA6), name: raff.MakeFourOctets('s', 'p', 'k', '6'), payload: PackHeaderPayload(layout)},
+		{icon: raff.MakeFourOctets(0xF0, 0x9F, 0x93, 0x9C), name: raff.MakeFourOctets('s', 't', 'i', '0'), payload: typeInfoPayload},
+		{icon: raff.MakeFourOctets(0xF0, 0x9F, 0x92, 0xBB), name: raff.MakeFourOctets('d', 'm', 'e', '1'), payload: dynamicMemory},
+		{icon: raff.MakeFourOctets(0xF0, 0x9F, 0x97, 0x92), name: raff.MakeFourOctets('l', 'd', 'g', '0'), payload: ledgerOctets},
+	}
+
+	var buf bytes.Buffer
+	if err := raff.WriteHeader(&buf); err != nil {
+		return nil, fmt.Errorf("pack write header %w", err)
+	}
+
+	for _, chunk := range chunks {
+		if err := raff.WriteChunk(&buf, chunk.icon, chunk.name, chunk.payload); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
 }
@@ -27,7 +27,7 @@ func generatePipeRight(code *assembler_sp.Code, target assembler_sp.TargetStackP
 }
 
 func handlePipeRight(code *assembler_sp.Code, operator *decorated.PipeRightOperator, genContext *generateContext) (assembler_sp.SourceStackPosRange, error) {
-	posRange := allocMemoryForType(genContext.context.stackMemory, operator.GenerateRight().Type(), "pipeRight")
+	posRange := allocMemoryForType(genContext.context, operator.GenerateRight().Type(), "pipeRight")
 
 	if err := generatePipeRight(code, posRange, operator, genContext); err != nil {
 		return assembler_sp.SourceStackPosRange{}, err
@@ -37,7 +37,7 @@ func handlePipeRight(code *assembler_sp.Code, operator *decorated.PipeRightOpera
 }
 
 func handlePipeLeft(code *assembler_sp.Code, operator *decorated.PipeLeftOperator, genContext *generateContext) (assembler_sp.SourceStackPosRange, error) {
-	posRange := allocMemoryForType(genContext.context.stackMemory, operator.GenerateLeft().Type(), "pipeLeft")
+	posRange := allocMemoryForType(genContext.context, operator.GenerateLeft().Type(), "pipeLeft")
 
 	if err := generatePipeLeft(code, posRange, operator, genContext); err != nil {
 		return assembler_sp.SourceStackPosRange{}, err
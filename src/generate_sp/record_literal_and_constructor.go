@@ -40,7 +40,7 @@ func generateRecordConstructorSortedAssignments(code *assembler_sp.Code, target
 func handleRecordConstructorSortedAssignments(code *assembler_sp.Code,
 	recordConstructor *decorated.RecordConstructorFromParameters, genContext *generateContext) (assembler_sp.SourceStackPosRange, error) {
 	recordType := recordConstructor.RecordType()
-	targetPosRange := allocMemoryForType(genContext.context.stackMemory, recordType, "record constructor: "+recordType.HumanReadable())
+	targetPosRange := allocMemoryForType(genContext.context, recordType, "record constructor: "+recordType.HumanReadable())
 	if err := generateRecordConstructorSortedAssignments(code, targetPosRange, recordConstructor, genContext); err != nil {
 		return assembler_sp.SourceStackPosRange{}, err
 	}
@@ -82,7 +82,7 @@ func generateRecordLiteral(code *assembler_sp.Code, target assembler_sp.TargetSt
 func handleRecordLiteral(code *assembler_sp.Code,
 	record *decorated.RecordLiteral, genContext *generateContext) (assembler_sp.SourceStackPosRange, error) {
 	recordType := record.RecordType()
-	targetPosRange := allocMemoryForType(genContext.context.stackMemory, recordType, "record literal: "+recordType.HumanReadable())
+	targetPosRange := allocMemoryForType(genContext.context, recordType, "record literal: "+recordType.HumanReadable())
 	if err := generateRecordLiteral(code, targetPosRange, record, genContext); err != nil {
 		return assembler_sp.SourceStackPosRange{}, err
 	}
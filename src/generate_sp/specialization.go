@@ -0,0 +1,18 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package generate_sp
+
+import (
+	"github.com/swamp/compiler/src/ast"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	"github.com/swamp/compiler/src/token"
+)
+
+// specializationIdentifier is the name of the generated function for a specialization of a generic function.
+func specializationIdentifier(functionIdentifier *ast.VariableIdentifier, specialization *decorated.FunctionSpecialization) *ast.VariableIdentifier {
+	name := functionIdentifier.Name() + specialization.Suffix()
+	return ast.NewVariableIdentifier(token.NewVariableSymbolToken(name, functionIdentifier.FetchPositionLength(), 0))
+}
@@ -0,0 +1,113 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package generate_sp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/swamp/assembler/lib/assembler_sp"
+	"github.com/swamp/compiler/src/decorated/decshared"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	"github.com/swamp/compiler/src/token"
+)
+
+// stackFrame records the highest position that the stack memory of a function has reached. The stack memory is
+// rewound when an expression is done, so the position at the end of the function is not the size of the frame.
+type stackFrame struct {
+	peak assembler_sp.TargetStackPos
+}
+
+func (f *stackFrame) reached(pos assembler_sp.TargetStackPos) {
+	if pos > f.peak {
+		f.peak = pos
+	}
+}
+
+// StackFrame is the peak stack memory of a generated function or lambda. It includes the return value, the
+// parameters, the let variables, the temporaries and the arguments for the functions that it calls, but not the
+// frames of the called functions. Reference is the declaration of the function or the lambda.
+type StackFrame struct {
+	Name      string
+	Octets    uint
+	Reference token.SourceFileReference
+}
+
+// StackReport is the peak stack memory for each function in a package.
+type StackReport struct {
+	PackageName string
+	Budget      uint
+	Frames      []StackFrame
+}
+
+// OverBudget returns the frames that are larger than the budget. A zero budget has no limit.
+func (r *StackReport) OverBudget() []StackFrame {
+	if r.Budget == 0 {
+		return nil
+	}
+
+	var over []StackFrame
+	for _, frame := range r.Frames {
+		if frame.Octets > r.Budget {
+			over = append(over, frame)
+		}
+	}
+
+	return over
+}
+
+// sortedFrames returns the largest frames first.
+func (r *StackReport) sortedFrames() []StackFrame {
+	frames := append([]StackFrame(nil), r.Frames...)
+	sort.SliceStable(frames, func(i, j int) bool {
+		if frames[i].Octets != frames[j].Octets {
+			return frames[i].Octets > frames[j].Octets
+		}
+		return frames[i].Name < frames[j].Name
+	})
+
+	return frames
+}
+
+func (r *StackReport) String() string {
+	var lines []string
+
+	if r.Budget == 0 {
+		lines = append(lines, fmt.Sprintf("stack frames in '%v'", r.PackageName))
+	} else {
+		lines = append(lines, fmt.Sprintf("stack frames in '%v' (budget: %d octets)", r.PackageName, r.Budget))
+	}
+
+	for _, frame := range r.sortedFrames() {
+		line := fmt.Sprintf("  %6d octets  %v", frame.Octets, frame.Name)
+		if r.Budget != 0 && frame.Octets > r.Budget {
+			line += " (over budget)"
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// checkBudget returns a diagnostic at the declaration of each function with a frame that is larger than the budget.
+func (r *StackReport) checkBudget() decshared.DecoratedError {
+	over := r.OverBudget()
+	if len(over) == 0 {
+		return nil
+	}
+
+	var errors []decshared.DecoratedError
+	for _, frame := range over {
+		errors = append(errors, decorated.NewStackFrameBudgetExceeded(frame.Reference, frame.Name, frame.Octets, r.Budget))
+	}
+
+	if len(errors) == 1 {
+		return errors[0]
+	}
+
+	return decorated.NewMultiErrors(errors)
+}
@@ -6,6 +6,7 @@
 package generate_sp
 
 import (
+	"bytes"
 	"fmt"
 	"log"
 	"reflect"
@@ -16,8 +17,11 @@ import (
 
 	dectype "github.com/swamp/compiler/src/decorated/types"
 	"github.com/swamp/compiler/src/loader"
+	"github.com/swamp/compiler/src/optimize"
+	"github.com/swamp/compiler/src/packinspect"
 
 	"github.com/swamp/compiler/src/parser"
+	"github.com/swamp/compiler/src/resourceid"
 
 	"github.com/swamp/assembler/lib/assembler_sp"
 	deccy "github.com/swamp/compiler/src/decorated"
@@ -27,6 +31,10 @@ import (
 )
 
 func testGenerateInternal(code string, useCores bool) (*assembler_sp.PackageConstants, []*assembler_sp.Constant, error) {
+	return testGenerateInternalOptimized(code, useCores, optimize.LevelNone)
+}
+
+func testGenerateInternalOptimized(code string, useCores bool, optimizeLevel optimize.Level) (*assembler_sp.PackageConstants, []*assembler_sp.Constant, error) {
 	//const useCores = true
 	const errorsAsWarnings = false
 	module, compileErr := deccy.CompileToModuleOnceForTest(code, useCores, errorsAsWarnings)
@@ -35,17 +43,23 @@ func testGenerateInternal(code string, useCores bool) (*assembler_sp.PackageCons
 	}
 
 	fileSystemRoot := loader.LocalFileSystemRoot("")
-	pack := loader.NewPackage(fileSystemRoot, "someName")
+	pack := loader.NewPackage(fileSystemRoot, "someName", dectype.DataLayoutLP64)
 	fullyQualifiedName := dectype.MakeArtifactFullyQualifiedModuleName(nil)
 	pack.AddModule(fullyQualifiedName, module)
 	gen := NewGenerator()
 	gen.PrepareForNewPackage()
 	const verboseFlag = verbosity.None
-	_, _, resourceLookup, typeInfoErr := typeinfo.GenerateModule(module)
+	typeInfoOctets, _, resourceLookup, typeInfoErr := typeinfo.GenerateModule(module)
 	if typeInfoErr != nil {
 		return nil, nil, typeInfoErr
 	}
 
+	if roundTripErr := checkTypeInfoRoundTrip(typeInfoOctets); roundTripErr != nil {
+		return nil, nil, roundTripErr
+	}
+
+	optimize.Package(pack, optimizeLevel, verboseFlag)
+
 	genErr := gen.GenerateFromPackage(pack, resourceLookup, verboseFlag)
 	if parser.IsCompileErr(genErr) {
 		return nil, nil, genErr
@@ -56,6 +70,32 @@ func testGenerateInternal(code string, useCores bool) (*assembler_sp.PackageCons
 	return gen.PackageConstants(), gen.LastFunctionConstants(), compileErr
 }
 
+// checkTypeInfoRoundTrip checks that the type information of every test program can be read back, and that it is
+// written exactly the same way again.
+func checkTypeInfoRoundTrip(octets []byte) error {
+	chunk, deserializeErr := typeinfo.DeserializeFromOctets(octets)
+	if deserializeErr != nil {
+		return fmt.Errorf("type information round trip: %w", deserializeErr)
+	}
+
+	for index, infoType := range chunk.InfoTypes() {
+		if infoType.Index() != index {
+			return fmt.Errorf("type information round trip: type %v has index %d, expected %d", infoType.Ref(), infoType.Index(), index)
+		}
+	}
+
+	serializedAgain, serializeErr := typeinfo.SerializeToOctets(chunk)
+	if serializeErr != nil {
+		return fmt.Errorf("type information round trip: %w", serializeErr)
+	}
+
+	if !bytes.Equal(octets, serializedAgain) {
+		return fmt.Errorf("type information round trip: serialized octets differ\n%x\n%x", octets, serializedAgain)
+	}
+
+	return nil
+}
+
 func checkGeneratedAssembler(constants *assembler_sp.PackageConstants, functions []*assembler_sp.Constant, expectedAsm string) error {
 	assemblerOutput := constants.DebugString([]assembler_sp.ConstantType{assembler_sp.ConstantTypeString, assembler_sp.ConstantTypeResourceName})
 	if len(assemblerOutput) > 0 {
@@ -113,6 +153,19 @@ func testGenerateWithoutCores(t *testing.T, code string, expectedAsm string) {
 	testGenerateHelper(t, code, expectedAsm, false)
 }
 
+// testGenerateOptimized checks the disassembly after the passes of the optimization level have been run.
+func testGenerateOptimized(t *testing.T, optimizeLevel optimize.Level, code string, expectedAsm string) {
+	const useCores = false
+	constants, functions, generateErr := testGenerateInternalOptimized(strings.TrimSpace(code), useCores, optimizeLevel)
+	if generateErr != nil && parser.TypeOfWarningRecursive(generateErr) >= parser.ReportAsSeverityError {
+		t.Fatal(generateErr)
+	}
+
+	if checkErr := checkGeneratedAssembler(constants, functions, expectedAsm); checkErr != nil {
+		t.Error(checkErr)
+	}
+}
+
 func testGenerateFail(t *testing.T, code string, expectedError interface{}) {
 	code = strings.TrimSpace(code)
 	_, _, testErr := testGenerateInternal(code, true)
@@ -121,8 +174,371 @@ func testGenerateFail(t *testing.T, code string, expectedError interface{}) {
 		t.Errorf("was supposed to fail")
 		return
 	}
-	isSameErr := reflect.TypeOf(expectedError) == reflect.TypeOf(testErr)
-	if !isSameErr {
-		t.Errorf("generate: unexpected fail: expected %T but received %T", expectedError, testErr)
+	if !isErrorOfType(expectedError, testErr) {
+		t.Errorf("generate: unexpected fail: expected %T but received %T %v", expectedError, testErr, testErr)
+	}
+}
+
+func isErrorOfType(expectedError interface{}, testErr error) bool {
+	multiErr, wasMultiErr := testErr.(*decorated.MultiErrors)
+	if !wasMultiErr {
+		return reflect.TypeOf(expectedError) == reflect.TypeOf(testErr)
+	}
+
+	for _, foundErr := range multiErr.Errors() {
+		if isErrorOfType(expectedError, foundErr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func describeMemoryOffsetInfo(info typeinfo.MemoryOffsetInfo) string {
+	return fmt.Sprintf("@%d (%d, %d)", info.MemoryOffset, info.MemoryInfo.MemorySize, info.MemoryInfo.MemoryAlign)
+}
+
+// describeInfoTypeLayout is like String(), but includes the memory layout and the generics.
+func describeInfoTypeLayout(infoType typeinfo.InfoType) string {
+	switch t := infoType.(type) {
+	case *typeinfo.RecordType:
+		var fields []string
+		for _, field := range t.Fields() {
+			fields = append(fields, fmt.Sprintf("%v:%v %v", field.Name(), field.FieldType().Ref(), describeMemoryOffsetInfo(field.MemoryOffsetInfo())))
+		}
+		return fmt.Sprintf("record (%d, %d) [%v]", t.MemoryInfo().MemorySize, t.MemoryInfo().MemoryAlign, strings.Join(fields, ", "))
+	case *typeinfo.TupleType:
+		var fields []string
+		for _, field := range t.Fields() {
+			fields = append(fields, fmt.Sprintf("%v %v", field.FieldType().Ref(), describeMemoryOffsetInfo(field.MemoryOffsetInfo())))
+		}
+		return fmt.Sprintf("tuple (%d, %d) [%v]", t.MemoryInfo().MemorySize, t.MemoryInfo().MemoryAlign, strings.Join(fields, ", "))
+	case *typeinfo.Variant:
+		var fields []string
+		for _, field := range t.Fields() {
+			fields = append(fields, fmt.Sprintf("%v %v", field.FieldType().Ref(), describeMemoryOffsetInfo(field.MemoryOffsetInfo())))
+		}
+		return fmt.Sprintf("variant %v in %v (%d, %d) [%v]", t.Name(), t.InCustomType().Ref(), t.MemoryInfo().MemorySize, t.MemoryInfo().MemoryAlign, strings.Join(fields, ", "))
+	case *typeinfo.CustomType:
+		var variants []string
+		for _, variant := range t.Variants() {
+			variants = append(variants, variant.Ref())
+		}
+		return fmt.Sprintf("custom %v<%v> (%d, %d) variants:%v", t.Name(), typeinfo.Refs(t.Generics()), t.MemoryInfo().MemorySize, t.MemoryInfo().MemoryAlign, strings.Join(variants, ", "))
+	}
+
+	return fmt.Sprintf("%v", infoType)
+}
+
+func testTypeInfoRoundTrip(t *testing.T, code string, expectedTypes string) {
+	testTypeInfoRoundTripWithDataLayout(t, dectype.DataLayoutLP64, code, expectedTypes)
+}
+
+func testTypeInfoRoundTripWithDataLayout(t *testing.T, dataLayout dectype.DataLayout, code string, expectedTypes string) {
+	const useCores = false
+	const errorsAsWarnings = false
+	module, compileErr := deccy.CompileToModuleOnceForTestWithDataLayout(code, useCores, errorsAsWarnings, dataLayout)
+	if parser.IsCompileError(compileErr) {
+		t.Fatal(compileErr)
+	}
+
+	typeInfoOctets, _, _, typeInfoErr := typeinfo.GenerateModule(module)
+	if typeInfoErr != nil {
+		t.Fatal(typeInfoErr)
+	}
+
+	if err := checkTypeInfoRoundTrip(typeInfoOctets); err != nil {
+		t.Fatal(err)
+	}
+
+	chunk, deserializeErr := typeinfo.DeserializeFromOctets(typeInfoOctets)
+	if deserializeErr != nil {
+		t.Fatal(deserializeErr)
+	}
+
+	var lines []string
+	for _, infoType := range chunk.InfoTypes() {
+		lines = append(lines, fmt.Sprintf("%v: %v", infoType.Ref(), describeInfoTypeLayout(infoType)))
+	}
+
+	actual := strings.Join(lines, "\n")
+	if actual != strings.TrimSpace(expectedTypes) {
+		t.Errorf("type information mismatch, got:\n%v\n", actual)
+	}
+}
+
+func describeExternalFunctionStackLayout(layout ExternalFunctionStackLayout) string {
+	if layout.HasLocalTypes {
+		return fmt.Sprintf("generic parameters:%d", len(layout.Slots))
+	}
+
+	parts := []string{fmt.Sprintf("return:%d+%d", layout.Return.Pos, layout.Return.Size)}
+	for _, slot := range layout.Slots {
+		kind := ""
+		if slot.IsTypeId {
+			kind = "typeId"
+		}
+		parts = append(parts, fmt.Sprintf("%d%v:%d+%d", slot.ParameterIndex, kind, slot.Range.Pos, slot.Range.Size))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func testExternalFunctionStackLayout(t *testing.T, code string, expectedLayouts string) {
+	testExternalFunctionStackLayoutWithDataLayout(t, dectype.DataLayoutLP64, code, expectedLayouts)
+}
+
+func testExternalFunctionStackLayoutWithDataLayout(t *testing.T, dataLayout dectype.DataLayout, code string, expectedLayouts string) {
+	const useCores = false
+	const errorsAsWarnings = false
+	module, compileErr := deccy.CompileToModuleOnceForTestWithDataLayout(strings.TrimSpace(code), useCores, errorsAsWarnings, dataLayout)
+	if parser.IsCompileError(compileErr) {
+		t.Fatal(compileErr)
+	}
+
+	var lines []string
+	for _, named := range module.LocalDefinitions().Definitions() {
+		functionValue, isFunction := named.Expression().(*decorated.FunctionValue)
+		if !isFunction || !functionValue.IsSomeKindOfExternal() {
+			continue
+		}
+		layout := CalculateExternalFunctionStackLayout(module.DataLayout(), functionValue.ForcedFunctionType(), len(functionValue.Parameters()))
+		lines = append(lines, fmt.Sprintf("%v: %v", named.Identifier().Name(), describeExternalFunctionStackLayout(layout)))
+	}
+
+	actual := strings.Join(lines, "\n")
+	if actual != strings.TrimSpace(expectedLayouts) {
+		t.Errorf("external function layout mismatch, got:\n%v\n", actual)
+	}
+}
+
+func testDeadCodeElimination(t *testing.T, code string, roots []string, expectedReport string) {
+	const useCores = false
+	const errorsAsWarnings = false
+	module, compileErr := deccy.CompileToModuleOnceForTest(strings.TrimSpace(code), useCores, errorsAsWarnings)
+	if parser.IsCompileError(compileErr) {
+		t.Fatal(compileErr)
+	}
+
+	pack := loader.NewPackage(loader.LocalFileSystemRoot(""), "someName", dectype.DataLayoutLP64)
+	pack.AddModule(dectype.MakeArtifactFullyQualifiedModuleName(nil), module)
+
+	gen := NewGenerator()
+	gen.SetDeadCodeElimination(roots, false)
+	if genErr := gen.GenerateFromPackage(pack, resourceid.NewResourceNameLookupImpl(), verbosity.None); genErr != nil {
+		t.Fatal(genErr)
+	}
+
+	report := gen.DeadCodeReport()
+	lines := []string{fmt.Sprintf("roots: %v", strings.Join(report.Roots, " "))}
+	for _, removed := range report.Removed {
+		lines = append(lines, fmt.Sprintf("removed %v %v", removed.Kind, removed.Name))
+	}
+	for _, unusedConstant := range report.UnusedConstants {
+		lines = append(lines, fmt.Sprintf("unused constant %v", unusedConstant))
+	}
+
+	actual := strings.Join(lines, "\n")
+	if actual != strings.TrimSpace(expectedReport) {
+		t.Errorf("dead code mismatch, got:\n%v\n", actual)
+	}
+}
+
+func testStackFrames(t *testing.T, code string, budget uint, expectedFrames string, expectedErr string) error {
+	const useCores = false
+	const errorsAsWarnings = false
+	module, compileErr := deccy.CompileToModuleOnceForTest(strings.TrimSpace(code), useCores, errorsAsWarnings)
+	if parser.IsCompileError(compileErr) {
+		t.Fatal(compileErr)
+	}
+
+	pack := loader.NewPackage(loader.LocalFileSystemRoot(""), "someName", dectype.DataLayoutLP64)
+	pack.AddModule(dectype.MakeArtifactFullyQualifiedModuleName(nil), module)
+
+	gen := NewGenerator()
+	gen.SetStackBudget(budget, false)
+	genErr := gen.GenerateFromPackage(pack, resourceid.NewResourceNameLookupImpl(), verbosity.None)
+	if expectedErr == "" && genErr != nil {
+		t.Fatal(genErr)
+	}
+	if expectedErr != "" && (genErr == nil || genErr.Error() != expectedErr) {
+		t.Errorf("stack budget error mismatch, got: %v", genErr)
+	}
+
+	var lines []string
+	for _, frame := range gen.StackReport().Frames {
+		lines = append(lines, fmt.Sprintf("%v %d", frame.Name, frame.Octets))
+	}
+
+	actual := strings.Join(lines, "\n")
+	if actual != strings.TrimSpace(expectedFrames) {
+		t.Errorf("stack frames mismatch, got:\n%v\n", actual)
+	}
+
+	return genErr
+}
+
+func testStrip(t *testing.T, code string, functionName string, opcodeOffset uint16, expectedSource string) {
+	const useCores = false
+	const errorsAsWarnings = false
+	module, compileErr := deccy.CompileToModuleOnceForTest(strings.TrimSpace(code), useCores, errorsAsWarnings)
+	if parser.IsCompileError(compileErr) {
+		t.Fatal(compileErr)
+	}
+
+	pack := loader.NewPackage(loader.LocalFileSystemRoot(""), "someName", dectype.DataLayoutLP64)
+	pack.AddModule(dectype.MakeArtifactFullyQualifiedModuleName(nil), module)
+
+	gen := NewGenerator()
+	gen.SetStrip(true)
+	if genErr := gen.GenerateFromPackage(pack, resourceid.NewResourceNameLookupImpl(), verbosity.None); genErr != nil {
+		t.Fatal(genErr)
+	}
+
+	packed, packErr := gen.pack(resourceid.NewResourceNameLookupImpl(), false, verbosity.None)
+	if packErr != nil {
+		t.Fatal(packErr)
+	}
+
+	inspected, readErr := packinspect.Read(packed)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+
+	symbols := gen.symbols.file
+	if inspected.BuildID == "" || inspected.BuildID != symbols.BuildID {
+		t.Errorf("build id mismatch, pack: '%v' symbols: '%v'", inspected.BuildID, symbols.BuildID)
+	}
+
+	if len(inspected.DebugFileUrls) != 0 {
+		t.Errorf("stripped pack has debug file urls: %v", inspected.DebugFileUrls)
+	}
+
+	for _, function := range inspected.Functions {
+		if len(function.DebugLines) != 0 {
+			t.Errorf("stripped pack has debug lines for '%v'", function.Name)
+		}
+	}
+
+	source, symbolizeErr := symbols.Symbolize(functionName, opcodeOffset)
+	if symbolizeErr != nil {
+		t.Fatal(symbolizeErr)
+	}
+
+	if source != expectedSource {
+		t.Errorf("symbolize mismatch, got: %v", source)
+	}
+
+	if outside, outsideErr := symbols.Symbolize(functionName, 0xffff); outsideErr == nil {
+		t.Errorf("symbolize should reject an offset outside of the function, got: %v", outside)
 	}
 }
+
+// describePack lists the constants, functions and debug lines that packinspect read back from the pack.
+func describePack(inspected *packinspect.Pack) string {
+	var lines []string
+	lines = append(lines, fmt.Sprintf("layout %v", inspected.DataLayout.Name))
+	for _, stringConstant := range inspected.Strings {
+		lines = append(lines, fmt.Sprintf("string %q", stringConstant.Value))
+	}
+	for _, resourceName := range inspected.ResourceNames {
+		lines = append(lines, fmt.Sprintf("resource %v", resourceName.Name))
+	}
+	for _, fileUrl := range inspected.DebugFileUrls {
+		lines = append(lines, fmt.Sprintf("file %v", fileUrl))
+	}
+	for _, externalFunction := range inspected.ExternalFunctions {
+		var parameters []string
+		for _, parameter := range externalFunction.Parameters {
+			parameters = append(parameters, parameter.String())
+		}
+		lines = append(lines, fmt.Sprintf("external %v return:%v parameters:%v", externalFunction.Name, externalFunction.Return, strings.Join(parameters, " ")))
+	}
+	for _, function := range inspected.Functions {
+		lines = append(lines, fmt.Sprintf("func %v parameters:%d+%d return:%d align:%d", function.Name, function.ParameterCount,
+			function.ParameterOctetSize, function.ReturnOctetSize, function.ReturnAlign))
+		for _, debugLine := range function.DebugLines {
+			lines = append(lines, fmt.Sprintf("  %04x %v", debugLine.OpcodePosition, packinspect.SourceString(inspected.DebugFileUrls, debugLine)))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// testPackRoundTrip writes the pack for the code, and checks what packinspect reads back from it. The opcodes that
+// are read back must be the same as the ones that were generated.
+func testPackRoundTrip(t *testing.T, code string, expectedPack string) {
+	const useCores = false
+	const errorsAsWarnings = false
+	module, compileErr := deccy.CompileToModuleOnceForTest(strings.TrimSpace(code), useCores, errorsAsWarnings)
+	if parser.IsCompileError(compileErr) {
+		t.Fatal(compileErr)
+	}
+
+	pack := loader.NewPackage(loader.LocalFileSystemRoot(""), "someName", dectype.DataLayoutLP64)
+	pack.AddModule(dectype.MakeArtifactFullyQualifiedModuleName(nil), module)
+
+	resourceNameLookup := resourceid.NewResourceNameLookupImpl()
+	gen := NewGenerator()
+	if genErr := gen.GenerateFromPackage(pack, resourceNameLookup, verbosity.None); genErr != nil {
+		t.Fatal(genErr)
+	}
+
+	packed, packErr := gen.pack(resourceNameLookup, false, verbosity.None)
+	if packErr != nil {
+		t.Fatal(packErr)
+	}
+
+	inspected, readErr := packinspect.Read(packed)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+
+	for _, functionConstant := range gen.LastFunctionConstants() {
+		if functionConstant.ConstantType() != assembler_sp.ConstantTypeFunction {
+			continue
+		}
+		function := inspected.FindFunction(functionConstant.FunctionReferenceFullyQualifiedName())
+		if function == nil {
+			t.Errorf("function '%v' was not read back", functionConstant.FunctionReferenceFullyQualifiedName())
+			continue
+		}
+		if !bytes.Equal(function.Opcodes, gen.PackageConstants().FetchOpcodes(functionConstant)) {
+			t.Errorf("opcodes of '%v' differ", function.Name)
+		}
+		if _, disassembleErr := inspected.Disassemble(function); disassembleErr != nil {
+			t.Errorf("couldn't disassemble '%v': %v", function.Name, disassembleErr)
+		}
+	}
+
+	actual := describePack(inspected)
+	if actual != strings.TrimSpace(expectedPack) {
+		t.Errorf("pack mismatch, got:\n%v\n", actual)
+	}
+}
+
+// testGeneratePack compiles the code for the data layout, and returns the generator and the octets of the `.swamp-pack`.
+func testGeneratePack(t *testing.T, dataLayout dectype.DataLayout, code string) (*Generator, []byte) {
+	const useCores = false
+	const errorsAsWarnings = false
+	module, compileErr := deccy.CompileToModuleOnceForTestWithDataLayout(strings.TrimSpace(code), useCores, errorsAsWarnings, dataLayout)
+	if parser.IsCompileError(compileErr) {
+		t.Fatal(compileErr)
+	}
+
+	pack := loader.NewPackage(loader.LocalFileSystemRoot(""), "someName", dataLayout)
+	pack.AddModule(dectype.MakeArtifactFullyQualifiedModuleName(nil), module)
+
+	resourceNameLookup := resourceid.NewResourceNameLookupImpl()
+	gen := NewGenerator()
+	if genErr := gen.GenerateFromPackage(pack, resourceNameLookup, verbosity.None); genErr != nil {
+		t.Fatal(genErr)
+	}
+
+	packed, packErr := gen.pack(resourceNameLookup, false, verbosity.None)
+	if packErr != nil {
+		t.Fatal(packErr)
+	}
+
+	return gen, packed
+}
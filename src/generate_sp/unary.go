@@ -56,7 +56,7 @@ func generateUnaryLogical(code *assembler_sp.Code, target assembler_sp.TargetSta
 }
 
 func handleUnaryLogical(code *assembler_sp.Code, operator *decorated.LogicalUnaryOperator, genContext *generateContext) (assembler_sp.SourceStackPosRange, error) {
-	itemSize, itemAlign := dectype.GetMemorySizeAndAlignment(operator.Type())
+	itemSize, itemAlign := dectype.GetMemorySizeAndAlignment(genContext.context.dataLayout, operator.Type())
 	unaryPointer := genContext.context.stackMemory.Allocate(uint(itemSize), uint32(itemAlign), "unary")
 
 	if err := generateUnaryLogical(code, unaryPointer, operator, genContext); err != nil {
@@ -65,6 +65,10 @@ func handleLetVariableReference(getVar *decorated.LetVariableReference,
 
 func generateLetVariableReference(code *assembler_sp.Code, target assembler_sp.TargetStackPosRange,
 	getVar *decorated.LetVariableReference, genContext *generateContext) error {
+	if instance := getVar.Instance(); instance != nil {
+		return generateLambda(code, target, instance, genContext)
+	}
+
 	context := genContext.context
 	sourcePosRange, err := handleLetVariableReference(getVar, context.scopeVariables)
 	if err != nil {
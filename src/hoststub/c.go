@@ -0,0 +1,109 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package hoststub
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/swamp/compiler/src/bindings"
+)
+
+func describeStub(stub *Stub) string {
+	parts := []string{fmt.Sprintf("return:%d+%d", stub.Return.Offset, stub.Return.Size)}
+	for _, argument := range stub.Arguments {
+		parts = append(parts, fmt.Sprintf("%v:%d+%d", argument.Name, argument.Range.Offset, argument.Range.Size))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+type cWriter struct {
+	writer io.Writer
+}
+
+func (w cWriter) printf(format string, a ...interface{}) {
+	fmt.Fprintf(w.writer, format, a...)
+}
+
+func cArgument(argument Argument) (string, string) {
+	framePosition := fmt.Sprintf("(frame + %d)", argument.Range.Offset)
+	if argument.IsTypeId {
+		return "int32_t", "*(const int32_t*) " + framePosition
+	}
+
+	valueType := argument.Parameter.CType()
+	if argument.Parameter.IsAggregate() {
+		return "const " + valueType + "*", fmt.Sprintf("(const %v*) %v", valueType, framePosition)
+	}
+
+	return valueType, fmt.Sprintf("*(%v const*) %v", valueType, framePosition)
+}
+
+func (w cWriter) writeStub(stub *Stub) {
+	hostName := "swampHost_" + bindings.Identifier(stub.Name)
+	stubName := "swampStub_" + bindings.Identifier(stub.Name)
+
+	if stub.IsGeneric {
+		w.printf("/* %v is generic, the arguments have no fixed layout and are read from the frame by the host */\n", stub.Name)
+		w.printf("void %v(uint8_t* frame);\n\n", hostName)
+		w.printf("static void %v(uint8_t* frame)\n{\n    %v(frame);\n}\n\n", stubName, hostName)
+		return
+	}
+
+	var parameters []string
+	var values []string
+	if stub.Return.Size > 0 {
+		resultType := stub.Result.CType()
+		parameters = append(parameters, fmt.Sprintf("%v* %v", resultType, stub.Result.Name))
+		values = append(values, fmt.Sprintf("(%v*) (frame + %d)", resultType, stub.Return.Offset))
+	}
+	for _, argument := range stub.Arguments {
+		parameterType, value := cArgument(argument)
+		parameters = append(parameters, parameterType+" "+argument.Name)
+		values = append(values, value)
+	}
+	if len(parameters) == 0 {
+		parameters = append(parameters, "void")
+	}
+
+	w.printf("/* %v %v */\n", stub.Name, describeStub(stub))
+	w.printf("void %v(%v);\n\n", hostName, strings.Join(parameters, ", "))
+	w.printf("static void %v(uint8_t* frame)\n{\n", stubName)
+	if len(values) == 0 {
+		w.printf("    (void) frame;\n")
+	}
+	w.printf("    %v(%v);\n}\n\n", hostName, strings.Join(values, ", "))
+}
+
+// WriteC writes a C11 source file with a stub for each external function, that reads the arguments from the call frame
+// and calls `swampHost_<name>`, which is implemented by the host. The `swampHostFunctions` table is terminated by an
+// entry where the name is NULL. The types are included from the header, or written to the file if the header is empty.
+func WriteC(writer io.Writer, stubs *Stubs, headerName string) {
+	w := cWriter{writer: writer}
+
+	w.printf("/* Generated by swamp stubs. Do not edit. */\n\n")
+	if headerName != "" {
+		w.printf("#include <stddef.h>\n#include <stdint.h>\n\n#include \"%v\"\n\n", headerName)
+	} else {
+		bindings.WriteC(writer, stubs.Bindings, "swamp_stubs_types")
+		w.printf("\n")
+	}
+
+	w.printf("typedef void (*SwampHostStub)(uint8_t* frame);\n\n")
+	w.printf("typedef struct SwampHostFunction {\n    const char* name;\n    size_t parameterCount;\n    SwampHostStub stub;\n} SwampHostFunction;\n\n")
+
+	for _, stub := range stubs.Stubs {
+		w.writeStub(stub)
+	}
+
+	w.printf("const SwampHostFunction swampHostFunctions[] = {\n")
+	for _, stub := range stubs.Stubs {
+		w.printf("    {\"%v\", %d, swampStub_%v},\n", stub.Name, len(stub.Slots), bindings.Identifier(stub.Name))
+	}
+	w.printf("    {NULL, 0, NULL},\n};\n")
+}
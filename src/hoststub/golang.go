@@ -0,0 +1,122 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package hoststub
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/swamp/compiler/src/typeinfo"
+)
+
+// goMethodName converts a fully qualified name, e.g. `Game.drawSprite`, to an exported Go identifier, `GameDrawSprite`.
+func goMethodName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var builder strings.Builder
+	for _, part := range parts {
+		runes := []rune(part)
+		builder.WriteRune(unicode.ToUpper(runes[0]))
+		builder.WriteString(string(runes[1:]))
+	}
+
+	return builder.String()
+}
+
+func goParameterName(name string) string {
+	if token.IsKeyword(name) {
+		return name + "_"
+	}
+
+	return name
+}
+
+// goArgument returns the Go type and the expression that reads the argument from the frame. Values that are stored by
+// value, and pointers to runtime structs, are passed as the octets in the frame.
+func goArgument(argument Argument) (string, string) {
+	start := argument.Range.Offset
+	end := argument.Range.Offset + argument.Range.Size
+	if argument.IsTypeId {
+		return "int32", fmt.Sprintf("int32(binary.LittleEndian.Uint32(frame[%d:%d]))", start, end)
+	}
+
+	switch argument.Parameter.InfoType().(type) {
+	case *typeinfo.IntType, *typeinfo.FixedType, *typeinfo.ResourceNameType, *typeinfo.TypeRefIdType:
+		return "int32", fmt.Sprintf("int32(binary.LittleEndian.Uint32(frame[%d:%d]))", start, end)
+	case *typeinfo.CharacterType:
+		return "uint32", fmt.Sprintf("binary.LittleEndian.Uint32(frame[%d:%d])", start, end)
+	case *typeinfo.BoolType:
+		return "bool", fmt.Sprintf("frame[%d] != 0", start)
+	}
+
+	return "[]byte", fmt.Sprintf("frame[%d:%d]", start, end)
+}
+
+// WriteGo writes a Go file with a `Host` interface that has a method for each external function, and a `Register`
+// function that returns the stubs that read the arguments from the frame and call the methods. It is intended for
+// testing packs from Go.
+func WriteGo(writer io.Writer, stubs *Stubs, packageName string) error {
+	var methods bytes.Buffer
+	var entries bytes.Buffer
+	usesBinary := false
+
+	for _, stub := range stubs.Stubs {
+		methodName := goMethodName(stub.Name)
+		if stub.IsGeneric {
+			fmt.Fprintf(&methods, "\t// %v is `%v`. It is generic, so the arguments are read from the frame.\n", methodName, stub.Name)
+			fmt.Fprintf(&methods, "\t%v(frame []byte)\n", methodName)
+			fmt.Fprintf(&entries, "\t\t{Name: %q, ParameterCount: %d, Stub: host.%v},\n", stub.Name, len(stub.Slots), methodName)
+			continue
+		}
+
+		var parameters []string
+		var values []string
+		if stub.Return.Size > 0 {
+			parameters = append(parameters, "result []byte")
+			values = append(values, fmt.Sprintf("frame[%d:%d]", stub.Return.Offset, stub.Return.Offset+stub.Return.Size))
+		}
+		for _, argument := range stub.Arguments {
+			parameterType, value := goArgument(argument)
+			if strings.Contains(value, "binary.") {
+				usesBinary = true
+			}
+			parameters = append(parameters, goParameterName(argument.Name)+" "+parameterType)
+			values = append(values, value)
+		}
+
+		fmt.Fprintf(&methods, "\t// %v is `%v` %v\n", methodName, stub.Name, describeStub(stub))
+		fmt.Fprintf(&methods, "\t%v(%v)\n", methodName, strings.Join(parameters, ", "))
+		fmt.Fprintf(&entries, "\t\t{Name: %q, ParameterCount: %d, Stub: func(frame []byte) {\n\t\t\thost.%v(%v)\n\t\t}},\n",
+			stub.Name, len(stub.Slots), methodName, strings.Join(values, ", "))
+	}
+
+	var source bytes.Buffer
+	fmt.Fprintf(&source, "// Code generated by swamp stubs. DO NOT EDIT.\n\npackage %v\n\n", packageName)
+	if usesBinary {
+		fmt.Fprintf(&source, "import \"encoding/binary\"\n\n")
+	}
+	fmt.Fprintf(&source, "// Host is implemented by the host, with a method for each external function.\ntype Host interface {\n%v}\n\n", methods.String())
+	fmt.Fprintf(&source, "// Entry is an external function. The stub is called with the frame, that starts at the return value.\n")
+	fmt.Fprintf(&source, "type Entry struct {\n\tName string\n\tParameterCount int\n\tStub func(frame []byte)\n}\n\n")
+	fmt.Fprintf(&source, "// Register returns the entries for all external functions, that call the methods on host.\n")
+	fmt.Fprintf(&source, "func Register(host Host) []Entry {\n\treturn []Entry{\n%v\t}\n}\n", entries.String())
+
+	formatted, formatErr := format.Source(source.Bytes())
+	if formatErr != nil {
+		return fmt.Errorf("generated Go source is not valid %w", formatErr)
+	}
+
+	_, writeErr := writer.Write(formatted)
+
+	return writeErr
+}
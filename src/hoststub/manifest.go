@@ -0,0 +1,126 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package hoststub
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/swamp/compiler/src/packinspect"
+)
+
+// ManifestFunction is an external function that the host implements, with the stack layout that the stub reads.
+type ManifestFunction struct {
+	Name       string       `toml:"name"`
+	Return     StackRange   `toml:"return"`
+	Parameters []StackRange `toml:"parameters"`
+}
+
+// Manifest lists the external functions that a host implements. It is written with the stubs and checked against packs.
+type Manifest struct {
+	Function []ManifestFunction `toml:"function"`
+}
+
+func (m Manifest) find(name string) *ManifestFunction {
+	for index := range m.Function {
+		if m.Function[index].Name == name {
+			return &m.Function[index]
+		}
+	}
+
+	return nil
+}
+
+func NewManifest(stubs *Stubs) Manifest {
+	manifest := Manifest{}
+	for _, stub := range stubs.Stubs {
+		manifest.Function = append(manifest.Function, ManifestFunction{Name: stub.Name, Return: stub.Return, Parameters: stub.Slots})
+	}
+
+	return manifest
+}
+
+func manifestRange(stackRange StackRange) string {
+	return fmt.Sprintf("{ offset = %d, size = %d }", stackRange.Offset, stackRange.Size)
+}
+
+// WriteManifest writes the manifest as TOML, with one line for each stack range.
+func WriteManifest(writer io.Writer, manifest Manifest) {
+	fmt.Fprintf(writer, "# Generated by swamp stubs. The external functions that the host implements.\n")
+	for _, function := range manifest.Function {
+		fmt.Fprintf(writer, "\n[[function]]\nname = %v\nreturn = %v\nparameters = [", strconv.Quote(function.Name), manifestRange(function.Return))
+		for _, parameter := range function.Parameters {
+			fmt.Fprintf(writer, "\n    %v,", manifestRange(parameter))
+		}
+		if len(function.Parameters) > 0 {
+			fmt.Fprintf(writer, "\n")
+		}
+		fmt.Fprintf(writer, "]\n")
+	}
+}
+
+func LoadManifest(reader io.Reader) (Manifest, error) {
+	var manifest Manifest
+	decoder := toml.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&manifest); err != nil {
+		return Manifest{}, err
+	}
+
+	return manifest, nil
+}
+
+func packRange(stackRange packinspect.StackRange) StackRange {
+	return StackRange{Offset: int(stackRange.Offset), Size: int(stackRange.Size)}
+}
+
+func describeRanges(ranges []StackRange) string {
+	var parts []string
+	for _, stackRange := range ranges {
+		parts = append(parts, fmt.Sprintf("%d+%d", stackRange.Offset, stackRange.Size))
+	}
+
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// Check returns a problem for each external function that the pack references, that the host doesn't implement, or
+// that is called with another stack layout than the host expects. Functions that are only in the manifest are allowed.
+func Check(pack *packinspect.Pack, manifest Manifest) []string {
+	var problems []string
+
+	for _, externalFunction := range pack.ExternalFunctions {
+		function := manifest.find(externalFunction.Name)
+		if function == nil {
+			problems = append(problems, fmt.Sprintf("'%v' is not implemented by the host", externalFunction.Name))
+			continue
+		}
+
+		returnRange := packRange(externalFunction.Return)
+		if returnRange != function.Return {
+			problems = append(problems, fmt.Sprintf("'%v' return is %d+%d in the pack, but %d+%d in the manifest", externalFunction.Name,
+				returnRange.Offset, returnRange.Size, function.Return.Offset, function.Return.Size))
+		}
+
+		var parameters []StackRange
+		for _, parameter := range externalFunction.Parameters {
+			parameters = append(parameters, packRange(parameter))
+		}
+
+		isSame := len(parameters) == len(function.Parameters)
+		for index := 0; isSame && index < len(parameters); index++ {
+			isSame = parameters[index] == function.Parameters[index]
+		}
+		if !isSame {
+			problems = append(problems, fmt.Sprintf("'%v' parameters are %v in the pack, but %v in the manifest", externalFunction.Name,
+				describeRanges(parameters), describeRanges(function.Parameters)))
+		}
+	}
+
+	return problems
+}
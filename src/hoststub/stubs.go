@@ -0,0 +1,122 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+// Package hoststub generates the host side of the external functions (`__externalfn`, `__externalvarfn` and
+// `__externalvarexfn`). The stubs read the arguments from the call frame at the same offsets as
+// generate_sp.CalculateExternalFunctionStackLayout, and the manifest is used to check that a pack matches the host.
+package hoststub
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/swamp/compiler/src/bindings"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
+	"github.com/swamp/compiler/src/generate_sp"
+	"github.com/swamp/compiler/src/loader"
+)
+
+// StackRange is the offset and octet size of a value in the call frame.
+type StackRange struct {
+	Offset int `json:"offset" toml:"offset"`
+	Size   int `json:"size" toml:"size"`
+}
+
+// Argument is a value that the stub reads from the call frame and passes to the host function.
+// Type id arguments are inserted before arguments of type Any. Parameter is nil for them.
+type Argument struct {
+	Name      string
+	Range     StackRange
+	IsTypeId  bool
+	Parameter *bindings.Parameter
+}
+
+type Stub struct {
+	Name      string
+	Return    StackRange
+	Result    *bindings.Parameter
+	Arguments []Argument
+	// Slots are all the parameter stack ranges in the order that they are stored in the pack
+	Slots     []StackRange
+	IsGeneric bool
+}
+
+type Stubs struct {
+	Bindings *bindings.Bindings
+	Stubs    []*Stub
+}
+
+func stackRange(layoutRange generate_sp.ExternalFunctionStackSlot) StackRange {
+	return StackRange{Offset: int(layoutRange.Range.Pos), Size: int(layoutRange.Range.Size)}
+}
+
+func newStub(externalFunction *bindings.ExternalFunction, functionValue *decorated.FunctionValue, dataLayout dectype.DataLayout) *Stub {
+	layout := generate_sp.CalculateExternalFunctionStackLayout(dataLayout, functionValue.ForcedFunctionType(), len(functionValue.Parameters()))
+
+	stub := &Stub{
+		Name:      externalFunction.Name,
+		Return:    StackRange{Offset: int(layout.Return.Pos), Size: int(layout.Return.Size)},
+		Result:    externalFunction.Return,
+		IsGeneric: layout.HasLocalTypes,
+	}
+
+	for index, slot := range layout.Slots {
+		stub.Slots = append(stub.Slots, stackRange(slot))
+		if layout.HasLocalTypes {
+			continue
+		}
+
+		parameter := &externalFunction.Parameters[slot.ParameterIndex]
+		// a type reference only has the type id slot, that is the value of the parameter
+		isOnlySlot := index+1 == len(layout.Slots) || layout.Slots[index+1].ParameterIndex != slot.ParameterIndex
+		if slot.IsTypeId && !isOnlySlot {
+			stub.Arguments = append(stub.Arguments, Argument{Name: parameter.Name + "TypeId", Range: stackRange(slot), IsTypeId: true})
+			continue
+		}
+		stub.Arguments = append(stub.Arguments, Argument{Name: parameter.Name, Range: stackRange(slot), Parameter: parameter})
+	}
+
+	return stub
+}
+
+// FromPackages collects the stack layout of the external functions in the packages, with the sizes of the dataLayout.
+// The core modules are not included.
+func FromPackages(compiledPackages []*loader.Package, dataLayout dectype.DataLayout) (*Stubs, error) {
+	foundBindings, bindingsErr := bindings.FromPackages(compiledPackages, dataLayout)
+	if bindingsErr != nil {
+		return nil, bindingsErr
+	}
+
+	functionValues := make(map[string]*decorated.FunctionValue)
+	for _, compiledPackage := range compiledPackages {
+		for _, module := range compiledPackage.AllModules() {
+			if module.IsInternal() {
+				continue
+			}
+			for _, named := range module.LocalDefinitions().Definitions() {
+				functionValue, isFunction := named.Expression().(*decorated.FunctionValue)
+				if isFunction && functionValue.IsSomeKindOfExternal() {
+					functionValues[module.FullyQualifiedName(named.Identifier()).String()] = functionValue
+				}
+			}
+		}
+	}
+
+	stubs := &Stubs{Bindings: foundBindings}
+	for _, externalFunction := range foundBindings.ExternalFunctions {
+		functionValue, wasFound := functionValues[externalFunction.Name]
+		if !wasFound {
+			return nil, fmt.Errorf("external function '%v' was not found", externalFunction.Name)
+		}
+		stubs.Stubs = append(stubs.Stubs, newStub(externalFunction, functionValue, dataLayout))
+	}
+
+	sort.Slice(stubs.Stubs, func(i, j int) bool {
+		return stubs.Stubs[i].Name < stubs.Stubs[j].Name
+	})
+
+	return stubs, nil
+}
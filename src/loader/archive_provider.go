@@ -0,0 +1,102 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package loader
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	"github.com/swamp/compiler/src/file"
+	"github.com/swamp/compiler/src/pkgstore"
+)
+
+// IsArchive returns true if the path is a `.swamp-lib` archive file.
+func IsArchive(archivePath string) bool {
+	return strings.HasSuffix(archivePath, pkgstore.ArchiveExtension) && file.HasFile(archivePath)
+}
+
+// SplitArchivePath splits a path that points into a `.swamp-lib` archive, e.g. `/libs/engine.swamp-lib/Main.swamp`,
+// into the archive filename and the name of the file inside the archive.
+func SplitArchivePath(completeFilename string) (string, string, bool) {
+	searchFrom := 0
+	for {
+		index := strings.Index(completeFilename[searchFrom:], pkgstore.ArchiveExtension+"/")
+		if index == -1 {
+			return "", "", false
+		}
+		archiveEnd := searchFrom + index + len(pkgstore.ArchiveExtension)
+		archiveFilename := completeFilename[:archiveEnd]
+		if file.HasFile(archiveFilename) {
+			return archiveFilename, completeFilename[archiveEnd+1:], true
+		}
+		searchFrom = archiveEnd
+	}
+}
+
+type readArchive struct {
+	modTime time.Time
+	files   map[string][]byte
+}
+
+// ArchiveDocumentProvider serves documents from inside `.swamp-lib` archives. The document path is the archive
+// path followed by the path inside the archive, so diagnostics and go to definition point into the archive.
+// All other documents are read from the fallback provider.
+type ArchiveDocumentProvider struct {
+	fallback DocumentProvider
+	mutex    sync.Mutex
+	archives map[string]*readArchive
+}
+
+func NewArchiveDocumentProvider(fallback DocumentProvider) *ArchiveDocumentProvider {
+	return &ArchiveDocumentProvider{fallback: fallback, archives: make(map[string]*readArchive)}
+}
+
+func (p *ArchiveDocumentProvider) archiveFiles(archiveFilename string) (map[string][]byte, error) {
+	info, statErr := os.Stat(archiveFilename)
+	if statErr != nil {
+		return nil, statErr
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	existing, wasFound := p.archives[archiveFilename]
+	if wasFound && existing.modTime.Equal(info.ModTime()) {
+		return existing.files, nil
+	}
+
+	files, readErr := pkgstore.ReadArchive(archiveFilename)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	p.archives[archiveFilename] = &readArchive{modTime: info.ModTime(), files: files}
+
+	return files, nil
+}
+
+func (p *ArchiveDocumentProvider) ReadDocument(completeFilename LocalFileSystemPath) (string, error) {
+	archiveFilename, insideFilename, isInsideArchive := SplitArchivePath(string(completeFilename))
+	if !isInsideArchive {
+		return p.fallback.ReadDocument(completeFilename)
+	}
+
+	files, archiveErr := p.archiveFiles(archiveFilename)
+	if archiveErr != nil {
+		return "", decorated.NewInternalError(fmt.Errorf("couldn't read archive '%s' (%v)", archiveFilename, archiveErr))
+	}
+
+	octets, wasFound := files[insideFilename]
+	if !wasFound {
+		return "", decorated.NewInternalError(fmt.Errorf("file '%s' didn't exist in archive '%s'", insideFilename, archiveFilename))
+	}
+
+	return string(octets), nil
+}
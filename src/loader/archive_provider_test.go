@@ -0,0 +1,215 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package loader
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+type archiveFormat int
+
+const (
+	formatZip archiveFormat = iota
+	formatTarGz
+)
+
+func (f archiveFormat) String() string {
+	if f == formatZip {
+		return "zip"
+	}
+	return "tar.gz"
+}
+
+// writeTestArchive writes the files, in name order, to a zip or tar.gz archive.
+func writeTestArchive(t *testing.T, filename string, format archiveFormat, files map[string]string) {
+	t.Helper()
+
+	if err := os.MkdirAll(path.Dir(filename), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	archiveFile, createErr := os.Create(filename)
+	if createErr != nil {
+		t.Fatal(createErr)
+	}
+	defer archiveFile.Close()
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	switch format {
+	case formatZip:
+		zipWriter := zip.NewWriter(archiveFile)
+		for _, name := range names {
+			fileWriter, writerErr := zipWriter.Create(name)
+			if writerErr != nil {
+				t.Fatal(writerErr)
+			}
+			if _, err := io.WriteString(fileWriter, files[name]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zipWriter.Close(); err != nil {
+			t.Fatal(err)
+		}
+	case formatTarGz:
+		gzipWriter := gzip.NewWriter(archiveFile)
+		tarWriter := tar.NewWriter(gzipWriter)
+		for _, name := range names {
+			header := &tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0o644, Size: int64(len(files[name]))}
+			if err := tarWriter.WriteHeader(header); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.WriteString(tarWriter, files[name]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tarWriter.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := gzipWriter.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// testDocumentProvider serves the documents in the map, and fails for all others.
+type testDocumentProvider map[LocalFileSystemPath]string
+
+func (p testDocumentProvider) ReadDocument(completeFilename LocalFileSystemPath) (string, error) {
+	content, wasFound := p[completeFilename]
+	if !wasFound {
+		return "", os.ErrNotExist
+	}
+
+	return content, nil
+}
+
+func TestSplitArchivePath(t *testing.T) {
+	directory := t.TempDir()
+	archiveFilename := path.Join(directory, "libs", "engine.swamp-lib")
+	writeTestArchive(t, archiveFilename, formatZip, map[string]string{"Main.swamp": ""})
+	if err := os.MkdirAll(path.Join(directory, "libs", "folder.swamp-lib"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, testCase := range []struct {
+		name            string
+		path            string
+		expectedArchive string
+		expectedInside  string
+		expectedFound   bool
+	}{
+		{"file in archive", archiveFilename + "/Main.swamp", archiveFilename, "Main.swamp", true},
+		{"file in directory of archive", archiveFilename + "/Engine/Render.swamp", archiveFilename, "Engine/Render.swamp", true},
+		{"archive itself", archiveFilename, "", "", false},
+		{"file outside archive", path.Join(directory, "libs", "Main.swamp"), "", "", false},
+		{"directory with the extension", path.Join(directory, "libs", "folder.swamp-lib", "Main.swamp"), "", "", false},
+		{"missing archive", path.Join(directory, "missing.swamp-lib", "Main.swamp"), "", "", false},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			archive, inside, wasFound := SplitArchivePath(testCase.path)
+			if wasFound != testCase.expectedFound || archive != testCase.expectedArchive || inside != testCase.expectedInside {
+				t.Errorf("expected (%v, %v, %v), got (%v, %v, %v)", testCase.expectedArchive, testCase.expectedInside,
+					testCase.expectedFound, archive, inside, wasFound)
+			}
+		})
+	}
+}
+
+func TestArchiveDocumentProvider(t *testing.T) {
+	for _, format := range []archiveFormat{formatZip, formatTarGz} {
+		t.Run(format.String(), func(t *testing.T) {
+			directory := t.TempDir()
+			archiveFilename := path.Join(directory, "engine.swamp-lib")
+			writeTestArchive(t, archiveFilename, format, map[string]string{
+				".swamp.toml":           "",
+				"Main.swamp":            "main",
+				"./Engine/Render.swamp": "render",
+			})
+			outsideFilename := LocalFileSystemPath(path.Join(directory, "Game.swamp"))
+			provider := NewArchiveDocumentProvider(testDocumentProvider{outsideFilename: "game"})
+
+			for _, testCase := range []struct {
+				name          string
+				path          LocalFileSystemPath
+				expected      string
+				expectedError string
+			}{
+				{"file in archive", LocalFileSystemPath(archiveFilename + "/Main.swamp"), "main", ""},
+				{"cleaned name in archive", LocalFileSystemPath(archiveFilename + "/Engine/Render.swamp"), "render", ""},
+				{"file outside archive", outsideFilename, "game", ""},
+				{"missing file in archive", LocalFileSystemPath(archiveFilename + "/Missing.swamp"), "", "didn't exist in archive"},
+			} {
+				t.Run(testCase.name, func(t *testing.T) {
+					content, readErr := provider.ReadDocument(testCase.path)
+					if testCase.expectedError != "" {
+						if readErr == nil || !strings.Contains(readErr.Error(), testCase.expectedError) {
+							t.Fatalf("expected an error containing '%v', got %v", testCase.expectedError, readErr)
+						}
+						return
+					}
+					if readErr != nil {
+						t.Fatal(readErr)
+					}
+					if content != testCase.expected {
+						t.Errorf("expected '%v', got '%v'", testCase.expected, content)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestArchiveDocumentProviderReadsChangedArchive(t *testing.T) {
+	archiveFilename := path.Join(t.TempDir(), "engine.swamp-lib")
+	writeTestArchive(t, archiveFilename, formatZip, map[string]string{"Main.swamp": "before"})
+	provider := NewArchiveDocumentProvider(testDocumentProvider{})
+	mainFilename := LocalFileSystemPath(archiveFilename + "/Main.swamp")
+
+	if content, err := provider.ReadDocument(mainFilename); err != nil || content != "before" {
+		t.Fatalf("expected 'before', got '%v' %v", content, err)
+	}
+
+	writeTestArchive(t, archiveFilename, formatTarGz, map[string]string{"Main.swamp": "after"})
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(archiveFilename, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	if content, err := provider.ReadDocument(mainFilename); err != nil || content != "after" {
+		t.Errorf("expected the changed archive to be read again, got '%v' %v", content, err)
+	}
+}
+
+func TestArchiveDocumentProviderIllegalNameFail(t *testing.T) {
+	for _, format := range []archiveFormat{formatZip, formatTarGz} {
+		t.Run(format.String(), func(t *testing.T) {
+			archiveFilename := path.Join(t.TempDir(), "engine.swamp-lib")
+			writeTestArchive(t, archiveFilename, format, map[string]string{
+				"Main.swamp":       "main",
+				"../Outside.swamp": "outside",
+			})
+			provider := NewArchiveDocumentProvider(testDocumentProvider{})
+
+			_, readErr := provider.ReadDocument(LocalFileSystemPath(archiveFilename + "/Main.swamp"))
+			if readErr == nil || !strings.Contains(readErr.Error(), "illegal file name '../Outside.swamp'") {
+				t.Errorf("expected the archive to be rejected, got %v", readErr)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ package loader
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path"
@@ -53,7 +54,7 @@ func FindSettingsDirectory(swampDirectory string) (string, error) {
 
 func ModuleTypeFromMapped(moduleMap settings.ModuleMap) decorated.ModuleType {
 	switch moduleMap {
-	case settings.ModuleFromEnvironment:
+	case settings.ModuleFromEnvironment, settings.ModuleFromStore:
 		return decorated.ModuleTypeFromEnvironment
 	case settings.ModuleFromPath:
 		return decorated.ModuleTypeFromPath
@@ -64,13 +65,22 @@ func ModuleTypeFromMapped(moduleMap settings.ModuleMap) decorated.ModuleType {
 func (r *LibraryReaderAndDecorator) loadAndApplySettings(world *Package, repository deccy.ModuleRepository, swampDirectory string, documentProvider DocumentProvider, configuration environment.Environment, verboseFlag verbosity.Verbosity) decshared.DecoratedError {
 	settingsFilename := path.Join(swampDirectory, ".swamp.toml")
 
-	settingsFile, settingsFileErr := os.Open(settingsFilename)
-	if settingsFileErr != nil {
-		return nil
+	var settingsReader io.Reader
+	if IsArchive(swampDirectory) {
+		settingsSource, readErr := documentProvider.ReadDocument(LocalFileSystemPath(settingsFilename))
+		if readErr != nil {
+			return nil
+		}
+		settingsReader = strings.NewReader(settingsSource)
+	} else {
+		settingsFile, settingsFileErr := os.Open(settingsFilename)
+		if settingsFileErr != nil {
+			return nil
+		}
+		defer settingsFile.Close()
+		settingsReader = bufio.NewReader(settingsFile)
 	}
 
-	settingsReader := bufio.NewReader(settingsFile)
-
 	foundSettings, loadErr := settings.Load(settingsReader, swampDirectory, configuration)
 	if loadErr != nil {
 		return decorated.NewInternalError(loadErr)
@@ -93,7 +103,7 @@ func (r *LibraryReaderAndDecorator) loadAndApplySettings(world *Package, reposit
 		rootDocumentFake := &token.SourceFileDocument{Uri: "file://root"}
 		rootNamespace := dectype.MakePackageRootModuleNameFromString(packagePath.Name, rootDocumentFake)
 		dependencyFilePrefix = filepath.ToSlash(dependencyFilePrefix)
-		if !file.IsDir(dependencyFilePrefix) {
+		if !file.IsDir(dependencyFilePrefix) && !IsArchive(dependencyFilePrefix) {
 			full, err := filepath.Abs(dependencyFilePrefix)
 			if err != nil {
 				return decorated.NewInternalError(fmt.Errorf("could not do abs of '%w'", err))
@@ -134,7 +144,7 @@ func (r *LibraryReaderAndDecorator) ReadLibraryModule(moduleType decorated.Modul
 
 	fileLoader := NewLoader(absoluteDirectory, documentProvider)
 
-	worldDecorator, worldDecoratorErr := NewWorldDecorator(enforceStyle, verboseFlag)
+	worldDecorator, worldDecoratorErr := NewWorldDecorator(enforceStyle, verboseFlag, world.DataLayout())
 	errors = decorated.AppendError(errors, worldDecoratorErr)
 	if parser.IsCompileErr(worldDecoratorErr) {
 		return nil, worldDecoratorErr
@@ -171,7 +181,7 @@ func (r *LibraryReaderAndDecorator) CompileAllInLibrary(moduleType decorated.Mod
 
 	fileLoader := NewLoader(absoluteDirectory, documentProvider)
 
-	worldDecorator, worldDecoratorErr := NewWorldDecorator(enforceStyle, verboseFlag)
+	worldDecorator, worldDecoratorErr := NewWorldDecorator(enforceStyle, verboseFlag, world.DataLayout())
 	if worldDecoratorErr != nil {
 		return nil, nil
 	}
@@ -20,8 +20,8 @@ type WorldDecorator struct {
 	forceStyle bool
 }
 
-func NewWorldDecorator(forceStyle bool, verbose verbosity.Verbosity) (*WorldDecorator, decshared.DecoratedError) {
-	rootModule, rootModuleErr := deccy.CreateDefaultRootModule(true)
+func NewWorldDecorator(forceStyle bool, verbose verbosity.Verbosity, dataLayout dectype.DataLayout) (*WorldDecorator, decshared.DecoratedError) {
+	rootModule, rootModuleErr := deccy.CreateDefaultRootModule(true, dataLayout)
 	if parser.IsCompileError(rootModuleErr) {
 		return nil, rootModuleErr
 	}
@@ -18,10 +18,11 @@ type Package struct {
 	modules            []*decorated.Module
 	root               LocalFileSystemRoot
 	name               string
+	dataLayout         dectype.DataLayout
 }
 
-func NewPackage(root LocalFileSystemRoot, name string) *Package {
-	return &Package{root: root, name: name, moduleLookup: make(map[string]*decorated.Module), absolutePathLookup: make(map[LocalFileSystemPath]*decorated.Module)}
+func NewPackage(root LocalFileSystemRoot, name string, dataLayout dectype.DataLayout) *Package {
+	return &Package{root: root, name: name, dataLayout: dataLayout, moduleLookup: make(map[string]*decorated.Module), absolutePathLookup: make(map[LocalFileSystemPath]*decorated.Module)}
 }
 
 func (w *Package) Root() LocalFileSystemRoot {
@@ -32,6 +33,11 @@ func (w *Package) Name() string {
 	return w.name
 }
 
+// DataLayout is the layout of the target that the package is compiled for.
+func (w *Package) DataLayout() dectype.DataLayout {
+	return w.dataLayout
+}
+
 func (w *Package) AllModules() []*decorated.Module {
 	return w.modules
 }
@@ -249,6 +249,8 @@ func tokenToDefinition(decoratedToken decorated.TypeOrToken) (token.SourceFileRe
 		return tokenToDefinition(t.FunctionExpression())
 	case *decorated.CurryFunction:
 		return tokenToDefinition(t.FunctionValue())
+	case *decorated.Lambda:
+		return t.FetchPositionLength(), nil
 	case *decorated.Constant:
 		return t.AstConstant().FetchPositionLength(), nil
 	// TYPES
@@ -13,6 +13,7 @@ import (
 
 	swampcompiler "github.com/swamp/compiler/src/compiler"
 	decorated "github.com/swamp/compiler/src/decorated/expression"
+	dectype "github.com/swamp/compiler/src/decorated/types"
 	"github.com/swamp/compiler/src/environment"
 	"github.com/swamp/compiler/src/loader"
 	"github.com/swamp/compiler/src/token"
@@ -38,7 +39,7 @@ func (l *LspImpl) Compile(filename string) (*decorated.Module, error) {
 
 	const verboseFlag = verbosity.None
 
-	world, module, err := swampcompiler.CompileMainFindLibraryRoot(filename, l.documentCache, l.configuration, enforceStyle, verboseFlag)
+	world, module, err := swampcompiler.CompileMainFindLibraryRoot(filename, l.documentCache, l.configuration, dectype.DataLayoutLP64, enforceStyle, verboseFlag)
 	if parser.IsCompileErr(err) {
 		return nil, err
 	}
@@ -0,0 +1,270 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package optimize
+
+import (
+	"math"
+
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+)
+
+// constantFolding calculates the operators that only have literals as operands, and replaces the references to let
+// variables that are assigned a literal with the literal. The let variables are removed by deadLetRemoval.
+type constantFolding struct{}
+
+func (p *constantFolding) Name() string {
+	return "constant folding"
+}
+
+func (p *constantFolding) Optimize(expression decorated.Expression) decorated.Expression {
+	return p.fold(expression, nil)
+}
+
+func (p *constantFolding) fold(expression decorated.Expression, literals map[*decorated.LetVariable]decorated.Expression) decorated.Expression {
+	switch e := expression.(type) {
+	case *decorated.LetVariableReference:
+		if literal, wasFound := literals[e.LetVariable()]; wasFound {
+			return literal
+		}
+		return e
+	case *decorated.Let:
+		return p.foldLet(e, literals)
+	}
+
+	folded := mapChildren(expression, func(subExpression decorated.Expression) decorated.Expression {
+		return p.fold(subExpression, literals)
+	})
+
+	return foldOperator(folded)
+}
+
+func (p *constantFolding) foldLet(let *decorated.Let, literals map[*decorated.LetVariable]decorated.Expression) decorated.Expression {
+	scopeLiterals := make(map[*decorated.LetVariable]decorated.Expression, len(literals))
+	for letVariable, literal := range literals {
+		scopeLiterals[letVariable] = literal
+	}
+
+	wasChanged := false
+	var subExpressions []decorated.Expression
+	for _, assignment := range let.Assignments() {
+		folded := p.fold(assignment.Expression(), scopeLiterals)
+		if folded != assignment.Expression() {
+			wasChanged = true
+		}
+		subExpressions = append(subExpressions, folded)
+
+		letVariables := assignment.LetVariables()
+		if len(letVariables) == 1 && !assignment.WasRecordDestructuring() && !letVariables[0].IsIgnore() && isLiteral(folded) {
+			scopeLiterals[letVariables[0]] = folded
+		}
+	}
+
+	consequence := p.fold(let.Consequence(), scopeLiterals)
+	if consequence != let.Consequence() {
+		wasChanged = true
+	}
+
+	if !wasChanged {
+		return let
+	}
+
+	return rebuild(let, append(subExpressions, consequence))
+}
+
+// foldOperator returns a literal if the operator can be calculated, otherwise the operator is returned as it is.
+func foldOperator(expression decorated.Expression) decorated.Expression {
+	switch e := expression.(type) {
+	case *decorated.ArithmeticOperator:
+		return foldArithmetic(e)
+	case *decorated.BooleanOperator:
+		return foldBoolean(e)
+	case *decorated.LogicalOperator:
+		return foldLogical(e)
+	case *decorated.ArithmeticUnaryOperator:
+		if e.OperatorType() != decorated.ArithmeticUnaryMinus {
+			return e
+		}
+		switch literal := e.Left().(type) {
+		case *decorated.IntegerLiteral:
+			if literal.Value() != math.MinInt32 {
+				return newIntegerLiteral(-literal.Value(), e.Type(), e.FetchPositionLength())
+			}
+		case *decorated.FixedLiteral:
+			if literal.Value() != math.MinInt32 {
+				return newFixedLiteral(-literal.Value(), e.Type(), e.FetchPositionLength())
+			}
+		}
+	case *decorated.LogicalUnaryOperator:
+		if literal, isLiteral := e.Left().(*decorated.BooleanLiteral); isLiteral && e.OperatorType() == decorated.LogicalUnaryNot {
+			return newBooleanLiteral(!literal.Value(), e.Type(), e.FetchPositionLength())
+		}
+	}
+
+	return expression
+}
+
+// calculateInteger calculates the integer operators. Overflow, division by zero and remainders of negative numbers
+// are left to the runtime, so that the result is always the same as when it is not optimized.
+func calculateInteger(operatorType decorated.ArithmeticOperatorType, left int32, right int32) (int32, bool) {
+	a := int64(left)
+	b := int64(right)
+
+	var result int64
+	switch operatorType {
+	case decorated.ArithmeticPlus:
+		result = a + b
+	case decorated.ArithmeticMinus:
+		result = a - b
+	case decorated.ArithmeticMultiply:
+		result = a * b
+	case decorated.ArithmeticDivide:
+		if b == 0 {
+			return 0, false
+		}
+		result = a / b
+	case decorated.ArithmeticRemainder:
+		if b <= 0 || a < 0 {
+			return 0, false
+		}
+		result = a % b
+	default:
+		return 0, false
+	}
+
+	if result < math.MinInt32 || result > math.MaxInt32 {
+		return 0, false
+	}
+
+	return int32(result), true
+}
+
+func foldArithmetic(operator *decorated.ArithmeticOperator) decorated.Expression {
+	switch left := operator.Left().(type) {
+	case *decorated.IntegerLiteral:
+		right, isInteger := operator.Right().(*decorated.IntegerLiteral)
+		if !isInteger {
+			return operator
+		}
+		if result, couldCalculate := calculateInteger(operator.OperatorType(), left.Value(), right.Value()); couldCalculate {
+			return newIntegerLiteral(result, operator.Type(), operator.FetchPositionLength())
+		}
+	case *decorated.FixedLiteral:
+		right, isFixed := operator.Right().(*decorated.FixedLiteral)
+		if !isFixed {
+			return operator
+		}
+		// Fixed multiplication and division are done by the runtime, only the operators that are the same as for
+		// the integers are folded.
+		if operator.OperatorType() != decorated.ArithmeticPlus && operator.OperatorType() != decorated.ArithmeticMinus {
+			return operator
+		}
+		if result, couldCalculate := calculateInteger(operator.OperatorType(), left.Value(), right.Value()); couldCalculate {
+			return newFixedLiteral(result, operator.Type(), operator.FetchPositionLength())
+		}
+	case *decorated.StringLiteral:
+		right, isString := operator.Right().(*decorated.StringLiteral)
+		if isString && operator.OperatorType() == decorated.ArithmeticAppend {
+			return newStringLiteral(left.Value()+right.Value(), operator.Type(), operator.FetchPositionLength())
+		}
+	}
+
+	return operator
+}
+
+// orderedValue returns the value of the literals that can be compared with less and greater.
+func orderedValue(expression decorated.Expression) (int32, bool) {
+	switch literal := expression.(type) {
+	case *decorated.IntegerLiteral:
+		return literal.Value(), true
+	case *decorated.FixedLiteral:
+		return literal.Value(), true
+	case *decorated.CharacterLiteral:
+		return int32(literal.Value()), true
+	}
+
+	return 0, false
+}
+
+// comparableValue returns the value of the literals that can only be compared with equal and not equal.
+func comparableValue(expression decorated.Expression) (interface{}, bool) {
+	switch literal := expression.(type) {
+	case *decorated.BooleanLiteral:
+		return literal.Value(), true
+	case *decorated.StringLiteral:
+		return literal.Value(), true
+	}
+
+	return nil, false
+}
+
+func foldBoolean(operator *decorated.BooleanOperator) decorated.Expression {
+	var result bool
+
+	left, leftIsOrdered := orderedValue(operator.Left())
+	right, rightIsOrdered := orderedValue(operator.Right())
+	if leftIsOrdered && rightIsOrdered {
+		switch operator.OperatorType() {
+		case decorated.BooleanEqual:
+			result = left == right
+		case decorated.BooleanNotEqual:
+			result = left != right
+		case decorated.BooleanLess:
+			result = left < right
+		case decorated.BooleanLessOrEqual:
+			result = left <= right
+		case decorated.BooleanGreater:
+			result = left > right
+		case decorated.BooleanGreaterOrEqual:
+			result = left >= right
+		}
+		return newBooleanLiteral(result, operator.Type(), operator.FetchPositionLength())
+	}
+
+	leftValue, leftIsComparable := comparableValue(operator.Left())
+	rightValue, rightIsComparable := comparableValue(operator.Right())
+	if !leftIsComparable || !rightIsComparable {
+		return operator
+	}
+
+	switch operator.OperatorType() {
+	case decorated.BooleanEqual:
+		result = leftValue == rightValue
+	case decorated.BooleanNotEqual:
+		result = leftValue != rightValue
+	default:
+		return operator
+	}
+
+	return newBooleanLiteral(result, operator.Type(), operator.FetchPositionLength())
+}
+
+// foldLogical removes the operator if one of the operands is a literal. The right operand is only removed if the left
+// is a literal, since the left operand is always evaluated.
+func foldLogical(operator *decorated.LogicalOperator) decorated.Expression {
+	if left, isLiteral := operator.Left().(*decorated.BooleanLiteral); isLiteral {
+		switch operator.OperatorType() {
+		case decorated.LogicalAnd:
+			if left.Value() {
+				return operator.Right()
+			}
+			return left
+		case decorated.LogicalOr:
+			if left.Value() {
+				return left
+			}
+			return operator.Right()
+		}
+	}
+
+	if right, isLiteral := operator.Right().(*decorated.BooleanLiteral); isLiteral {
+		if (operator.OperatorType() == decorated.LogicalAnd && right.Value()) ||
+			(operator.OperatorType() == decorated.LogicalOr && !right.Value()) {
+			return operator.Left()
+		}
+	}
+
+	return operator
+}
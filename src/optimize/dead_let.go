@@ -0,0 +1,69 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package optimize
+
+import (
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+)
+
+// deadLetRemoval removes the let assignments where none of the variables are used. A `let` without any assignments
+// left is replaced with the `in` expression. An assignment to `_` is always kept, since it is only written for the
+// side effects of the expression.
+type deadLetRemoval struct{}
+
+func (p *deadLetRemoval) Name() string {
+	return "dead let removal"
+}
+
+func (p *deadLetRemoval) Optimize(expression decorated.Expression) decorated.Expression {
+	removed := mapChildren(expression, p.Optimize)
+
+	let, isLet := removed.(*decorated.Let)
+	if !isLet {
+		return removed
+	}
+
+	return removeUnusedAssignments(let)
+}
+
+func isIgnoreAssignment(assignment *decorated.LetAssignment) bool {
+	letVariables := assignment.LetVariables()
+	return len(letVariables) == 1 && letVariables[0].IsIgnore()
+}
+
+func removeUnusedAssignments(let *decorated.Let) decorated.Expression {
+	assignments := let.Assignments()
+	counts := letVariableReferenceCounts(let.Consequence())
+
+	// An assignment can only be used by the assignments after it, so an assignment that is only used by a removed
+	// assignment is removed as well.
+	var keptInReverse []*decorated.LetAssignment
+	for index := len(assignments) - 1; index >= 0; index-- {
+		assignment := assignments[index]
+		if !isIgnoreAssignment(assignment) && !isReferenced(assignment.LetVariables(), counts) {
+			continue
+		}
+		keptInReverse = append(keptInReverse, assignment)
+		for letVariable, count := range letVariableReferenceCounts(assignment.Expression()) {
+			counts[letVariable] += count
+		}
+	}
+
+	if len(keptInReverse) == len(assignments) {
+		return let
+	}
+
+	if len(keptInReverse) == 0 {
+		return let.Consequence()
+	}
+
+	kept := make([]*decorated.LetAssignment, len(keptInReverse))
+	for index, assignment := range keptInReverse {
+		kept[len(keptInReverse)-1-index] = assignment
+	}
+
+	return decorated.NewLet(let.AstLet(), kept, let.Consequence())
+}
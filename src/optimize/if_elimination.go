@@ -0,0 +1,38 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package optimize
+
+import (
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+)
+
+// ifElimination replaces an `if` that has a literal condition, e.g. `if True then a else b`, with the branch that is
+// always taken.
+type ifElimination struct{}
+
+func (p *ifElimination) Name() string {
+	return "if elimination"
+}
+
+func (p *ifElimination) Optimize(expression decorated.Expression) decorated.Expression {
+	eliminated := mapChildren(expression, p.Optimize)
+
+	ifExpression, isIf := eliminated.(*decorated.If)
+	if !isIf {
+		return eliminated
+	}
+
+	condition, isLiteral := ifExpression.Condition().(*decorated.BooleanLiteral)
+	if !isLiteral {
+		return eliminated
+	}
+
+	if condition.Value() {
+		return ifExpression.Consequence()
+	}
+
+	return ifExpression.Alternative()
+}
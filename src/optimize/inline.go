@@ -0,0 +1,234 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package optimize
+
+import (
+	"fmt"
+
+	"github.com/swamp/compiler/src/ast"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	"github.com/swamp/compiler/src/loader"
+	"github.com/swamp/compiler/src/token"
+)
+
+// maxInlineExpressionCount is the maximum number of expressions in a function that is inlined.
+const maxInlineExpressionCount = 16
+
+// inlining replaces a call to a small function in the same package with the body of the function. The arguments are
+// assigned to let variables, so that each argument is still only calculated once:
+//
+//	add 2 x  =>  let a@1 = 2
+//	                 b@1 = x
+//	             in a@1 + b@1
+//
+// The code generator finds the variables by name, so the parameters and the let variables of the inlined function
+// are renamed with a suffix that is unique within the package.
+type inlining struct {
+	bodies   map[*decorated.FunctionValue]decorated.Expression
+	inlineID int
+}
+
+func (p *inlining) Name() string {
+	return "inlining"
+}
+
+// newInlining finds the functions that can be inlined. The bodies are saved before any function is optimized, so an
+// inlined body is always the original one.
+func newInlining(compilePackage *loader.Package) *inlining {
+	var functionValues []*decorated.FunctionValue
+	for _, module := range compilePackage.AllModules() {
+		for _, named := range module.LocalDefinitions().Definitions() {
+			functionValue, isFunction := named.Expression().(*decorated.FunctionValue)
+			if !isFunction || functionValue.IsSomeKindOfExternal() || functionValue.IsGeneric() {
+				continue
+			}
+			functionValues = append(functionValues, functionValue)
+		}
+	}
+
+	calls := make(map[*decorated.FunctionValue][]*decorated.FunctionValue)
+	for _, functionValue := range functionValues {
+		for _, node := range decorated.ExpandAllChildNodes([]decorated.Node{functionValue.Expression()}) {
+			if reference, isReference := node.(*decorated.FunctionReference); isReference {
+				calls[functionValue] = append(calls[functionValue], reference.FunctionValue())
+			}
+		}
+	}
+
+	bodies := make(map[*decorated.FunctionValue]decorated.Expression)
+	for _, functionValue := range functionValues {
+		if len(functionValue.Lambdas()) > 0 || isRecursive(functionValue, calls) {
+			continue
+		}
+		count, canBeCopied := inlineExpressionCount(functionValue.Expression())
+		if !canBeCopied || count > maxInlineExpressionCount {
+			continue
+		}
+		bodies[functionValue] = functionValue.Expression()
+	}
+
+	return &inlining{bodies: bodies}
+}
+
+// isRecursive returns true if the function can call itself, directly or through other functions.
+func isRecursive(functionValue *decorated.FunctionValue, calls map[*decorated.FunctionValue][]*decorated.FunctionValue) bool {
+	visited := make(map[*decorated.FunctionValue]bool)
+	queue := append([]*decorated.FunctionValue(nil), calls[functionValue]...)
+	for len(queue) > 0 {
+		called := queue[0]
+		queue = queue[1:]
+		if called == functionValue {
+			return true
+		}
+		if visited[called] {
+			continue
+		}
+		visited[called] = true
+		queue = append(queue, calls[called]...)
+	}
+
+	return false
+}
+
+// inlineExpressionCount returns the number of expressions, and false if the expression has anything that can not be
+// copied into another function. A `case`, a lambda or a `recur` is never inlined.
+func inlineExpressionCount(expression decorated.Expression) (int, bool) {
+	if isLeaf(expression) {
+		return 1, true
+	}
+
+	switch e := expression.(type) {
+	case *decorated.FunctionCall:
+		if _, isReference := e.FunctionExpression().(*decorated.FunctionReference); !isReference {
+			return 0, false
+		}
+	case *decorated.Let:
+		for _, assignment := range e.Assignments() {
+			// The fields are found by the name of the variables
+			if assignment.WasRecordDestructuring() {
+				return 0, false
+			}
+		}
+	}
+
+	subExpressions := children(expression)
+	if subExpressions == nil {
+		return 0, false
+	}
+
+	total := 1
+	for _, subExpression := range subExpressions {
+		count, canBeCopied := inlineExpressionCount(subExpression)
+		if !canBeCopied {
+			return 0, false
+		}
+		total += count
+	}
+
+	return total, true
+}
+
+func (p *inlining) Optimize(expression decorated.Expression) decorated.Expression {
+	inlined := mapChildren(expression, p.Optimize)
+
+	call, isCall := inlined.(*decorated.FunctionCall)
+	if !isCall || call.Specialization() != nil {
+		return inlined
+	}
+
+	reference, isReference := call.FunctionExpression().(*decorated.FunctionReference)
+	if !isReference {
+		return inlined
+	}
+
+	functionValue := reference.FunctionValue()
+	body, canBeInlined := p.bodies[functionValue]
+	if !canBeInlined || len(call.Arguments()) != len(functionValue.Parameters()) {
+		return inlined
+	}
+
+	return p.inline(call, functionValue, body)
+}
+
+func (p *inlining) inline(call *decorated.FunctionCall, functionValue *decorated.FunctionValue, body decorated.Expression) decorated.Expression {
+	p.inlineID++
+	copier := &inlineCopy{
+		suffix:       fmt.Sprintf("@%d", p.inlineID),
+		parameters:   make(map[*decorated.FunctionParameterDefinition]*decorated.LetVariable),
+		letVariables: make(map[*decorated.LetVariable]*decorated.LetVariable),
+	}
+
+	position := call.FetchPositionLength()
+
+	var astAssignments []ast.LetAssignment
+	var assignments []*decorated.LetAssignment
+	for index, parameter := range functionValue.Parameters() {
+		identifier := ast.NewVariableIdentifier(token.NewVariableSymbolToken(parameter.Parameter().Name()+copier.suffix, position, 0))
+		letVariable := decorated.NewLetVariable(identifier, parameter.Type(), nil)
+		copier.parameters[parameter] = letVariable
+
+		astAssignment := ast.NewLetAssignment(false, []*ast.VariableIdentifier{identifier}, call.AstFunctionCall(), nil)
+		astAssignments = append(astAssignments, astAssignment)
+		assignments = append(assignments, decorated.NewLetAssignment(astAssignment, []*decorated.LetVariable{letVariable}, call.Arguments()[index]))
+	}
+
+	inlinedBody := copier.expression(body)
+	if len(assignments) == 0 {
+		return inlinedBody
+	}
+
+	astLet := ast.NewLet(token.NewKeyword("let", token.Let, position), token.NewKeyword("in", token.In, position), astAssignments, call.AstFunctionCall())
+
+	return decorated.NewLet(astLet, assignments, inlinedBody)
+}
+
+// inlineCopy copies the body of an inlined function, where the parameters are replaced by let variables and the let
+// variables are renamed.
+type inlineCopy struct {
+	suffix       string
+	parameters   map[*decorated.FunctionParameterDefinition]*decorated.LetVariable
+	letVariables map[*decorated.LetVariable]*decorated.LetVariable
+}
+
+func (c *inlineCopy) expression(expression decorated.Expression) decorated.Expression {
+	switch e := expression.(type) {
+	case *decorated.FunctionParameterReference:
+		letVariable, wasFound := c.parameters[e.ParameterRef()]
+		if !wasFound {
+			panic(fmt.Errorf("optimize: inlined function refers to unknown parameter %v", e))
+		}
+		return decorated.NewLetVariableReference(e.Identifier(), letVariable)
+	case *decorated.LetVariableReference:
+		if renamed, wasRenamed := c.letVariables[e.LetVariable()]; wasRenamed {
+			return decorated.NewLetVariableReference(e.Identifier(), renamed)
+		}
+		return e
+	case *decorated.Let:
+		return c.let(e)
+	}
+
+	return mapChildren(expression, c.expression)
+}
+
+func (c *inlineCopy) let(let *decorated.Let) decorated.Expression {
+	var assignments []*decorated.LetAssignment
+	for _, assignment := range let.Assignments() {
+		var letVariables []*decorated.LetVariable
+		for _, letVariable := range assignment.LetVariables() {
+			if letVariable.IsIgnore() {
+				letVariables = append(letVariables, letVariable)
+				continue
+			}
+			identifier := ast.NewVariableIdentifier(token.NewVariableSymbolToken(letVariable.Name().Name()+c.suffix, letVariable.FetchPositionLength(), 0))
+			renamed := decorated.NewLetVariable(identifier, letVariable.Type(), letVariable.Comment())
+			c.letVariables[letVariable] = renamed
+			letVariables = append(letVariables, renamed)
+		}
+		assignments = append(assignments, decorated.NewLetAssignment(assignment.AstLetAssignment(), letVariables, c.expression(assignment.Expression())))
+	}
+
+	return decorated.NewLet(let.AstLet(), assignments, c.expression(let.Consequence()))
+}
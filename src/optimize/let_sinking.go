@@ -0,0 +1,91 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package optimize
+
+import (
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+)
+
+// letSinking moves a let assignment into the branch of an `if` that uses it, so that it is only calculated when that
+// branch is taken. It is only done for a `let` that has the `if` as the `in` expression, and only if the variables are
+// not used in the condition, in the other branch or in the assignments after it.
+type letSinking struct{}
+
+func (p *letSinking) Name() string {
+	return "let sinking"
+}
+
+func (p *letSinking) Optimize(expression decorated.Expression) decorated.Expression {
+	sunk := mapChildren(expression, p.Optimize)
+
+	let, isLet := sunk.(*decorated.Let)
+	if !isLet {
+		return sunk
+	}
+
+	return sinkAssignments(let)
+}
+
+func wrapInLet(let *decorated.Let, assignments []*decorated.LetAssignment, expression decorated.Expression) decorated.Expression {
+	if len(assignments) == 0 {
+		return expression
+	}
+
+	return decorated.NewLet(let.AstLet(), assignments, expression)
+}
+
+func sinkAssignments(let *decorated.Let) decorated.Expression {
+	ifExpression, isIf := let.Consequence().(*decorated.If)
+	if !isIf {
+		return let
+	}
+
+	conditionCounts := letVariableReferenceCounts(ifExpression.Condition())
+	consequenceCounts := letVariableReferenceCounts(ifExpression.Consequence())
+	alternativeCounts := letVariableReferenceCounts(ifExpression.Alternative())
+
+	var kept []*decorated.LetAssignment
+	var toConsequence []*decorated.LetAssignment
+	var toAlternative []*decorated.LetAssignment
+
+	assignments := let.Assignments()
+	for index, assignment := range assignments {
+		letVariables := assignment.LetVariables()
+		if isIgnoreAssignment(assignment) || isReferenced(letVariables, conditionCounts) {
+			kept = append(kept, assignment)
+			continue
+		}
+
+		var laterExpressions []decorated.Expression
+		for _, laterAssignment := range assignments[index+1:] {
+			laterExpressions = append(laterExpressions, laterAssignment.Expression())
+		}
+		if isReferenced(letVariables, letVariableReferenceCounts(laterExpressions...)) {
+			kept = append(kept, assignment)
+			continue
+		}
+
+		usedInConsequence := isReferenced(letVariables, consequenceCounts)
+		usedInAlternative := isReferenced(letVariables, alternativeCounts)
+		switch {
+		case usedInConsequence && !usedInAlternative:
+			toConsequence = append(toConsequence, assignment)
+		case usedInAlternative && !usedInConsequence:
+			toAlternative = append(toAlternative, assignment)
+		default:
+			kept = append(kept, assignment)
+		}
+	}
+
+	if len(kept) == len(assignments) {
+		return let
+	}
+
+	sunkIf := decorated.NewIf(ifExpression.AstIf(), ifExpression.Condition(),
+		wrapInLet(let, toConsequence, ifExpression.Consequence()), wrapInLet(let, toAlternative, ifExpression.Alternative()))
+
+	return wrapInLet(let, kept, sunkIf)
+}
@@ -0,0 +1,57 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package optimize
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/swamp/compiler/src/ast"
+	"github.com/swamp/compiler/src/decorated/dtype"
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	"github.com/swamp/compiler/src/token"
+)
+
+// The literals that are created by the optimizer use the source position of the expression that they replace, so
+// that the debug information still points to the original source.
+
+func newIntegerLiteral(value int32, integerType dtype.Type, position token.SourceFileReference) *decorated.IntegerLiteral {
+	numberToken := token.NewNumberToken(strconv.Itoa(int(value)), value, false, position)
+	return decorated.NewIntegerLiteral(ast.NewIntegerLiteral(numberToken, value), integerType)
+}
+
+func newFixedLiteral(value int32, fixedType dtype.Type, position token.SourceFileReference) *decorated.FixedLiteral {
+	numberToken := token.NewNumberToken(fmt.Sprintf("%d", value), value, true, position)
+	return decorated.NewFixedLiteral(ast.NewFixedLiteral(numberToken, value), fixedType)
+}
+
+func newBooleanLiteral(value bool, booleanType dtype.Type, position token.SourceFileReference) *decorated.BooleanLiteral {
+	raw := "False"
+	if value {
+		raw = "True"
+	}
+	return decorated.NewBooleanLiteral(ast.NewBooleanLiteral(token.NewBooleanToken(raw, value, position)), booleanType)
+}
+
+func newStringLiteral(text string, stringType dtype.Type, position token.SourceFileReference) *decorated.StringLiteral {
+	var stringLines []token.SameLineRange
+	if len(text) > 0 {
+		stringLines = append(stringLines, token.MakeSameLineRange(position.Range.Start(), len(text), 0))
+	}
+	stringToken := token.NewStringToken(strconv.Quote(text), text, position, stringLines)
+	return decorated.NewStringLiteral(ast.NewStringLiteral(stringToken), stringType)
+}
+
+// isLiteral returns true for the literals that a let variable can be replaced with.
+func isLiteral(expression decorated.Expression) bool {
+	switch expression.(type) {
+	case *decorated.IntegerLiteral, *decorated.FixedLiteral, *decorated.BooleanLiteral, *decorated.StringLiteral,
+		*decorated.CharacterLiteral:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,145 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package optimize
+
+import (
+	"fmt"
+	"log"
+
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+	"github.com/swamp/compiler/src/loader"
+	"github.com/swamp/compiler/src/verbosity"
+)
+
+// Level is the optimization level that is selected with `-O0`, `-O1` or `-O2`.
+type Level uint8
+
+const (
+	// LevelNone generates the decorated tree as it is.
+	LevelNone Level = iota
+	// LevelBasic folds constants, removes `if` with a literal condition and removes let variables that are not used.
+	LevelBasic
+	// LevelFull also inlines small functions and moves let assignments into the branch that uses them.
+	LevelFull
+)
+
+func (l Level) String() string {
+	return fmt.Sprintf("O%d", l)
+}
+
+func NewLevel(level int) (Level, error) {
+	if level < int(LevelNone) || level > int(LevelFull) {
+		return LevelNone, fmt.Errorf("unknown optimization level %d, must be 0, 1 or 2", level)
+	}
+
+	return Level(level), nil
+}
+
+// Pass rewrites the expression of a function. The expression is returned as it is, if the pass didn't change anything.
+type Pass interface {
+	Name() string
+	Optimize(expression decorated.Expression) decorated.Expression
+}
+
+// maxRounds is the maximum number of times that all passes are run on a function. A pass often makes it possible for
+// another pass to do more, e.g. an inlined function can be folded, which removes an `if`, which makes a let variable unused.
+const maxRounds = 4
+
+// PassManager runs the passes on the functions, lambdas and specializations of a package.
+type PassManager struct {
+	passes      []Pass
+	verboseFlag verbosity.Verbosity
+}
+
+func NewPassManager(passes []Pass, verboseFlag verbosity.Verbosity) *PassManager {
+	return &PassManager{passes: passes, verboseFlag: verboseFlag}
+}
+
+// Passes returns the passes for the level, in the order they are run.
+func Passes(level Level, compilePackage *loader.Package) []Pass {
+	switch level {
+	case LevelNone:
+		return nil
+	case LevelBasic:
+		return []Pass{&constantFolding{}, &ifElimination{}, &deadLetRemoval{}}
+	case LevelFull:
+		return []Pass{newInlining(compilePackage), &constantFolding{}, &ifElimination{}, &deadLetRemoval{}, &letSinking{}}
+	}
+
+	panic(fmt.Errorf("unknown optimization level %v", level))
+}
+
+// Package optimizes all the functions in the package. It must be called after the package is decorated and before it
+// is generated.
+func Package(compilePackage *loader.Package, level Level, verboseFlag verbosity.Verbosity) {
+	passes := Passes(level, compilePackage)
+	if len(passes) == 0 {
+		return
+	}
+
+	NewPassManager(passes, verboseFlag).OptimizePackage(compilePackage)
+}
+
+func (m *PassManager) OptimizePackage(compilePackage *loader.Package) {
+	for _, module := range compilePackage.AllModules() {
+		m.OptimizeModule(module)
+	}
+}
+
+func (m *PassManager) OptimizeModule(module *decorated.Module) {
+	for _, named := range module.LocalDefinitions().Definitions() {
+		functionValue, isFunction := named.Expression().(*decorated.FunctionValue)
+		if !isFunction || functionValue.IsSomeKindOfExternal() {
+			continue
+		}
+
+		name := module.FullyQualifiedName(named.Identifier()).String()
+		if functionValue.NeedsSpecialization() {
+			for _, specialization := range functionValue.Specializations() {
+				m.optimizeFunctionValue(name+specialization.Suffix(), specialization.FunctionValue())
+			}
+			continue
+		}
+
+		m.optimizeFunctionValue(name, functionValue)
+	}
+}
+
+func (m *PassManager) optimizeFunctionValue(name string, functionValue *decorated.FunctionValue) {
+	if optimized, wasChanged := m.optimizeExpression(name, functionValue.Expression()); wasChanged {
+		functionValue.DefineExpression(optimized)
+	}
+
+	for _, lambda := range functionValue.Lambdas() {
+		if optimized, wasChanged := m.optimizeExpression(name, lambda.Expression()); wasChanged {
+			lambda.DefineExpression(optimized)
+		}
+	}
+}
+
+func (m *PassManager) optimizeExpression(name string, expression decorated.Expression) (decorated.Expression, bool) {
+	original := expression
+	for round := 0; round < maxRounds; round++ {
+		wasChangedInRound := false
+		for _, pass := range m.passes {
+			optimized := pass.Optimize(expression)
+			if optimized == expression {
+				continue
+			}
+			if m.verboseFlag >= verbosity.High {
+				log.Printf("optimize: %v changed '%v' in round %d", pass.Name(), name, round)
+			}
+			expression = optimized
+			wasChangedInRound = true
+		}
+
+		if !wasChangedInRound {
+			break
+		}
+	}
+
+	return expression, expression != original
+}
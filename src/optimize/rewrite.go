@@ -0,0 +1,149 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package optimize
+
+import (
+	"fmt"
+
+	decorated "github.com/swamp/compiler/src/decorated/expression"
+)
+
+// children returns the sub expressions of the expressions that can be rebuilt. Other expressions return nil, and are
+// left as they are, including everything inside them.
+func children(expression decorated.Expression) []decorated.Expression {
+	switch e := expression.(type) {
+	case *decorated.ArithmeticOperator:
+		return []decorated.Expression{e.Left(), e.Right()}
+	case *decorated.BooleanOperator:
+		return []decorated.Expression{e.Left(), e.Right()}
+	case *decorated.LogicalOperator:
+		return []decorated.Expression{e.Left(), e.Right()}
+	case *decorated.ArithmeticUnaryOperator:
+		return []decorated.Expression{e.Left()}
+	case *decorated.LogicalUnaryOperator:
+		return []decorated.Expression{e.Left()}
+	case *decorated.If:
+		return []decorated.Expression{e.Condition(), e.Consequence(), e.Alternative()}
+	case *decorated.Let:
+		var expressions []decorated.Expression
+		for _, assignment := range e.Assignments() {
+			expressions = append(expressions, assignment.Expression())
+		}
+		return append(expressions, e.Consequence())
+	case *decorated.FunctionCall:
+		return e.Arguments()
+	}
+
+	return nil
+}
+
+// isLeaf returns true for the expressions that have no sub expressions and can be copied into another function.
+func isLeaf(expression decorated.Expression) bool {
+	switch expression.(type) {
+	case *decorated.IntegerLiteral, *decorated.FixedLiteral, *decorated.BooleanLiteral, *decorated.StringLiteral,
+		*decorated.CharacterLiteral, *decorated.LetVariableReference, *decorated.FunctionParameterReference,
+		*decorated.FunctionReference, *decorated.ConstantReference:
+		return true
+	}
+
+	return false
+}
+
+// rebuild creates a copy of the expression with other sub expressions, in the same order as returned by children.
+func rebuild(expression decorated.Expression, subExpressions []decorated.Expression) decorated.Expression {
+	switch e := expression.(type) {
+	case *decorated.ArithmeticOperator:
+		rebuilt, err := decorated.NewArithmeticOperator(e.AstBinaryOperator(), subExpressions[0], subExpressions[1], e.OperatorType())
+		if err != nil {
+			panic(fmt.Errorf("optimize: could not rebuild %v: %w", e, err))
+		}
+		return rebuilt
+	case *decorated.BooleanOperator:
+		rebuilt, err := decorated.NewBooleanOperator(nil, subExpressions[0], subExpressions[1], e.OperatorType(), e.Type())
+		if err != nil {
+			panic(fmt.Errorf("optimize: could not rebuild %v: %w", e, err))
+		}
+		return rebuilt
+	case *decorated.LogicalOperator:
+		rebuilt, err := decorated.NewLogicalOperator(subExpressions[0], subExpressions[1], e.OperatorType(), e.Type())
+		if err != nil {
+			panic(fmt.Errorf("optimize: could not rebuild %v: %w", e, err))
+		}
+		return rebuilt
+	case *decorated.ArithmeticUnaryOperator:
+		rebuilt, _ := decorated.NewArithmeticUnaryOperator(e.AstUnaryExpression(), subExpressions[0], e.OperatorType())
+		return rebuilt
+	case *decorated.LogicalUnaryOperator:
+		rebuilt, _ := decorated.NewLogicalUnaryOperator(e.AstUnaryExpression(), subExpressions[0], e.OperatorType())
+		return rebuilt
+	case *decorated.If:
+		return decorated.NewIf(e.AstIf(), subExpressions[0], subExpressions[1], subExpressions[2])
+	case *decorated.Let:
+		var assignments []*decorated.LetAssignment
+		for index, assignment := range e.Assignments() {
+			assignments = append(assignments, decorated.NewLetAssignment(assignment.AstLetAssignment(), assignment.LetVariables(), subExpressions[index]))
+		}
+		return decorated.NewLet(e.AstLet(), assignments, subExpressions[len(subExpressions)-1])
+	case *decorated.FunctionCall:
+		rebuilt := decorated.NewFunctionCall(e.AstFunctionCall(), e.FunctionExpression(), e.SmashedFunctionType(), subExpressions)
+		rebuilt.SetSpecialization(e.Specialization())
+		return rebuilt
+	}
+
+	panic(fmt.Errorf("optimize: can not rebuild %T", expression))
+}
+
+// mapChildren calls the function on every sub expression and rebuilds the expression if any of them changed.
+func mapChildren(expression decorated.Expression, f func(decorated.Expression) decorated.Expression) decorated.Expression {
+	subExpressions := children(expression)
+	if len(subExpressions) == 0 {
+		return expression
+	}
+
+	wasChanged := false
+	changed := make([]decorated.Expression, len(subExpressions))
+	for index, subExpression := range subExpressions {
+		changed[index] = f(subExpression)
+		if changed[index] != subExpression {
+			wasChanged = true
+		}
+	}
+
+	if !wasChanged {
+		return expression
+	}
+
+	return rebuild(expression, changed)
+}
+
+// letVariableReferenceCounts counts the references to each let variable in the expression, including the references
+// in lambdas and in expressions that can not be rebuilt.
+func letVariableReferenceCounts(expressions ...decorated.Expression) map[*decorated.LetVariable]int {
+	var nodes []decorated.Node
+	for _, expression := range expressions {
+		nodes = append(nodes, expression)
+	}
+
+	counts := make(map[*decorated.LetVariable]int)
+	for _, node := range decorated.ExpandAllChildNodes(nodes) {
+		if reference, isReference := node.(*decorated.LetVariableReference); isReference {
+			counts[reference.LetVariable()]++
+		}
+	}
+
+	return counts
+}
+
+// isReferenced returns true if any of the let variables are used in the counts.
+func isReferenced(letVariables []*decorated.LetVariable, counts map[*decorated.LetVariable]int) bool {
+	for _, letVariable := range letVariables {
+		if counts[letVariable] > 0 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,68 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package packinspect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	swampdisasmsp "github.com/swamp/disassembler/lib"
+)
+
+// unknownFileIndex is used in the debug lines for instructions that have no source document.
+const unknownFileIndex = 0xffff
+
+type Instruction struct {
+	OpcodePosition uint16 `json:"opcodePosition"`
+	Text           string `json:"text"`
+	Source         string `json:"source,omitempty"`
+}
+
+func (p *Pack) sourceString(line DebugLine) string {
+	return SourceString(p.DebugFileUrls, line)
+}
+
+// SourceString returns the source position of the debug line as `file:line:column`, where the line and column start
+// at one. The file is left out if it is unknown.
+func SourceString(fileUrls []string, line DebugLine) string {
+	if line.FileIndex == unknownFileIndex || int(line.FileIndex) >= len(fileUrls) {
+		return fmt.Sprintf("%d:%d", line.Line+1, line.Column+1)
+	}
+
+	return fmt.Sprintf("%v:%d:%d", fileUrls[line.FileIndex], line.Line+1, line.Column+1)
+}
+
+// Disassemble returns the instructions of the function. Instructions that start a new source position
+// are annotated with the debug line.
+func (p *Pack) Disassemble(function *Function) (instructions []Instruction, err error) {
+	defer func() {
+		// the disassembler panics on opcodes that it doesn't understand
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("couldn't disassemble '%v': %v", function.Name, recovered)
+		}
+	}()
+
+	sourceAtPosition := make(map[uint16]string)
+	for _, line := range function.DebugLines {
+		sourceAtPosition[line.OpcodePosition] = p.sourceString(line)
+	}
+
+	for _, text := range swampdisasmsp.Disassemble(function.Opcodes, false) {
+		positionString, instructionText, _ := strings.Cut(text, ": ")
+		position, parseErr := strconv.ParseUint(positionString, 16, 16)
+		if parseErr != nil {
+			return nil, fmt.Errorf("unexpected disassembler output '%v'", text)
+		}
+		instructions = append(instructions, Instruction{
+			OpcodePosition: uint16(position),
+			Text:           instructionText,
+			Source:         sourceAtPosition[uint16(position)],
+		})
+	}
+
+	return instructions, nil
+}
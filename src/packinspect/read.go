@@ -0,0 +1,536 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+// Package packinspect reads the `.swamp-pack` files that are written by generate_sp.Pack.
+package packinspect
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	raff "github.com/piot/raff-go/src"
+	"github.com/swamp/assembler/lib/assembler_sp"
+)
+
+var (
+	packChunkName          = raff.MakeFourOctets('s', 'p', 'k', '5')
+	packLayoutChunkName    = raff.MakeFourOctets('s', 'p', 'k', '6')
+	typeInfoChunkName      = raff.MakeFourOctets('s', 't', 'i', '0')
+	dynamicMemoryChunkName = raff.MakeFourOctets('d', 'm', 'e', '1')
+	ledgerChunkName        = raff.MakeFourOctets('l', 'd', 'g', '0')
+	buildIDChunkName       = raff.MakeFourOctets('b', 'i', 'd', '0')
+)
+
+// offsets in the structs that are written by assembler_sp.PackageConstants
+const (
+	functionParameterCountOffset         = 8
+	functionParameterOctetSizeOffset     = 16
+	functionOpcodesOffset                = assembler_sp.SwampFuncOpcodeOffset
+	functionReturnOctetSizeOffset        = 40
+	functionReturnAlignOffset            = 48
+	functionDebugNameOffset              = 56
+	functionTypeIndexOffset              = 64
+	functionDebugLinesOffset             = assembler_sp.SwampFuncDebugLinesOffset
+	externalFunctionDebugNameOffset      = 120
+	debugLineOctetCount                  = 4 * 2
+	debugInfoFilesPointerOffset          = 8
+	resourceNameChunkCountOffset         = 8
+	externalFunctionParameterCountOffset = 8
+	externalFunctionReturnOffset         = 16
+	externalFunctionParametersOffset     = 24
+	externalFunctionMaxParameterCount    = (externalFunctionDebugNameOffset - externalFunctionParametersOffset) / 8
+)
+
+type LedgerEntry struct {
+	ConstantType assembler_sp.ConstantType
+	Position     uint32
+}
+
+type DebugLine struct {
+	OpcodePosition uint16 `json:"opcodePosition"`
+	FileIndex      uint16 `json:"fileIndex"`
+	Line           uint16 `json:"line"`
+	Column         uint16 `json:"column"`
+}
+
+type Function struct {
+	Name               string      `json:"name"`
+	Position           uint32      `json:"position"`
+	ParameterCount     uint64      `json:"parameterCount"`
+	ParameterOctetSize uint64      `json:"parameterOctetSize"`
+	ReturnOctetSize    uint64      `json:"returnOctetSize"`
+	ReturnAlign        uint64      `json:"returnAlign"`
+	TypeIndex          uint64      `json:"typeIndex"`
+	Opcodes            []byte      `json:"-"`
+	OpcodeOctetCount   int         `json:"opcodeOctetCount"`
+	DebugLines         []DebugLine `json:"debugLines,omitempty"`
+}
+
+// StackRange is the position and octet size of a value on the stack, relative to the start of the call frame.
+type StackRange struct {
+	Offset uint32 `json:"offset"`
+	Size   uint32 `json:"size"`
+}
+
+func (r StackRange) String() string {
+	return fmt.Sprintf("%d+%d", r.Offset, r.Size)
+}
+
+type ExternalFunction struct {
+	Name           string       `json:"name"`
+	Position       uint32       `json:"position"`
+	ParameterCount uint64       `json:"parameterCount"`
+	Return         StackRange   `json:"return"`
+	Parameters     []StackRange `json:"parameters"`
+}
+
+type StringConstant struct {
+	Value    string `json:"value"`
+	Position uint32 `json:"position"`
+}
+
+type ResourceName struct {
+	Name     string `json:"name"`
+	Position uint32 `json:"position"`
+}
+
+// DataLayout is the target data layout that is recorded in the `spk6` pack header. Recorded is false for packs with
+// the `spk5` header, they are always lp64.
+type DataLayout struct {
+	Name         string `json:"name"`
+	PointerSize  int    `json:"pointerSize"`
+	PointerAlign int    `json:"pointerAlign"`
+	Recorded     bool   `json:"recorded"`
+}
+
+type TypeInfo struct {
+	Version    string `json:"version"`
+	TypeCount  int    `json:"typeCount"`
+	OctetCount int    `json:"octetCount"`
+}
+
+// Pack is the decoded content of a `.swamp-pack` file.
+type Pack struct {
+	Functions         []*Function         `json:"functions"`
+	ExternalFunctions []*ExternalFunction `json:"externalFunctions"`
+	Strings           []*StringConstant   `json:"strings"`
+	ResourceNames     []*ResourceName     `json:"resourceNames"`
+	DebugFileUrls     []string            `json:"debugFileUrls"`
+	BuildID           string              `json:"buildId,omitempty"`
+	DataLayout        DataLayout          `json:"dataLayout"`
+	DynamicMemorySize int                 `json:"dynamicMemorySize"`
+	TypeInfo          TypeInfo            `json:"typeInfo"`
+	TypeInfoOctets    []byte              `json:"-"`
+}
+
+type memoryReader struct {
+	octets []byte
+}
+
+func (m memoryReader) check(position uint64, count uint64) error {
+	if position+count > uint64(len(m.octets)) || position+count < position {
+		return fmt.Errorf("reading %d octets at %04X is outside of dynamic memory (%d octets)", count, position, len(m.octets))
+	}
+
+	return nil
+}
+
+func (m memoryReader) uint16(position uint64) (uint16, error) {
+	if err := m.check(position, 2); err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint16(m.octets[position:]), nil
+}
+
+func (m memoryReader) uint32(position uint64) (uint32, error) {
+	if err := m.check(position, 4); err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint32(m.octets[position:]), nil
+}
+
+func (m memoryReader) uint64(position uint64) (uint64, error) {
+	if err := m.check(position, 8); err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint64(m.octets[position:]), nil
+}
+
+func (m memoryReader) slice(position uint64, count uint64) ([]byte, error) {
+	if err := m.check(position, count); err != nil {
+		return nil, err
+	}
+
+	return m.octets[position : position+count], nil
+}
+
+// zeroTerminatedString reads a string that is allocated by assembler_sp.AllocateStringOctets.
+func (m memoryReader) zeroTerminatedString(position uint64) (string, error) {
+	if err := m.check(position, 1); err != nil {
+		return "", err
+	}
+
+	end := bytes.IndexByte(m.octets[position:], 0)
+	if end == -1 {
+		return "", fmt.Errorf("string at %04X is not zero terminated", position)
+	}
+
+	return string(m.octets[position : position+uint64(end)]), nil
+}
+
+func (m memoryReader) pointerAndSize(position uint64) ([]byte, error) {
+	pointer, pointerErr := m.uint64(position)
+	if pointerErr != nil {
+		return nil, pointerErr
+	}
+
+	size, sizeErr := m.uint64(position + 8)
+	if sizeErr != nil {
+		return nil, sizeErr
+	}
+
+	return m.slice(pointer, size)
+}
+
+func readLedger(octets []byte) ([]LedgerEntry, error) {
+	var entries []LedgerEntry
+
+	for position := 0; position+8 <= len(octets); position += 8 {
+		constantType := binary.LittleEndian.Uint32(octets[position : position+4])
+		constantPosition := binary.LittleEndian.Uint32(octets[position+4 : position+8])
+		if constantType == 0 {
+			return entries, nil
+		}
+		entries = append(entries, LedgerEntry{ConstantType: assembler_sp.ConstantType(constantType), Position: constantPosition})
+	}
+
+	return nil, fmt.Errorf("ledger is not terminated")
+}
+
+func readDebugLines(memory memoryReader, position uint64) ([]DebugLine, error) {
+	debugLinesStructPointer, pointerErr := memory.uint64(position)
+	if pointerErr != nil {
+		return nil, pointerErr
+	}
+
+	if debugLinesStructPointer == 0 {
+		return nil, nil
+	}
+
+	count, countErr := memory.uint32(debugLinesStructPointer)
+	if countErr != nil {
+		return nil, countErr
+	}
+
+	linesPointer, linesPointerErr := memory.uint64(debugLinesStructPointer + 8)
+	if linesPointerErr != nil {
+		return nil, linesPointerErr
+	}
+
+	lineOctets, lineOctetsErr := memory.slice(linesPointer, uint64(count)*debugLineOctetCount)
+	if lineOctetsErr != nil {
+		return nil, lineOctetsErr
+	}
+
+	return DecodeDebugLines(lineOctets)
+}
+
+// DecodeDebugLines decodes the debug lines that are written by opcode_sp.SerializeDebugLines.
+func DecodeDebugLines(lineOctets []byte) ([]DebugLine, error) {
+	if len(lineOctets)%debugLineOctetCount != 0 {
+		return nil, fmt.Errorf("debug lines have wrong size (%d octets)", len(lineOctets))
+	}
+
+	lines := make([]DebugLine, len(lineOctets)/debugLineOctetCount)
+	for index := range lines {
+		entry := lineOctets[index*debugLineOctetCount:]
+		lines[index] = DebugLine{
+			OpcodePosition: binary.LittleEndian.Uint16(entry[0:2]),
+			FileIndex:      binary.LittleEndian.Uint16(entry[2:4]),
+			Line:           binary.LittleEndian.Uint16(entry[4:6]),
+			Column:         binary.LittleEndian.Uint16(entry[6:8]),
+		}
+	}
+
+	return lines, nil
+}
+
+func readFunction(memory memoryReader, position uint64) (*Function, error) {
+	f := &Function{Position: uint32(position)}
+
+	var err error
+	if f.ParameterCount, err = memory.uint64(position + functionParameterCountOffset); err != nil {
+		return nil, err
+	}
+	if f.ParameterOctetSize, err = memory.uint64(position + functionParameterOctetSizeOffset); err != nil {
+		return nil, err
+	}
+	if f.ReturnOctetSize, err = memory.uint64(position + functionReturnOctetSizeOffset); err != nil {
+		return nil, err
+	}
+	if f.ReturnAlign, err = memory.uint64(position + functionReturnAlignOffset); err != nil {
+		return nil, err
+	}
+	if f.TypeIndex, err = memory.uint64(position + functionTypeIndexOffset); err != nil {
+		return nil, err
+	}
+
+	namePointer, namePointerErr := memory.uint64(position + functionDebugNameOffset)
+	if namePointerErr != nil {
+		return nil, namePointerErr
+	}
+	if f.Name, err = memory.zeroTerminatedString(namePointer); err != nil {
+		return nil, err
+	}
+
+	if f.Opcodes, err = memory.pointerAndSize(position + functionOpcodesOffset); err != nil {
+		return nil, fmt.Errorf("opcodes for '%v' %w", f.Name, err)
+	}
+	f.OpcodeOctetCount = len(f.Opcodes)
+
+	if f.DebugLines, err = readDebugLines(memory, position+functionDebugLinesOffset); err != nil {
+		return nil, fmt.Errorf("debug lines for '%v' %w", f.Name, err)
+	}
+
+	return f, nil
+}
+
+func readExternalFunction(memory memoryReader, position uint64) (*ExternalFunction, error) {
+	parameterCount, countErr := memory.uint64(position + externalFunctionParameterCountOffset)
+	if countErr != nil {
+		return nil, countErr
+	}
+
+	if parameterCount > externalFunctionMaxParameterCount {
+		return nil, fmt.Errorf("external function has too many parameters (%v)", parameterCount)
+	}
+
+	returnRange, returnErr := readStackRange(memory, position+externalFunctionReturnOffset)
+	if returnErr != nil {
+		return nil, returnErr
+	}
+
+	parameters := make([]StackRange, parameterCount)
+	for index := range parameters {
+		parameterRange, parameterErr := readStackRange(memory, position+externalFunctionParametersOffset+uint64(index)*8)
+		if parameterErr != nil {
+			return nil, parameterErr
+		}
+		parameters[index] = parameterRange
+	}
+
+	namePointer, namePointerErr := memory.uint64(position + externalFunctionDebugNameOffset)
+	if namePointerErr != nil {
+		return nil, namePointerErr
+	}
+
+	name, nameErr := memory.zeroTerminatedString(namePointer)
+	if nameErr != nil {
+		return nil, nameErr
+	}
+
+	return &ExternalFunction{Name: name, Position: uint32(position), ParameterCount: parameterCount,
+		Return: returnRange, Parameters: parameters}, nil
+}
+
+func readStackRange(memory memoryReader, position uint64) (StackRange, error) {
+	offset, offsetErr := memory.uint32(position)
+	if offsetErr != nil {
+		return StackRange{}, offsetErr
+	}
+
+	size, sizeErr := memory.uint32(position + 4)
+	if sizeErr != nil {
+		return StackRange{}, sizeErr
+	}
+
+	return StackRange{Offset: offset, Size: size}, nil
+}
+
+func readString(memory memoryReader, position uint64) (*StringConstant, error) {
+	value, valueErr := memory.pointerAndSize(position)
+	if valueErr != nil {
+		return nil, valueErr
+	}
+
+	return &StringConstant{Value: string(value), Position: uint32(position)}, nil
+}
+
+func readDebugFileUrls(memory memoryReader, position uint64) ([]string, error) {
+	count, countErr := memory.uint32(position)
+	if countErr != nil {
+		return nil, countErr
+	}
+
+	arrayPointer, arrayPointerErr := memory.uint64(position + debugInfoFilesPointerOffset)
+	if arrayPointerErr != nil {
+		return nil, arrayPointerErr
+	}
+
+	urls := make([]string, count)
+	for index := range urls {
+		stringPointer, stringPointerErr := memory.uint64(arrayPointer + uint64(index)*8)
+		if stringPointerErr != nil {
+			return nil, stringPointerErr
+		}
+		url, urlErr := memory.zeroTerminatedString(stringPointer)
+		if urlErr != nil {
+			return nil, urlErr
+		}
+		urls[index] = url
+	}
+
+	return urls, nil
+}
+
+func readTypeInfo(octets []byte) TypeInfo {
+	info := TypeInfo{OctetCount: len(octets)}
+	if len(octets) >= 5 {
+		info.Version = fmt.Sprintf("%d.%d.%d", octets[0], octets[1], octets[2])
+		info.TypeCount = int(binary.BigEndian.Uint16(octets[3:5]))
+	}
+
+	return info
+}
+
+func (p *Pack) addConstant(memory memoryReader, entry LedgerEntry) error {
+	position := uint64(entry.Position)
+
+	switch entry.ConstantType {
+	case assembler_sp.ConstantTypeFunction:
+		function, err := readFunction(memory, position)
+		if err != nil {
+			return err
+		}
+		p.Functions = append(p.Functions, function)
+	case assembler_sp.ConstantTypeFunctionExternal:
+		externalFunction, err := readExternalFunction(memory, position)
+		if err != nil {
+			return err
+		}
+		p.ExternalFunctions = append(p.ExternalFunctions, externalFunction)
+	case assembler_sp.ConstantTypeString:
+		stringConstant, err := readString(memory, position)
+		if err != nil {
+			return err
+		}
+		p.Strings = append(p.Strings, stringConstant)
+	case assembler_sp.ConstantTypeResourceName:
+		name, err := memory.zeroTerminatedString(position)
+		if err != nil {
+			return err
+		}
+		p.ResourceNames = append(p.ResourceNames, &ResourceName{Name: name, Position: entry.Position})
+	case assembler_sp.ConstantTypeResourceNameChunk:
+		// The chunk only points to the resource names that are already in the ledger, but check that it agrees
+		count, err := memory.uint64(position + resourceNameChunkCountOffset)
+		if err != nil {
+			return err
+		}
+		if count != uint64(len(p.ResourceNames)) {
+			return fmt.Errorf("resource name chunk has %d names, but the ledger has %d", count, len(p.ResourceNames))
+		}
+	case assembler_sp.ConstantTypeDebugInfoFiles:
+		urls, err := readDebugFileUrls(memory, position)
+		if err != nil {
+			return err
+		}
+		p.DebugFileUrls = urls
+	default:
+		return fmt.Errorf("unknown constant type %d at %04X", entry.ConstantType, entry.Position)
+	}
+
+	return nil
+}
+
+// readDataLayout reads the payload that generate_sp.PackHeaderPayload writes in the `spk6` chunk.
+func readDataLayout(payload []byte) (DataLayout, error) {
+	if len(payload) < 3 || len(payload) != 3+int(payload[2]) {
+		return DataLayout{}, fmt.Errorf("pack header has wrong size (%d octets)", len(payload))
+	}
+
+	return DataLayout{Name: string(payload[3:]), PointerSize: int(payload[0]), PointerAlign: int(payload[1]), Recorded: true}, nil
+}
+
+// Read decodes a `.swamp-pack` file.
+func Read(octets []byte) (*Pack, error) {
+	reader := bytes.NewReader(octets)
+	if err := raff.ReadHeader(reader); err != nil {
+		return nil, fmt.Errorf("not a swamp pack: %w", err)
+	}
+
+	chunks := make(map[raff.FourOctets][]byte)
+	for reader.Len() > 0 {
+		header, payload, chunkErr := raff.ReadChunk(reader)
+		if chunkErr != nil {
+			return nil, fmt.Errorf("couldn't read chunk: %w", chunkErr)
+		}
+		chunks[header.Name] = payload
+	}
+
+	dataLayout := DataLayout{Name: "lp64", PointerSize: 8, PointerAlign: 8}
+	if layoutHeader, hasLayoutChunk := chunks[packLayoutChunkName]; hasLayoutChunk {
+		var dataLayoutErr error
+		dataLayout, dataLayoutErr = readDataLayout(layoutHeader)
+		if dataLayoutErr != nil {
+			return nil, dataLayoutErr
+		}
+	} else if _, hasPackChunk := chunks[packChunkName]; !hasPackChunk {
+		return nil, fmt.Errorf("not a swamp pack: missing '%v' chunk", raff.NameToString(packChunkName))
+	}
+
+	for _, requiredName := range []raff.FourOctets{typeInfoChunkName, dynamicMemoryChunkName, ledgerChunkName} {
+		if _, wasFound := chunks[requiredName]; !wasFound {
+			return nil, fmt.Errorf("missing '%v' chunk", raff.NameToString(requiredName))
+		}
+	}
+
+	ledger, ledgerErr := readLedger(chunks[ledgerChunkName])
+	if ledgerErr != nil {
+		return nil, ledgerErr
+	}
+
+	memory := memoryReader{octets: chunks[dynamicMemoryChunkName]}
+	p := &Pack{
+		Functions:         []*Function{},
+		ExternalFunctions: []*ExternalFunction{},
+		Strings:           []*StringConstant{},
+		ResourceNames:     []*ResourceName{},
+		DebugFileUrls:     []string{},
+		DataLayout:        dataLayout,
+		DynamicMemorySize: len(memory.octets),
+		TypeInfo:          readTypeInfo(chunks[typeInfoChunkName]),
+		TypeInfoOctets:    chunks[typeInfoChunkName],
+	}
+
+	// Only stripped packs have a build ID, that is the same as in the `.swamp-pdb`
+	if buildID, hasBuildID := chunks[buildIDChunkName]; hasBuildID {
+		p.BuildID = hex.EncodeToString(buildID)
+	}
+
+	for _, entry := range ledger {
+		if err := p.addConstant(memory, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// FindFunction returns the function with the fully qualified name, or nil if there is none.
+func (p *Pack) FindFunction(name string) *Function {
+	for _, function := range p.Functions {
+		if function.Name == name {
+			return function
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package packinspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type DisassembledFunction struct {
+	Name         string        `json:"name"`
+	Instructions []Instruction `json:"instructions"`
+}
+
+// DisassembleFunctions disassembles the functions with the names. If all is set, every function is disassembled.
+func (p *Pack) DisassembleFunctions(names []string, all bool) ([]DisassembledFunction, error) {
+	var functions []*Function
+	if all {
+		functions = p.Functions
+	} else {
+		for _, name := range names {
+			function := p.FindFunction(name)
+			if function == nil {
+				return nil, fmt.Errorf("there is no function named '%v' in the pack", name)
+			}
+			functions = append(functions, function)
+		}
+	}
+
+	var disassembled []DisassembledFunction
+	for _, function := range functions {
+		instructions, err := p.Disassemble(function)
+		if err != nil {
+			return nil, err
+		}
+		disassembled = append(disassembled, DisassembledFunction{Name: function.Name, Instructions: instructions})
+	}
+
+	return disassembled, nil
+}
+
+func WriteText(writer io.Writer, p *Pack, disassembled []DisassembledFunction) {
+	recorded := ""
+	if !p.DataLayout.Recorded {
+		recorded = ", implied by the spk5 header"
+	}
+	fmt.Fprintf(writer, "data layout: %v (pointer size %d, align %d%v)\n", p.DataLayout.Name, p.DataLayout.PointerSize, p.DataLayout.PointerAlign, recorded)
+	fmt.Fprintf(writer, "dynamic memory: %d octets\n", p.DynamicMemorySize)
+	fmt.Fprintf(writer, "type information: version %v, %d types, %d octets\n", p.TypeInfo.Version, p.TypeInfo.TypeCount, p.TypeInfo.OctetCount)
+	if p.BuildID != "" {
+		fmt.Fprintf(writer, "build id: %v (stripped, the debug information is in the .swamp-pdb)\n", p.BuildID)
+	}
+
+	fmt.Fprintf(writer, "\nfunctions (%d):\n", len(p.Functions))
+	for _, function := range p.Functions {
+		fmt.Fprintf(writer, "  %04X %v parameters:%d (%d octets) return:%d align:%d type:%d opcodes:%d octets\n", function.Position,
+			function.Name, function.ParameterCount, function.ParameterOctetSize, function.ReturnOctetSize, function.ReturnAlign,
+			function.TypeIndex, function.OpcodeOctetCount)
+	}
+
+	fmt.Fprintf(writer, "\nexternal functions (%d):\n", len(p.ExternalFunctions))
+	for _, externalFunction := range p.ExternalFunctions {
+		fmt.Fprintf(writer, "  %04X %v parameters:%d return:%v stack:%v\n", externalFunction.Position, externalFunction.Name,
+			externalFunction.ParameterCount, externalFunction.Return, externalFunction.Parameters)
+	}
+
+	fmt.Fprintf(writer, "\nstrings (%d):\n", len(p.Strings))
+	for _, stringConstant := range p.Strings {
+		fmt.Fprintf(writer, "  %04X %v\n", stringConstant.Position, strconv.Quote(stringConstant.Value))
+	}
+
+	fmt.Fprintf(writer, "\nresource names (%d):\n", len(p.ResourceNames))
+	for _, resourceName := range p.ResourceNames {
+		fmt.Fprintf(writer, "  %04X %v\n", resourceName.Position, resourceName.Name)
+	}
+
+	fmt.Fprintf(writer, "\ndebug files (%d):\n", len(p.DebugFileUrls))
+	for index, url := range p.DebugFileUrls {
+		fmt.Fprintf(writer, "  %d %v\n", index, url)
+	}
+
+	for _, function := range disassembled {
+		fmt.Fprintf(writer, "\nfunc %v\n", function.Name)
+		lastSource := ""
+		for _, instruction := range function.Instructions {
+			if instruction.Source != "" && instruction.Source != lastSource {
+				fmt.Fprintf(writer, "  ; %v\n", instruction.Source)
+				lastSource = instruction.Source
+			}
+			fmt.Fprintf(writer, "  %04x: %v\n", instruction.OpcodePosition, instruction.Text)
+		}
+	}
+}
+
+func WriteJSON(writer io.Writer, p *Pack, disassembled []DisassembledFunction) error {
+	output := struct {
+		*Pack
+		Disassembly []DisassembledFunction `json:"disassembly,omitempty"`
+	}{Pack: p, Disassembly: disassembled}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(output)
+}
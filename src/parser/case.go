@@ -36,9 +36,148 @@ func parseCase(p ParseStream, keyword token.Keyword, startIndentation int, previ
 
 	subPreviousComent := report.Comments.LastComment()
 
-	if p.detectTypeIdentifierWithoutScope() {
-		return parseCaseForCustomType(p, test, keyword, ofToken, startIndentation, consequenceIndentation, subPreviousComent)
+	consequences, consequencesErr := parseCaseConsequences(p, consequenceIndentation, subPreviousComent)
+	if consequencesErr != nil {
+		return nil, consequencesErr
 	}
 
-	return parseCasePatternMatching(p, test, keyword, ofToken, startIndentation, consequenceIndentation, subPreviousComent)
+	if caseForCustomType := caseForCustomTypeFromPatterns(keyword, ofToken, test, consequences); caseForCustomType != nil {
+		return caseForCustomType, nil
+	}
+
+	if caseForPatternMatching := caseForPatternMatchingFromPatterns(keyword, ofToken, test, consequences); caseForPatternMatching != nil {
+		return caseForPatternMatching, nil
+	}
+
+	return ast.NewCaseForPatterns(keyword, ofToken, test, consequences), nil
+}
+
+func parseCaseConsequences(p ParseStream, consequenceIndentation int, previousComment token.Comment) ([]*ast.CaseConsequenceForPatterns, parerr.ParseError) {
+	var consequences []*ast.CaseConsequenceForPatterns
+	for {
+		pattern, patternErr := parseCasePattern(p, consequenceIndentation)
+		if patternErr != nil {
+			return nil, patternErr
+		}
+
+		_, oneSpaceAfterPattern := p.eatOneSpace("space after case consequence pattern")
+		if oneSpaceAfterPattern != nil {
+			return nil, oneSpaceAfterPattern
+		}
+
+		var guard ast.Expression
+		if _, wasGuard := p.maybeGuardPipe(); wasGuard {
+			if _, spaceAfterGuardErr := p.eatOneSpace("space after case guard"); spaceAfterGuardErr != nil {
+				return nil, spaceAfterGuardErr
+			}
+			var guardErr parerr.ParseError
+			guard, guardErr = p.parseExpressionNormal(consequenceIndentation)
+			if guardErr != nil {
+				return nil, guardErr
+			}
+			if _, spaceAfterGuardExpressionErr := p.eatOneSpace("space after case guard expression"); spaceAfterGuardExpressionErr != nil {
+				return nil, spaceAfterGuardExpressionErr
+			}
+		}
+
+		if arrowRightErr := p.eatRightArrow(); arrowRightErr != nil {
+			return nil, parerr.NewCaseConsequenceExpectedVariableOrRightArrow(arrowRightErr)
+		}
+
+		detectedIndentation, _, oneSpaceAfterArrowErr := p.eatContinuationReturnIndentation(consequenceIndentation)
+		if oneSpaceAfterArrowErr != nil {
+			return nil, oneSpaceAfterArrowErr
+		}
+		wasIndented := detectedIndentation != consequenceIndentation
+		expressionIndentation := consequenceIndentation
+		if wasIndented {
+			expressionIndentation = consequenceIndentation + 1
+		}
+
+		expr, exprErr := p.parseExpressionNormalNewDepth(expressionIndentation)
+		if exprErr != nil {
+			return nil, exprErr
+		}
+
+		consequence := ast.NewCaseConsequenceForPatterns(len(consequences), pattern, guard, expr, previousComment)
+		consequences = append(consequences, consequence)
+
+		foundTextInColumnBelow, report, posLengthErr := p.eatOneNewLineContinuationOrDedentAllowComment(consequenceIndentation)
+		if posLengthErr != nil {
+			return nil, posLengthErr
+		}
+		if !foundTextInColumnBelow {
+			break
+		}
+		previousComment = report.Comments.LastComment()
+	}
+
+	return consequences, nil
+}
+
+// caseForCustomTypeFromPatterns returns a case for custom type if all consequences are variants with
+// only plain identifiers as arguments, optionally followed by a default consequence without a guard.
+func caseForCustomTypeFromPatterns(keywordCase token.Keyword, keywordOf token.Keyword, test ast.Expression, consequences []*ast.CaseConsequenceForPatterns) *ast.CaseForCustomType {
+	if _, firstWasVariant := consequences[0].Pattern().(*ast.PatternVariant); !firstWasVariant {
+		return nil
+	}
+
+	var customTypeConsequences []*ast.CaseConsequenceForCustomType
+	for _, consequence := range consequences {
+		var prefix *ast.TypeIdentifier
+		var parameters []*ast.VariableIdentifier
+
+		switch t := consequence.Pattern().(type) {
+		case *ast.PatternWildcard:
+			if consequence.Guard() != nil {
+				return nil
+			}
+			fakeSymbol := token.NewTypeSymbolToken("_", t.FetchPositionLength(), 0)
+			prefix = ast.NewTypeIdentifier(fakeSymbol)
+		case *ast.PatternVariant:
+			prefix = t.Identifier()
+			for _, argument := range t.Arguments() {
+				variable, wasVariable := argument.(*ast.PatternVariable)
+				if !wasVariable {
+					return nil
+				}
+				parameters = append(parameters, variable.Identifier())
+			}
+		default:
+			return nil
+		}
+
+		customTypeConsequence := ast.NewCaseConsequenceForCustomType(prefix, parameters, consequence.Guard(), consequence.Expression(), consequence.Comment())
+		customTypeConsequences = append(customTypeConsequences, customTypeConsequence)
+	}
+
+	return ast.NewCaseForCustomType(keywordCase, keywordOf, test, customTypeConsequences)
+}
+
+// caseForPatternMatchingFromPatterns returns a case for pattern matching if all consequences are
+// single literals (that are not booleans) or the default consequence without a guard.
+func caseForPatternMatchingFromPatterns(keywordCase token.Keyword, keywordOf token.Keyword, test ast.Expression, consequences []*ast.CaseConsequenceForPatterns) *ast.CaseForPatternMatching {
+	var patternMatchingConsequences []*ast.CaseConsequencePatternMatching
+	for _, consequence := range consequences {
+		var literal ast.Literal
+
+		switch t := consequence.Pattern().(type) {
+		case *ast.PatternWildcard:
+			if consequence.Guard() != nil {
+				return nil
+			}
+		case *ast.PatternLiteral:
+			if _, wasBoolean := t.Literal().(*ast.BooleanLiteral); wasBoolean {
+				return nil
+			}
+			literal = t.Literal()
+		default:
+			return nil
+		}
+
+		patternMatchingConsequence := ast.NewCaseConsequenceForPatternMatching(consequence.Index(), literal, consequence.Guard(), consequence.Expression(), consequence.Comment())
+		patternMatchingConsequences = append(patternMatchingConsequences, patternMatchingConsequence)
+	}
+
+	return ast.NewCaseForPatternMatching(keywordCase, keywordOf, test, patternMatchingConsequences)
 }
@@ -0,0 +1,143 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package parser
+
+import (
+	"github.com/swamp/compiler/src/ast"
+	parerr "github.com/swamp/compiler/src/parser/errors"
+	"github.com/swamp/compiler/src/tokenize"
+)
+
+// parseCasePattern parses a complete case pattern, including variant arguments and a trailing `as` binding.
+// The space after the pattern is never consumed.
+func parseCasePattern(p ParseStream, indentation int) (ast.CasePattern, parerr.ParseError) {
+	pattern, patternErr := parseCasePatternWithArguments(p, indentation)
+	if patternErr != nil {
+		return nil, patternErr
+	}
+
+	keywordAs, wasAs := p.maybeOneSpaceAndKeywordAs()
+	if !wasAs {
+		return pattern, nil
+	}
+
+	if _, spaceErr := p.eatOneSpace("space after as in case pattern"); spaceErr != nil {
+		return nil, spaceErr
+	}
+
+	identifier, identifierErr := p.readVariableIdentifier()
+	if identifierErr != nil {
+		return nil, identifierErr
+	}
+
+	return ast.NewPatternAs(pattern, keywordAs, identifier), nil
+}
+
+func parseCasePatternWithArguments(p ParseStream, indentation int) (ast.CasePattern, parerr.ParseError) {
+	if !p.detectTypeIdentifierWithoutScope() {
+		return parseCasePatternAtom(p, indentation)
+	}
+
+	variantName, variantNameErr := p.readTypeIdentifier()
+	if variantNameErr != nil {
+		return nil, parerr.NewExpectedCaseConsequenceSymbolError(variantNameErr)
+	}
+
+	var arguments []ast.CasePattern
+	for p.detectOneSpaceAndCasePatternArgument() {
+		if _, spaceErr := p.eatOneSpace("space before case pattern argument"); spaceErr != nil {
+			return nil, spaceErr
+		}
+		argument, argumentErr := parseCasePatternAtom(p, indentation)
+		if argumentErr != nil {
+			return nil, argumentErr
+		}
+		arguments = append(arguments, argument)
+	}
+
+	return ast.NewPatternVariant(variantName, arguments), nil
+}
+
+func parseCasePatternAtom(p ParseStream, indentation int) (ast.CasePattern, parerr.ParseError) {
+	if defaultSymbolToken, wasDefaultSymbol := p.wasDefaultSymbol(); wasDefaultSymbol {
+		return ast.NewPatternWildcard(defaultSymbolToken), nil
+	}
+
+	if p.detectTypeIdentifierWithoutScope() {
+		variantName, variantNameErr := p.readTypeIdentifier()
+		if variantNameErr != nil {
+			return nil, parerr.NewExpectedCaseConsequenceSymbolError(variantNameErr)
+		}
+		return ast.NewPatternVariant(variantName, nil), nil
+	}
+
+	if identifier, wasIdentifier := p.wasVariableIdentifier(); wasIdentifier {
+		if booleanToken, booleanErr := tokenize.DetectLowerCaseBoolean(identifier.Symbol()); booleanErr == nil {
+			return ast.NewPatternLiteral(ast.NewBooleanLiteral(booleanToken)), nil
+		}
+		return ast.NewPatternVariable(identifier), nil
+	}
+
+	if leftParen, wasLeftParen := p.maybeLeftParen(); wasLeftParen {
+		p.maybeOneSpace()
+		var patterns []ast.CasePattern
+		for {
+			pattern, patternErr := parseCasePattern(p, indentation)
+			if patternErr != nil {
+				return nil, patternErr
+			}
+			patterns = append(patterns, pattern)
+
+			wasComma, _, separatorErr := p.eatCommaSeparatorOrTermination(indentation+1, tokenize.NotAllowedAtAll)
+			if separatorErr != nil {
+				return nil, separatorErr
+			}
+			if !wasComma {
+				break
+			}
+		}
+		rightParen, rightParenErr := p.readRightParen()
+		if rightParenErr != nil {
+			return nil, rightParenErr
+		}
+		if len(patterns) == 1 {
+			return patterns[0], nil
+		}
+		return ast.NewPatternTuple(leftParen, rightParen, patterns), nil
+	}
+
+	if leftCurly, wasLeftCurly := p.maybeLeftCurly(); wasLeftCurly {
+		p.maybeOneSpace()
+		var fields []*ast.VariableIdentifier
+		for {
+			field, fieldErr := p.readVariableIdentifier()
+			if fieldErr != nil {
+				return nil, fieldErr
+			}
+			fields = append(fields, field)
+
+			wasComma, _, separatorErr := p.eatCommaSeparatorOrTermination(indentation+1, tokenize.NotAllowedAtAll)
+			if separatorErr != nil {
+				return nil, separatorErr
+			}
+			if !wasComma {
+				break
+			}
+		}
+		rightCurly, rightCurlyErr := p.readRightCurly()
+		if rightCurlyErr != nil {
+			return nil, rightCurlyErr
+		}
+		return ast.NewPatternRecord(leftCurly, rightCurly, fields), nil
+	}
+
+	literal, literalErr := ParseLiteralOrConstant(p, indentation)
+	if literalErr != nil {
+		return nil, parerr.NewExpectedCaseConsequenceSymbolError(literalErr)
+	}
+
+	return ast.NewPatternLiteral(literal), nil
+}
@@ -0,0 +1,44 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package parser
+
+import (
+	"github.com/swamp/compiler/src/ast"
+	parerr "github.com/swamp/compiler/src/parser/errors"
+	"github.com/swamp/compiler/src/token"
+)
+
+func parseLambda(p ParseStream, lambdaToken token.LambdaToken, startIndentation int) (ast.Expression, parerr.ParseError) {
+	var identifiers []*ast.VariableIdentifier
+
+	for {
+		identifier, identifierErr := p.readVariableIdentifier()
+		if identifierErr != nil {
+			return nil, identifierErr
+		}
+		identifiers = append(identifiers, identifier)
+
+		if _, spaceErr := p.eatOneSpace("space after lambda parameter"); spaceErr != nil {
+			return nil, spaceErr
+		}
+
+		if p.maybeRightArrow() {
+			break
+		}
+	}
+
+	bodyIndentation, _, spaceAfterArrowErr := p.eatContinuationReturnIndentation(startIndentation)
+	if spaceAfterArrowErr != nil {
+		return nil, spaceAfterArrowErr
+	}
+
+	expression, expressionErr := p.parseExpressionNormal(bodyIndentation)
+	if expressionErr != nil {
+		return nil, expressionErr
+	}
+
+	return ast.NewLambda(lambdaToken, identifiers, expression), nil
+}
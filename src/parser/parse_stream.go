@@ -105,6 +105,8 @@ type ParseStream interface {
 	maybePipeLeft() bool
 	maybeRightArrow() bool
 	maybeOneSpaceAndRightArrow() bool
+	maybeOneSpaceAndKeywordAs() (token.Keyword, bool)
+	maybeGuardPipe() (token.GuardToken, bool)
 	maybeLeftParen() (token.ParenToken, bool)
 	maybeAsterisk() (token.OperatorToken, bool)
 	maybeLeftCurly() (token.ParenToken, bool)
@@ -120,6 +122,7 @@ type ParseStream interface {
 	detectOneSpaceAndTermination() bool
 	detectTypeIdentifierWithoutScope() bool
 	detectNormalOrScopedTypeIdentifier() bool
+	detectOneSpaceAndCasePatternArgument() bool
 
 	// -----------------------------------------------------------------------------------------------------------------
 	// parse. parse expressions and terms
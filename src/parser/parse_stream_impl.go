@@ -353,6 +353,36 @@ func (p *ParseStreamImpl) maybeOneSpaceAndRightArrow() bool {
 	return foundArrow
 }
 
+func (p *ParseStreamImpl) maybeOneSpaceAndKeywordAs() (token.Keyword, bool) {
+	pos := p.tokenizer.Tell()
+	report, _ := p.tokenizer.SkipWhitespaceToNextIndentation()
+	if !p.disableEnforceStyle {
+		if report.NewLineCount != 0 || report.SpacesUntilMaybeNewline != 1 {
+			p.tokenizer.Seek(pos)
+			return token.Keyword{}, false
+		}
+	}
+	keyword, wasAs := p.maybeKeywordAs()
+	if !wasAs {
+		p.tokenizer.Seek(pos)
+	}
+
+	return keyword, wasAs
+}
+
+func (p *ParseStreamImpl) maybeGuardPipe() (token.GuardToken, bool) {
+	if !p.tokenizer.DetectString("| ") {
+		return token.GuardToken{}, false
+	}
+
+	guardToken, guardErr := p.readGuardPipe()
+	if guardErr != nil {
+		return token.GuardToken{}, false
+	}
+
+	return guardToken, true
+}
+
 func (p *ParseStreamImpl) maybeLeftParen() (token.ParenToken, bool) {
 	return p.maybeSpecificParenToken(token.LeftParen)
 }
@@ -370,6 +400,28 @@ func (p *ParseStreamImpl) detectTypeIdentifierWithoutScope() bool {
 	return !accessorFollowing && typeSymbolErr == nil
 }
 
+// detectOneSpaceAndCasePatternArgument reports if a single space is followed by something that can start
+// a case pattern argument, and not by the arrow, an `as`, a guard or a terminating separator.
+func (p *ParseStreamImpl) detectOneSpaceAndCasePatternArgument() bool {
+	pos := p.tokenizer.Tell()
+	defer p.tokenizer.Seek(pos)
+
+	report, err := p.tokenizer.SkipWhitespaceToNextIndentation()
+	if err != nil || report.NewLineCount != 0 || report.SpacesUntilMaybeNewline != 1 {
+		return false
+	}
+
+	for _, terminator := range []string{"->", "|", ")", "}", ","} {
+		if p.tokenizer.DetectString(terminator) {
+			return false
+		}
+	}
+
+	_, wasAs := p.maybeKeywordAs()
+
+	return !wasAs
+}
+
 func (p *ParseStreamImpl) detectNormalOrScopedTypeIdentifier() bool {
 	pos := p.tokenizer.Tell()
 
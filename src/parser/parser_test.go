@@ -1333,3 +1333,58 @@ tester : (b: String) -> Bool =
 [FnDef $tester = [Fn ([[Arg $b: [TypeReference $String]]]) => [TypeReference $Bool] = (([Call $first [(#2 + #2)]] |> [Call $second [$b]]) |> $third)]]
 `)
 }
+
+func TestLambda(t *testing.T) {
+	testParse(t,
+		`
+main : (x: Int) -> Int =
+    apply (\a b -> a + b * x) 3
+`,
+		`
+[FnDef $main = [Fn ([[Arg $x: [TypeReference $Int]]]) => [TypeReference $Int] = [Call $apply [[Lambda [$a $b] => ($a + ($b * $x))] #3]]]]
+`)
+}
+
+func TestCaseNestedPatterns(t *testing.T) {
+	testParseExpression(t,
+		`
+case x of
+    Just (Ok v) -> v
+
+    Just (Err _) as original -> 0
+
+    Nothing -> 1
+`, "[CasePatterns $x of [CaseConsPatterns [PVariant $Just [PVariant $Ok $v]] => $v];[CaseConsPatterns [PAs [PVariant $Just [PVariant $Err _]] $original] => #0];[CaseConsPatterns [PVariant $Nothing] => #1]]")
+}
+
+func TestCaseTupleAndRecordPatterns(t *testing.T) {
+	testParseExpression(t,
+		`
+case x of
+    ( 0, y ) -> y
+
+    ( _, { a, b } ) -> a
+`, "[CasePatterns $x of [CaseConsPatterns [PTuple #0 $y] => $y];[CaseConsPatterns [PTuple _ [PRecord [$a $b]]] => $a]]")
+}
+
+func TestCaseGuard(t *testing.T) {
+	testParseExpression(t,
+		`
+case x of
+    Running speed | speed > 10 -> speed
+
+    Running speed -> 0
+
+    _ -> 1
+`, "[CaseCustomType $x of [CaseConsCustomType $Running ([$speed]) | ($speed > #10) => $speed];[CaseConsCustomType $Running ([$speed]) => #0];[CaseConsCustomType $_ ([]) => #1]]")
+}
+
+func TestCasePatternMatchingGuard(t *testing.T) {
+	testParseExpression(t,
+		`
+case x of
+    0 | y -> 1
+
+    _ -> 2
+`, "[CasePm $x of [CaseConsPm #0 | $y => #1];[CaseConsPm '_' => #2]]")
+}
@@ -30,5 +30,9 @@ func (p *Parser) parsePrefix(t token.Token, startIndentation int) (ast.Expressio
 		return parseGuard(p.stream, t.(token.GuardToken), startIndentation, p.previousComment)
 	}
 
+	if t.Type() == token.Lambda {
+		return parseLambda(p.stream, t.(token.LambdaToken), startIndentation)
+	}
+
 	return nil, parerr.NewUnknownPrefixInExpression(t)
 }
@@ -255,6 +255,45 @@ func HighestSeverity(err error) ReportAsSeverity {
 	return ReportAsSeverityError
 }
 
+// Diagnostics returns the errors, warnings and notes in err, with the multi errors and module errors unwrapped.
+func Diagnostics(err error) []parerr.ParseError {
+	if err == nil {
+		return nil
+	}
+
+	switch t := err.(type) {
+	case *decorated.ModuleError:
+		return Diagnostics(t.WrappedError())
+	case *parerr.ParseAliasError:
+		return Diagnostics(t.Unwrap())
+	case *decorated.MultiErrors:
+		var diagnostics []parerr.ParseError
+		for _, subErr := range t.Errors() {
+			diagnostics = append(diagnostics, Diagnostics(subErr)...)
+		}
+		return diagnostics
+	case parerr.MultiError:
+		var diagnostics []parerr.ParseError
+		for _, subErr := range t.Errors() {
+			diagnostics = append(diagnostics, Diagnostics(subErr)...)
+		}
+		return diagnostics
+	case *tokenize.MultiErrors:
+		var diagnostics []parerr.ParseError
+		for _, subErr := range t.Errors() {
+			diagnostics = append(diagnostics, Diagnostics(subErr)...)
+		}
+		return diagnostics
+	}
+
+	parserErr, wasParserErr := err.(parerr.ParseError)
+	if !wasParserErr {
+		return nil
+	}
+
+	return []parerr.ParseError{parserErr}
+}
+
 func IsCompileError(parseError parerr.ParseError) bool {
 	return HighestSeverity(parseError) == ReportAsSeverityError
 }
@@ -264,7 +303,12 @@ func IsCompileErr(parseError error) bool {
 }
 
 func TypeOfWarning(parserError parerr.ParseError) ReportAsSeverity {
-	switch parserError.(type) {
+	switch e := parserError.(type) {
+	case *decorated.CachedWarning:
+		if e.IsNote() {
+			return ReportAsSeverityNote
+		}
+		return ReportAsSeverityWarning
 	case parerr.ExpectedOneSpace:
 		return ReportAsSeverityWarning
 	case parerr.UnexpectedImportAlias:
@@ -277,6 +321,10 @@ func TypeOfWarning(parserError parerr.ParseError) ReportAsSeverity {
 		return ReportAsSeverityNote
 	case *decorated.UnusedImportWarning:
 		return ReportAsSeverityWarning
+	case *decorated.RedundantCasePatternWarning:
+		return ReportAsSeverityWarning
+	case *decorated.MissingResourceFileWarning:
+		return ReportAsSeverityWarning
 	case tokenize.LineIsLongerThanRecommendedError:
 		return ReportAsSeverityNote
 	case tokenize.LineIsTooLongError:
@@ -0,0 +1,116 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+// Package pdb reads and writes the `.swamp-pdb` files. They have the debug information that is left out of a
+// `.swamp-pack` that is built with `--strip`, so that crash reports from a stripped pack can be mapped back to the source.
+package pdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/swamp/compiler/src/packinspect"
+)
+
+// Version is the version of the `.swamp-pdb` format.
+const Version = 1
+
+const FileExtension = ".swamp-pdb"
+
+// Variable is a let variable or parameter, with the opcode positions where it is valid and where it is on the stack.
+type Variable struct {
+	Name                string `json:"name"`
+	StartOpcodePosition uint16 `json:"startOpcodePosition"`
+	EndOpcodePosition   uint16 `json:"endOpcodePosition"`
+	ScopeID             uint   `json:"scopeId"`
+	TypeID              uint32 `json:"typeId"`
+	StackPosition       uint32 `json:"stackPosition"`
+	StackSize           uint32 `json:"stackSize"`
+}
+
+// Function is the debug information for a function or lambda. OpcodeOctetCount is the size of the opcodes of the
+// function, so that offsets outside of the function can be rejected.
+type Function struct {
+	Name             string                  `json:"name"`
+	OpcodeOctetCount uint16                  `json:"opcodeOctetCount"`
+	DebugLines       []packinspect.DebugLine `json:"debugLines"`
+	Variables        []Variable              `json:"variables"`
+}
+
+// File is the content of a `.swamp-pdb`. The BuildID is the same as in the stripped pack that it belongs to.
+type File struct {
+	Version   int         `json:"version"`
+	BuildID   string      `json:"buildId"`
+	Package   string      `json:"package"`
+	FileUrls  []string    `json:"fileUrls"`
+	Functions []*Function `json:"functions"`
+}
+
+// Filename returns the name of the `.swamp-pdb` that is written next to the `.swamp-pack` of the package.
+func Filename(outputDirectory string, packageName string) string {
+	return path.Join(outputDirectory, packageName+FileExtension)
+}
+
+func Write(writer io.Writer, file *File) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(file)
+}
+
+func Read(reader io.Reader) (*File, error) {
+	var file File
+	if err := json.NewDecoder(reader).Decode(&file); err != nil {
+		return nil, err
+	}
+
+	if file.Version != Version {
+		return nil, fmt.Errorf("unsupported .swamp-pdb version %d", file.Version)
+	}
+
+	return &file, nil
+}
+
+// FindFunction returns the function with the fully qualified name, or nil if there is none.
+func (f *File) FindFunction(name string) *Function {
+	for _, function := range f.Functions {
+		if function.Name == name {
+			return function
+		}
+	}
+
+	return nil
+}
+
+// Symbolize returns the source position, `file:line:column`, of the instruction at the opcode offset in the function.
+// The offset does not have to be at the start of an instruction, it is the last debug line at or before the offset
+// that is used. An offset past the end of the function is an error, since it can not be from this build.
+func (f *File) Symbolize(functionName string, opcodeOffset uint16) (string, error) {
+	function := f.FindFunction(functionName)
+	if function == nil {
+		return "", fmt.Errorf("function '%v' is not in the .swamp-pdb for package '%v'", functionName, f.Package)
+	}
+
+	if opcodeOffset >= function.OpcodeOctetCount {
+		return "", fmt.Errorf("opcode offset %04x is outside of function '%v' (%d opcode octets)", opcodeOffset, functionName, function.OpcodeOctetCount)
+	}
+
+	var found *packinspect.DebugLine
+	for index := range function.DebugLines {
+		line := &function.DebugLines[index]
+		if line.OpcodePosition > opcodeOffset {
+			break
+		}
+		found = line
+	}
+
+	if found == nil {
+		return "", fmt.Errorf("function '%v' has no debug line at or before opcode offset %04x", functionName, opcodeOffset)
+	}
+
+	return packinspect.SourceString(f.FileUrls, *found), nil
+}
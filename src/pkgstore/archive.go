@@ -0,0 +1,134 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package pkgstore
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveExtension is the file extension of a library that is packed into a single zip or tar.gz file.
+const ArchiveExtension = ".swamp-lib"
+
+func cleanArchiveName(name string) (string, bool) {
+	cleaned := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	cleaned = strings.TrimPrefix(cleaned, "./")
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") || path.IsAbs(cleaned) {
+		return "", false
+	}
+
+	return cleaned, true
+}
+
+func readZip(octets []byte) (map[string][]byte, error) {
+	zipReader, zipErr := zip.NewReader(bytes.NewReader(octets), int64(len(octets)))
+	if zipErr != nil {
+		return nil, zipErr
+	}
+
+	files := make(map[string][]byte)
+	for _, zipFile := range zipReader.File {
+		if zipFile.FileInfo().IsDir() {
+			continue
+		}
+		name, isValid := cleanArchiveName(zipFile.Name)
+		if !isValid {
+			return nil, fmt.Errorf("illegal file name '%v' in archive", zipFile.Name)
+		}
+		fileReader, openErr := zipFile.Open()
+		if openErr != nil {
+			return nil, openErr
+		}
+		content, readErr := io.ReadAll(fileReader)
+		fileReader.Close()
+		if readErr != nil {
+			return nil, readErr
+		}
+		files[name] = content
+	}
+
+	return files, nil
+}
+
+func readTarGz(octets []byte) (map[string][]byte, error) {
+	gzipReader, gzipErr := gzip.NewReader(bytes.NewReader(octets))
+	if gzipErr != nil {
+		return nil, gzipErr
+	}
+	defer gzipReader.Close()
+
+	files := make(map[string][]byte)
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, nextErr := tarReader.Next()
+		if nextErr == io.EOF {
+			break
+		}
+		if nextErr != nil {
+			return nil, nextErr
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name, isValid := cleanArchiveName(header.Name)
+		if !isValid {
+			return nil, fmt.Errorf("illegal file name '%v' in archive", header.Name)
+		}
+		content, readErr := io.ReadAll(tarReader)
+		if readErr != nil {
+			return nil, readErr
+		}
+		files[name] = content
+	}
+
+	return files, nil
+}
+
+// ReadArchive reads all files in a zip or tar.gz archive. The format is detected from the content.
+// The returned names use forward slashes and are relative to the root of the archive.
+func ReadArchive(filename string) (map[string][]byte, error) {
+	octets, readErr := os.ReadFile(filename)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	switch {
+	case bytes.HasPrefix(octets, []byte("PK\x03\x04")):
+		return readZip(octets)
+	case bytes.HasPrefix(octets, []byte{0x1f, 0x8b}):
+		return readTarGz(octets)
+	}
+
+	return nil, fmt.Errorf("'%v' is neither a zip nor a tar.gz archive", filename)
+}
+
+// ExtractArchive writes all files in the archive to the target directory.
+func ExtractArchive(filename string, targetDirectory string) error {
+	files, readErr := ReadArchive(filename)
+	if readErr != nil {
+		return readErr
+	}
+
+	for name, content := range files {
+		targetFilename := filepath.Join(targetDirectory, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(targetFilename), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(targetFilename, content, 0o644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package pkgstore
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/swamp/compiler/src/file"
+)
+
+// LockFilename is the name of the lock file, that is placed next to the `.swamp.toml` of the package.
+const LockFilename = "swamp.lock"
+
+type LockedPackage struct {
+	Name       string
+	Constraint string
+	Version    string
+	Hash       string
+}
+
+// Lock records the exact version and content hash that each versioned module was resolved to.
+type Lock struct {
+	Package []LockedPackage
+}
+
+// Requirement is a versioned module in a `.swamp.toml`.
+type Requirement struct {
+	Name       string
+	Constraint string
+}
+
+func LoadLock(directory string) (Lock, bool, error) {
+	lockFilename := path.Join(directory, LockFilename)
+	if !file.HasFile(lockFilename) {
+		return Lock{}, false, nil
+	}
+
+	data, readErr := os.ReadFile(lockFilename)
+	if readErr != nil {
+		return Lock{}, false, readErr
+	}
+
+	lock := Lock{}
+	if err := toml.Unmarshal(data, &lock); err != nil {
+		return Lock{}, false, fmt.Errorf("couldn't load lock file %v %w", lockFilename, err)
+	}
+
+	return lock, true, nil
+}
+
+func (l Lock) Save(directory string) error {
+	data, marshalErr := toml.Marshal(&l)
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	header := "# Generated by `swamp deps update`. Do not edit.\n\n"
+
+	return os.WriteFile(path.Join(directory, LockFilename), append([]byte(header), data...), 0o644)
+}
+
+// RemoveLock removes the lock file in the directory. It returns false if there was no lock file.
+func RemoveLock(directory string) (bool, error) {
+	lockFilename := path.Join(directory, LockFilename)
+	if !file.HasFile(lockFilename) {
+		return false, nil
+	}
+
+	if err := os.Remove(lockFilename); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (l Lock) Find(name string) (LockedPackage, bool) {
+	for _, locked := range l.Package {
+		if locked.Name == name {
+			return locked, true
+		}
+	}
+
+	return LockedPackage{}, false
+}
+
+// Update resolves each requirement to the highest matching version in the store. Only the requirements that are given
+// are locked, not the versioned modules of the packages in the store. Those are resolved with the `swamp.lock` that
+// is in the store package itself.
+func Update(store *Store, requirements []Requirement) (Lock, error) {
+	lock := Lock{}
+	for _, requirement := range requirements {
+		constraint, constraintErr := ParseConstraint(requirement.Constraint)
+		if constraintErr != nil {
+			return Lock{}, fmt.Errorf("module '%v' %w", requirement.Name, constraintErr)
+		}
+
+		entry, resolveErr := store.Resolve(requirement.Name, constraint)
+		if resolveErr != nil {
+			return Lock{}, resolveErr
+		}
+
+		contentHash, hashErr := entry.ContentHash()
+		if hashErr != nil {
+			return Lock{}, hashErr
+		}
+
+		lock.Package = append(lock.Package, LockedPackage{
+			Name:       requirement.Name,
+			Constraint: requirement.Constraint,
+			Version:    entry.Version.String(),
+			Hash:       contentHash,
+		})
+	}
+
+	return lock, nil
+}
+
+// LockedSourceDirectory returns the source directory of the locked version of the requirement. It fails if the
+// requirement is not in the lock file, if the constraint has changed since the lock file was written, or if the
+// store does not have the locked version with the same content.
+func LockedSourceDirectory(store *Store, lock Lock, requirement Requirement) (string, error) {
+	const runUpdate = "run `swamp deps update`"
+
+	locked, wasFound := lock.Find(requirement.Name)
+	if !wasFound {
+		return "", fmt.Errorf("module '%v' is not in %v, %v", requirement.Name, LockFilename, runUpdate)
+	}
+
+	if locked.Constraint != requirement.Constraint {
+		return "", fmt.Errorf("module '%v' was locked for '%v' but requires '%v', %v", requirement.Name, locked.Constraint, requirement.Constraint, runUpdate)
+	}
+
+	version, versionErr := ParseVersion(locked.Version)
+	if versionErr != nil {
+		return "", fmt.Errorf("module '%v' in %v %w", requirement.Name, LockFilename, versionErr)
+	}
+
+	entry, findErr := store.Find(requirement.Name, version)
+	if findErr != nil {
+		return "", fmt.Errorf("%w, it is locked in %v", findErr, LockFilename)
+	}
+
+	contentHash, hashErr := entry.ContentHash()
+	if hashErr != nil {
+		return "", hashErr
+	}
+
+	if contentHash != locked.Hash {
+		return "", fmt.Errorf("package '%v' version %v in the store '%v' does not match the hash in %v", requirement.Name, version, store.Directory(), LockFilename)
+	}
+
+	return store.SourceDirectory(entry, contentHash)
+}
@@ -0,0 +1,130 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package pkgstore
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func testLock(t *testing.T, store *Store, requirement Requirement) Lock {
+	t.Helper()
+
+	lock, updateErr := Update(store, []Requirement{requirement})
+	if updateErr != nil {
+		t.Fatal(updateErr)
+	}
+
+	return lock
+}
+
+func testLockedSourceDirectoryFail(t *testing.T, store *Store, lock Lock, requirement Requirement, expectedError string) {
+	t.Helper()
+
+	_, err := LockedSourceDirectory(store, lock, requirement)
+	if err == nil || !strings.Contains(err.Error(), expectedError) {
+		t.Errorf("expected error containing '%v', but got %v", expectedError, err)
+	}
+}
+
+func TestLockedSourceDirectory(t *testing.T) {
+	store := newTestStore(t)
+	requirement := Requirement{Name: "Helpers", Constraint: "~1.2.0"}
+	lock := testLock(t, store, requirement)
+
+	directory, lockedErr := LockedSourceDirectory(store, lock, requirement)
+	if lockedErr != nil {
+		t.Fatal(lockedErr)
+	}
+
+	if directory != path.Join(store.Directory(), "Helpers", "1.2.0") {
+		t.Errorf("wrong source directory %v", directory)
+	}
+}
+
+func TestLockedSourceDirectoryArchive(t *testing.T) {
+	store := newTestStore(t)
+	requirement := Requirement{Name: "Helpers", Constraint: "1.3"}
+	lock := testLock(t, store, requirement)
+
+	directory, lockedErr := LockedSourceDirectory(store, lock, requirement)
+	if lockedErr != nil {
+		t.Fatal(lockedErr)
+	}
+
+	content, readErr := os.ReadFile(path.Join(directory, "Helpers.swamp"))
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if string(content) != "-- 1.3.0\n" {
+		t.Errorf("wrong extracted content '%v'", string(content))
+	}
+
+	secondDirectory, secondErr := LockedSourceDirectory(store, lock, requirement)
+	if secondErr != nil {
+		t.Fatal(secondErr)
+	}
+	if secondDirectory != directory {
+		t.Errorf("archive should only be extracted once, got %v and %v", directory, secondDirectory)
+	}
+}
+
+func TestLockedSourceDirectoryNotLockedFail(t *testing.T) {
+	store := newTestStore(t)
+
+	testLockedSourceDirectoryFail(t, store, Lock{}, Requirement{Name: "Helpers", Constraint: "^1.0.0"}, "is not in")
+}
+
+func TestLockedSourceDirectoryConstraintChangedFail(t *testing.T) {
+	store := newTestStore(t)
+	lock := testLock(t, store, Requirement{Name: "Helpers", Constraint: "~1.2.0"})
+
+	testLockedSourceDirectoryFail(t, store, lock, Requirement{Name: "Helpers", Constraint: "^2.0.0"}, "was locked for")
+}
+
+func TestLockedSourceDirectoryMissingVersionFail(t *testing.T) {
+	store := newTestStore(t)
+	requirement := Requirement{Name: "Helpers", Constraint: "~1.2.0"}
+	lock := testLock(t, store, requirement)
+
+	if err := os.RemoveAll(path.Join(store.Directory(), "Helpers", "1.2.0")); err != nil {
+		t.Fatal(err)
+	}
+
+	testLockedSourceDirectoryFail(t, store, lock, requirement, "is not in the package store")
+}
+
+func TestLockedSourceDirectoryChangedContentFail(t *testing.T) {
+	store := newTestStore(t)
+	requirement := Requirement{Name: "Helpers", Constraint: "~1.2.0"}
+	lock := testLock(t, store, requirement)
+
+	writeTestFile(t, path.Join(store.Directory(), "Helpers", "1.2.0", "Helpers.swamp"), "-- changed\n")
+
+	testLockedSourceDirectoryFail(t, store, lock, requirement, "does not match the hash")
+}
+
+func TestRemoveLock(t *testing.T) {
+	directory := t.TempDir()
+
+	if wasRemoved, err := RemoveLock(directory); err != nil || wasRemoved {
+		t.Fatalf("expected nothing to remove, got %v %v", wasRemoved, err)
+	}
+
+	if err := (Lock{Package: []LockedPackage{{Name: "Helpers", Constraint: "~1.2.0", Version: "1.2.0"}}}).Save(directory); err != nil {
+		t.Fatal(err)
+	}
+
+	if wasRemoved, err := RemoveLock(directory); err != nil || !wasRemoved {
+		t.Fatalf("expected the lock file to be removed, got %v %v", wasRemoved, err)
+	}
+
+	if _, wasFound, err := LoadLock(directory); err != nil || wasFound {
+		t.Errorf("expected no lock file after it was removed, got %v %v", wasFound, err)
+	}
+}
@@ -0,0 +1,190 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package pkgstore
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/swamp/compiler/src/file"
+)
+
+// Store is a local directory with versioned packages. Each version is either a directory or an archive:
+//
+//	<store>/<name>/<version>/
+//	<store>/<name>/<version>.swamp-lib
+type Store struct {
+	directory string
+}
+
+func NewStore(directory string) *Store {
+	return &Store{directory: directory}
+}
+
+func (s *Store) Directory() string {
+	return s.directory
+}
+
+type Entry struct {
+	Name      string
+	Version   Version
+	Path      string
+	IsArchive bool
+}
+
+// Versions returns all versions of the package in the store, lowest version first.
+func (s *Store) Versions(name string) ([]Entry, error) {
+	packageDirectory := path.Join(s.directory, name)
+	dirEntries, readErr := os.ReadDir(packageDirectory)
+	if readErr != nil {
+		if os.IsNotExist(readErr) {
+			return nil, fmt.Errorf("package '%v' is not in the package store '%v'", name, s.directory)
+		}
+		return nil, readErr
+	}
+
+	var entries []Entry
+	for _, dirEntry := range dirEntries {
+		versionName := dirEntry.Name()
+		isArchive := !dirEntry.IsDir()
+		if isArchive {
+			if !strings.HasSuffix(versionName, ArchiveExtension) {
+				continue
+			}
+			versionName = strings.TrimSuffix(versionName, ArchiveExtension)
+		}
+
+		version, versionErr := ParseVersion(versionName)
+		if versionErr != nil {
+			continue
+		}
+
+		entries = append(entries, Entry{Name: name, Version: version, Path: path.Join(packageDirectory, dirEntry.Name()), IsArchive: isArchive})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Version.Compare(entries[j].Version) < 0
+	})
+
+	return entries, nil
+}
+
+// Resolve returns the highest version of the package that matches the constraint.
+func (s *Store) Resolve(name string, constraint Constraint) (Entry, error) {
+	entries, versionsErr := s.Versions(name)
+	if versionsErr != nil {
+		return Entry{}, versionsErr
+	}
+
+	for index := len(entries) - 1; index >= 0; index-- {
+		if constraint.Matches(entries[index].Version) {
+			return entries[index], nil
+		}
+	}
+
+	return Entry{}, fmt.Errorf("no version of package '%v' in '%v' matches '%v'", name, s.directory, constraint)
+}
+
+// Find returns the exact version of the package.
+func (s *Store) Find(name string, version Version) (Entry, error) {
+	entries, versionsErr := s.Versions(name)
+	if versionsErr != nil {
+		return Entry{}, versionsErr
+	}
+
+	for _, entry := range entries {
+		if entry.Version.Compare(version) == 0 {
+			return entry, nil
+		}
+	}
+
+	return Entry{}, fmt.Errorf("package '%v' version %v is not in the package store '%v'", name, version, s.directory)
+}
+
+// ContentHash returns a hash of the archive file, or of the names and contents of all files in the directory.
+func (e Entry) ContentHash() (string, error) {
+	hasher := sha256.New()
+
+	if e.IsArchive {
+		octets, readErr := os.ReadFile(e.Path)
+		if readErr != nil {
+			return "", readErr
+		}
+		hasher.Write(octets)
+	} else {
+		walkErr := filepath.WalkDir(e.Path, func(filename string, dirEntry fs.DirEntry, err error) error {
+			if err != nil || dirEntry.IsDir() {
+				return err
+			}
+			relativeName, relErr := filepath.Rel(e.Path, filename)
+			if relErr != nil {
+				return relErr
+			}
+			octets, readErr := os.ReadFile(filename)
+			if readErr != nil {
+				return readErr
+			}
+			fileHash := sha256.Sum256(octets)
+			fmt.Fprintf(hasher, "%v %x\n", filepath.ToSlash(relativeName), fileHash)
+			return nil
+		})
+		if walkErr != nil {
+			return "", walkErr
+		}
+	}
+
+	return fmt.Sprintf("sha256:%x", hasher.Sum(nil)), nil
+}
+
+// SourceDirectory returns the directory with the source files of the entry. Archives are extracted into the
+// `.extracted` directory of the store the first time they are used.
+func (s *Store) SourceDirectory(entry Entry, contentHash string) (string, error) {
+	if !entry.IsArchive {
+		return entry.Path, nil
+	}
+
+	hashSuffix := strings.TrimPrefix(contentHash, "sha256:")
+	if len(hashSuffix) > 16 {
+		hashSuffix = hashSuffix[:16]
+	}
+
+	extractedDirectory := path.Join(s.directory, ".extracted", entry.Name, fmt.Sprintf("%v-%v", entry.Version, hashSuffix))
+	if file.IsDir(extractedDirectory) {
+		return extractedDirectory, nil
+	}
+
+	temporaryDirectory, tempErr := os.MkdirTemp(s.directory, ".extracting-*")
+	if tempErr != nil {
+		return "", tempErr
+	}
+
+	if err := ExtractArchive(entry.Path, temporaryDirectory); err != nil {
+		os.RemoveAll(temporaryDirectory)
+		return "", err
+	}
+
+	if err := os.MkdirAll(path.Dir(extractedDirectory), 0o755); err != nil {
+		os.RemoveAll(temporaryDirectory)
+		return "", err
+	}
+
+	if err := os.Rename(temporaryDirectory, extractedDirectory); err != nil {
+		os.RemoveAll(temporaryDirectory)
+		if file.IsDir(extractedDirectory) {
+			// Extracted by another build at the same time
+			return extractedDirectory, nil
+		}
+		return "", err
+	}
+
+	return extractedDirectory, nil
+}
@@ -0,0 +1,131 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package pkgstore
+
+import (
+	"archive/zip"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, filename string, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(path.Dir(filename), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTestArchive(t *testing.T, filename string, files map[string]string) {
+	t.Helper()
+
+	if err := os.MkdirAll(path.Dir(filename), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	archiveFile, createErr := os.Create(filename)
+	if createErr != nil {
+		t.Fatal(createErr)
+	}
+	defer archiveFile.Close()
+
+	zipWriter := zip.NewWriter(archiveFile)
+	for name, content := range files {
+		fileWriter, writerErr := zipWriter.Create(name)
+		if writerErr != nil {
+			t.Fatal(writerErr)
+		}
+		if _, err := fileWriter.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestStore creates a store where Helpers has the versions 1.0.0, 1.2.0, 1.4.0-beta.1 and 2.0.0 as directories,
+// and 1.3.0 as an archive.
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	directory := t.TempDir()
+	for _, version := range []string{"1.0.0", "1.2.0", "1.4.0-beta.1", "2.0.0"} {
+		writeTestFile(t, path.Join(directory, "Helpers", version, "Helpers.swamp"), "-- "+version+"\n")
+	}
+	writeTestArchive(t, path.Join(directory, "Helpers", "1.3.0"+ArchiveExtension),
+		map[string]string{"Helpers.swamp": "-- 1.3.0\n"})
+	writeTestFile(t, path.Join(directory, "Helpers", "notes.txt"), "not a version\n")
+
+	return NewStore(directory)
+}
+
+func testResolve(t *testing.T, store *Store, constraintString string, expectedVersion string) Entry {
+	t.Helper()
+
+	constraint, parseErr := ParseConstraint(constraintString)
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+
+	entry, resolveErr := store.Resolve("Helpers", constraint)
+	if resolveErr != nil {
+		t.Fatal(resolveErr)
+	}
+
+	if entry.Version.String() != expectedVersion {
+		t.Errorf("'%v' resolved to %v, expected %v", constraintString, entry.Version, expectedVersion)
+	}
+
+	return entry
+}
+
+func TestResolveHighestMatching(t *testing.T) {
+	store := newTestStore(t)
+
+	testResolve(t, store, "^1.0.0", "1.3.0")
+	testResolve(t, store, "~1.2.0", "1.2.0")
+	testResolve(t, store, "*", "2.0.0")
+	testResolve(t, store, "<1.2.0", "1.0.0")
+}
+
+func TestResolveArchive(t *testing.T) {
+	store := newTestStore(t)
+
+	entry := testResolve(t, store, "1.3", "1.3.0")
+	if !entry.IsArchive {
+		t.Errorf("1.3.0 should be an archive")
+	}
+}
+
+func TestResolvePrerelease(t *testing.T) {
+	store := newTestStore(t)
+
+	testResolve(t, store, "^1.4.0-beta.1", "1.4.0-beta.1")
+}
+
+func TestResolveNoMatchFail(t *testing.T) {
+	store := newTestStore(t)
+
+	constraint, _ := ParseConstraint("^3.0.0")
+	if _, err := store.Resolve("Helpers", constraint); err == nil {
+		t.Errorf("should not find a version for ^3.0.0")
+	}
+}
+
+func TestResolveUnknownPackageFail(t *testing.T) {
+	store := newTestStore(t)
+
+	constraint, _ := ParseConstraint("*")
+	_, err := store.Resolve("Unknown", constraint)
+	if err == nil || !strings.Contains(err.Error(), "is not in the package store") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package pkgstore
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version, MAJOR.MINOR.PATCH with an optional -prerelease.
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1. A prerelease version is lower than the same version without a prerelease.
+func (v Version) Compare(other Version) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if pair[0] < pair[1] {
+			return -1
+		}
+		if pair[0] > pair[1] {
+			return 1
+		}
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+
+	return comparePrerelease(v.Prerelease, other.Prerelease)
+}
+
+// comparePrerelease compares the dot separated identifiers one by one. Numeric identifiers are compared as
+// numbers and are lower than alphanumeric ones, so `rc.2` < `rc.10` < `rc.a`. If all identifiers are equal,
+// the one with fewer identifiers is lower.
+func comparePrerelease(a string, b string) int {
+	aIdentifiers := strings.Split(a, ".")
+	bIdentifiers := strings.Split(b, ".")
+
+	for index := 0; index < len(aIdentifiers) && index < len(bIdentifiers); index++ {
+		aIdentifier := aIdentifiers[index]
+		bIdentifier := bIdentifiers[index]
+		aNumber, aErr := strconv.ParseUint(aIdentifier, 10, 64)
+		bNumber, bErr := strconv.ParseUint(bIdentifier, 10, 64)
+		aIsNumeric := aErr == nil
+		bIsNumeric := bErr == nil
+
+		switch {
+		case aIsNumeric && bIsNumeric:
+			if aNumber < bNumber {
+				return -1
+			}
+			if aNumber > bNumber {
+				return 1
+			}
+		case aIsNumeric:
+			return -1
+		case bIsNumeric:
+			return 1
+		default:
+			if result := strings.Compare(aIdentifier, bIdentifier); result != 0 {
+				return result
+			}
+		}
+	}
+
+	switch {
+	case len(aIdentifiers) < len(bIdentifiers):
+		return -1
+	case len(aIdentifiers) > len(bIdentifiers):
+		return 1
+	}
+
+	return 0
+}
+
+// parsePartialVersion parses a version where the minor and patch can be left out. It returns how many parts were given.
+func parsePartialVersion(s string) (Version, int, error) {
+	withoutBuild, _, _ := strings.Cut(strings.TrimPrefix(s, "v"), "+")
+	numbers, prerelease, _ := strings.Cut(withoutBuild, "-")
+
+	parts := strings.Split(numbers, ".")
+	if len(parts) > 3 || numbers == "" {
+		return Version{}, 0, fmt.Errorf("'%v' is not a semantic version", s)
+	}
+
+	var values [3]int
+	for index, part := range parts {
+		value, convErr := strconv.Atoi(part)
+		if convErr != nil || value < 0 {
+			return Version{}, 0, fmt.Errorf("'%v' is not a semantic version", s)
+		}
+		values[index] = value
+	}
+
+	return Version{Major: values[0], Minor: values[1], Patch: values[2], Prerelease: prerelease}, len(parts), nil
+}
+
+func ParseVersion(s string) (Version, error) {
+	version, partCount, err := parsePartialVersion(s)
+	if err != nil {
+		return Version{}, err
+	}
+	if partCount != 3 {
+		return Version{}, fmt.Errorf("'%v' must have major, minor and patch", s)
+	}
+
+	return version, nil
+}
+
+type comparator struct {
+	operator string
+	version  Version
+}
+
+func (c comparator) matches(v Version) bool {
+	result := v.Compare(c.version)
+	switch c.operator {
+	case "=":
+		return result == 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	}
+
+	panic(fmt.Errorf("unknown comparator %v", c.operator))
+}
+
+// Constraint is a set of comparators that all must match, e.g. `>=1.2.0 <2.0.0`.
+type Constraint struct {
+	source      string
+	comparators []comparator
+}
+
+func (c Constraint) String() string {
+	return c.source
+}
+
+func expandComparator(operator string, version Version, partCount int) []comparator {
+	switch operator {
+	case "^":
+		upper := Version{Major: version.Major + 1}
+		if version.Major == 0 && partCount > 1 {
+			upper = Version{Minor: version.Minor + 1}
+		}
+		return []comparator{{">=", version}, {"<", upper}}
+	case "~":
+		upper := Version{Major: version.Major, Minor: version.Minor + 1}
+		if partCount == 1 {
+			upper = Version{Major: version.Major + 1}
+		}
+		return []comparator{{">=", version}, {"<", upper}}
+	case "", "=":
+		if partCount == 3 {
+			return []comparator{{"=", version}}
+		}
+		return expandComparator("~", version, partCount)
+	}
+
+	return []comparator{{operator, version}}
+}
+
+// ParseConstraint parses space or comma separated comparators. A comparator is a version with an optional
+// operator: `=`, `>`, `>=`, `<`, `<=`, `^` (compatible with) or `~` (same minor). `*` matches any version.
+func ParseConstraint(s string) (Constraint, error) {
+	constraint := Constraint{source: strings.TrimSpace(s)}
+
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	if len(fields) == 0 {
+		return Constraint{}, fmt.Errorf("empty version constraint")
+	}
+
+	for _, field := range fields {
+		if field == "*" {
+			continue
+		}
+
+		operatorLength := len(field) - len(strings.TrimLeft(field, "=<>^~"))
+		operator := field[:operatorLength]
+		switch operator {
+		case "", "=", ">", ">=", "<", "<=", "^", "~":
+		default:
+			return Constraint{}, fmt.Errorf("unknown operator '%v' in version constraint '%v'", operator, s)
+		}
+
+		version, partCount, versionErr := parsePartialVersion(field[operatorLength:])
+		if versionErr != nil {
+			return Constraint{}, versionErr
+		}
+
+		constraint.comparators = append(constraint.comparators, expandComparator(operator, version, partCount)...)
+	}
+
+	return constraint, nil
+}
+
+// Matches returns true if all comparators match. A prerelease version only matches if one of the comparators
+// has a prerelease of the same major, minor and patch, so `^1.2.0` does not pick `2.0.0-beta` or `1.3.0-beta`.
+func (c Constraint) Matches(v Version) bool {
+	allowsPrerelease := v.Prerelease == ""
+	for _, comparator := range c.comparators {
+		if !comparator.matches(v) {
+			return false
+		}
+		if comparator.version.Prerelease != "" && comparator.version.Major == v.Major &&
+			comparator.version.Minor == v.Minor && comparator.version.Patch == v.Patch {
+			allowsPrerelease = true
+		}
+	}
+
+	return allowsPrerelease
+}
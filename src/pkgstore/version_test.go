@@ -0,0 +1,107 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package pkgstore
+
+import (
+	"testing"
+)
+
+func testConstraint(t *testing.T, constraintString string, matching []string, notMatching []string) {
+	t.Helper()
+
+	constraint, parseErr := ParseConstraint(constraintString)
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+
+	for _, versionString := range matching {
+		version, versionErr := ParseVersion(versionString)
+		if versionErr != nil {
+			t.Fatal(versionErr)
+		}
+		if !constraint.Matches(version) {
+			t.Errorf("'%v' should match %v", constraintString, versionString)
+		}
+	}
+
+	for _, versionString := range notMatching {
+		version, versionErr := ParseVersion(versionString)
+		if versionErr != nil {
+			t.Fatal(versionErr)
+		}
+		if constraint.Matches(version) {
+			t.Errorf("'%v' should not match %v", constraintString, versionString)
+		}
+	}
+}
+
+func TestConstraintCaret(t *testing.T) {
+	testConstraint(t, "^1.2.0", []string{"1.2.0", "1.2.9", "1.9.0"}, []string{"1.1.9", "2.0.0", "0.9.0"})
+}
+
+func TestConstraintCaretZeroMajor(t *testing.T) {
+	testConstraint(t, "^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0", "0.2.2", "1.0.0"})
+}
+
+func TestConstraintTilde(t *testing.T) {
+	testConstraint(t, "~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.3.0", "1.2.2"})
+}
+
+func TestConstraintExact(t *testing.T) {
+	testConstraint(t, "1.2.3", []string{"1.2.3"}, []string{"1.2.4", "1.2.2"})
+}
+
+func TestConstraintPartial(t *testing.T) {
+	testConstraint(t, "1.2", []string{"1.2.0", "1.2.7"}, []string{"1.3.0", "1.1.0"})
+}
+
+func TestConstraintRange(t *testing.T) {
+	testConstraint(t, ">=1.2.0, <2.0.0", []string{"1.2.0", "1.99.0"}, []string{"2.0.0", "1.1.0"})
+}
+
+func TestConstraintAny(t *testing.T) {
+	testConstraint(t, "*", []string{"0.0.1", "12.3.4"}, []string{"1.0.0-beta"})
+}
+
+func TestConstraintPrereleaseExcluded(t *testing.T) {
+	testConstraint(t, "^1.2.0", nil, []string{"2.0.0-beta", "1.3.0-beta", "1.2.1-rc.1"})
+}
+
+func TestConstraintPrereleaseSameVersion(t *testing.T) {
+	testConstraint(t, "^1.2.0-beta.2", []string{"1.2.0-beta.2", "1.2.0-beta.10", "1.2.0", "1.5.0"},
+		[]string{"1.2.0-beta.1", "1.3.0-beta", "2.0.0"})
+}
+
+func TestConstraintUnknownOperatorFail(t *testing.T) {
+	if _, err := ParseConstraint("!1.2.0"); err == nil {
+		t.Errorf("should fail on unknown operator")
+	}
+}
+
+func TestConstraintEmptyFail(t *testing.T) {
+	if _, err := ParseConstraint(" , "); err == nil {
+		t.Errorf("should fail on empty constraint")
+	}
+}
+
+func TestConstraintNotAVersionFail(t *testing.T) {
+	if _, err := ParseConstraint("^1.x"); err == nil {
+		t.Errorf("should fail on version that is not a number")
+	}
+}
+
+func TestVersionComparePrerelease(t *testing.T) {
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2",
+		"1.0.0-beta.11", "1.0.0-rc.1", "1.0.0"}
+
+	for index := 1; index < len(ordered); index++ {
+		lower, _ := ParseVersion(ordered[index-1])
+		higher, _ := ParseVersion(ordered[index])
+		if lower.Compare(higher) != -1 || higher.Compare(lower) != 1 {
+			t.Errorf("%v should be lower than %v", lower, higher)
+		}
+	}
+}
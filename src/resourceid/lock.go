@@ -0,0 +1,104 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package resourceid
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/swamp/compiler/src/file"
+)
+
+// LockFilename is the name of the resource lock file, that is placed next to the `.swamp.toml` of the package.
+const LockFilename = "resources.lock"
+
+type LockedResourceName struct {
+	Name string
+	ID   ResourceID
+}
+
+// Lock records the ID of every resource name that has been used in the package. The resource names are stored in the
+// pack in ID order, so the IDs must start at zero and have no gaps.
+type Lock struct {
+	Resource []LockedResourceName
+}
+
+func LoadLock(directory string) (Lock, bool, error) {
+	lockFilename := path.Join(directory, LockFilename)
+	if !file.HasFile(lockFilename) {
+		return Lock{}, false, nil
+	}
+
+	data, readErr := os.ReadFile(lockFilename)
+	if readErr != nil {
+		return Lock{}, false, readErr
+	}
+
+	lock := Lock{}
+	if err := toml.Unmarshal(data, &lock); err != nil {
+		return Lock{}, false, fmt.Errorf("couldn't load resource lock file %v %w", lockFilename, err)
+	}
+
+	return lock, true, nil
+}
+
+// Save writes the lock file. It is only written if the content changed, so that `swamp watch` doesn't see it as a change.
+func (l Lock) Save(directory string) error {
+	data, marshalErr := toml.Marshal(&l)
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	header := "# Generated by `swamp build`. Keeps the resource name IDs stable between builds.\n\n"
+	octets := append([]byte(header), data...)
+
+	lockFilename := path.Join(directory, LockFilename)
+	if existing, readErr := os.ReadFile(lockFilename); readErr == nil && bytes.Equal(existing, octets) {
+		return nil
+	}
+
+	return os.WriteFile(lockFilename, octets, 0o644)
+}
+
+// NewResourceNameLookupFromLock returns a lookup that starts with the IDs in the lock. Names that are not in the
+// lock get the IDs after the locked ones. The locked names are kept, even if they are not used anymore.
+func NewResourceNameLookupFromLock(lock Lock) (*ResourceNameLookupImpl, error) {
+	locked := append([]LockedResourceName(nil), lock.Resource...)
+	sort.Slice(locked, func(i, j int) bool {
+		return locked[i].ID < locked[j].ID
+	})
+
+	r := NewResourceNameLookupImpl()
+	for index, lockedName := range locked {
+		if lockedName.ID != ResourceID(index) {
+			return nil, fmt.Errorf("resource lock IDs must start at zero and have no gaps, '%v' has ID %v, expected %v", lockedName.Name, lockedName.ID, index)
+		}
+		if _, alreadyLocked := r.lookup[lockedName.Name]; alreadyLocked {
+			return nil, fmt.Errorf("resource name '%v' is locked more than once", lockedName.Name)
+		}
+		r.lookup[lockedName.Name] = lockedName.ID
+		r.stored = append(r.stored, lockedName.Name)
+	}
+
+	return r, nil
+}
+
+// Lock returns all the resource names with their IDs, in ID order.
+func (r *ResourceNameLookupImpl) Lock() Lock {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	lock := Lock{}
+	for index, name := range r.stored {
+		lock.Resource = append(lock.Resource, LockedResourceName{Name: name, ID: ResourceID(index)})
+	}
+
+	return lock
+}
@@ -0,0 +1,117 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package resourceid
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLockSaveAndLoad(t *testing.T) {
+	directory := t.TempDir()
+
+	if _, hasLock, err := LoadLock(directory); err != nil || hasLock {
+		t.Fatalf("expected no lock, got %v %v", hasLock, err)
+	}
+
+	lock := Lock{Resource: []LockedResourceName{{Name: "sprites/hero", ID: 0}, {Name: "sounds/jump", ID: 1}}}
+	if err := lock.Save(directory); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, hasLock, loadErr := LoadLock(directory)
+	if loadErr != nil || !hasLock {
+		t.Fatalf("expected the saved lock, got %v %v", hasLock, loadErr)
+	}
+	if !reflect.DeepEqual(loaded, lock) {
+		t.Errorf("expected %v, got %v", lock, loaded)
+	}
+}
+
+func TestLockSaveUnchanged(t *testing.T) {
+	directory := t.TempDir()
+	lock := Lock{Resource: []LockedResourceName{{Name: "sprites/hero", ID: 0}}}
+	if err := lock.Save(directory); err != nil {
+		t.Fatal(err)
+	}
+
+	lockFilename := path.Join(directory, LockFilename)
+	earlier := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(lockFilename, earlier, earlier); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lock.Save(directory); err != nil {
+		t.Fatal(err)
+	}
+	if info, statErr := os.Stat(lockFilename); statErr != nil || !info.ModTime().Equal(earlier) {
+		t.Errorf("expected an unchanged lock to not be written again")
+	}
+
+	changed := Lock{Resource: append(lock.Resource, LockedResourceName{Name: "sounds/jump", ID: 1})}
+	if err := changed.Save(directory); err != nil {
+		t.Fatal(err)
+	}
+	if info, statErr := os.Stat(lockFilename); statErr != nil || info.ModTime().Equal(earlier) {
+		t.Errorf("expected a changed lock to be written")
+	}
+}
+
+func TestLoadLockFail(t *testing.T) {
+	directory := t.TempDir()
+	if err := os.WriteFile(path.Join(directory, LockFilename), []byte("[[Resource]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := LoadLock(directory); err == nil {
+		t.Errorf("expected a broken lock file to fail")
+	}
+}
+
+func TestNewResourceNameLookupFromLock(t *testing.T) {
+	// The IDs come from the lock, not from the order in the file
+	lock := Lock{Resource: []LockedResourceName{{Name: "sounds/jump", ID: 1}, {Name: "sprites/hero", ID: 0}, {Name: "unused", ID: 2}}}
+	lookup, lookupErr := NewResourceNameLookupFromLock(lock)
+	if lookupErr != nil {
+		t.Fatal(lookupErr)
+	}
+
+	if id := lookup.LookupResourceId("sounds/jump"); id != 1 {
+		t.Errorf("expected the locked ID 1, got %v", id)
+	}
+	if id := lookup.LookupResourceId("sprites/enemy"); id != 3 {
+		t.Errorf("expected a new name to get the ID after the locked ones, got %v", id)
+	}
+
+	expected := Lock{Resource: []LockedResourceName{{"sprites/hero", 0}, {"sounds/jump", 1}, {"unused", 2}, {"sprites/enemy", 3}}}
+	if !reflect.DeepEqual(lookup.Lock(), expected) {
+		t.Errorf("expected the unused locked name to be kept, got %v", lookup.Lock())
+	}
+}
+
+func TestNewResourceNameLookupFromLockFail(t *testing.T) {
+	for _, testCase := range []struct {
+		name          string
+		lock          Lock
+		expectedError string
+	}{
+		{"not starting at zero", Lock{Resource: []LockedResourceName{{"sprites/hero", 1}}}, "'sprites/hero' has ID 1, expected 0"},
+		{"gap", Lock{Resource: []LockedResourceName{{"sprites/hero", 0}, {"sounds/jump", 2}}}, "'sounds/jump' has ID 2, expected 1"},
+		{"duplicate ID", Lock{Resource: []LockedResourceName{{"sprites/hero", 0}, {"sounds/jump", 0}}}, "expected 1"},
+		{"duplicate name", Lock{Resource: []LockedResourceName{{"sprites/hero", 0}, {"sprites/hero", 1}}}, "'sprites/hero' is locked more than once"},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := NewResourceNameLookupFromLock(testCase.lock)
+			if err == nil || !strings.Contains(err.Error(), testCase.expectedError) {
+				t.Errorf("expected an error containing '%v', got %v", testCase.expectedError, err)
+			}
+		})
+	}
+}
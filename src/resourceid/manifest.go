@@ -0,0 +1,70 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package resourceid
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type ManifestResource struct {
+	ID   ResourceID `json:"id"`
+	Name string     `json:"name"`
+}
+
+// Manifest lists every resource name in a pack with the ID that the code uses for it.
+type Manifest struct {
+	Package   string             `json:"package"`
+	Resources []ManifestResource `json:"resources"`
+}
+
+// NewManifest creates a manifest from the resource names in the order that they are stored in the pack, which is the ID order.
+func NewManifest(packageName string, resourceNames []string) Manifest {
+	manifest := Manifest{Package: packageName, Resources: []ManifestResource{}}
+	for index, name := range resourceNames {
+		manifest.Resources = append(manifest.Resources, ManifestResource{ID: ResourceID(index), Name: name})
+	}
+
+	return manifest
+}
+
+func (m Manifest) Save(filename string) error {
+	data, marshalErr := json.MarshalIndent(m, "", "  ")
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	return os.WriteFile(filename, append(data, '\n'), 0o644)
+}
+
+func LoadManifest(filename string) (Manifest, error) {
+	data, readErr := os.ReadFile(filename)
+	if readErr != nil {
+		return Manifest{}, readErr
+	}
+
+	manifest := Manifest{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return Manifest{}, fmt.Errorf("couldn't load resource manifest %v %w", filename, err)
+	}
+
+	return manifest, nil
+}
+
+// NewResourceNameLookupFromManifests merges the resource names of the manifests into one lookup. The names get IDs in
+// the order of the manifests, and in ID order within each manifest, so the merged IDs do not depend on the order that
+// the packs were built in.
+func NewResourceNameLookupFromManifests(manifests []Manifest) *ResourceNameLookupImpl {
+	r := NewResourceNameLookupImpl()
+	for _, manifest := range manifests {
+		for _, resource := range manifest.Resources {
+			r.LookupResourceId(resource.Name)
+		}
+	}
+
+	return r
+}
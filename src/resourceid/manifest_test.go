@@ -0,0 +1,81 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package resourceid
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestManifestSaveAndLoad(t *testing.T) {
+	filename := path.Join(t.TempDir(), "game.resources.json")
+	manifest := NewManifest("game", []string{"sprites/hero", "sounds/jump"})
+
+	expected := Manifest{Package: "game", Resources: []ManifestResource{{ID: 0, Name: "sprites/hero"}, {ID: 1, Name: "sounds/jump"}}}
+	if !reflect.DeepEqual(manifest, expected) {
+		t.Errorf("expected the IDs in resource name order, got %v", manifest)
+	}
+
+	if err := manifest.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, loadErr := LoadManifest(filename)
+	if loadErr != nil {
+		t.Fatal(loadErr)
+	}
+	if !reflect.DeepEqual(loaded, manifest) {
+		t.Errorf("expected %v, got %v", manifest, loaded)
+	}
+}
+
+func TestManifestWithoutResources(t *testing.T) {
+	filename := path.Join(t.TempDir(), "game.resources.json")
+	if err := NewManifest("game", nil).Save(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	octets, readErr := os.ReadFile(filename)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+
+	const expected = "{\n  \"package\": \"game\",\n  \"resources\": []\n}\n"
+	if string(octets) != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, string(octets))
+	}
+}
+
+func TestLoadManifestFail(t *testing.T) {
+	directory := t.TempDir()
+	brokenFilename := path.Join(directory, "broken.resources.json")
+	if err := os.WriteFile(brokenFilename, []byte("{\"package\": "), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, filename := range []string{brokenFilename, path.Join(directory, "missing.resources.json")} {
+		if _, err := LoadManifest(filename); err == nil {
+			t.Errorf("expected loading '%v' to fail", filename)
+		}
+	}
+}
+
+func TestNewResourceNameLookupFromManifests(t *testing.T) {
+	manifests := []Manifest{
+		NewManifest("game", []string{"sprites/hero", "sounds/jump"}),
+		NewManifest("menu", []string{"sounds/click", "sprites/hero"}),
+		NewManifest("empty", nil),
+	}
+
+	lookup := NewResourceNameLookupFromManifests(manifests)
+
+	expected := []string{"sprites/hero", "sounds/jump", "sounds/click"}
+	if names := lookup.SortedResourceNames(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected the names in manifest order without duplicates %v, got %v", expected, names)
+	}
+}
@@ -5,6 +5,8 @@
 
 package resourceid
 
+import "sync"
+
 type ResourceID uint32
 
 type ResourceNameLookup interface {
@@ -12,7 +14,10 @@ type ResourceNameLookup interface {
 	SortedResourceNames() []string
 }
 
+// ResourceNameLookupImpl assigns resource IDs in the order the names are first looked up.
+// It is safe to use from more than one goroutine.
 type ResourceNameLookupImpl struct {
+	mutex  sync.Mutex
 	lookup map[string]ResourceID
 	stored []string
 }
@@ -24,6 +29,9 @@ func NewResourceNameLookupImpl() *ResourceNameLookupImpl {
 }
 
 func (r *ResourceNameLookupImpl) LookupResourceId(resourceName string) ResourceID {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
 	existingResourceID, hasID := r.lookup[resourceName]
 	if hasID {
 		return existingResourceID
@@ -37,5 +45,8 @@ func (r *ResourceNameLookupImpl) LookupResourceId(resourceName string) ResourceI
 }
 
 func (r *ResourceNameLookupImpl) SortedResourceNames() []string {
-	return r.stored
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]string(nil), r.stored...)
 }
@@ -361,6 +361,11 @@ func addSemanticTokenCaseForCustomType(caseNode *decorated.CaseCustomType, build
 			}
 		}
 
+		if consequence.Guard() != nil {
+			if err := addSemanticToken(consequence.Guard(), builder); err != nil {
+				return err
+			}
+		}
 		if err := addSemanticToken(consequence.Expression(), builder); err != nil {
 			return err
 		}
@@ -399,6 +404,11 @@ func addSemanticTokenCaseForPatternMatching(caseNode *decorated.CaseForPatternMa
 		if err := addSemanticToken(consequence.Literal(), builder); err != nil {
 			return err
 		}
+		if consequence.Guard() != nil {
+			if err := addSemanticToken(consequence.Guard(), builder); err != nil {
+				return err
+			}
+		}
 		if err := addSemanticToken(consequence.Expression(), builder); err != nil {
 			return err
 		}
@@ -413,6 +423,83 @@ func addSemanticTokenCaseForPatternMatching(caseNode *decorated.CaseForPatternMa
 	return nil
 }
 
+func addSemanticTokenCasePattern(pattern decorated.CasePattern, builder *SemanticBuilder) error {
+	switch t := pattern.(type) {
+	case *decorated.CasePatternBinding:
+		return encodeVariable(builder, t.Parameter().Identifier())
+	case *decorated.CasePatternLiteral:
+		return addSemanticToken(t.Literal(), builder)
+	case *decorated.CasePatternVariant:
+		if err := encodeEnumMember(builder, t.VariantReference().AstIdentifier().SomeTypeIdentifier()); err != nil {
+			return err
+		}
+		for _, argument := range t.Arguments() {
+			if err := addSemanticTokenCasePattern(argument, builder); err != nil {
+				return err
+			}
+		}
+	case *decorated.CasePatternTuple:
+		for _, subPattern := range t.Patterns() {
+			if err := addSemanticTokenCasePattern(subPattern, builder); err != nil {
+				return err
+			}
+		}
+	case *decorated.CasePatternRecord:
+		for _, field := range t.Fields() {
+			if err := encodeVariable(builder, field.Parameter().Identifier()); err != nil {
+				return err
+			}
+		}
+	case *decorated.CasePatternAs:
+		if err := addSemanticTokenCasePattern(t.Pattern(), builder); err != nil {
+			return err
+		}
+		if err := encodeKeyword(builder, t.AstPattern().KeywordAs()); err != nil {
+			return err
+		}
+		return encodeVariable(builder, t.Parameter().Identifier())
+	}
+
+	return nil
+}
+
+func addSemanticTokenCaseForPatterns(caseNode *decorated.CaseForPatterns, builder *SemanticBuilder) error {
+	keywordCase := caseNode.AstCasePatterns().KeywordCase()
+	keywordOf := caseNode.AstCasePatterns().KeywordOf()
+	if err := encodeKeyword(builder, keywordCase); err != nil {
+		return err
+	}
+
+	if err := addSemanticToken(caseNode.Test(), builder); err != nil {
+		return err
+	}
+
+	if err := encodeKeyword(builder, keywordOf); err != nil {
+		return err
+	}
+
+	for _, consequence := range caseNode.Consequences() {
+		if consequence.AstConsequence().Comment() != nil {
+			if err := encodeComment(builder, consequence.AstConsequence().Comment()); err != nil {
+				return err
+			}
+		}
+		if err := addSemanticTokenCasePattern(consequence.Pattern(), builder); err != nil {
+			return err
+		}
+		if consequence.Guard() != nil {
+			if err := addSemanticToken(consequence.Guard(), builder); err != nil {
+				return err
+			}
+		}
+		if err := addSemanticToken(consequence.Expression(), builder); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func addSemanticTokenGuardToken(basic ast.GuardItemBasic, builder *SemanticBuilder) error {
 	guardToken := basic.GuardToken
 	operatorToken := token.NewOperatorToken(guardToken.Type(), guardToken.SourceFileReference, guardToken.Raw(), guardToken.DebugString())
@@ -809,6 +896,16 @@ func addSemanticTokenCurryFunction(funcCall *decorated.CurryFunction, builder *S
 	return nil
 }
 
+func addSemanticTokenLambda(lambda *decorated.Lambda, builder *SemanticBuilder) error {
+	for _, parameter := range lambda.Parameters() {
+		if err := builder.EncodeSymbol(parameter.Parameter().Identifier().FetchPositionLength().Range, "parameter", []string{}, parameter); err != nil {
+			return err
+		}
+	}
+
+	return addSemanticToken(lambda.Expression(), builder)
+}
+
 func addSemanticTokenNamedTypeReference(named *dectype.NamedDefinitionTypeReference, builder *SemanticBuilder) error {
 	isScoped := named.ModuleReference() != nil
 	if isScoped {
@@ -1020,6 +1117,8 @@ func addSemanticToken(typeOrToken decorated.TypeOrToken, builder *SemanticBuilde
 		return addSemanticTokenFunctionCall(t, builder)
 	case *decorated.CurryFunction:
 		return addSemanticTokenCurryFunction(t, builder)
+	case *decorated.Lambda:
+		return addSemanticTokenLambda(t, builder)
 	case *decorated.FunctionName:
 		return addSemanticTokenFunctionName(t, builder)
 	case *decorated.CustomTypeVariantConstructor:
@@ -1034,6 +1133,8 @@ func addSemanticToken(typeOrToken decorated.TypeOrToken, builder *SemanticBuilde
 		return addSemanticTokenCaseForCustomType(t, builder)
 	case *decorated.CaseForPatternMatching:
 		return addSemanticTokenCaseForPatternMatching(t, builder)
+	case *decorated.CaseForPatterns:
+		return addSemanticTokenCaseForPatterns(t, builder)
 	case *decorated.Guard:
 		return addSemanticTokenGuard(t, builder)
 	case *decorated.ArithmeticOperator:
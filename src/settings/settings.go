@@ -6,14 +6,20 @@
 package settings
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/pelletier/go-toml/v2"
 	"github.com/swamp/compiler/src/environment"
+	"github.com/swamp/compiler/src/file"
+	"github.com/swamp/compiler/src/pkgstore"
 )
 
 type ModuleMap = int
@@ -21,17 +27,44 @@ type ModuleMap = int
 const (
 	ModuleFromEnvironment ModuleMap = iota
 	ModuleFromPath
+	ModuleFromStore
 )
 
+// Module maps a module name to a directory. The directory is either given by Path, or resolved from the
+// package store when Version has a semantic version constraint, e.g. `^1.2.0`.
 type Module struct {
-	Name   string
-	Path   string
-	Mapped ModuleMap
+	Name    string
+	Path    string
+	Version string
+	Mapped  ModuleMap
+}
+
+// Resources configures the resource names, e.g. `@sprites/hero`. With Lock, the IDs are kept in `resources.lock`, so they
+// are the same between builds. Assets is the directory that the resource names are checked against.
+type Resources struct {
+	Lock   bool
+	Assets string
+}
+
+// DeadCode configures the dead code elimination. Roots are the fully qualified names of the functions and constants,
+// e.g. `Game.update`, that are kept in addition to `main` and `init` in the main module.
+type DeadCode struct {
+	Disable bool
+	Roots   []string
+}
+
+// Stack configures the stack memory. Budget is the largest stack frame, in octets, that a function is allowed to
+// use. Zero has no limit.
+type Stack struct {
+	Budget uint
 }
 
 type Settings struct {
-	Name   string
-	Module []Module
+	Name      string
+	Module    []Module
+	Resources Resources
+	DeadCode  DeadCode
+	Stack     Stack
 }
 
 func Load(reader io.Reader, rootDirectory string, configuration environment.Environment) (Settings, error) {
@@ -49,8 +82,39 @@ func Load(reader io.Reader, rootDirectory string, configuration environment.Envi
 		return Settings{}, unmarshalErr
 	}
 
+	if settings.Resources.Assets != "" && !filepath.IsAbs(settings.Resources.Assets) {
+		settings.Resources.Assets = path.Join(rootDirectory, settings.Resources.Assets)
+	}
+
+	var lock *pkgstore.Lock
+
 	for index, mod := range settings.Module {
 		cleanedUpPath := strings.TrimSpace(mod.Path)
+		if mod.Version != "" {
+			if cleanedUpPath != "" {
+				return Settings{}, fmt.Errorf("module '%v' can not have both a path and a version", mod.Name)
+			}
+			if lock == nil {
+				loadedLock, _, lockErr := pkgstore.LoadLock(rootDirectory)
+				if lockErr != nil {
+					return Settings{}, lockErr
+				}
+				lock = &loadedLock
+			}
+			storeDirectory, storeErr := configuration.StoreDirectory()
+			if storeErr != nil {
+				return Settings{}, storeErr
+			}
+			requirement := pkgstore.Requirement{Name: mod.Name, Constraint: mod.Version}
+			sourceDirectory, lockedErr := pkgstore.LockedSourceDirectory(pkgstore.NewStore(storeDirectory), *lock, requirement)
+			if lockedErr != nil {
+				return Settings{}, lockedErr
+			}
+			settings.Module[index].Path = filepath.ToSlash(sourceDirectory)
+			settings.Module[index].Mapped = ModuleFromStore
+			continue
+		}
+
 		convertedPath := cleanedUpPath
 		if strings.HasPrefix(cleanedUpPath, "${") {
 			endIndex := strings.Index(cleanedUpPath, "}")
@@ -62,7 +126,7 @@ func Load(reader io.Reader, rootDirectory string, configuration environment.Envi
 			convertedPath = configuration.Lookup(packageName)
 			if convertedPath == "" {
 				fileName, _ := environment.EnvironmentTomlFilename()
-				return Settings{}, fmt.Errorf("could not resolve external package name '%v', please add it to '%v' file", packageName, fileName)
+				return Settings{}, fmt.Errorf("could not resolve external package name '%v', please add it to '%v' file, a project '%v' file or set %v", packageName, fileName, environment.ProjectFilename, environment.VariableName(packageName))
 			}
 			convertedPath = convertedPath + suffix
 			mapped = ModuleFromEnvironment
@@ -79,3 +143,106 @@ func Load(reader io.Reader, rootDirectory string, configuration environment.Envi
 
 	return settings, nil
 }
+
+// LoadFromDirectory loads the `.swamp.toml` in the directory, or in the root of a `.swamp-lib` library archive.
+// A directory or archive without a settings file has no module mappings, so empty settings are returned.
+func LoadFromDirectory(directory string, configuration environment.Environment) (Settings, error) {
+	if strings.HasSuffix(directory, pkgstore.ArchiveExtension) && file.HasFile(directory) {
+		return loadFromArchive(directory, configuration)
+	}
+
+	if !file.IsDir(directory) {
+		return Settings{}, nil
+	}
+
+	settingsFile, openErr := os.Open(path.Join(directory, ".swamp.toml"))
+	if openErr != nil {
+		if errors.Is(openErr, os.ErrNotExist) {
+			return Settings{}, nil
+		}
+		return Settings{}, openErr
+	}
+	defer settingsFile.Close()
+
+	return Load(bufio.NewReader(settingsFile), directory, configuration)
+}
+
+// loadFromArchive loads the `.swamp.toml` in the root of the archive. Relative module paths are relative to the
+// archive, in the same way as for a directory.
+func loadFromArchive(archiveFilename string, configuration environment.Environment) (Settings, error) {
+	files, readErr := pkgstore.ReadArchive(archiveFilename)
+	if readErr != nil {
+		return Settings{}, fmt.Errorf("couldn't read archive '%v' %w", archiveFilename, readErr)
+	}
+
+	settingsOctets, hasSettings := files[".swamp.toml"]
+	if !hasSettings {
+		return Settings{}, nil
+	}
+
+	return Load(bytes.NewReader(settingsOctets), archiveFilename, configuration)
+}
+
+// ReachableDirectories returns the directory and all directories that are mapped from it, directly
+// or through the `.swamp.toml` of another mapped directory.
+func ReachableDirectories(directory string, configuration environment.Environment) ([]string, error) {
+	var directories []string
+	visited := make(map[string]bool)
+
+	var visit func(directory string) error
+	visit = func(directory string) error {
+		absoluteDirectory, absErr := filepath.Abs(directory)
+		if absErr != nil {
+			return absErr
+		}
+		if visited[absoluteDirectory] {
+			return nil
+		}
+		visited[absoluteDirectory] = true
+		directories = append(directories, absoluteDirectory)
+
+		foundSettings, loadErr := LoadFromDirectory(absoluteDirectory, configuration)
+		if loadErr != nil {
+			return loadErr
+		}
+
+		for _, module := range foundSettings.Module {
+			if err := visit(module.Path); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := visit(directory); err != nil {
+		return nil, err
+	}
+
+	return directories, nil
+}
+
+// LoadRequirements returns the modules in the `.swamp.toml` of the directory that are resolved from the package store.
+func LoadRequirements(directory string) ([]pkgstore.Requirement, error) {
+	data, readErr := os.ReadFile(path.Join(directory, ".swamp.toml"))
+	if readErr != nil {
+		if errors.Is(readErr, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, readErr
+	}
+
+	settings := Settings{}
+	if err := toml.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+
+	var requirements []pkgstore.Requirement
+	for _, mod := range settings.Module {
+		if mod.Version != "" {
+			requirements = append(requirements, pkgstore.Requirement{Name: mod.Name, Constraint: mod.Version})
+		}
+	}
+
+	return requirements, nil
+}
@@ -0,0 +1,106 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package settings
+
+import (
+	"archive/zip"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/swamp/compiler/src/environment"
+)
+
+func writeTestZip(t *testing.T, filename string, files map[string]string) {
+	t.Helper()
+
+	archiveFile, createErr := os.Create(filename)
+	if createErr != nil {
+		t.Fatal(createErr)
+	}
+	defer archiveFile.Close()
+
+	zipWriter := zip.NewWriter(archiveFile)
+	for name, content := range files {
+		fileWriter, writerErr := zipWriter.Create(name)
+		if writerErr != nil {
+			t.Fatal(writerErr)
+		}
+		if _, err := fileWriter.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeTestPackages writes a package `game` that maps the archive `engine.swamp-lib`, that in turn maps the
+// directory `math`.
+func writeTestPackages(t *testing.T) string {
+	t.Helper()
+	directory := filepath.ToSlash(t.TempDir())
+	for _, subDirectory := range []string{"game", "math"} {
+		if err := os.MkdirAll(path.Join(directory, subDirectory), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(path.Join(directory, "game", ".swamp.toml"), []byte("[[module]]\nname = \"Engine\"\npath = \"../engine.swamp-lib\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeTestZip(t, path.Join(directory, "engine.swamp-lib"), map[string]string{
+		".swamp.toml": "[[module]]\nname = \"Math\"\npath = \"../math\"\n",
+		"Main.swamp":  "",
+	})
+
+	return directory
+}
+
+func TestLoadFromDirectoryArchive(t *testing.T) {
+	directory := writeTestPackages(t)
+
+	foundSettings, loadErr := LoadFromDirectory(path.Join(directory, "engine.swamp-lib"), environment.Environment{})
+	if loadErr != nil {
+		t.Fatal(loadErr)
+	}
+
+	if len(foundSettings.Module) != 1 || foundSettings.Module[0].Name != "Math" || foundSettings.Module[0].Path != path.Join(directory, "math") {
+		t.Errorf("expected the module mapping in the archive, got %+v", foundSettings.Module)
+	}
+}
+
+func TestLoadFromDirectoryArchiveWithoutSettings(t *testing.T) {
+	archiveFilename := path.Join(t.TempDir(), "engine.swamp-lib")
+	writeTestZip(t, archiveFilename, map[string]string{"Main.swamp": ""})
+
+	foundSettings, loadErr := LoadFromDirectory(archiveFilename, environment.Environment{})
+	if loadErr != nil {
+		t.Fatal(loadErr)
+	}
+	if len(foundSettings.Module) != 0 {
+		t.Errorf("expected no module mappings, got %+v", foundSettings.Module)
+	}
+}
+
+func TestReachableDirectoriesThroughArchive(t *testing.T) {
+	directory := writeTestPackages(t)
+
+	directories, reachableErr := ReachableDirectories(path.Join(directory, "game"), environment.Environment{})
+	if reachableErr != nil {
+		t.Fatal(reachableErr)
+	}
+
+	expected := []string{path.Join(directory, "game"), path.Join(directory, "engine.swamp-lib"), path.Join(directory, "math")}
+	if len(directories) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, directories)
+	}
+	for index, expectedDirectory := range expected {
+		if filepath.ToSlash(directories[index]) != expectedDirectory {
+			t.Errorf("expected %v, got %v", expected, directories)
+		}
+	}
+}
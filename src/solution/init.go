@@ -0,0 +1,238 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package solution
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+	"github.com/swamp/compiler/src/file"
+)
+
+const packageSettingsTemplate = `# Modules from other packages are mapped here, e.g.
+#
+# [[module]]
+# name = "Lib"
+# path = "../lib"
+`
+
+const mainTemplate = `{-| The entry point of the package.
+-}
+main : (x: Int) -> Int =
+    x + 1
+`
+
+var packagesKeyPattern = regexp.MustCompile(`(?im)^[ \t]*packages[ \t]*=[ \t]*\[`)
+
+// findRootTableEnd returns the position of the line with the first table header, or the end of the source if there is
+// no table. The source is parsed, so that brackets in arrays and strings are not mistaken for a table header.
+func findRootTableEnd(source string) (int, error) {
+	parser := unstable.Parser{}
+	parser.Reset([]byte(source))
+	for parser.NextExpression() {
+		expression := parser.Expression()
+		if expression.Kind != unstable.Table && expression.Kind != unstable.ArrayTable {
+			continue
+		}
+		keyParts := expression.Key()
+		keyParts.Next()
+		keyStart := int(keyParts.Node().Raw.Offset)
+
+		return strings.LastIndex(source[:keyStart], "\n") + 1, nil
+	}
+
+	if err := parser.Error(); err != nil {
+		return 0, err
+	}
+
+	return len(source), nil
+}
+
+func lineIndentation(source string, position int) string {
+	lineStart := strings.LastIndex(source[:position], "\n") + 1
+	line := source[lineStart:position]
+
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// addPackageToSource adds the package name to the `packages` array in the root table of the solution file source.
+// Only the array is changed, so comments and formatting in the rest of the file are kept.
+func addPackageToSource(source string, packageName string) (string, error) {
+	quotedName := strconv.Quote(packageName)
+
+	// The packages key is in the root table, that ends at the first table header. Keys with the same name in other
+	// tables are left as they are.
+	rootTableEnd, rootTableErr := findRootTableEnd(source)
+	if rootTableErr != nil {
+		return "", rootTableErr
+	}
+
+	keyLocation := packagesKeyPattern.FindStringIndex(source[:rootTableEnd])
+	if keyLocation == nil {
+		line := fmt.Sprintf("packages = [%v]\n", quotedName)
+		if rootTableEnd < len(source) {
+			return source[:rootTableEnd] + line + source[rootTableEnd:], nil
+		}
+		if source != "" && !strings.HasSuffix(source, "\n") {
+			source += "\n"
+		}
+		return source + line, nil
+	}
+
+	lastValueEnd := -1
+	trailingComma := -1
+	isMultiline := false
+	inComment := false
+
+	for position := keyLocation[1]; position < len(source); position++ {
+		ch := source[position]
+		if inComment {
+			if ch == '\n' {
+				inComment = false
+				isMultiline = true
+			}
+			continue
+		}
+
+		switch ch {
+		case '#':
+			inComment = true
+		case '\n':
+			isMultiline = true
+		case ',':
+			trailingComma = position
+		case '"', '\'':
+			end := position + 1
+			for end < len(source) && source[end] != ch && source[end] != '\n' {
+				if ch == '"' && source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(source) || source[end] != ch {
+				return "", fmt.Errorf("unterminated string in packages")
+			}
+			position = end
+			lastValueEnd = end + 1
+			trailingComma = -1
+		case ']':
+			if lastValueEnd == -1 {
+				return source[:position] + quotedName + source[position:], nil
+			}
+
+			if trailingComma == -1 {
+				source = source[:lastValueEnd] + "," + source[lastValueEnd:]
+				trailingComma = lastValueEnd
+				position++
+			}
+
+			if !isMultiline {
+				return source[:trailingComma+1] + " " + quotedName + source[trailingComma+1:], nil
+			}
+
+			// The new package is added on its own line, after any comment that follows the last package
+			lineEnd := position
+			if newLine := strings.IndexByte(source[trailingComma:position], '\n'); newLine != -1 {
+				lineEnd = trailingComma + newLine
+			}
+			entry := "\n" + lineIndentation(source, lastValueEnd) + quotedName + ","
+			if lineEnd == position {
+				entry += "\n"
+			}
+
+			return source[:lineEnd] + entry + source[lineEnd:], nil
+		case ' ', '\t', '\r':
+		default:
+			return "", fmt.Errorf("packages must only contain strings, found '%c'", ch)
+		}
+	}
+
+	return "", fmt.Errorf("packages array is not closed")
+}
+
+func writeFileIfMissing(filename string, content string, created []string) ([]string, error) {
+	if file.HasFile(filename) {
+		return created, nil
+	}
+
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		return created, err
+	}
+
+	return append(created, filename), nil
+}
+
+// Init creates a solution file in the directory, and a package directory with a `.swamp.toml` and a `Main.swamp`.
+// If there already is a solution file, the package is added to its packages. Existing files are never overwritten.
+// It returns the files that were created or changed.
+func Init(directory string, packageName string) ([]string, error) {
+	if packageName == "" || packageName != filepath.Base(packageName) || strings.HasPrefix(packageName, ".") {
+		return nil, fmt.Errorf("'%v' is not a valid package directory name", packageName)
+	}
+
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, err
+	}
+
+	var created []string
+
+	solutionFilename := Filename(directory)
+	if file.HasFile(solutionFilename) {
+		existingSettings, loadErr := LoadIfExists(directory)
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		for _, existingPackage := range existingSettings.Packages {
+			if existingPackage == packageName {
+				return nil, fmt.Errorf("package '%v' is already in '%v'", packageName, solutionFilename)
+			}
+		}
+
+		source, readErr := os.ReadFile(solutionFilename)
+		if readErr != nil {
+			return nil, readErr
+		}
+
+		changedSource, addErr := addPackageToSource(string(source), packageName)
+		if addErr != nil {
+			return nil, fmt.Errorf("could not add package to '%v' %w", solutionFilename, addErr)
+		}
+
+		if err := os.WriteFile(solutionFilename, []byte(changedSource), 0o644); err != nil {
+			return nil, err
+		}
+		created = append(created, solutionFilename)
+	} else {
+		absoluteDirectory, absErr := filepath.Abs(directory)
+		if absErr != nil {
+			return nil, absErr
+		}
+
+		solutionSource := fmt.Sprintf("name = %v\npackages = [%v]\n", strconv.Quote(filepath.Base(absoluteDirectory)), strconv.Quote(packageName))
+		if err := os.WriteFile(solutionFilename, []byte(solutionSource), 0o644); err != nil {
+			return nil, err
+		}
+		created = append(created, solutionFilename)
+	}
+
+	packageDirectory := path.Join(directory, packageName)
+	if err := os.MkdirAll(packageDirectory, 0o755); err != nil {
+		return created, err
+	}
+
+	created, settingsErr := writeFileIfMissing(path.Join(packageDirectory, ".swamp.toml"), packageSettingsTemplate, created)
+	if settingsErr != nil {
+		return created, settingsErr
+	}
+
+	return writeFileIfMissing(path.Join(packageDirectory, "Main.swamp"), mainTemplate, created)
+}
@@ -0,0 +1,120 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package solution
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddPackageToSource(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "existing array",
+			source:   "packages = [\"game\"]\n",
+			expected: "packages = [\"game\", \"tools\"]\n",
+		},
+		{
+			name:     "existing empty array",
+			source:   "packages = []\n",
+			expected: "packages = [\"tools\"]\n",
+		},
+		{
+			name:     "existing array with trailing comma",
+			source:   "packages = [\"game\",]\n",
+			expected: "packages = [\"game\", \"tools\"]\n",
+		},
+		{
+			name:     "existing multiline array",
+			source:   "packages = [\n    \"game\",\n    \"engine\", # the engine\n]\n",
+			expected: "packages = [\n    \"game\",\n    \"engine\", # the engine\n    \"tools\",\n]\n",
+		},
+		{
+			name:     "existing multiline array without trailing comma",
+			source:   "packages = [\n    \"game\"\n]\n",
+			expected: "packages = [\n    \"game\",\n    \"tools\",\n]\n",
+		},
+		{
+			name:     "existing array before a table",
+			source:   "# the solution\npackages = ['game']\n\n[build]\njobs = 2\n",
+			expected: "# the solution\npackages = ['game', \"tools\"]\n\n[build]\njobs = 2\n",
+		},
+		{
+			name:     "missing key in empty file",
+			source:   "",
+			expected: "packages = [\"tools\"]\n",
+		},
+		{
+			name:     "missing key without a line ending",
+			source:   "name = \"solution\"",
+			expected: "name = \"solution\"\npackages = [\"tools\"]\n",
+		},
+		{
+			name:     "missing key before a table",
+			source:   "name = \"solution\"\n\n[build]\njobs = 2\n",
+			expected: "name = \"solution\"\n\npackages = [\"tools\"]\n[build]\njobs = 2\n",
+		},
+		{
+			name:     "missing key before a multiline array",
+			source:   "matrix = [\n    [1, 2],\n    [3]\n]\n",
+			expected: "matrix = [\n    [1, 2],\n    [3]\n]\npackages = [\"tools\"]\n",
+		},
+		{
+			name:     "key in other table",
+			source:   "[build]\npackages = [\"game\"]\n",
+			expected: "packages = [\"tools\"]\n[build]\npackages = [\"game\"]\n",
+		},
+		{
+			name:     "missing key before a quoted table name",
+			source:   "[\"build\\tsettings\"]\npackages = [\"game\"]\n",
+			expected: "packages = [\"tools\"]\n[\"build\\tsettings\"]\npackages = [\"game\"]\n",
+		},
+		{
+			name:     "key in root table and in array of tables",
+			source:   "packages = [\"game\"]\n\n[[target]]\npackages = [\"game\"]\n",
+			expected: "packages = [\"game\", \"tools\"]\n\n[[target]]\npackages = [\"game\"]\n",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			changed, err := addPackageToSource(testCase.source, "tools")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != testCase.expected {
+				t.Errorf("expected:\n%v\ngot:\n%v", testCase.expected, changed)
+			}
+
+			settings, loadErr := Load(strings.NewReader(changed), "")
+			if loadErr != nil {
+				t.Fatalf("the changed source is not valid: %v", loadErr)
+			}
+			if last := settings.Packages[len(settings.Packages)-1]; last != "tools" {
+				t.Errorf("expected the package to be added last, got %v", settings.Packages)
+			}
+		})
+	}
+}
+
+func TestAddPackageToSourceFail(t *testing.T) {
+	for _, testCase := range []struct {
+		name   string
+		source string
+	}{
+		{"not closed", "packages = [\"game\"\n"},
+		{"unterminated string", "packages = [\"game]\n"},
+		{"not a string", "packages = [1]\n"},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			if changed, err := addPackageToSource(testCase.source, "tools"); err == nil {
+				t.Errorf("expected an error, got:\n%v", changed)
+			}
+		})
+	}
+}
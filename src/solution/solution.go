@@ -42,12 +42,13 @@ func Load(reader io.Reader, solutionFileDirectory string) (Settings, error) {
 	return settings, nil
 }
 
-func fileFromDirectory(rootDirectory string) string {
+// Filename returns the name of the solution file in the root directory.
+func Filename(rootDirectory string) string {
 	return path.Join(rootDirectory, "swamp.solution.toml")
 }
 
 func LoadIfExists(rootDirectory string) (Settings, error) {
-	tomlFilename := fileFromDirectory(rootDirectory)
+	tomlFilename := Filename(rootDirectory)
 	if !file.HasFile(tomlFilename) {
 		return Settings{}, fmt.Errorf("didn't find solution file in %s", rootDirectory)
 	}
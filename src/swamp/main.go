@@ -9,10 +9,16 @@ import (
 	"fmt"
 	"github.com/alecthomas/kong"
 	"github.com/swamp/compiler/src/decorated/decshared"
+	dectype "github.com/swamp/compiler/src/decorated/types"
 	"github.com/swamp/compiler/src/parser"
 	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/piot/lsp-server/lspserv"
@@ -20,24 +26,36 @@ import (
 	"github.com/swamp/compiler/src/environment"
 	"github.com/swamp/compiler/src/verbosity"
 
+	"github.com/swamp/compiler/src/bindings"
+	"github.com/swamp/compiler/src/buildcache"
 	swampcompiler "github.com/swamp/compiler/src/compiler"
+	"github.com/swamp/compiler/src/depgraph"
 	"github.com/swamp/compiler/src/file"
+	"github.com/swamp/compiler/src/hoststub"
 	"github.com/swamp/compiler/src/loader"
 	"github.com/swamp/compiler/src/lspservice"
+	"github.com/swamp/compiler/src/optimize"
+	"github.com/swamp/compiler/src/packinspect"
+	"github.com/swamp/compiler/src/pdb"
+	"github.com/swamp/compiler/src/pkgstore"
+	"github.com/swamp/compiler/src/settings"
+	"github.com/swamp/compiler/src/solution"
+	"github.com/swamp/compiler/src/watch"
 )
 
 var Version string
 
-func buildCommandLine(fileOrDirectory string, outputDirectory string, enforceStyle bool, assembler bool, target swampcompiler.Target, verbosity verbosity.Verbosity) ([]*loader.Package, error) {
+func buildCommandLine(fileOrDirectory string, outputDirectory string, enforceStyle bool, assembler bool, target swampcompiler.Target,
+	options swampcompiler.GenerateOptions, jobs int, cache *buildcache.Cache, verbosity verbosity.Verbosity) ([]*loader.Package, error) {
 	filenameToCompile := fileOrDirectory
 
-	return swampcompiler.BuildMain(filenameToCompile, outputDirectory, enforceStyle, assembler, target, verbosity)
+	return swampcompiler.BuildMain(filenameToCompile, outputDirectory, enforceStyle, assembler, target, options, jobs, cache, verbosity)
 }
 
-func buildCommandLineNoOutput(fileOrDirectory string, enforceStyle bool, verbosity verbosity.Verbosity) ([]*loader.Package, error) {
+func buildCommandLineNoOutput(fileOrDirectory string, dataLayout dectype.DataLayout, enforceStyle bool, jobs int, verbosity verbosity.Verbosity) ([]*loader.Package, error) {
 	filenameToCompile := fileOrDirectory
 
-	return swampcompiler.BuildMainOnlyCompile(filenameToCompile, enforceStyle, verbosity)
+	return swampcompiler.BuildMainOnlyCompile(filenameToCompile, dataLayout, enforceStyle, jobs, verbosity)
 }
 
 type FmtCmd struct {
@@ -75,10 +93,11 @@ type DocCmd struct {
 	Path         string `help:"path to file or directory" arg:"" default:"." type:"path"`
 	Verbosity    int    `help:"verbose output" type:"counter" short:"v"`
 	DisableStyle bool   `help:"disable enforcing of style" default:"false"`
+	Jobs         int    `help:"number of packages to compile at the same time, 0 uses all cores" short:"j" default:"0"`
 }
 
 func (c *DocCmd) Run() error {
-	compiledPackages, err := buildCommandLineNoOutput(c.Path, !c.DisableStyle, verbosity.Verbosity(c.Verbosity))
+	compiledPackages, err := buildCommandLineNoOutput(c.Path, dectype.DataLayoutLP64, !c.DisableStyle, c.Jobs, verbosity.Verbosity(c.Verbosity))
 	if err != nil {
 		return err
 	}
@@ -97,8 +116,8 @@ func (c *DocCmd) Run() error {
 type LspCmd struct{}
 
 func (c *LspCmd) Run() error {
-	fileSystem := loader.NewFileSystemDocumentProvider()
-	config, _, configErr := environment.LoadFromConfig()
+	fileSystem := loader.NewArchiveDocumentProvider(loader.NewFileSystemDocumentProvider())
+	config, configErr := environment.LoadResolved(".")
 	if configErr != nil {
 		return configErr
 	}
@@ -113,13 +132,41 @@ func (c *LspCmd) Run() error {
 }
 
 type BuildCmd struct {
-	Path         string `help:"path to file or directory" arg:"" default:"." type:"path"`
-	DisableStyle bool   `help:"disable enforcing of style" default:"false"`
-	Output       string `help:"output directory" type:"existingdir" short:"o" default:"."`
-	Target       string `help:"target platform" enum:"swamp-pack,llvm-ir" short:"t" default:"swamp-pack"`
-	Verbosity    int    `help:"verbose output" type:"counter" short:"v"`
-	Assembler    bool   `help:"output assembler" short:"s" default:"false"`
-	Modules      string
+	Path               string `help:"path to file or directory" arg:"" default:"." type:"path"`
+	DisableStyle       bool   `help:"disable enforcing of style" default:"false"`
+	Output             string `help:"output directory" type:"existingdir" short:"o" default:"."`
+	Target             string `help:"target platform" enum:"swamp-pack,llvm-ir" short:"t" default:"swamp-pack"`
+	Verbosity          int    `help:"verbose output" type:"counter" short:"v"`
+	Assembler          bool   `help:"output assembler" short:"s" default:"false"`
+	Jobs               int    `help:"number of packages to compile at the same time, 0 uses all cores" short:"j" default:"0"`
+	NoCache            bool   `help:"do not reuse or store output in the build cache" default:"false"`
+	VerifyReproducible bool   `help:"build twice, without the build cache, and fail if the output is not identical" default:"false"`
+	Modules            string
+	GenerateFlags      `embed:""`
+}
+
+// GenerateFlags are the code generation flags that build and watch have in common.
+type GenerateFlags struct {
+	ReportDce   bool   `help:"list the functions and constants that were removed by the dead code elimination" default:"false"`
+	Optimize    int    `help:"optimization level, 0 (none), 1 (constant folding and dead lets) or 2 (also inlining)" short:"O" default:"0"`
+	StackReport bool   `help:"print the peak stack frame size of each function" default:"false"`
+	Strip       bool   `help:"leave the debug information out of the .swamp-pack and write it to a .swamp-pdb" default:"false"`
+	DataLayout  string `help:"target data layout, that decides the size of pointers" enum:"lp64,ilp32,wasm32" default:"lp64"`
+}
+
+func (f *GenerateFlags) generateOptions() (swampcompiler.GenerateOptions, error) {
+	optimizeLevel, levelErr := optimize.NewLevel(f.Optimize)
+	if levelErr != nil {
+		return swampcompiler.GenerateOptions{}, levelErr
+	}
+
+	dataLayout, layoutErr := dectype.FindDataLayout(f.DataLayout)
+	if layoutErr != nil {
+		return swampcompiler.GenerateOptions{}, layoutErr
+	}
+
+	return swampcompiler.GenerateOptions{ReportDeadCode: f.ReportDce, ReportStack: f.StackReport, OptimizeLevel: optimizeLevel, Strip: f.Strip,
+		DataLayout: dataLayout}, nil
 }
 
 func (c *BuildCmd) Run() error {
@@ -134,7 +181,25 @@ func (c *BuildCmd) Run() error {
 		target = swampcompiler.LlvmIr
 	}
 
-	compiledPackages, err := buildCommandLine(c.Path, c.Output, !c.DisableStyle, c.Assembler, target, verbosity.Verbosity(c.Verbosity))
+	options, optionsErr := c.generateOptions()
+	if optionsErr != nil {
+		return optionsErr
+	}
+
+	var cache *buildcache.Cache
+	if !c.NoCache {
+		cache = buildcache.NewCache(buildcache.DirectoryFromOutput(c.Output), buildcache.ExecutableVersion(Version))
+	}
+
+	var compiledPackages []*loader.Package
+	var err error
+	if c.VerifyReproducible {
+		compiledPackages, err = swampcompiler.BuildMainReproducible(c.Path, c.Output, !c.DisableStyle, c.Assembler, target,
+			options, c.Jobs, verbosity.Verbosity(c.Verbosity))
+	} else {
+		compiledPackages, err = buildCommandLine(c.Path, c.Output, !c.DisableStyle, c.Assembler, target,
+			options, c.Jobs, cache, verbosity.Verbosity(c.Verbosity))
+	}
 	if err != nil {
 		return err
 	}
@@ -146,6 +211,467 @@ func (c *BuildCmd) Run() error {
 	return nil
 }
 
+type WatchCmd struct {
+	Path          string        `help:"path to file or directory" arg:"" default:"." type:"path"`
+	DisableStyle  bool          `help:"disable enforcing of style" default:"false"`
+	Output        string        `help:"output directory" type:"existingdir" short:"o" default:"."`
+	Target        string        `help:"target platform" enum:"swamp-pack,llvm-ir" short:"t" default:"swamp-pack"`
+	Verbosity     int           `help:"verbose output" type:"counter" short:"v"`
+	Jobs          int           `help:"number of packages to compile at the same time, 0 uses all cores" short:"j" default:"0"`
+	NoCache       bool          `help:"do not reuse or store output in the build cache" default:"false"`
+	Interval      time.Duration `help:"how often to check the source files for changes" default:"500ms"`
+	Then          string        `help:"shell command to run after each successful build"`
+	GenerateFlags `embed:""`
+}
+
+func runShellCommand(command string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+func (c *WatchCmd) build(target swampcompiler.Target, options swampcompiler.GenerateOptions, cache *buildcache.Cache) {
+	_, err := buildCommandLine(c.Path, c.Output, !c.DisableStyle, false, target, options, c.Jobs, cache, verbosity.Verbosity(c.Verbosity))
+	if err != nil {
+		if reportError(err) >= parser.ReportAsSeverityError {
+			color.Red("build failed, waiting for changes")
+			return
+		}
+	}
+
+	color.Green("build done, waiting for changes")
+
+	if c.Then != "" {
+		if thenErr := runShellCommand(c.Then); thenErr != nil {
+			log.Printf("'%v' failed: %v", c.Then, thenErr)
+		}
+	}
+}
+
+// Run builds the path, and then builds it again every time a source file that it reads, the solution file or an
+// environment file is changed. Packages that are not affected by the change are taken from the build cache.
+func (c *WatchCmd) Run() error {
+	c.Path = filepath.ToSlash(c.Path)
+
+	options, optionsErr := c.generateOptions()
+	if optionsErr != nil {
+		return optionsErr
+	}
+
+	target := swampcompiler.SwampOpcode
+	if c.Target == "llvm-ir" {
+		target = swampcompiler.LlvmIr
+	}
+
+	var cache *buildcache.Cache
+	if !c.NoCache {
+		cache = buildcache.NewCache(buildcache.DirectoryFromOutput(c.Output), buildcache.ExecutableVersion(Version))
+	}
+
+	// The environment is loaded again for every poll, since an override can map a package to another directory
+	sources := func() (watch.Sources, error) {
+		config, configErr := environment.LoadResolved(c.Path)
+		if configErr != nil {
+			return watch.Sources{}, configErr
+		}
+
+		directories, directoriesErr := swampcompiler.SourceDirectories(c.Path, config)
+		if directoriesErr != nil {
+			return watch.Sources{}, directoriesErr
+		}
+
+		return watch.Sources{Directories: directories, Files: swampcompiler.WatchedFiles(c.Path)}, nil
+	}
+
+	return watch.Poll(c.Interval, sources, func() {
+		c.build(target, options, cache)
+	})
+}
+
+type InitCmd struct {
+	Path    string `help:"solution directory" arg:"" default:"." type:"path"`
+	Package string `help:"name of the package directory to create" default:"main"`
+}
+
+func (c *InitCmd) Run() error {
+	createdFiles, err := solution.Init(c.Path, c.Package)
+	for _, createdFile := range createdFiles {
+		fmt.Printf("wrote '%v'\n", createdFile)
+	}
+
+	return err
+}
+
+// DepsUpdateCmd writes the `swamp.lock` of each package, or removes it if the package has no versioned modules.
+// Only the versioned modules of the packages are locked. The versioned modules of a package in the store are resolved
+// with the `swamp.lock` that is in the store package.
+type DepsUpdateCmd struct {
+	Path string `help:"path to solution, package or file" arg:"" default:"." type:"path"`
+}
+
+func (c *DepsUpdateCmd) Run() error {
+	configuration, configErr := environment.LoadResolved(c.Path)
+	if configErr != nil {
+		return configErr
+	}
+
+	storeDirectory, storeErr := configuration.StoreDirectory()
+	if storeErr != nil {
+		return storeErr
+	}
+	store := pkgstore.NewStore(storeDirectory)
+
+	packageDirectories, packagesErr := swampcompiler.PackageDirectories(c.Path)
+	if packagesErr != nil {
+		return packagesErr
+	}
+
+	for _, packageDirectory := range packageDirectories {
+		requirements, requirementsErr := settings.LoadRequirements(packageDirectory)
+		if requirementsErr != nil {
+			return requirementsErr
+		}
+		if len(requirements) == 0 {
+			// There is nothing to lock, and a lock file that is left would be stale
+			wasRemoved, removeErr := pkgstore.RemoveLock(packageDirectory)
+			if removeErr != nil {
+				return removeErr
+			}
+			if wasRemoved {
+				fmt.Printf("%v: removed %v, there are no versioned modules\n", packageDirectory, pkgstore.LockFilename)
+			}
+			continue
+		}
+
+		lock, updateErr := pkgstore.Update(store, requirements)
+		if updateErr != nil {
+			return updateErr
+		}
+
+		for _, locked := range lock.Package {
+			fmt.Printf("%v: '%v' %v => %v\n", packageDirectory, locked.Name, locked.Constraint, locked.Version)
+		}
+
+		if err := lock.Save(packageDirectory); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type BindingsCmd struct {
+	Path         string `help:"path to solution, package or file" arg:"" default:"." type:"path"`
+	Lang         string `help:"output language" enum:"c,json" default:"c"`
+	Output       string `help:"file to write to, instead of stdout" short:"o" type:"path"`
+	DisableStyle bool   `help:"disable enforcing of style" default:"false"`
+	Jobs         int    `help:"number of packages to compile at the same time, 0 uses all cores" short:"j" default:"0"`
+	Verbosity    int    `help:"verbose output" type:"counter" short:"v"`
+	DataLayout   string `help:"target data layout, that decides the size of pointers" enum:"lp64,ilp32,wasm32" default:"lp64"`
+}
+
+func (c *BindingsCmd) Run() error {
+	dataLayout, layoutErr := dectype.FindDataLayout(c.DataLayout)
+	if layoutErr != nil {
+		return layoutErr
+	}
+
+	compiledPackages, err := buildCommandLineNoOutput(c.Path, dataLayout, !c.DisableStyle, c.Jobs, verbosity.Verbosity(c.Verbosity))
+	if err != nil {
+		return err
+	}
+
+	foundBindings, bindingsErr := bindings.FromPackages(compiledPackages, dataLayout)
+	if bindingsErr != nil {
+		return bindingsErr
+	}
+
+	writer := os.Stdout
+	guardName := "swamp_bindings"
+	if c.Output != "" {
+		outputFile, createErr := os.Create(c.Output)
+		if createErr != nil {
+			return createErr
+		}
+		defer outputFile.Close()
+		writer = outputFile
+		guardName = strings.TrimSuffix(filepath.Base(c.Output), filepath.Ext(c.Output))
+	}
+
+	if c.Lang == "json" {
+		return bindings.WriteJSON(writer, foundBindings)
+	}
+
+	bindings.WriteC(writer, foundBindings, guardName)
+
+	return nil
+}
+
+type StubsGenerateCmd struct {
+	Path         string `help:"path to solution, package or file" arg:"" default:"." type:"path"`
+	Lang         string `help:"output language, or the manifest of the host functions" enum:"c,go,manifest" default:"c"`
+	Output       string `help:"file to write to, instead of stdout" short:"o" type:"path"`
+	Header       string `help:"header from swamp bindings to include in the C stubs, instead of writing the types"`
+	GoPackage    string `help:"package name of the Go stubs" name:"go-package" default:"swamphost"`
+	DisableStyle bool   `help:"disable enforcing of style" default:"false"`
+	Jobs         int    `help:"number of packages to compile at the same time, 0 uses all cores" short:"j" default:"0"`
+	Verbosity    int    `help:"verbose output" type:"counter" short:"v"`
+	DataLayout   string `help:"target data layout, that decides the size of pointers" enum:"lp64,ilp32,wasm32" default:"lp64"`
+}
+
+func (c *StubsGenerateCmd) Run() error {
+	dataLayout, layoutErr := dectype.FindDataLayout(c.DataLayout)
+	if layoutErr != nil {
+		return layoutErr
+	}
+
+	compiledPackages, err := buildCommandLineNoOutput(c.Path, dataLayout, !c.DisableStyle, c.Jobs, verbosity.Verbosity(c.Verbosity))
+	if err != nil {
+		return err
+	}
+
+	stubs, stubsErr := hoststub.FromPackages(compiledPackages, dataLayout)
+	if stubsErr != nil {
+		return stubsErr
+	}
+
+	writer := os.Stdout
+	if c.Output != "" {
+		outputFile, createErr := os.Create(c.Output)
+		if createErr != nil {
+			return createErr
+		}
+		defer outputFile.Close()
+		writer = outputFile
+	}
+
+	switch c.Lang {
+	case "go":
+		return hoststub.WriteGo(writer, stubs, c.GoPackage)
+	case "manifest":
+		hoststub.WriteManifest(writer, hoststub.NewManifest(stubs))
+	default:
+		hoststub.WriteC(writer, stubs, c.Header)
+	}
+
+	return nil
+}
+
+type StubsCheckCmd struct {
+	Path     string `help:"the .swamp-pack file" arg:"" type:"existingfile"`
+	Manifest string `help:"manifest of the functions that the host implements" required:"" type:"existingfile"`
+}
+
+func (c *StubsCheckCmd) Run() error {
+	octets, readErr := os.ReadFile(c.Path)
+	if readErr != nil {
+		return readErr
+	}
+
+	pack, packErr := packinspect.Read(octets)
+	if packErr != nil {
+		return fmt.Errorf("%v: %w", c.Path, packErr)
+	}
+
+	manifestFile, openErr := os.Open(c.Manifest)
+	if openErr != nil {
+		return openErr
+	}
+	defer manifestFile.Close()
+
+	manifest, manifestErr := hoststub.LoadManifest(manifestFile)
+	if manifestErr != nil {
+		return fmt.Errorf("%v: %w", c.Manifest, manifestErr)
+	}
+
+	problems := hoststub.Check(pack, manifest)
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "%v: %v\n", c.Path, problem)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("the pack does not match the host manifest (%d problems)", len(problems))
+	}
+
+	return nil
+}
+
+type StubsCmd struct {
+	Generate StubsGenerateCmd `cmd:"" help:"writes the host stubs for the external functions"`
+	Check    StubsCheckCmd    `cmd:"" help:"checks the external functions that a .swamp-pack references against a host manifest"`
+}
+
+type InspectCmd struct {
+	Path        string   `help:"the .swamp-pack file" arg:"" type:"existingfile"`
+	Disassemble []string `help:"fully qualified names of functions to disassemble" short:"d"`
+	All         bool     `help:"disassemble all functions"`
+	JSON        bool     `help:"write JSON instead of text" name:"json"`
+}
+
+func (c *InspectCmd) Run() error {
+	octets, readErr := os.ReadFile(c.Path)
+	if readErr != nil {
+		return readErr
+	}
+
+	pack, packErr := packinspect.Read(octets)
+	if packErr != nil {
+		return fmt.Errorf("%v: %w", c.Path, packErr)
+	}
+
+	disassembled, disassembleErr := pack.DisassembleFunctions(c.Disassemble, c.All)
+	if disassembleErr != nil {
+		return disassembleErr
+	}
+
+	if c.JSON {
+		return packinspect.WriteJSON(os.Stdout, pack, disassembled)
+	}
+
+	packinspect.WriteText(os.Stdout, pack, disassembled)
+
+	return nil
+}
+
+type SymbolizeCmd struct {
+	Pdb      string `help:"the .swamp-pdb file" arg:"" type:"existingfile"`
+	Function string `help:"fully qualified name of the function" arg:""`
+	Offset   string `help:"opcode offset in the function, decimal or hexadecimal with 0x" arg:""`
+	Pack     string `help:"the stripped .swamp-pack, to check that the build id matches" type:"existingfile"`
+	BuildID  string `help:"build id from the crash report, to check that it matches" name:"build-id"`
+}
+
+func (c *SymbolizeCmd) Run() error {
+	offset, offsetErr := strconv.ParseUint(c.Offset, 0, 16)
+	if offsetErr != nil {
+		return fmt.Errorf("illegal opcode offset '%v': %w", c.Offset, offsetErr)
+	}
+
+	pdbFile, openErr := os.Open(c.Pdb)
+	if openErr != nil {
+		return openErr
+	}
+	defer pdbFile.Close()
+
+	symbols, symbolsErr := pdb.Read(pdbFile)
+	if symbolsErr != nil {
+		return fmt.Errorf("%v: %w", c.Pdb, symbolsErr)
+	}
+
+	expectedBuildID := c.BuildID
+	if c.Pack != "" {
+		octets, readErr := os.ReadFile(c.Pack)
+		if readErr != nil {
+			return readErr
+		}
+
+		pack, packErr := packinspect.Read(octets)
+		if packErr != nil {
+			return fmt.Errorf("%v: %w", c.Pack, packErr)
+		}
+
+		if pack.BuildID == "" {
+			return fmt.Errorf("%v: the pack is not stripped and has no build id", c.Pack)
+		}
+
+		expectedBuildID = pack.BuildID
+	}
+
+	if expectedBuildID != "" && !strings.EqualFold(expectedBuildID, symbols.BuildID) {
+		return fmt.Errorf("build id %v does not match the build id %v of '%v'", expectedBuildID, symbols.BuildID, c.Pdb)
+	}
+
+	source, symbolizeErr := symbols.Symbolize(c.Function, uint16(offset))
+	if symbolizeErr != nil {
+		return symbolizeErr
+	}
+
+	fmt.Println(source)
+
+	return nil
+}
+
+type DepsGraphCmd struct {
+	Path         string `help:"path to solution, package or file" arg:"" default:"." type:"path"`
+	Format       string `help:"output format" enum:"dot,mermaid,json" default:"dot"`
+	Collapse     bool   `help:"show one node per package instead of one per module"`
+	Cycles       bool   `help:"highlight import cycles, and write the cycles even if they make the build fail"`
+	Reverse      string `help:"only show the modules that import this module, directly or indirectly"`
+	DisableStyle bool   `help:"disable enforcing of style" default:"false"`
+	Jobs         int    `help:"number of packages to compile at the same time, 0 uses all cores" short:"j" default:"0"`
+	Verbosity    int    `help:"verbose output" type:"counter" short:"v"`
+}
+
+func (c *DepsGraphCmd) Run() error {
+	compiledPackages, err := buildCommandLineNoOutput(c.Path, dectype.DataLayoutLP64, !c.DisableStyle, c.Jobs, verbosity.Verbosity(c.Verbosity))
+	if err != nil && !c.Cycles {
+		return err
+	}
+
+	graph := depgraph.FromPackages(compiledPackages)
+
+	if err != nil {
+		// An import cycle fails the build, so add the cycles from the error to the modules that could be compiled
+		var cycleCount int
+		graph, cycleCount = graph.WithCircularDependencies(err)
+		if cycleCount == 0 {
+			return err
+		}
+		reportError(err)
+	}
+
+	if c.Reverse != "" {
+		nodeIndex, findErr := graph.FindNode(c.Reverse)
+		if findErr != nil {
+			return findErr
+		}
+		graph = graph.ReverseDependencies(nodeIndex)
+	}
+
+	if c.Collapse {
+		graph = graph.CollapseByPackage()
+	}
+
+	if c.Cycles {
+		graph.MarkCycles()
+	}
+
+	switch c.Format {
+	case "mermaid":
+		return depgraph.WriteMermaid(os.Stdout, graph)
+	case "json":
+		return depgraph.WriteJSON(os.Stdout, graph)
+	default:
+		return depgraph.WriteDOT(os.Stdout, graph)
+	}
+}
+
+type DepsCmd struct {
+	Update DepsUpdateCmd `cmd:"" help:"resolves versioned modules from the package store and writes swamp.lock"`
+	Graph  DepsGraphCmd  `cmd:"" help:"writes the module import graph"`
+}
+
+type CleanCmd struct {
+	Output    string `help:"output directory" type:"existingdir" short:"o" default:"."`
+	Verbosity int    `help:"verbose output" type:"counter" short:"v"`
+}
+
+func (c *CleanCmd) Run() error {
+	cache := buildcache.NewCache(buildcache.DirectoryFromOutput(c.Output), Version)
+	if c.Verbosity > 0 {
+		fmt.Printf("removing build cache '%v'\n", cache.Directory())
+	}
+
+	return cache.Clean()
+}
+
 type EnvironmentSetCmd struct {
 	Name string `help:"fmt" arg:""`
 	Path string `help:"fmt" arg:""`
@@ -169,9 +695,45 @@ func (c *EnvironmentSetCmd) Run() error {
 	return configuration.SaveToConfig()
 }
 
-type EnvironmentListCmd struct{}
+type EnvironmentUnsetCmd struct {
+	Name string `help:"name of the package to remove" arg:""`
+}
+
+func (c *EnvironmentUnsetCmd) Run() error {
+	configuration, _, err := environment.LoadFromConfig()
+	if err != nil {
+		return err
+	}
+
+	if !configuration.Unset(c.Name) {
+		return fmt.Errorf("'%v' is not set in the user environment", c.Name)
+	}
+
+	fmt.Printf("removed '%v'\n", c.Name)
+
+	return configuration.SaveToConfig()
+}
+
+type EnvironmentListCmd struct {
+	Resolved bool   `help:"include the project environment file and SWAMP_PKG_ variables, and show where each value came from"`
+	Path     string `help:"directory to find the project environment file from" default:"." type:"path"`
+}
 
 func (c *EnvironmentListCmd) Run() error {
+	if c.Resolved {
+		configuration, err := environment.LoadResolved(c.Path)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Environment:\n")
+		for _, module := range configuration.Package {
+			fmt.Printf("'%v'='%v' (%v)\n", module.Name, module.Path, module.Source)
+		}
+
+		return nil
+	}
+
 	configuration, _, err := environment.LoadFromConfig()
 	if err != nil {
 		return err
@@ -186,8 +748,9 @@ func (c *EnvironmentListCmd) Run() error {
 }
 
 type EnvironmentCmd struct {
-	Set  EnvironmentSetCmd  `cmd:"" help:"set swamp environment package"`
-	List EnvironmentListCmd `cmd:"" default:"1" help:"list swamp environment packages"`
+	Set   EnvironmentSetCmd   `cmd:"" help:"set swamp environment package"`
+	Unset EnvironmentUnsetCmd `cmd:"" help:"remove swamp environment package"`
+	List  EnvironmentListCmd  `cmd:"" default:"1" help:"list swamp environment packages"`
 }
 
 func (c *EnvironmentCmd) Run() error {
@@ -202,12 +765,20 @@ func (c *VersionCmd) Run() error {
 }
 
 type Options struct {
-	Lsp     LspCmd         `help:"lsp" cmd:""`
-	Fmt     FmtCmd         `help:"fmt" cmd:""`
-	Doc     DocCmd         `help:"fmt" cmd:""`
-	Build   BuildCmd       `cmd:"" help:"builds a swamp application"`
-	Env     EnvironmentCmd `cmd:"" help:"manage swamp environment"`
-	Version VersionCmd     `cmd:"" help:"shows the version information"`
+	Lsp       LspCmd         `help:"lsp" cmd:""`
+	Fmt       FmtCmd         `help:"fmt" cmd:""`
+	Doc       DocCmd         `help:"fmt" cmd:""`
+	Build     BuildCmd       `cmd:"" help:"builds a swamp application"`
+	Watch     WatchCmd       `cmd:"" help:"builds a swamp application every time a source file changes"`
+	Clean     CleanCmd       `cmd:"" help:"removes the build cache"`
+	Init      InitCmd        `cmd:"" help:"creates a solution, or adds a package to an existing solution"`
+	Deps      DepsCmd        `cmd:"" help:"manage versioned package dependencies"`
+	Inspect   InspectCmd     `cmd:"" help:"lists the content of a .swamp-pack and disassembles functions"`
+	Symbolize SymbolizeCmd   `cmd:"" help:"maps a function and opcode offset in a stripped .swamp-pack to the source position"`
+	Bindings  BindingsCmd    `cmd:"" help:"writes the memory layout of the types as C headers or JSON"`
+	Stubs     StubsCmd       `cmd:"" help:"writes host stubs for the external functions, and checks packs against a host manifest"`
+	Env       EnvironmentCmd `cmd:"" help:"manage swamp environment"`
+	Version   VersionCmd     `cmd:"" help:"shows the version information"`
 }
 
 /*
@@ -241,18 +812,23 @@ multiErr, wasMultiErr := decErr.(*decorated.MultiErrors)
 		}
 	}
 */
+func reportError(err error) parser.ReportAsSeverity {
+	log.Print(err)
+	decErr, wasDecorated := err.(decshared.DecoratedError)
+	highestError := parser.ReportAsSeverityError
+	if wasDecorated {
+		highestError = parser.ShowWarningOrError(nil, decErr)
+	}
+
+	return highestError
+}
+
 func main() {
 	ctx := kong.Parse(&Options{})
 
 	err := ctx.Run()
 	if err != nil {
-		log.Print(err)
-		decErr, wasDecorated := err.(decshared.DecoratedError)
-		highestError := parser.ReportAsSeverityError
-		if wasDecorated {
-			highestError = parser.ShowWarningOrError(nil, decErr)
-		}
-		if highestError >= parser.ReportAsSeverityError {
+		if reportError(err) >= parser.ReportAsSeverityError {
 			os.Exit(-1)
 		}
 	}
@@ -0,0 +1,38 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package token
+
+import "fmt"
+
+// LambdaToken :
+type LambdaToken struct {
+	SourceFileReference
+	raw string
+}
+
+func NewLambdaToken(startPosition SourceFileReference, raw string) LambdaToken {
+	return LambdaToken{SourceFileReference: startPosition, raw: raw}
+}
+
+func (s LambdaToken) Type() Type {
+	return Lambda
+}
+
+func (s LambdaToken) String() string {
+	return "\\"
+}
+
+func (s LambdaToken) Raw() string {
+	return s.raw
+}
+
+func (s LambdaToken) DebugString() string {
+	return fmt.Sprintf("[lambda %s]", s.raw)
+}
+
+func (s LambdaToken) FetchPositionLength() SourceFileReference {
+	return s.SourceFileReference
+}
@@ -37,6 +37,7 @@ const (
 	Colon
 	OperatorAssign
 	Guard
+	Lambda
 	OperatorUpdate
 	Accessor
 	RightParen
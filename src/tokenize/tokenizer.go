@@ -1036,6 +1036,8 @@ func (t *Tokenizer) readTermToken() (token.Token, TokenError) {
 		return nil, NewUnexpectedEatTokenError(singleCharLength, ' ', ' ')
 	} else if r == '-' {
 		return token.NewOperatorToken(token.OperatorUnaryMinus, singleCharLength, string(r), "unary-"), nil
+	} else if r == '\\' {
+		return token.NewLambdaToken(singleCharLength, string(r)), nil
 	} else if r == '_' {
 		nextRune := t.nextRune()
 		if nextRune == '_' {
@@ -19,8 +19,12 @@ type TypeLookup interface {
 	Lookup(d dtype.Type) (int, error)
 }
 
-func generateModuleToChunk(module *decorated.Module, chunk *Chunk, verboseFlag verbosity.Verbosity) error {
+func generateModuleToChunk(module *decorated.Module, chunk *Chunk, include func(name *decorated.FullyQualifiedPackageVariableName) bool,
+	verboseFlag verbosity.Verbosity) error {
 	for _, exposedDef := range module.LocalDefinitions().Definitions() {
+		if include != nil && !include(module.FullyQualifiedName(exposedDef.Identifier())) {
+			continue
+		}
 		exposedType := exposedDef.Expression().Type()
 		if verboseFlag >= verbosity.Low {
 			log.Printf("generateModuleToChunkTypeInfo definition: %s\n", exposedType.String())
@@ -49,10 +53,10 @@ func ChunkToOctets(chunk *Chunk) ([]byte, error) {
 func GenerateModule(module *decorated.Module) ([]byte, TypeLookup, resourceid.ResourceNameLookup, error) {
 	const verboseFlag = verbosity.None
 
-	chunk := &Chunk{}
+	chunk := NewChunk(module.DataLayout())
 	resourceLookup := resourceid.NewResourceNameLookupImpl()
 
-	if err := generateModuleToChunk(module, chunk, verboseFlag); err != nil {
+	if err := generateModuleToChunk(module, chunk, nil, verboseFlag); err != nil {
 		return nil, nil, nil, err
 	}
 
@@ -65,9 +69,15 @@ func GenerateModule(module *decorated.Module) ([]byte, TypeLookup, resourceid.Re
 }
 
 func GeneratePackageToChunk(world *loader.Package, chunk *Chunk) error {
+	return GeneratePackageDefinitionsToChunk(world, chunk, nil)
+}
+
+// GeneratePackageDefinitionsToChunk only adds the types of the definitions that include returns true for. All
+// definitions are added if include is nil.
+func GeneratePackageDefinitionsToChunk(world *loader.Package, chunk *Chunk, include func(name *decorated.FullyQualifiedPackageVariableName) bool) error {
 	const verboseFlag = verbosity.None
 	for _, module := range world.AllModules() {
-		if err := generateModuleToChunk(module, chunk, verboseFlag); err != nil {
+		if err := generateModuleToChunk(module, chunk, include, verboseFlag); err != nil {
 			return err
 		}
 	}
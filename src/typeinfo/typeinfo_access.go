@@ -0,0 +1,126 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package typeinfo
+
+func (c *Chunk) InfoTypes() []InfoType {
+	return c.infoTypes
+}
+
+func (t *TypeRefIdType) OriginalType() InfoType {
+	return t.originalType
+}
+
+func (t *ListType) ItemType() InfoType {
+	return t.itemType
+}
+
+func (t *ListType) ItemMemoryInfo() MemoryInfo {
+	return MemoryInfo{MemorySize: t.itemSize, MemoryAlign: t.itemAlign}
+}
+
+func (t *ArrayType) ItemType() InfoType {
+	return t.itemType
+}
+
+func (t *ArrayType) ItemMemoryInfo() MemoryInfo {
+	return MemoryInfo{MemorySize: t.itemSize, MemoryAlign: t.itemAlign}
+}
+
+func (t *AliasType) Name() string {
+	return t.name
+}
+
+func (t *AliasType) RealType() InfoType {
+	return t.realType
+}
+
+func (t RecordField) Name() string {
+	return t.name
+}
+
+func (t RecordField) FieldType() InfoType {
+	return t.fieldType
+}
+
+func (t RecordField) MemoryOffsetInfo() MemoryOffsetInfo {
+	return t.memoryOffsetInfo
+}
+
+func (t *RecordType) Fields() []RecordField {
+	return t.fields
+}
+
+func (t *RecordType) MemoryInfo() MemoryInfo {
+	return t.memoryInfo
+}
+
+func (t VariantField) Index() uint {
+	return t.index
+}
+
+func (t VariantField) FieldType() InfoType {
+	return t.fieldType
+}
+
+func (t VariantField) MemoryOffsetInfo() MemoryOffsetInfo {
+	return t.memoryOffset
+}
+
+func (t *Variant) InCustomType() InfoType {
+	return t.inCustomType
+}
+
+func (t *Variant) Name() string {
+	return t.name
+}
+
+func (t *Variant) Fields() []VariantField {
+	return t.fields
+}
+
+func (t *Variant) MemoryInfo() MemoryInfo {
+	return t.memoryInfo
+}
+
+func (t *CustomType) Name() string {
+	return t.name
+}
+
+func (t *CustomType) Generics() []InfoType {
+	return t.generics
+}
+
+func (t *CustomType) Variants() []*Variant {
+	return t.variants
+}
+
+func (t *CustomType) MemoryInfo() MemoryInfo {
+	return t.memoryInfo
+}
+
+func (t *FunctionType) ParameterTypes() []InfoType {
+	return t.parameterTypes
+}
+
+func (t TupleTypeField) FieldType() InfoType {
+	return t.fieldType
+}
+
+func (t TupleTypeField) MemoryOffsetInfo() MemoryOffsetInfo {
+	return t.memoryOffsetInfo
+}
+
+func (t *TupleType) Fields() []TupleTypeField {
+	return t.fields
+}
+
+func (t *TupleType) MemoryInfo() MemoryInfo {
+	return t.memoryInfo
+}
+
+func (t *UnmanagedType) Name() string {
+	return t.name
+}
@@ -416,7 +416,13 @@ func (t *TupleType) HumanReadable() string {
 }
 
 type Chunk struct {
-	infoTypes []InfoType
+	infoTypes  []InfoType
+	dataLayout dectype.DataLayout
+}
+
+// NewChunk creates an empty chunk that calculates memory sizes and offsets for the dataLayout.
+func NewChunk(dataLayout dectype.DataLayout) *Chunk {
+	return &Chunk{dataLayout: dataLayout}
 }
 
 func customsAreSame(custom *CustomType, other *CustomType) bool {
@@ -724,7 +730,7 @@ func (c *Chunk) consumeCustomVariant(variant *dectype.CustomTypeVariantAtom) (*V
 	for index, field := range variant.Fields() {
 		var memInfo MemoryOffsetInfo
 		if !dectype.TypeIsTemplateHasLocalTypes(field.Type()) {
-			memInfo = memoryOffsetInfo(field.MemoryOffset(), field.Type())
+			memInfo = c.memoryOffsetInfo(field.MemoryOffset(), field.Type())
 		}
 
 		newFields := VariantField{
@@ -735,7 +741,7 @@ func (c *Chunk) consumeCustomVariant(variant *dectype.CustomTypeVariantAtom) (*V
 		fields = append(fields, newFields)
 	}
 
-	memorySize, memoryAlign := dectype.GetMemorySizeAndAlignmentInternal(variant)
+	memorySize, memoryAlign := dectype.GetMemorySizeAndAlignmentInternal(c.dataLayout, variant)
 
 	proposedNewVariant := &Variant{
 		inCustomType: &CustomType{},
@@ -796,7 +802,7 @@ func (c *Chunk) consumeCustom(custom *dectype.CustomTypeAtom) (*CustomType, erro
 		consumedVariants = append(consumedVariants, newVariant)
 	}
 
-	memorySize, memoryAlign := dectype.GetMemorySizeAndAlignment(custom)
+	memorySize, memoryAlign := dectype.GetMemorySizeAndAlignment(c.dataLayout, custom)
 	proposedNewCustom.memoryInfo = MemoryInfo{
 		MemorySize:  MemorySize(memorySize),
 		MemoryAlign: MemoryAlign(memoryAlign),
@@ -813,8 +819,8 @@ func (c *Chunk) consumeCustom(custom *dectype.CustomTypeAtom) (*CustomType, erro
 	return proposedNewCustom, nil
 }
 
-func memoryOffsetInfo(offset dectype.MemoryOffset, p dtype.Type) MemoryOffsetInfo {
-	size, align := dectype.GetMemorySizeAndAlignment(p)
+func (c *Chunk) memoryOffsetInfo(offset dectype.MemoryOffset, p dtype.Type) MemoryOffsetInfo {
+	size, align := dectype.GetMemorySizeAndAlignment(c.dataLayout, p)
 	return MemoryOffsetInfo{
 		MemoryOffset: MemoryOffset(offset),
 		MemoryInfo: MemoryInfo{
@@ -834,13 +840,13 @@ func (c *Chunk) consumeRecord(record *dectype.RecordAtom) (*RecordType, error) {
 		}
 		recordField := RecordField{
 			name:             field.Name(),
-			memoryOffsetInfo: memoryOffsetInfo(field.MemoryOffset(), field.Type()),
+			memoryOffsetInfo: c.memoryOffsetInfo(field.MemoryOffset(), field.Type()),
 			fieldType:        consumeFieldType,
 		}
 		fields = append(fields, recordField)
 	}
 
-	memorySize, memoryAlign := dectype.GetMemorySizeAndAlignment(record)
+	memorySize, memoryAlign := dectype.GetMemorySizeAndAlignment(c.dataLayout, record)
 
 	proposedNewRecord := &RecordType{
 		Type:   Type{},
@@ -904,13 +910,13 @@ func (c *Chunk) consumeTuple(fn *dectype.TupleTypeAtom) (*TupleType, error) {
 			return nil, fmt.Errorf("this should not be needed")
 		}
 		tupleField := TupleTypeField{
-			memoryOffsetInfo: memoryOffsetInfo(field.MemoryOffset(), field.Type()),
+			memoryOffsetInfo: c.memoryOffsetInfo(field.MemoryOffset(), field.Type()),
 			fieldType:        consumeType,
 		}
 		tupleFields = append(tupleFields, tupleField)
 	}
 
-	memorySize, memoryAlign := dectype.GetMemorySizeAndAlignment(fn)
+	memorySize, memoryAlign := dectype.GetMemorySizeAndAlignment(c.dataLayout, fn)
 
 	proposedNewTuple := &TupleType{
 		Type:   Type{},
@@ -944,7 +950,7 @@ func (c *Chunk) consumeArray(genericTypes []dtype.Type) (InfoType, error) {
 		return nil, err
 	}
 
-	memorySize, memoryAlign := dectype.GetMemorySizeAndAlignmentInternal(itemType)
+	memorySize, memoryAlign := dectype.GetMemorySizeAndAlignmentInternal(c.dataLayout, itemType)
 
 	proposedNewArray := &ArrayType{
 		Type:      Type{},
@@ -978,7 +984,7 @@ func (c *Chunk) consumeList(genericTypes []dtype.Type) (InfoType, error) {
 		return nil, nil
 	}
 
-	memorySize, memoryAlign := dectype.GetMemorySizeAndAlignmentInternal(itemType)
+	memorySize, memoryAlign := dectype.GetMemorySizeAndAlignmentInternal(c.dataLayout, itemType)
 	if !dectype.IsLocalType(itemType) && memorySize == 0 {
 		panic(fmt.Errorf("can not have zero item size"))
 	}
@@ -0,0 +1,493 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package typeinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+)
+
+// deserializer reads the format that is written by Serialize. Type references can point to types that come later
+// in the chunk, so they are collected and resolved when all types have been read.
+type deserializer struct {
+	reader     io.Reader
+	infoTypes  []InfoType
+	unresolved []func() error
+}
+
+func (d *deserializer) readUint8() (uint8, error) {
+	var octets [1]byte
+	if _, err := io.ReadFull(d.reader, octets[:]); err != nil {
+		return 0, err
+	}
+
+	return octets[0], nil
+}
+
+func (d *deserializer) readUint16() (int, error) {
+	var octets [2]byte
+	if _, err := io.ReadFull(d.reader, octets[:]); err != nil {
+		return 0, err
+	}
+
+	return int(binary.BigEndian.Uint16(octets[:])), nil
+}
+
+func (d *deserializer) readCount() (int, error) {
+	count, err := d.readUint8()
+	return int(count), err
+}
+
+func (d *deserializer) readName() (string, error) {
+	count, countErr := d.readCount()
+	if countErr != nil {
+		return "", countErr
+	}
+
+	octets := make([]byte, count)
+	if _, err := io.ReadFull(d.reader, octets); err != nil {
+		return "", err
+	}
+
+	return string(octets), nil
+}
+
+func (d *deserializer) readMemoryInfo() (MemoryInfo, error) {
+	size, sizeErr := d.readUint16()
+	if sizeErr != nil {
+		return MemoryInfo{}, sizeErr
+	}
+
+	align, alignErr := d.readUint8()
+	if alignErr != nil {
+		return MemoryInfo{}, alignErr
+	}
+
+	if align > 8 {
+		return MemoryInfo{}, fmt.Errorf("illegal memory align %d", align)
+	}
+
+	return MemoryInfo{MemorySize: MemorySize(size), MemoryAlign: MemoryAlign(align)}, nil
+}
+
+func (d *deserializer) readMemoryOffsetInfo() (MemoryOffsetInfo, error) {
+	offset, offsetErr := d.readUint16()
+	if offsetErr != nil {
+		return MemoryOffsetInfo{}, offsetErr
+	}
+
+	memoryInfo, memoryInfoErr := d.readMemoryInfo()
+	if memoryInfoErr != nil {
+		return MemoryOffsetInfo{}, memoryInfoErr
+	}
+
+	return MemoryOffsetInfo{MemoryOffset: MemoryOffset(offset), MemoryInfo: memoryInfo}, nil
+}
+
+func (d *deserializer) lookup(index int) (InfoType, error) {
+	if index >= len(d.infoTypes) {
+		return nil, fmt.Errorf("type reference %d is outside of the chunk (%d types)", index, len(d.infoTypes))
+	}
+
+	return d.infoTypes[index], nil
+}
+
+// readTypeRef reads a type reference, and sets target when all types have been read.
+func (d *deserializer) readTypeRef(target *InfoType) error {
+	index, err := d.readUint16()
+	if err != nil {
+		return err
+	}
+
+	d.unresolved = append(d.unresolved, func() error {
+		infoType, lookupErr := d.lookup(index)
+		if lookupErr != nil {
+			return lookupErr
+		}
+		*target = infoType
+		return nil
+	})
+
+	return nil
+}
+
+func (d *deserializer) readTypeRefs(count int) ([]InfoType, error) {
+	if count == 0 {
+		return nil, nil
+	}
+
+	types := make([]InfoType, count)
+	for index := range types {
+		if err := d.readTypeRef(&types[index]); err != nil {
+			return nil, err
+		}
+	}
+
+	return types, nil
+}
+
+func (d *deserializer) readList() (*ListType, error) {
+	list := &ListType{}
+	if err := d.readTypeRef(&list.itemType); err != nil {
+		return nil, err
+	}
+
+	memoryInfo, memoryInfoErr := d.readMemoryInfo()
+	if memoryInfoErr != nil {
+		return nil, memoryInfoErr
+	}
+	list.itemSize = memoryInfo.MemorySize
+	list.itemAlign = memoryInfo.MemoryAlign
+
+	return list, nil
+}
+
+func (d *deserializer) readArray() (*ArrayType, error) {
+	array := &ArrayType{}
+	if err := d.readTypeRef(&array.itemType); err != nil {
+		return nil, err
+	}
+
+	memoryInfo, memoryInfoErr := d.readMemoryInfo()
+	if memoryInfoErr != nil {
+		return nil, memoryInfoErr
+	}
+	array.itemSize = memoryInfo.MemorySize
+	array.itemAlign = memoryInfo.MemoryAlign
+
+	return array, nil
+}
+
+func (d *deserializer) readUnmanaged() (*UnmanagedType, error) {
+	name, nameErr := d.readName()
+	if nameErr != nil {
+		return nil, nameErr
+	}
+
+	lowHash, hashErr := d.readUint16()
+	if hashErr != nil {
+		return nil, hashErr
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(name))
+	if expectedLowHash := int(hash.Sum32() & 0xffff); lowHash != expectedLowHash {
+		return nil, fmt.Errorf("unmanaged type '%v' has hash %04X, expected %04X", name, lowHash, expectedLowHash)
+	}
+
+	return &UnmanagedType{name: name}, nil
+}
+
+func (d *deserializer) readTuple() (*TupleType, error) {
+	memoryInfo, memoryInfoErr := d.readMemoryInfo()
+	if memoryInfoErr != nil {
+		return nil, memoryInfoErr
+	}
+
+	count, countErr := d.readCount()
+	if countErr != nil {
+		return nil, countErr
+	}
+
+	tuple := &TupleType{memoryInfo: memoryInfo, fields: make([]TupleTypeField, count)}
+	for index := range tuple.fields {
+		field := &tuple.fields[index]
+		var offsetErr error
+		if field.memoryOffsetInfo, offsetErr = d.readMemoryOffsetInfo(); offsetErr != nil {
+			return nil, offsetErr
+		}
+		if err := d.readTypeRef(&field.fieldType); err != nil {
+			return nil, err
+		}
+	}
+
+	return tuple, nil
+}
+
+func (d *deserializer) readRecord() (*RecordType, error) {
+	memoryInfo, memoryInfoErr := d.readMemoryInfo()
+	if memoryInfoErr != nil {
+		return nil, memoryInfoErr
+	}
+
+	count, countErr := d.readCount()
+	if countErr != nil {
+		return nil, countErr
+	}
+
+	record := &RecordType{memoryInfo: memoryInfo, fields: make([]RecordField, count)}
+	for index := range record.fields {
+		field := &record.fields[index]
+		var nameErr error
+		if field.name, nameErr = d.readName(); nameErr != nil {
+			return nil, nameErr
+		}
+		var offsetErr error
+		if field.memoryOffsetInfo, offsetErr = d.readMemoryOffsetInfo(); offsetErr != nil {
+			return nil, offsetErr
+		}
+		if err := d.readTypeRef(&field.fieldType); err != nil {
+			return nil, err
+		}
+	}
+
+	return record, nil
+}
+
+func (d *deserializer) readAlias() (*AliasType, error) {
+	name, nameErr := d.readName()
+	if nameErr != nil {
+		return nil, nameErr
+	}
+
+	alias := &AliasType{name: name}
+	if err := d.readTypeRef(&alias.realType); err != nil {
+		return nil, err
+	}
+
+	return alias, nil
+}
+
+func (d *deserializer) readTypeRefId() (*TypeRefIdType, error) {
+	typeRefId := &TypeRefIdType{}
+	if err := d.readTypeRef(&typeRefId.originalType); err != nil {
+		return nil, err
+	}
+
+	return typeRefId, nil
+}
+
+func (d *deserializer) readFunction() (*FunctionType, error) {
+	count, countErr := d.readCount()
+	if countErr != nil {
+		return nil, countErr
+	}
+
+	parameterTypes, parametersErr := d.readTypeRefs(count)
+	if parametersErr != nil {
+		return nil, parametersErr
+	}
+
+	return &FunctionType{parameterTypes: parameterTypes}, nil
+}
+
+func (d *deserializer) readCustomTypeVariant() (*Variant, error) {
+	variant := &Variant{}
+	if err := d.readTypeRef(&variant.inCustomType); err != nil {
+		return nil, err
+	}
+
+	var nameErr error
+	if variant.name, nameErr = d.readName(); nameErr != nil {
+		return nil, nameErr
+	}
+
+	var memoryInfoErr error
+	if variant.memoryInfo, memoryInfoErr = d.readMemoryInfo(); memoryInfoErr != nil {
+		return nil, memoryInfoErr
+	}
+
+	count, countErr := d.readCount()
+	if countErr != nil {
+		return nil, countErr
+	}
+
+	if count > 0 {
+		variant.fields = make([]VariantField, count)
+	}
+	for index := range variant.fields {
+		field := &variant.fields[index]
+		field.index = uint(index)
+		if err := d.readTypeRef(&field.fieldType); err != nil {
+			return nil, err
+		}
+		var offsetErr error
+		if field.memoryOffset, offsetErr = d.readMemoryOffsetInfo(); offsetErr != nil {
+			return nil, offsetErr
+		}
+	}
+
+	d.unresolved = append(d.unresolved, func() error {
+		if _, isCustom := variant.inCustomType.(*CustomType); !isCustom {
+			return fmt.Errorf("variant '%v' is in %v, that is not a custom type", variant.name, variant.inCustomType)
+		}
+		return nil
+	})
+
+	return variant, nil
+}
+
+func (d *deserializer) readCustom() (*CustomType, error) {
+	name, nameErr := d.readName()
+	if nameErr != nil {
+		return nil, nameErr
+	}
+
+	memoryInfo, memoryInfoErr := d.readMemoryInfo()
+	if memoryInfoErr != nil {
+		return nil, memoryInfoErr
+	}
+
+	genericCount, genericCountErr := d.readCount()
+	if genericCountErr != nil {
+		return nil, genericCountErr
+	}
+
+	generics, genericsErr := d.readTypeRefs(genericCount)
+	if genericsErr != nil {
+		return nil, genericsErr
+	}
+
+	variantCount, variantCountErr := d.readCount()
+	if variantCountErr != nil {
+		return nil, variantCountErr
+	}
+
+	variantTypes, variantsErr := d.readTypeRefs(variantCount)
+	if variantsErr != nil {
+		return nil, variantsErr
+	}
+
+	custom := &CustomType{name: name, memoryInfo: memoryInfo, generics: generics}
+
+	d.unresolved = append(d.unresolved, func() error {
+		for _, variantType := range variantTypes {
+			variant, isVariant := variantType.(*Variant)
+			if !isVariant {
+				return fmt.Errorf("custom type '%v' has %v as a variant", name, variantType)
+			}
+			custom.variants = append(custom.variants, variant)
+		}
+		return nil
+	})
+
+	return custom, nil
+}
+
+func (d *deserializer) readInfoType() (InfoType, error) {
+	typeID, typeIDErr := d.readUint8()
+	if typeIDErr != nil {
+		return nil, typeIDErr
+	}
+
+	switch SwtiType(typeID) {
+	case SwtiTypeList:
+		return d.readList()
+	case SwtiTypeArray:
+		return d.readArray()
+	case SwtiTypeInt:
+		return &IntType{}, nil
+	case SwtiTypeString:
+		return &StringType{}, nil
+	case SwtiTypeChar:
+		return &CharacterType{}, nil
+	case SwtiTypeResourceName:
+		return &ResourceNameType{}, nil
+	case SwtiTypeFixed:
+		return &FixedType{}, nil
+	case SwtiTypeBoolean:
+		return &BoolType{}, nil
+	case SwtiTypeBlob:
+		return &BlobType{}, nil
+	case SwtiTypeRecord:
+		return d.readRecord()
+	case SwtiTypeAlias:
+		return d.readAlias()
+	case SwtiTypeFunction:
+		return d.readFunction()
+	case SwtiTypeCustom:
+		return d.readCustom()
+	case SwtiTypeCustomVariant:
+		return d.readCustomTypeVariant()
+	case SwtiTypeRefId:
+		return d.readTypeRefId()
+	case SwtiTypeTuple:
+		return d.readTuple()
+	case SwtiTypeAny:
+		return &AnyType{}, nil
+	case SwtiTypeUnmanaged:
+		return d.readUnmanaged()
+	case SwtiTypeAnyMatchingTypes:
+		return &AnyMatchingTypes{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown type id %d", typeID)
+}
+
+// setIndex is promoted from Type to all info types.
+func (t *Type) setIndex(index int) {
+	t.index = index
+}
+
+type indexSetter interface {
+	setIndex(index int)
+}
+
+func readVersion(d *deserializer) error {
+	var version [3]byte
+	for index := range version {
+		octet, err := d.readUint8()
+		if err != nil {
+			return err
+		}
+		version[index] = octet
+	}
+
+	if version[0] != 0 || version[1] != 2 {
+		return fmt.Errorf("unsupported type information version %d.%d.%d", version[0], version[1], version[2])
+	}
+
+	return nil
+}
+
+// Deserialize reads a chunk that was written by Serialize.
+func Deserialize(reader io.Reader) (*Chunk, error) {
+	d := &deserializer{reader: reader}
+
+	if err := readVersion(d); err != nil {
+		return nil, err
+	}
+
+	count, countErr := d.readUint16()
+	if countErr != nil {
+		return nil, countErr
+	}
+
+	for index := 0; index < count; index++ {
+		infoType, err := d.readInfoType()
+		if err != nil {
+			return nil, fmt.Errorf("type information entry %d: %w", index, err)
+		}
+		infoType.(indexSetter).setIndex(index)
+		d.infoTypes = append(d.infoTypes, infoType)
+	}
+
+	for _, resolve := range d.unresolved {
+		if err := resolve(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Chunk{infoTypes: d.infoTypes}, nil
+}
+
+func DeserializeFromOctets(octets []byte) (*Chunk, error) {
+	reader := bytes.NewReader(octets)
+
+	chunk, err := Deserialize(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if reader.Len() != 0 {
+		return nil, fmt.Errorf("%d octets left after the type information", reader.Len())
+	}
+
+	return chunk, nil
+}
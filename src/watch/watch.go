@@ -0,0 +1,107 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package watch
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/swamp/compiler/src/buildcache"
+)
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// Snapshot is the size and modification time of every source file in a set of directories, and of the single files
+// that exist. A directory that does not exist is the same as an empty one.
+type Snapshot map[string]fileState
+
+// Sources are the directories with source files, and the single files, like the solution and environment files, that
+// a build reads. The single files do not have to exist, so that it is noticed when one is created.
+type Sources struct {
+	Directories []string
+	Files       []string
+}
+
+func TakeSnapshot(sources Sources) (Snapshot, error) {
+	snapshot := make(Snapshot)
+	for _, filename := range sources.Files {
+		info, statErr := os.Stat(filename)
+		if statErr != nil {
+			continue
+		}
+		snapshot[filename] = fileState{size: info.Size(), modTime: info.ModTime()}
+	}
+
+	for _, directory := range sources.Directories {
+		filenames, filesErr := buildcache.SourceFiles(directory)
+		if filesErr != nil && !errors.Is(filesErr, fs.ErrNotExist) {
+			return nil, filesErr
+		}
+		// A directory that does not exist, or is removed while it is listed, has no more source files than were listed.
+		// Polling goes on, so that it is noticed when the directory is created again.
+		for _, filename := range filenames {
+			info, statErr := os.Stat(filename)
+			if statErr != nil {
+				// The file was removed after it was listed, it will be missing in the next snapshot instead
+				continue
+			}
+			snapshot[filename] = fileState{size: info.Size(), modTime: info.ModTime()}
+		}
+	}
+
+	return snapshot, nil
+}
+
+func (s Snapshot) IsEqual(other Snapshot) bool {
+	if len(s) != len(other) {
+		return false
+	}
+
+	for filename, state := range s {
+		otherState, wasFound := other[filename]
+		if !wasFound || otherState.size != state.size || !otherState.modTime.Equal(state.modTime) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Poll calls onChange once, and then every time a source file is added, removed or modified.
+// The sources are fetched again for every poll, since a changed `.swamp.toml` can map other directories. If
+// that fails, e.g. while a `.swamp.toml` is being edited, the sources from the previous poll are used. Polling is used instead of file system notifications, so that it works the same in containers and on network drives.
+func Poll(interval time.Duration, sources func() (Sources, error), onChange func()) error {
+	var previous Snapshot
+	var previousSources *Sources
+
+	for {
+		foundSources, sourcesErr := sources()
+		if sourcesErr != nil {
+			if previousSources == nil {
+				return sourcesErr
+			}
+			foundSources = *previousSources
+		}
+		previousSources = &foundSources
+
+		snapshot, snapshotErr := TakeSnapshot(foundSources)
+		if snapshotErr != nil {
+			return snapshotErr
+		}
+
+		if previous == nil || !snapshot.IsEqual(previous) {
+			onChange()
+		}
+		previous = snapshot
+
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,110 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Peter Bjorklund. All rights reserved.
+ *  Licensed under the MIT License. See LICENSE in the project root for license information.
+ *--------------------------------------------------------------------------------------------*/
+
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeSource(t *testing.T, filename string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotIsEqual(t *testing.T) {
+	for _, testCase := range []struct {
+		name        string
+		change      func(t *testing.T, directory string)
+		expectEqual bool
+	}{
+		{"unchanged", func(t *testing.T, directory string) {}, true},
+		{"added source file", func(t *testing.T, directory string) {
+			writeSource(t, filepath.Join(directory, "lib", "Other.swamp"), "other")
+		}, false},
+		{"modified source file", func(t *testing.T, directory string) {
+			writeSource(t, filepath.Join(directory, "lib", "Main.swamp"), "changed main")
+		}, false},
+		{"modified time of source file", func(t *testing.T, directory string) {
+			later := time.Now().Add(time.Hour)
+			if err := os.Chtimes(filepath.Join(directory, "lib", "Main.swamp"), later, later); err != nil {
+				t.Fatal(err)
+			}
+		}, false},
+		{"deleted source file", func(t *testing.T, directory string) {
+			if err := os.Remove(filepath.Join(directory, "lib", "Main.swamp")); err != nil {
+				t.Fatal(err)
+			}
+		}, false},
+		{"created single file", func(t *testing.T, directory string) {
+			writeSource(t, filepath.Join(directory, "swamp.env.toml"), "")
+		}, false},
+		{"added other file", func(t *testing.T, directory string) {
+			writeSource(t, filepath.Join(directory, "lib", "notes.txt"), "notes")
+		}, true},
+		{"removed directory", func(t *testing.T, directory string) {
+			if err := os.RemoveAll(filepath.Join(directory, "lib")); err != nil {
+				t.Fatal(err)
+			}
+		}, false},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			directory := t.TempDir()
+			writeSource(t, filepath.Join(directory, "lib", "Main.swamp"), "main")
+			writeSource(t, filepath.Join(directory, "lib", ".swamp.toml"), "")
+			sources := Sources{
+				Directories: []string{filepath.Join(directory, "lib")},
+				Files:       []string{filepath.Join(directory, "swamp.env.toml")},
+			}
+
+			before, beforeErr := TakeSnapshot(sources)
+			if beforeErr != nil {
+				t.Fatal(beforeErr)
+			}
+
+			testCase.change(t, directory)
+
+			after, afterErr := TakeSnapshot(sources)
+			if afterErr != nil {
+				t.Fatal(afterErr)
+			}
+
+			if before.IsEqual(after) != testCase.expectEqual || after.IsEqual(before) != testCase.expectEqual {
+				t.Errorf("expected the snapshots to be equal: %v", testCase.expectEqual)
+			}
+		})
+	}
+}
+
+func TestSnapshotMissingDirectory(t *testing.T) {
+	directory := filepath.Join(t.TempDir(), "lib")
+	sources := Sources{Directories: []string{directory}}
+
+	missing, missingErr := TakeSnapshot(sources)
+	if missingErr != nil {
+		t.Fatalf("expected a missing directory to be empty, got %v", missingErr)
+	}
+	if len(missing) != 0 {
+		t.Errorf("expected an empty snapshot, got %v", missing)
+	}
+
+	writeSource(t, filepath.Join(directory, "Main.swamp"), "main")
+
+	created, createdErr := TakeSnapshot(sources)
+	if createdErr != nil {
+		t.Fatal(createdErr)
+	}
+	if created.IsEqual(missing) {
+		t.Errorf("expected the created directory to be noticed")
+	}
+}